@@ -16,17 +16,33 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/jackc/pgx/v5/stdlib"
 
+	"github.com/ishantswami13-crypto/vantro-backend/internal/accounting"
 	"github.com/ishantswami13-crypto/vantro-backend/internal/admin"
 	appapi "github.com/ishantswami13-crypto/vantro-backend/internal/api"
 	"github.com/ishantswami13-crypto/vantro-backend/internal/billing"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/blobstore"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/bot"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/delivery"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/duplicates"
 	"github.com/ishantswami13-crypto/vantro-backend/internal/expense"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/export"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/goals"
 	apphttp "github.com/ishantswami13-crypto/vantro-backend/internal/http"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/imports"
 	"github.com/ishantswami13-crypto/vantro-backend/internal/income"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/invoices"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/messaging"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/ocr"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/outbox"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/phonelink"
 	"github.com/ishantswami13-crypto/vantro-backend/internal/points"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/recurring"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/reminders"
 	"github.com/ishantswami13-crypto/vantro-backend/internal/reports"
 	"github.com/ishantswami13-crypto/vantro-backend/internal/router"
 	"github.com/ishantswami13-crypto/vantro-backend/internal/summary"
 	"github.com/ishantswami13-crypto/vantro-backend/internal/transactions"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/upi"
 	"github.com/ishantswami13-crypto/vantro-backend/internal/whatsapp"
 )
 
@@ -123,14 +139,67 @@ func main() {
 	adminHandler := admin.NewHandler(pool)
 	reportsHandler := reports.NewHandler(pool)
 	pointsHandler := points.NewHandler(pool)
+	goalsRepo := goals.NewRepository(pool)
+	goalsHandler := goals.NewHandler(goalsRepo)
+	recurringHandler := recurring.NewHandler(recurring.NewRepository(pool))
+	invoicesHandler := invoices.NewHandler(invoices.NewRepository(pool))
+	duplicatesHandler := duplicates.NewHandler(duplicates.NewRepository(pool))
+	importsHandler := imports.NewHandler(imports.NewRepository(pool))
+	exportHandler := export.NewHandler(pool)
+	accountingHandler := accounting.NewHandler(accounting.NewRepository(pool))
 	simpleTxRepo := transactions.NewSimpleRepo(pool)
 	simpleTxHandler := transactions.NewSimpleHandler(simpleTxRepo)
 	billingStore := &billing.Store{DB: db}
 	razorpayClient := billing.NewRazorpayFromEnv()
 	expenseStore := &expense.Store{DB: db}
-	repStore := &reports.Store{DB: db}
+	blobs, err := blobstore.FromEnv()
+	if err != nil {
+		log.Fatalf("blob store: %v", err)
+	}
+	expenseStore.Blobs = blobs
+	repStore := &reports.Store{DB: db, Blobs: blobs}
 	twilioClient := whatsapp.NewTwilioFromEnv()
-	apiServer := &appapi.Server{DB: db}
+	twilioGuard := whatsapp.NewWebhookGuardFromEnv(db)
+	// chat channels; SMS and Telegram join when configured, the recording fake only in dev
+	chatChannels := []messaging.Channel{messaging.NewTwilioWhatsApp(twilioClient, twilioGuard)}
+	if sms := messaging.NewTwilioSMS(twilioClient, twilioGuard); sms != nil {
+		chatChannels = append(chatChannels, sms)
+	}
+	if tg := messaging.NewTelegramFromEnv(db); tg != nil {
+		chatChannels = append(chatChannels, tg)
+	}
+	if strings.EqualFold(strings.TrimSpace(os.Getenv("ENV")), "dev") {
+		chatChannels = append(chatChannels, &messaging.Recorder{})
+	}
+	channels := messaging.NewRegistry(chatChannels...)
+	directory := &messaging.Directory{DB: db}
+	outboxRepo := outbox.NewRepository(db)
+	outboxHandler := outbox.NewHandler(outboxRepo)
+	apiServer := &appapi.Server{DB: db, Pool: pool}
+	deliveryRepo := delivery.NewRepository(pool)
+	deliveryHandler := delivery.NewHandler(deliveryRepo)
+
+	scheduler := delivery.NewScheduler(deliveryRepo, delivery.NewSMTPFromEnv(), channels, directory, repStore)
+	repStore.Regenerators = map[string]reports.Regenerator{
+		reports.KindExpenseMemory: expense.MonthlyRegenerator{Store: expenseStore},
+		reports.KindScheduled:     scheduler,
+	}
+
+	reminderStore := &reminders.Store{DB: db}
+	phoneLinks := &phonelink.Store{DB: db}
+	reminderScheduler := reminders.NewScheduler(reminderStore, outboxRepo, pool)
+	reminderScheduler.Accounts = phoneLinks
+
+	// Scheduled report delivery, expired report and receipt draft cleanup, logging reminders and
+	// the outbound message queue; REPORT_SCHEDULER=off leaves them to another instance
+	if !strings.EqualFold(strings.TrimSpace(os.Getenv("REPORT_SCHEDULER")), "off") {
+		go scheduler.Run(ctx)
+		janitor := reports.NewJanitor(repStore)
+		janitor.Drafts = expenseStore
+		go janitor.Run(ctx)
+		go reminderScheduler.Run(ctx)
+		go outbox.NewWorker(outboxRepo, channels, directory).Run(ctx)
+	}
 
 	authMiddleware := buildJWTMiddleware(pool)
 
@@ -146,6 +215,8 @@ func main() {
 	app.Post("/v1/expense/add", expense.AddExpenseHandler(expenseStore))
 	app.Get("/v1/expense/list", expense.ListExpensesHandler(expenseStore))
 	app.Get("/v1/expense/summary", expense.MonthlySummaryHandler(expenseStore))
+	app.Get("/v1/expense/subscriptions", expense.SubscriptionsHandler(expenseStore))
+	app.Post("/v1/upi/sms", upi.SMSHandler(&upi.Store{DB: db}))
 
 	// Expense reports (paid)
 	app.Get("/v1/expense/report", expense.MonthlyPDFHandler(expenseStore, billingStore))
@@ -153,13 +224,36 @@ func main() {
 	// Billing / Razorpay
 	app.Get("/v1/billing/status", billing.StatusHandler(billingStore))
 	app.Post("/v1/billing/create-link", billing.CreatePaymentLinkHandler(billingStore, razorpayClient))
-	app.Post("/v1/billing/webhook", billing.RazorpayWebhookHandler(billingStore, expenseStore, repStore, twilioClient))
+	app.Post("/v1/billing/webhook", billing.RazorpayWebhookHandler(billingStore, expenseStore, repStore, outboxRepo))
+
+	// Chat bot inbound webhooks, one per channel
+	chatBot := bot.New(expenseStore, &bot.Sessions{DB: db}, billingStore, repStore)
+	chatBot.Directory, chatBot.Channels = directory, channels
+	chatBot.Reminders = reminderStore
+	if chatBot.OCR, err = ocr.FromEnv(); err != nil {
+		log.Fatalf("ocr: %v", err)
+	}
+	for _, ch := range chatChannels {
+		app.Post(inboundPaths[ch.Name()], bot.WebhookHandler(ch, chatBot, directory))
+	}
+	// status callbacks repeat a MessageSid by design, so they skip the replay check
+	statusGuard := *twilioGuard
+	statusGuard.DB = nil
+	app.Post("/v1/whatsapp/status", statusGuard.Middleware(), outboxHandler.StatusCallback)
+
+	// Outbound message history (admin)
+	app.Get("/api/admin/messages", admin.RequireAdminAPIKey(), outboxHandler.History)
 
-	// WhatsApp inbound (Twilio webhook)
-	app.Post("/v1/whatsapp/inbound", whatsapp.Inbound)
+	// Public report download (tokenized); the POST answers the optional one-time code
+	reportDownload := reports.DownloadHandler(repStore, twilioClient)
+	app.Get("/r/:token", reportDownload)
+	app.Post("/r/:token", router.RateLimitAuth(), reportDownload)
 
-	// Public report download (tokenized)
-	app.Get("/r/:token", reports.DownloadHandler(repStore))
+	// reminder settings and Expense Memory exports reach only the bot or the signed-in owner of
+	// the linked phone
+	phoneLinkHandler := phonelink.NewHandler(phoneLinks, channels, expenseStore)
+	phoneLinkHandler.Reminders = reminderStore
+	phoneLinkHandler.Export = exportHandler
 
 	r := &router.Router{
 		AuthHandler:         authHandler,
@@ -172,7 +266,17 @@ func main() {
 		AdminHandler:        adminHandler,
 		OnboardingHandler:   onboardingHandler,
 		ReportsHandler:      reportsHandler,
+		ReportLinksHandler:  reports.NewLinksHandler(repStore),
 		PointsHandler:       pointsHandler,
+		GoalsHandler:        goalsHandler,
+		RecurringHandler:    recurringHandler,
+		InvoicesHandler:     invoicesHandler,
+		DuplicatesHandler:   duplicatesHandler,
+		ImportsHandler:      importsHandler,
+		ExportHandler:       exportHandler,
+		AccountingHandler:   accountingHandler,
+		DeliveryHandler:     deliveryHandler,
+		PhoneLinkHandler:    phoneLinkHandler,
 		AuthMW:              authMiddleware,
 	}
 	r.RegisterRoutes(app)
@@ -186,6 +290,14 @@ func main() {
 	log.Fatal(app.Listen(":" + port))
 }
 
+// inboundPaths are the chat bot's webhook routes by channel.
+var inboundPaths = map[string]string{
+	messaging.ChannelWhatsApp: "/v1/whatsapp/inbound",
+	messaging.ChannelSMS:      "/v1/sms/inbound",
+	messaging.ChannelTelegram: "/v1/telegram/webhook",
+	messaging.ChannelFake:     "/v1/fake/inbound",
+}
+
 func rateLimitTransactions() fiber.Handler {
 	max := 60
 	if v := strings.TrimSpace(os.Getenv("RATE_LIMIT_TX_MAX")); v != "" {
@@ -235,6 +347,15 @@ func apiKeyMiddleware() fiber.Handler {
 		if path == "/" || path == "/healthz" || path == "/api/auth/demo" || path == "/auth/demo" {
 			return c.Next()
 		}
+		// providers can't send an API key; these routes check the provider's signature instead
+		if path == "/v1/whatsapp/status" {
+			return c.Next()
+		}
+		for _, p := range inboundPaths {
+			if path == p {
+				return c.Next()
+			}
+		}
 
 		env := strings.ToLower(strings.TrimSpace(os.Getenv("ENV")))
 		expected := strings.TrimSpace(os.Getenv("API_KEY"))
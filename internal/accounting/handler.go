@@ -0,0 +1,420 @@
+package accounting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/audit"
+)
+
+// a year per file keeps Tally imports quick and matches the financial year people export by
+const maxExportDays = 366
+
+type Handler struct {
+	Repo *Repository
+}
+
+func NewHandler(repo *Repository) *Handler {
+	return &Handler{Repo: repo}
+}
+
+func (h *Handler) GetConfig(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	cfg, err := h.Repo.GetConfig(userContext(c), userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load accounting settings: "+err.Error())
+	}
+	return c.JSON(fiber.Map{"settings": cfg.Settings, "mappings": cfg.Mappings, "defaults": defaultSettings})
+}
+
+func (h *Handler) SaveSettings(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	var s Settings
+	if err := c.BodyParser(&s); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+	for _, f := range []*string{&s.CompanyName, &s.BankLedger, &s.IncomeLedger, &s.ExpenseLedger, &s.SalesLedger, &s.PurchaseLedger} {
+		*f = strings.TrimSpace(*f)
+		if len(*f) > 100 {
+			return fiber.NewError(fiber.StatusBadRequest, "ledger and company names must be at most 100 characters")
+		}
+	}
+	if err := h.Repo.SaveSettings(userContext(c), userID, s); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to save accounting settings: "+err.Error())
+	}
+	return c.JSON(s)
+}
+
+// SaveMappings replaces the ledger mapping table: body is {"mappings": [...]}.
+func (h *Handler) SaveMappings(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	var req struct {
+		Mappings []Mapping `json:"mappings"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+	if len(req.Mappings) > 500 {
+		return fiber.NewError(fiber.StatusBadRequest, "at most 500 mappings")
+	}
+
+	seen := map[string]bool{}
+	for i := range req.Mappings {
+		m := &req.Mappings[i]
+		m.Kind = strings.ToLower(strings.TrimSpace(m.Kind))
+		m.Key = strings.Join(strings.Fields(m.Key), " ")
+		m.Ledger = strings.TrimSpace(m.Ledger)
+		m.VoucherType = titleCase(m.VoucherType)
+
+		switch m.Kind {
+		case MapCategory:
+			if m.VoucherType != "" && m.VoucherType != VoucherPayment && m.VoucherType != VoucherPurchase {
+				return fiber.NewError(fiber.StatusBadRequest, "category voucher_type must be Payment or Purchase")
+			}
+		case MapClient:
+			if m.VoucherType != "" && m.VoucherType != VoucherReceipt && m.VoucherType != VoucherSales {
+				return fiber.NewError(fiber.StatusBadRequest, "client voucher_type must be Receipt or Sales")
+			}
+		default:
+			return fiber.NewError(fiber.StatusBadRequest, "kind must be category or client")
+		}
+		if m.Key == "" || (m.Ledger == "" && m.VoucherType == "") {
+			return fiber.NewError(fiber.StatusBadRequest, "each mapping needs a key and a ledger or voucher_type")
+		}
+		if len(m.Key) > 100 || len(m.Ledger) > 100 {
+			return fiber.NewError(fiber.StatusBadRequest, "keys and ledger names must be at most 100 characters")
+		}
+		k := m.Kind + ":" + mappingKey(m.Key)
+		if seen[k] {
+			return fiber.NewError(fiber.StatusBadRequest, "duplicate mapping for "+m.Kind+" "+strconv.Quote(m.Key))
+		}
+		seen[k] = true
+	}
+
+	if err := h.Repo.ReplaceMappings(userContext(c), userID, req.Mappings); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to save ledger mappings: "+err.Error())
+	}
+	if req.Mappings == nil {
+		req.Mappings = []Mapping{}
+	}
+	return c.JSON(fiber.Map{"mappings": req.Mappings})
+}
+
+// Preview reports what an export would contain: GET ?format=&from=&to=.
+func (h *Handler) Preview(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	req := ExportRequest{Format: c.Query("format"), From: c.Query("from"), To: c.Query("to")}
+	format, from, to, err := parseRange(req)
+	if err != nil {
+		return err
+	}
+
+	ctx := userContext(c)
+	entries, already, err := h.Repo.Entries(ctx, userID, format, from, to, false)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load entries: "+err.Error())
+	}
+	overlapping, err := h.Repo.Overlapping(ctx, userID, format, from, to)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load exports: "+err.Error())
+	}
+	return c.JSON(Preview{
+		Format:          format,
+		From:            from.Format("2006-01-02"),
+		To:              to.Format("2006-01-02"),
+		NewEntries:      len(entries),
+		AlreadyExported: already,
+		Overlapping:     overlapping,
+	})
+}
+
+// Export records a new export of the range and returns the file. Entries already sent to the
+// same software are skipped unless include_exported is set.
+func (h *Handler) Export(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	var req ExportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+	format, from, to, err := parseRange(req)
+	if err != nil {
+		return err
+	}
+
+	ctx := userContext(c)
+	cfg, err := h.Repo.GetConfig(ctx, userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load accounting settings: "+err.Error())
+	}
+	exp, entries, err := h.Repo.CreateExport(ctx, userID, format, from, to, req.IncludeExported)
+	if errors.Is(err, ErrEmpty) {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to record export: "+err.Error())
+	}
+
+	h.audit(c, userID, exp)
+	return sendFile(c, cfg, exp, entries, req.IncludeLedgers)
+}
+
+func (h *Handler) List(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	items, err := h.Repo.ListExports(userContext(c), userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to list exports: "+err.Error())
+	}
+	return c.JSON(items)
+}
+
+// Download re-renders a past export with the current ledger settings.
+func (h *Handler) Download(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	id, err := strconv.ParseInt(strings.TrimSpace(c.Params("id")), 10, 64)
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid export id")
+	}
+
+	ctx := userContext(c)
+	exp, entries, err := h.Repo.ExportEntries(ctx, userID, id)
+	if errors.Is(err, ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load export: "+err.Error())
+	}
+	cfg, err := h.Repo.GetConfig(ctx, userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load accounting settings: "+err.Error())
+	}
+	return sendFile(c, cfg, exp, entries, c.QueryBool("include_ledgers"))
+}
+
+// GetJournalAccounts returns the saved account templates next to the defaults.
+func (h *Handler) GetJournalAccounts(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	a, err := h.Repo.GetJournalAccounts(userContext(c), userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load journal accounts: "+err.Error())
+	}
+	return c.JSON(fiber.Map{"accounts": a, "effective": a.withDefaults(), "defaults": defaultJournalAccounts})
+}
+
+// SaveJournalAccounts stores account templates; empty fields fall back to the defaults.
+func (h *Handler) SaveJournalAccounts(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	var a JournalAccounts
+	if err := c.BodyParser(&a); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+	for _, f := range []*string{&a.Bank, &a.Income, &a.Expense, &a.Savings, &a.Points, &a.PointsIncome, &a.PointsRedeemed} {
+		*f = strings.TrimSpace(*f)
+		if len(*f) > 200 {
+			return fiber.NewError(fiber.StatusBadRequest, "account names must be at most 200 characters")
+		}
+		if *f != "" && !validAccountRoot(*f) {
+			return fiber.NewError(fiber.StatusBadRequest, "account "+strconv.Quote(*f)+" must start with Assets, Liabilities, Equity, Income or Expenses")
+		}
+	}
+	if err := h.Repo.SaveJournalAccounts(userContext(c), userID, a); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to save journal accounts: "+err.Error())
+	}
+	return c.JSON(fiber.Map{"accounts": a, "effective": a.withDefaults()})
+}
+
+// Journal renders a Beancount or ledger-cli journal:
+// GET ?format=beancount|ledger|hledger&from=&to=&include=incomes,expenses,transfers,points.
+// Both bounds are optional, so the whole history can be exported in one file.
+func (h *Handler) Journal(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	format, ext := FormatBeancount, ".beancount"
+	switch strings.ToLower(strings.TrimSpace(c.Query("format", FormatBeancount))) {
+	case FormatBeancount:
+	case FormatLedger:
+		format, ext = FormatLedger, ".ledger"
+	case "hledger":
+		format, ext = FormatLedger, ".journal"
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, "format must be beancount, ledger or hledger")
+	}
+
+	var from, to *time.Time
+	for _, b := range []struct {
+		key string
+		dst **time.Time
+	}{{"from", &from}, {"to", &to}} {
+		raw := strings.TrimSpace(c.Query(b.key))
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, b.key+" must be YYYY-MM-DD")
+		}
+		*b.dst = &t
+	}
+	if from != nil && to != nil && to.Before(*from) {
+		return fiber.NewError(fiber.StatusBadRequest, "to must not be before from")
+	}
+
+	kinds := map[string]bool{}
+	include := strings.TrimSpace(c.Query("include", "incomes,expenses,transfers,points"))
+	for _, k := range strings.Split(include, ",") {
+		switch strings.ToLower(strings.TrimSpace(k)) {
+		case "incomes", "income":
+			kinds[KindIncome] = true
+		case "expenses", "expense":
+			kinds[KindExpense] = true
+		case "transfers", "transfer", "savings":
+			kinds[KindTransfer] = true
+		case "points":
+			kinds[KindPoints] = true
+		case "":
+		default:
+			return fiber.NewError(fiber.StatusBadRequest, "include takes incomes, expenses, transfers and points")
+		}
+	}
+
+	ctx := userContext(c)
+	accounts, err := h.Repo.GetJournalAccounts(ctx, userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load journal accounts: "+err.Error())
+	}
+	entries, err := h.Repo.JournalEntries(ctx, userID, from, to, kinds)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load entries: "+err.Error())
+	}
+
+	name := "vantro"
+	if from != nil {
+		name += "-from-" + from.Format("2006-01-02")
+	}
+	if to != nil {
+		name += "-to-" + to.Format("2006-01-02")
+	}
+	c.Set(fiber.HeaderContentType, "text/plain; charset=utf-8")
+	c.Set("X-Entry-Count", strconv.Itoa(len(entries)))
+	c.Attachment(name + ext)
+	return c.Send(RenderJournal(format, accounts, entries))
+}
+
+func sendFile(c *fiber.Ctx, cfg Config, exp *Export, entries []Entry, withLedgers bool) error {
+	vouchers := newLedgers(cfg).Vouchers(entries)
+	name := "vantro-" + exp.Format + "-" + exp.From.Format("2006-01-02") + "-to-" + exp.To.Format("2006-01-02")
+	c.Set("X-Export-Id", strconv.FormatInt(exp.ID, 10))
+
+	if exp.Format == FormatTally {
+		c.Set(fiber.HeaderContentType, "application/xml; charset=utf-8")
+		c.Attachment(name + ".xml")
+		return c.Send(RenderTally(cfg, vouchers, withLedgers))
+	}
+	body, err := RenderJournalCSV(vouchers)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to build CSV")
+	}
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Attachment(name + ".csv")
+	return c.Send(body)
+}
+
+func parseRange(req ExportRequest) (string, time.Time, time.Time, error) {
+	format := strings.ToLower(strings.TrimSpace(req.Format))
+	if format != FormatTally && format != FormatZoho {
+		return "", time.Time{}, time.Time{}, fiber.NewError(fiber.StatusBadRequest, "format must be tally or zoho")
+	}
+	from, err1 := time.Parse("2006-01-02", strings.TrimSpace(req.From))
+	to, err2 := time.Parse("2006-01-02", strings.TrimSpace(req.To))
+	if err1 != nil || err2 != nil {
+		return "", time.Time{}, time.Time{}, fiber.NewError(fiber.StatusBadRequest, "from and to must be YYYY-MM-DD")
+	}
+	if to.Before(from) {
+		return "", time.Time{}, time.Time{}, fiber.NewError(fiber.StatusBadRequest, "to must not be before from")
+	}
+	if to.Sub(from) > maxExportDays*24*time.Hour {
+		return "", time.Time{}, time.Time{}, fiber.NewError(fiber.StatusBadRequest, "range must be at most a year")
+	}
+	return format, from, to, nil
+}
+
+func (h *Handler) audit(c *fiber.Ctx, userID string, exp *Export) {
+	uid := userID
+	entityID := strconv.FormatInt(exp.ID, 10)
+	meta, _ := json.Marshal(fiber.Map{
+		"format":      exp.Format,
+		"from":        exp.From.Format("2006-01-02"),
+		"to":          exp.To.Format("2006-01-02"),
+		"entry_count": exp.EntryCount,
+	})
+	entry := audit.Entry{
+		UserID:     &uid,
+		Action:     "accounting_export",
+		EntityType: "accounting_export",
+		EntityID:   &entityID,
+		Metadata:   meta,
+	}
+	if ip := strings.TrimSpace(c.IP()); ip != "" {
+		entry.IP = &ip
+	}
+	if ua := strings.TrimSpace(c.Get("User-Agent")); ua != "" {
+		entry.UserAgent = &ua
+	}
+	_ = audit.Write(userContext(c), h.Repo.Pool, entry)
+}
+
+func extractUserID(c *fiber.Ctx) (string, error) {
+	val := c.Locals("user_id")
+	if val == nil {
+		val = c.Locals("userID")
+	}
+	if val == nil {
+		return "", errors.New("user id missing")
+	}
+	if uid, ok := val.(string); ok && strings.TrimSpace(uid) != "" {
+		return uid, nil
+	}
+	return "", errors.New("user id missing")
+}
+
+func userContext(c *fiber.Ctx) context.Context {
+	if ctx := c.UserContext(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
@@ -0,0 +1,307 @@
+package accounting
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	FormatBeancount = "beancount"
+	FormatLedger    = "ledger" // ledger-cli; hledger reads the same file
+)
+
+// PointsCommodity is the commodity reward points are booked in.
+const PointsCommodity = "VPTS"
+
+// Entry kinds only plain-text journals carry.
+const (
+	KindTransfer = "transfer" // savings goal contribution
+	KindPoints   = "points"
+)
+
+// JournalAccounts are account name templates. {party}, {category} and {goal} are replaced with
+// the sanitised client/vendor, expense category and savings goal name.
+type JournalAccounts struct {
+	Bank           string `json:"bank"`
+	Income         string `json:"income"`
+	Expense        string `json:"expense"`
+	Savings        string `json:"savings"`
+	Points         string `json:"points"`
+	PointsIncome   string `json:"points_income"`
+	PointsRedeemed string `json:"points_redeemed"`
+}
+
+var defaultJournalAccounts = JournalAccounts{
+	Bank:           "Assets:Bank:Checking",
+	Income:         "Income:Clients:{party}",
+	Expense:        "Expenses:{category}",
+	Savings:        "Assets:Savings:{goal}",
+	Points:         "Assets:Rewards:Vantro",
+	PointsIncome:   "Income:Rewards:Vantro",
+	PointsRedeemed: "Expenses:Rewards:Redeemed",
+}
+
+func (a JournalAccounts) withDefaults() JournalAccounts {
+	d := defaultJournalAccounts
+	for _, f := range []struct {
+		dst *string
+		def string
+	}{
+		{&a.Bank, d.Bank}, {&a.Income, d.Income}, {&a.Expense, d.Expense}, {&a.Savings, d.Savings},
+		{&a.Points, d.Points}, {&a.PointsIncome, d.PointsIncome}, {&a.PointsRedeemed, d.PointsRedeemed},
+	} {
+		if strings.TrimSpace(*f.dst) == "" {
+			*f.dst = f.def
+		}
+	}
+	return a
+}
+
+// JournalEntry is one source row: an income, expense, savings transfer or points movement.
+type JournalEntry struct {
+	Kind     string
+	ID       string
+	Date     time.Time
+	Party    string // client, vendor or goal name; points reason
+	Category string
+	Amount   int64 // paise, or points for KindPoints
+	Currency string
+	Note     string
+}
+
+type posting struct {
+	account   string
+	amount    int64
+	scale     int // decimal places: 2 for money, 0 for points
+	commodity string
+}
+
+type journalTxn struct {
+	entry    JournalEntry
+	payee    string
+	postings []posting
+}
+
+func (a JournalAccounts) txn(e JournalEntry) journalTxn {
+	t := journalTxn{entry: e, payee: e.Party}
+	money := func(account string, amount int64) posting {
+		return posting{account: account, amount: amount, scale: 2, commodity: strings.ToUpper(firstNonEmpty(e.Currency, "INR"))}
+	}
+	switch e.Kind {
+	case KindIncome:
+		t.postings = []posting{
+			money(a.Bank, e.Amount),
+			money(expand(a.Income, e), -e.Amount),
+		}
+	case KindExpense:
+		t.postings = []posting{
+			money(expand(a.Expense, e), e.Amount),
+			money(a.Bank, -e.Amount),
+		}
+	case KindTransfer:
+		t.payee = "Savings goal"
+		t.postings = []posting{
+			money(expand(a.Savings, e), e.Amount),
+			money(a.Bank, -e.Amount),
+		}
+	case KindPoints:
+		t.payee = "Vantro rewards"
+		counter := a.PointsIncome
+		if e.Amount < 0 {
+			counter = a.PointsRedeemed
+		}
+		t.postings = []posting{
+			{account: a.Points, amount: e.Amount, commodity: PointsCommodity},
+			{account: counter, amount: -e.Amount, commodity: PointsCommodity},
+		}
+	}
+	return t
+}
+
+// expand fills an account template. Every colon-separated component is sanitised so client
+// names like "Acme Corp. (India)" still make a valid account.
+func expand(template string, e JournalEntry) string {
+	r := strings.NewReplacer(
+		"{party}", e.Party,
+		"{category}", titleCase(e.Category),
+		"{goal}", e.Party,
+	)
+	parts := strings.Split(r.Replace(template), ":")
+	for i, p := range parts {
+		parts[i] = accountComponent(p)
+	}
+	return strings.Join(parts, ":")
+}
+
+// validAccountRoot reports whether a template starts with one of the five root account types
+// both Beancount and hledger understand.
+func validAccountRoot(template string) bool {
+	root, _, _ := strings.Cut(template, ":")
+	switch root {
+	case "Assets", "Liabilities", "Equity", "Income", "Expenses":
+		return true
+	}
+	return false
+}
+
+// accountComponent keeps ASCII letters, digits and dashes and capitalises the first letter,
+// which satisfies Beancount's account grammar and never contains ledger's two-space separator.
+func accountComponent(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range s {
+		switch {
+		case r <= unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		default:
+			dash = true
+		}
+	}
+	out := b.String()
+	if out == "" {
+		return "Unknown"
+	}
+	return strings.ToUpper(out[:1]) + out[1:]
+}
+
+// RenderJournal writes entries as a Beancount or ledger-cli journal: commodity declarations,
+// account opens, then one transaction per entry carrying its Vantro id as metadata.
+func RenderJournal(format string, accounts JournalAccounts, entries []JournalEntry) []byte {
+	accounts = accounts.withDefaults()
+	txns := make([]journalTxn, len(entries))
+	for i, e := range entries {
+		txns[i] = accounts.txn(e)
+	}
+
+	// first use of each account and commodity, for open/commodity directives
+	opened := map[string]time.Time{}
+	commodities := map[string]time.Time{}
+	for _, t := range txns {
+		for _, p := range t.postings {
+			if d, ok := opened[p.account]; !ok || t.entry.Date.Before(d) {
+				opened[p.account] = t.entry.Date
+			}
+			if d, ok := commodities[p.commodity]; !ok || t.entry.Date.Before(d) {
+				commodities[p.commodity] = t.entry.Date
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	if format == FormatBeancount {
+		b.WriteString(`option "title" "Vantro export"` + "\n")
+		b.WriteString(`option "operating_currency" "INR"` + "\n\n")
+		for _, c := range sortedKeys(commodities) {
+			b.WriteString(commodities[c].Format("2006-01-02") + " commodity " + c + "\n")
+		}
+		b.WriteString("\n")
+		for _, acct := range sortedKeys(opened) {
+			b.WriteString(opened[acct].Format("2006-01-02") + " open " + acct + "\n")
+		}
+	} else {
+		for _, c := range sortedKeys(commodities) {
+			b.WriteString("commodity " + c + "\n")
+			if c == PointsCommodity {
+				b.WriteString("    format 1 " + c + "\n")
+			} else {
+				b.WriteString("    format 1,000.00 " + c + "\n")
+			}
+		}
+		b.WriteString("\n")
+		for _, acct := range sortedKeys(opened) {
+			b.WriteString("account " + acct + "\n")
+		}
+	}
+
+	for _, t := range txns {
+		e := t.entry
+		b.WriteString("\n")
+		ref := e.Kind + ":" + e.ID
+		if format == FormatBeancount {
+			b.WriteString(e.Date.Format("2006-01-02") + " * " + quote(t.payee) + " " + quote(narrationText(e)) + "\n")
+			b.WriteString("  vantro-id: " + quote(ref) + "\n")
+			if e.Category != "" {
+				b.WriteString("  vantro-category: " + quote(e.Category) + "\n")
+			}
+		} else {
+			// the note goes in a comment: ledger-cli and hledger split "payee | note" differently
+			b.WriteString(e.Date.Format("2006/01/02") + " * " + ledgerText(t.payee) + "\n")
+			if n := narrationText(e); n != "" {
+				b.WriteString("    ; " + ledgerText(n) + "\n")
+			}
+			b.WriteString("    ; vantro-id: " + ref + "\n")
+			if e.Category != "" {
+				b.WriteString("    ; vantro-category: " + ledgerText(e.Category) + "\n")
+			}
+		}
+		for _, p := range t.postings {
+			indent := "    "
+			if format == FormatBeancount {
+				indent = "  "
+			}
+			amount := formatUnits(p.amount, p.scale) + " " + p.commodity
+			pad := 48 - len(p.account) - len(amount)
+			if pad < 2 {
+				pad = 2
+			}
+			b.WriteString(indent + p.account + strings.Repeat(" ", pad) + amount + "\n")
+		}
+	}
+	return b.Bytes()
+}
+
+func narrationText(e JournalEntry) string {
+	switch e.Kind {
+	case KindTransfer:
+		return firstNonEmpty(e.Party+" "+e.Note, e.Party)
+	case KindPoints:
+		return e.Party
+	}
+	return e.Note
+}
+
+func formatUnits(n int64, scale int) string {
+	if scale == 0 {
+		return strconv.FormatInt(n, 10)
+	}
+	sign := ""
+	if n < 0 {
+		sign, n = "-", -n
+	}
+	return sign + strconv.FormatInt(n/100, 10) + "." + leftPad(strconv.FormatInt(n%100, 10), 2)
+}
+
+func leftPad(s string, n int) string {
+	for len(s) < n {
+		s = "0" + s
+	}
+	return s
+}
+
+func quote(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// ledgerText keeps payees and notes on one line without the comment and separator characters.
+func ledgerText(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return strings.NewReplacer(";", ",", "|", "/").Replace(s)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
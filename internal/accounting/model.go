@@ -0,0 +1,121 @@
+package accounting
+
+import "time"
+
+const (
+	FormatTally = "tally"
+	FormatZoho  = "zoho"
+)
+
+// Entry kinds: the source rows vouchers are built from.
+const (
+	KindIncome  = "income"
+	KindExpense = "expense"
+	KindInvoice = "invoice"
+)
+
+// Tally voucher types.
+const (
+	VoucherReceipt  = "Receipt"
+	VoucherPayment  = "Payment"
+	VoucherSales    = "Sales"
+	VoucherPurchase = "Purchase"
+)
+
+// Entry is one income, expense or invoice in the export range.
+type Entry struct {
+	Kind     string    `json:"kind"`
+	ID       string    `json:"id"`
+	Date     time.Time `json:"date"`
+	Party    string    `json:"party"` // client or vendor
+	Category string    `json:"category,omitempty"`
+	Amount   int64     `json:"amount"` // paise
+	Currency string    `json:"currency"`
+	Note     string    `json:"note,omitempty"`
+	// for incomes booked by paying an invoice: the receipt settles the client's receivable
+	InvoiceID string `json:"invoice_id,omitempty"`
+}
+
+// Ref is the stable reference written into every exported voucher.
+func (e Entry) Ref() string {
+	return "vantro:" + e.Kind + ":" + e.ID
+}
+
+// Line is one side of a voucher. Positive amounts are debits, negative are credits.
+type Line struct {
+	Ledger string `json:"ledger"`
+	Amount int64  `json:"amount"`
+}
+
+type Voucher struct {
+	Type  string `json:"type"`
+	Entry Entry  `json:"entry"`
+	Party string `json:"party,omitempty"` // party ledger, for vouchers against a debtor/creditor
+	Lines []Line `json:"lines"`
+}
+
+// Settings are the user's default ledger names. Empty fields fall back to the defaults below.
+type Settings struct {
+	CompanyName    string `json:"company_name"` // Tally company to import into; empty uses the open one
+	BankLedger     string `json:"bank_ledger"`
+	IncomeLedger   string `json:"income_ledger"`
+	ExpenseLedger  string `json:"expense_ledger"` // empty: "<Category> Expenses" per category
+	SalesLedger    string `json:"sales_ledger"`
+	PurchaseLedger string `json:"purchase_ledger"`
+}
+
+var defaultSettings = Settings{
+	BankLedger:     "Bank Account",
+	IncomeLedger:   "Professional Fees",
+	SalesLedger:    "Sales",
+	PurchaseLedger: "Purchase",
+}
+
+// Mapping kinds.
+const (
+	MapCategory = "category"
+	MapClient   = "client"
+)
+
+// Mapping sends one expense category or income client to a ledger, optionally as a different
+// voucher type (Purchase instead of Payment, Sales instead of Receipt).
+type Mapping struct {
+	Kind        string `json:"kind"`
+	Key         string `json:"key"`
+	Ledger      string `json:"ledger"`
+	VoucherType string `json:"voucher_type,omitempty"`
+}
+
+type Config struct {
+	Settings Settings  `json:"settings"`
+	Mappings []Mapping `json:"mappings"`
+}
+
+// Export is a recorded export run. Entries are remembered so the same income or expense is
+// not sent to the same software twice.
+type Export struct {
+	ID         int64     `json:"id"`
+	Format     string    `json:"format"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	EntryCount int       `json:"entry_count"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ExportRequest struct {
+	Format          string `json:"format"` // tally | zoho
+	From            string `json:"from"`   // YYYY-MM-DD
+	To              string `json:"to"`     // YYYY-MM-DD
+	IncludeExported bool   `json:"include_exported"`
+	IncludeLedgers  bool   `json:"include_ledgers"` // Tally only: also create the ledger masters
+}
+
+// Preview is what an export would contain, without recording anything.
+type Preview struct {
+	Format          string   `json:"format"`
+	From            string   `json:"from"`
+	To              string   `json:"to"`
+	NewEntries      int      `json:"new_entries"`
+	AlreadyExported int      `json:"already_exported"`
+	Overlapping     []Export `json:"overlapping_exports"`
+}
@@ -0,0 +1,377 @@
+package accounting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrNotFound = errors.New("export not found")
+	ErrEmpty    = errors.New("nothing to export in this range")
+)
+
+type Repository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{Pool: pool}
+}
+
+func (r *Repository) GetConfig(ctx context.Context, userID string) (Config, error) {
+	cfg := Config{Mappings: make([]Mapping, 0)}
+	err := r.Pool.QueryRow(ctx, `
+SELECT company_name, bank_ledger, income_ledger, expense_ledger, sales_ledger, purchase_ledger
+FROM accounting_settings
+WHERE user_id = $1
+`, userID).Scan(&cfg.Settings.CompanyName, &cfg.Settings.BankLedger, &cfg.Settings.IncomeLedger,
+		&cfg.Settings.ExpenseLedger, &cfg.Settings.SalesLedger, &cfg.Settings.PurchaseLedger)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return cfg, err
+	}
+
+	rows, err := r.Pool.Query(ctx, `
+SELECT kind, match_key, ledger_name, COALESCE(voucher_type,'')
+FROM accounting_ledger_mappings
+WHERE user_id = $1
+ORDER BY kind, lower(match_key)
+`, userID)
+	if err != nil {
+		return cfg, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m Mapping
+		if err := rows.Scan(&m.Kind, &m.Key, &m.Ledger, &m.VoucherType); err != nil {
+			return cfg, err
+		}
+		cfg.Mappings = append(cfg.Mappings, m)
+	}
+	return cfg, rows.Err()
+}
+
+func (r *Repository) SaveSettings(ctx context.Context, userID string, s Settings) error {
+	_, err := r.Pool.Exec(ctx, `
+INSERT INTO accounting_settings (user_id, company_name, bank_ledger, income_ledger, expense_ledger, sales_ledger, purchase_ledger)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (user_id) DO UPDATE SET
+  company_name = EXCLUDED.company_name,
+  bank_ledger = EXCLUDED.bank_ledger,
+  income_ledger = EXCLUDED.income_ledger,
+  expense_ledger = EXCLUDED.expense_ledger,
+  sales_ledger = EXCLUDED.sales_ledger,
+  purchase_ledger = EXCLUDED.purchase_ledger,
+  updated_at = now()
+`, userID, s.CompanyName, s.BankLedger, s.IncomeLedger, s.ExpenseLedger, s.SalesLedger, s.PurchaseLedger)
+	return err
+}
+
+// ReplaceMappings swaps the user's whole mapping table in one transaction.
+func (r *Repository) ReplaceMappings(ctx context.Context, userID string, mappings []Mapping) error {
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM accounting_ledger_mappings WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, m := range mappings {
+		if _, err := tx.Exec(ctx, `
+INSERT INTO accounting_ledger_mappings (user_id, kind, match_key, ledger_name, voucher_type)
+VALUES ($1, $2, $3, $4, NULLIF($5,''))
+`, userID, m.Kind, m.Key, m.Ledger, m.VoucherType); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// entriesQuery lists every income, expense and non-void invoice dated in [$2, $3], flagging the
+// ones already exported in format $4. $5, when non-zero, limits it to one past export.
+const entriesQuery = `
+SELECT x.kind, x.id, x.date, x.party, x.category, x.amount, x.currency, x.note, x.invoice_id,
+  EXISTS (
+    SELECT 1 FROM accounting_exported_entries a
+    WHERE a.user_id = $1 AND a.format = $4 AND a.entry_kind = x.kind AND a.entry_id = x.id
+  ) AS exported
+FROM (
+  SELECT 'income' AS kind, i.id::text AS id, i.received_on AS date, i.client_name AS party, '' AS category,
+    i.amount, i.currency, COALESCE(i.note,'') AS note, COALESCE(inv.id::text,'') AS invoice_id
+  FROM incomes i
+  LEFT JOIN invoices inv ON inv.user_id = i.user_id AND inv.income_id = i.id::text
+  WHERE i.user_id = $1 AND i.deleted_at IS NULL AND i.received_on BETWEEN $2 AND $3
+  UNION ALL
+  SELECT 'expense', e.id::text, e.spent_on, e.vendor_name, e.category, e.amount, e.currency, COALESCE(e.note,''), ''
+  FROM expenses e
+  WHERE e.user_id = $1 AND e.deleted_at IS NULL AND e.spent_on BETWEEN $2 AND $3
+  UNION ALL
+  SELECT 'invoice', v.id::text, v.issued_on, v.client_name, '', v.amount, v.currency, COALESCE(v.note,''), ''
+  FROM invoices v
+  WHERE v.user_id = $1 AND v.status <> 'VOID' AND v.issued_on BETWEEN $2 AND $3
+) x
+WHERE $5::bigint = 0 OR EXISTS (
+  SELECT 1 FROM accounting_exported_entries a
+  WHERE a.export_id = $5 AND a.entry_kind = x.kind AND a.entry_id = x.id
+)
+ORDER BY x.date, x.kind, x.id`
+
+type flaggedEntry struct {
+	Entry
+	exported bool
+}
+
+func loadEntries(ctx context.Context, q querier, userID, format string, from, to time.Time, exportID int64) ([]flaggedEntry, error) {
+	rows, err := q.Query(ctx, entriesQuery, userID, from, to, format, exportID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]flaggedEntry, 0)
+	for rows.Next() {
+		var e flaggedEntry
+		if err := rows.Scan(&e.Kind, &e.ID, &e.Date, &e.Party, &e.Category, &e.Amount, &e.Currency,
+			&e.Note, &e.InvoiceID, &e.exported); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// Entries returns the entries in range, without the ones already exported in format unless
+// includeExported is set.
+func (r *Repository) Entries(ctx context.Context, userID, format string, from, to time.Time, includeExported bool) ([]Entry, int, error) {
+	flagged, err := loadEntries(ctx, r.Pool, userID, format, from, to, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	out := make([]Entry, 0, len(flagged))
+	already := 0
+	for _, e := range flagged {
+		if e.exported {
+			already++
+			if !includeExported {
+				continue
+			}
+		}
+		out = append(out, e.Entry)
+	}
+	return out, already, nil
+}
+
+// Overlapping lists earlier exports in the same format whose range touches [from, to].
+func (r *Repository) Overlapping(ctx context.Context, userID, format string, from, to time.Time) ([]Export, error) {
+	return r.listExports(ctx, `
+WHERE user_id = $1 AND format = $2 AND from_date <= $4 AND to_date >= $3`, userID, format, from, to)
+}
+
+func (r *Repository) ListExports(ctx context.Context, userID string) ([]Export, error) {
+	return r.listExports(ctx, `WHERE user_id = $1`, userID)
+}
+
+func (r *Repository) listExports(ctx context.Context, where string, args ...any) ([]Export, error) {
+	rows, err := r.Pool.Query(ctx, `
+SELECT id, format, from_date, to_date, entry_count, created_at
+FROM accounting_exports
+`+where+`
+ORDER BY created_at DESC
+LIMIT 200`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Export, 0)
+	for rows.Next() {
+		var e Export
+		if err := rows.Scan(&e.ID, &e.Format, &e.From, &e.To, &e.EntryCount, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// CreateExport picks the entries to export and records them in one transaction. A per-user
+// advisory lock keeps two concurrent exports from both claiming the same entries.
+func (r *Repository) CreateExport(ctx context.Context, userID, format string, from, to time.Time, includeExported bool) (*Export, []Entry, error) {
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('accounting_export:' || $1))`, userID); err != nil {
+		return nil, nil, err
+	}
+
+	flagged, err := loadEntries(ctx, tx, userID, format, from, to, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	entries := make([]Entry, 0, len(flagged))
+	for _, e := range flagged {
+		if e.exported && !includeExported {
+			continue
+		}
+		entries = append(entries, e.Entry)
+	}
+	if len(entries) == 0 {
+		return nil, nil, ErrEmpty
+	}
+
+	exp := &Export{Format: format, From: from, To: to, EntryCount: len(entries)}
+	if err := tx.QueryRow(ctx, `
+INSERT INTO accounting_exports (user_id, format, from_date, to_date, entry_count)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, created_at
+`, userID, format, from, to, exp.EntryCount).Scan(&exp.ID, &exp.CreatedAt); err != nil {
+		return nil, nil, err
+	}
+
+	batch := &pgx.Batch{}
+	for _, e := range entries {
+		batch.Queue(`
+INSERT INTO accounting_exported_entries (export_id, user_id, format, entry_kind, entry_id)
+VALUES ($1, $2, $3, $4, $5)
+`, exp.ID, userID, format, e.Kind, e.ID)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	return exp, entries, nil
+}
+
+// ExportEntries reloads a past export's entries so its file can be downloaded again. Entries
+// deleted since then are left out.
+func (r *Repository) ExportEntries(ctx context.Context, userID string, id int64) (*Export, []Entry, error) {
+	var exp Export
+	err := r.Pool.QueryRow(ctx, `
+SELECT id, format, from_date, to_date, entry_count, created_at
+FROM accounting_exports
+WHERE id = $1 AND user_id = $2
+`, id, userID).Scan(&exp.ID, &exp.Format, &exp.From, &exp.To, &exp.EntryCount, &exp.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	flagged, err := loadEntries(ctx, r.Pool, userID, exp.Format, exp.From, exp.To, exp.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	entries := make([]Entry, len(flagged))
+	for i, e := range flagged {
+		entries[i] = e.Entry
+	}
+	return &exp, entries, nil
+}
+
+func (r *Repository) GetJournalAccounts(ctx context.Context, userID string) (JournalAccounts, error) {
+	var a JournalAccounts
+	var raw []byte
+	err := r.Pool.QueryRow(ctx, `SELECT journal_accounts FROM accounting_settings WHERE user_id = $1`, userID).Scan(&raw)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && len(raw) == 0) {
+		return a, nil
+	}
+	if err != nil {
+		return a, err
+	}
+	return a, json.Unmarshal(raw, &a)
+}
+
+func (r *Repository) SaveJournalAccounts(ctx context.Context, userID string, a JournalAccounts) error {
+	raw, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	_, err = r.Pool.Exec(ctx, `
+INSERT INTO accounting_settings (user_id, journal_accounts)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET journal_accounts = EXCLUDED.journal_accounts, updated_at = now()
+`, userID, raw)
+	return err
+}
+
+// JournalEntries loads the requested kinds dated within [from, to]; nil bounds are open.
+// Timestamped rows (savings transfers, points) are dated by their IST calendar day.
+func (r *Repository) JournalEntries(ctx context.Context, userID string, from, to *time.Time, kinds map[string]bool) ([]JournalEntry, error) {
+	queries := []struct {
+		kind string
+		sql  string
+	}{
+		{KindIncome, `
+SELECT id::text, received_on, client_name, '', amount, currency, COALESCE(note,'')
+FROM incomes
+WHERE user_id = $1 AND deleted_at IS NULL
+  AND ($2::date IS NULL OR received_on >= $2) AND ($3::date IS NULL OR received_on <= $3)
+ORDER BY 2, 1`},
+		{KindExpense, `
+SELECT id::text, spent_on, vendor_name, category, amount, currency, COALESCE(note,'')
+FROM expenses
+WHERE user_id = $1 AND deleted_at IS NULL
+  AND ($2::date IS NULL OR spent_on >= $2) AND ($3::date IS NULL OR spent_on <= $3)
+ORDER BY 2, 1`},
+		{KindTransfer, `
+SELECT c.id::text, (c.created_at AT TIME ZONE 'Asia/Kolkata')::date AS d, g.name, '', c.amount, g.currency,
+  COALESCE(c.note,'')
+FROM savings_goal_contributions c
+JOIN savings_goals g ON g.id = c.goal_id
+WHERE c.user_id = $1
+  AND ($2::date IS NULL OR (c.created_at AT TIME ZONE 'Asia/Kolkata')::date >= $2)
+  AND ($3::date IS NULL OR (c.created_at AT TIME ZONE 'Asia/Kolkata')::date <= $3)
+ORDER BY 2, 1`},
+		{KindPoints, `
+SELECT id::text, (created_at AT TIME ZONE 'Asia/Kolkata')::date AS d, reason, '', points_delta::bigint, '',
+  ''
+FROM points_ledger
+WHERE user_id = $1 AND points_delta <> 0
+  AND ($2::date IS NULL OR (created_at AT TIME ZONE 'Asia/Kolkata')::date >= $2)
+  AND ($3::date IS NULL OR (created_at AT TIME ZONE 'Asia/Kolkata')::date <= $3)
+ORDER BY 2, 1`},
+	}
+
+	out := make([]JournalEntry, 0)
+	for _, q := range queries {
+		if !kinds[q.kind] {
+			continue
+		}
+		rows, err := r.Pool.Query(ctx, q.sql, userID, from, to)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			e := JournalEntry{Kind: q.kind}
+			if err := rows.Scan(&e.ID, &e.Date, &e.Party, &e.Category, &e.Amount, &e.Currency, &e.Note); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			out = append(out, e)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Date.Before(out[j].Date) })
+	return out, nil
+}
@@ -0,0 +1,107 @@
+package accounting
+
+import (
+	"bytes"
+	"encoding/xml"
+	"sort"
+	"strings"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
+)
+
+// RenderTally writes an "Import Data" envelope Tally Prime accepts through Import > Vouchers
+// or the XML port. Each voucher carries REMOTEID=<Entry.Ref()>, so importing the same file
+// twice alters the vouchers instead of duplicating them.
+func RenderTally(cfg Config, vouchers []Voucher, withLedgers bool) []byte {
+	l := newLedgers(cfg)
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString("<ENVELOPE>\n <HEADER>\n  <TALLYREQUEST>Import Data</TALLYREQUEST>\n </HEADER>\n <BODY>\n  <IMPORTDATA>\n")
+	b.WriteString("   <REQUESTDESC>\n    <REPORTNAME>Vouchers</REPORTNAME>\n")
+	if name := strings.TrimSpace(cfg.Settings.CompanyName); name != "" {
+		b.WriteString("    <STATICVARIABLES>\n     <SVCURRENTCOMPANY>" + esc(name) + "</SVCURRENTCOMPANY>\n    </STATICVARIABLES>\n")
+	}
+	b.WriteString("   </REQUESTDESC>\n   <REQUESTDATA>\n")
+
+	if withLedgers {
+		for _, name := range ledgerNames(vouchers) {
+			b.WriteString(`    <TALLYMESSAGE xmlns:UDF="TallyUDF">` + "\n")
+			b.WriteString(`     <LEDGER NAME="` + esc(name) + `" ACTION="Create">` + "\n")
+			b.WriteString("      <NAME>" + esc(name) + "</NAME>\n")
+			b.WriteString("      <PARENT>" + esc(l.ledgerGroup(name, vouchers)) + "</PARENT>\n")
+			b.WriteString("     </LEDGER>\n    </TALLYMESSAGE>\n")
+		}
+	}
+
+	for _, v := range vouchers {
+		e := v.Entry
+		b.WriteString(`    <TALLYMESSAGE xmlns:UDF="TallyUDF">` + "\n")
+		b.WriteString(`     <VOUCHER REMOTEID="` + esc(e.Ref()) + `" VCHTYPE="` + v.Type + `" ACTION="Create" OBJVIEW="Accounting Voucher View">` + "\n")
+		b.WriteString("      <DATE>" + e.Date.Format("20060102") + "</DATE>\n")
+		b.WriteString("      <VOUCHERTYPENAME>" + v.Type + "</VOUCHERTYPENAME>\n")
+		b.WriteString("      <VOUCHERNUMBER>" + esc(voucherNumber(e)) + "</VOUCHERNUMBER>\n")
+		b.WriteString("      <REFERENCE>" + esc(e.Ref()) + "</REFERENCE>\n")
+		if v.Party != "" {
+			b.WriteString("      <PARTYLEDGERNAME>" + esc(v.Party) + "</PARTYLEDGERNAME>\n")
+		}
+		b.WriteString("      <NARRATION>" + esc(narration(e)) + "</NARRATION>\n")
+		for _, line := range v.Lines {
+			// Tally's sign convention: debits are negative and "deemed positive"
+			deemed, amount := "No", line.Amount
+			if line.Amount > 0 {
+				deemed, amount = "Yes", -line.Amount
+			} else {
+				amount = -line.Amount
+			}
+			b.WriteString("      <ALLLEDGERENTRIES.LIST>\n")
+			b.WriteString("       <LEDGERNAME>" + esc(line.Ledger) + "</LEDGERNAME>\n")
+			b.WriteString("       <ISDEEMEDPOSITIVE>" + deemed + "</ISDEEMEDPOSITIVE>\n")
+			b.WriteString("       <AMOUNT>" + money.PaiseToRupeesString(amount) + "</AMOUNT>\n")
+			b.WriteString("      </ALLLEDGERENTRIES.LIST>\n")
+		}
+		b.WriteString("     </VOUCHER>\n    </TALLYMESSAGE>\n")
+	}
+
+	b.WriteString("   </REQUESTDATA>\n  </IMPORTDATA>\n </BODY>\n</ENVELOPE>\n")
+	return b.Bytes()
+}
+
+func voucherNumber(e Entry) string {
+	id := e.ID
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return "VAN-" + strings.ToUpper(e.Kind[:3]) + "-" + id
+}
+
+func narration(e Entry) string {
+	parts := []string{e.Party}
+	if e.Kind == KindInvoice {
+		parts[0] = "Invoice " + e.ID + " to " + e.Party
+	}
+	if e.Note != "" {
+		parts = append(parts, e.Note)
+	}
+	return strings.Join(parts, " - ")
+}
+
+func ledgerNames(vouchers []Voucher) []string {
+	seen := map[string]bool{}
+	for _, v := range vouchers {
+		for _, line := range v.Lines {
+			seen[line.Ledger] = true
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for name := range seen {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func esc(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
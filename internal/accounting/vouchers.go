@@ -0,0 +1,156 @@
+package accounting
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ledgers resolves ledger names and voucher types from the user's configuration.
+type ledgers struct {
+	s        Settings
+	category map[string]Mapping
+	client   map[string]Mapping
+}
+
+func newLedgers(cfg Config) *ledgers {
+	l := &ledgers{s: cfg.Settings, category: map[string]Mapping{}, client: map[string]Mapping{}}
+	for _, f := range []struct {
+		dst *string
+		def string
+	}{
+		{&l.s.BankLedger, defaultSettings.BankLedger},
+		{&l.s.IncomeLedger, defaultSettings.IncomeLedger},
+		{&l.s.SalesLedger, defaultSettings.SalesLedger},
+		{&l.s.PurchaseLedger, defaultSettings.PurchaseLedger},
+	} {
+		if strings.TrimSpace(*f.dst) == "" {
+			*f.dst = f.def
+		}
+	}
+	for _, m := range cfg.Mappings {
+		switch m.Kind {
+		case MapCategory:
+			l.category[mappingKey(m.Key)] = m
+		case MapClient:
+			l.client[mappingKey(m.Key)] = m
+		}
+	}
+	return l
+}
+
+func mappingKey(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// Vouchers turns entries into balanced double-entry vouchers:
+//
+//	income          Receipt   Dr bank            Cr income ledger (or the client, when it paid an invoice)
+//	income (Sales)  Sales     Dr bank            Cr sales ledger
+//	expense         Payment   Dr expense ledger  Cr bank
+//	expense (Purc.) Purchase  Dr purchase ledger Cr bank
+//	invoice         Sales     Dr client          Cr sales ledger
+func (l *ledgers) Vouchers(entries []Entry) []Voucher {
+	out := make([]Voucher, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, l.voucher(e))
+	}
+	return out
+}
+
+func (l *ledgers) voucher(e Entry) Voucher {
+	v := Voucher{Entry: e}
+	switch e.Kind {
+	case KindInvoice:
+		party := l.clientLedger(e.Party)
+		v.Type, v.Party = VoucherSales, party
+		v.Lines = []Line{{party, e.Amount}, {l.s.SalesLedger, -e.Amount}}
+
+	case KindIncome:
+		m, mapped := l.client[mappingKey(e.Party)]
+		switch {
+		case e.InvoiceID != "":
+			// the Sales voucher for the invoice already booked the income
+			party := l.clientLedger(e.Party)
+			v.Type, v.Party = VoucherReceipt, party
+			v.Lines = []Line{{l.s.BankLedger, e.Amount}, {party, -e.Amount}}
+		case mapped && strings.EqualFold(m.VoucherType, VoucherSales):
+			v.Type = VoucherSales
+			v.Lines = []Line{{l.s.BankLedger, e.Amount}, {firstNonEmpty(m.Ledger, l.s.SalesLedger), -e.Amount}}
+		default:
+			v.Type = VoucherReceipt
+			income := l.s.IncomeLedger
+			if mapped && m.Ledger != "" {
+				income = m.Ledger
+			}
+			v.Lines = []Line{{l.s.BankLedger, e.Amount}, {income, -e.Amount}}
+		}
+
+	default: // expense
+		m, mapped := l.category[mappingKey(e.Category)]
+		v.Type = VoucherPayment
+		ledger := l.expenseLedger(e.Category)
+		if mapped && strings.EqualFold(m.VoucherType, VoucherPurchase) {
+			v.Type = VoucherPurchase
+			ledger = firstNonEmpty(m.Ledger, l.s.PurchaseLedger)
+		}
+		v.Lines = []Line{{ledger, e.Amount}, {l.s.BankLedger, -e.Amount}}
+	}
+	return v
+}
+
+func (l *ledgers) clientLedger(client string) string {
+	if m, ok := l.client[mappingKey(client)]; ok && m.Ledger != "" && !strings.EqualFold(m.VoucherType, VoucherSales) {
+		return m.Ledger
+	}
+	return firstNonEmpty(strings.TrimSpace(client), "Sundry Debtor")
+}
+
+func (l *ledgers) expenseLedger(category string) string {
+	if m, ok := l.category[mappingKey(category)]; ok && m.Ledger != "" {
+		return m.Ledger
+	}
+	if l.s.ExpenseLedger != "" {
+		return l.s.ExpenseLedger
+	}
+	return titleCase(firstNonEmpty(category, "General")) + " Expenses"
+}
+
+// ledgerGroup is the Tally group a ledger is created under when masters are exported.
+func (l *ledgers) ledgerGroup(name string, vouchers []Voucher) string {
+	switch name {
+	case l.s.BankLedger:
+		return "Bank Accounts"
+	case l.s.SalesLedger:
+		return "Sales Accounts"
+	case l.s.PurchaseLedger:
+		return "Purchase Accounts"
+	}
+	for _, v := range vouchers {
+		if v.Party == name {
+			return "Sundry Debtors"
+		}
+		if v.Entry.Kind == KindIncome && len(v.Lines) == 2 && v.Lines[1].Ledger == name {
+			return "Indirect Incomes"
+		}
+	}
+	return "Indirect Expenses"
+}
+
+func titleCase(s string) string {
+	words := strings.Fields(strings.ToLower(strings.ReplaceAll(s, "_", " ")))
+	for i, w := range words {
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		words[i] = string(r)
+	}
+	return strings.Join(words, " ")
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+package accounting
+
+import (
+	"bytes"
+	"encoding/csv"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
+)
+
+// RenderJournalCSV writes one row per voucher line in the manual-journal import layout of Zoho
+// Books. Rows sharing a Reference Number form one balanced journal, which most accounting
+// packages with a CSV journal import accept as-is.
+func RenderJournalCSV(vouchers []Voucher) ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	_ = w.Write([]string{
+		"Journal Date", "Reference Number", "Journal Type", "Notes", "Currency",
+		"Account", "Description", "Contact Name", "Debit", "Credit",
+	})
+	for _, v := range vouchers {
+		e := v.Entry
+		for _, line := range v.Lines {
+			debit, credit := "", ""
+			if line.Amount > 0 {
+				debit = money.PaiseToRupeesString(line.Amount)
+			} else {
+				credit = money.PaiseToRupeesString(-line.Amount)
+			}
+			// the contact belongs on the receivable line only
+			contact := ""
+			if v.Party != "" && line.Ledger == v.Party {
+				contact = e.Party
+			}
+			_ = w.Write([]string{
+				e.Date.Format("2006-01-02"), e.Ref(), "both", v.Type + ": " + narration(e), e.Currency,
+				line.Ledger, narration(e), contact, debit, credit,
+			})
+		}
+	}
+	w.Flush()
+	return b.Bytes(), w.Error()
+}
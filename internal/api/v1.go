@@ -13,10 +13,15 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/duplicates"
 )
 
 type Server struct {
 	DB *sql.DB
+	// Pool is optional; when set, new transactions are checked for likely duplicates.
+	Pool *pgxpool.Pool
 }
 
 type createTxnRequest struct {
@@ -110,6 +115,16 @@ func (s *Server) CreateTransaction(c *fiber.Ctx) error {
 		"created_at":     createdAt.Format(time.RFC3339),
 		"points_awarded": pointsAwarded,
 	}
+	if w := duplicates.Warn(ctx, s.Pool, userID, duplicates.Record{
+		Kind:      duplicates.KindTransaction,
+		ID:        strconv.FormatInt(id, 10),
+		Direction: body.Direction,
+		Amount:    body.Amount,
+		Date:      createdAt,
+		Label:     body.Note,
+	}); w != nil {
+		resp["duplicate_warning"] = w
+	}
 
 	if idemKey != "" && requestHash != "" {
 		if buf, mErr := json.Marshal(resp); mErr == nil {
@@ -139,7 +154,7 @@ func (s *Server) ListTransactions(c *fiber.Ctx) error {
 	rows, err := s.DB.QueryContext(c.UserContext(), `
 		SELECT id, amount, direction, COALESCE(note,''), created_at
 		FROM transactions_v1
-		WHERE user_id = $1
+		WHERE user_id = $1 AND deleted_at IS NULL
 		ORDER BY created_at DESC
 		LIMIT 50
 	`, userID)
@@ -1,23 +1,19 @@
 package billing
 
 import (
-	"context"
 	"encoding/json"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/ishantswami13-crypto/vantro-backend/internal/expense"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/outbox"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/pdfkit"
 	"github.com/ishantswami13-crypto/vantro-backend/internal/reports"
 )
 
-type pdfSender interface {
-	SendWhatsAppPDF(ctx context.Context, toPhone, caption, pdfURL string) error
-}
-
 func CreatePaymentLinkHandler(store *Store, rp *RazorpayClient) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var req CreateLinkRequest
@@ -69,7 +65,7 @@ func RazorpayWebhookHandler(
 	billStore *Store,
 	expStore *expense.Store,
 	repStore *reports.Store,
-	tw pdfSender,
+	queue *outbox.Repository,
 ) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		raw := c.Body()
@@ -122,21 +118,17 @@ func RazorpayWebhookHandler(
 			return c.Status(fiber.StatusInternalServerError).SendString("summary error")
 		}
 
-		pdfBytes, err := expense.BuildMonthlyPDF(sum)
+		pdfBytes, err := expense.BuildMonthlyPDF(sum, pdfkit.LangEnglish)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).SendString("pdf error")
 		}
 
-		dir := filepath.Join("data", "reports", safePhone(phone))
-		if mkErr := os.MkdirAll(dir, 0o755); mkErr != nil {
-			return c.Status(fiber.StatusInternalServerError).SendString("file error")
-		}
-		filePath := filepath.Join(dir, "vantro-"+sum.Month+".pdf")
-		if writeErr := os.WriteFile(filePath, pdfBytes, 0o644); writeErr != nil {
-			return c.Status(fiber.StatusInternalServerError).SendString("file error")
-		}
-
-		token, _, err := repStore.Create(c.Context(), phone, sum.Month, filePath, 7*24*time.Hour)
+		token, _, err := repStore.Create(c.Context(), reports.Report{
+			Phone:  phone,
+			Month:  sum.Month,
+			Kind:   reports.KindExpenseMemory,
+			Params: json.RawMessage(`{"lang":"` + pdfkit.LangEnglish + `"}`),
+		}, pdfBytes, 7*24*time.Hour)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).SendString("report token error")
 		}
@@ -144,20 +136,15 @@ func RazorpayWebhookHandler(
 		base := strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/")
 		pdfURL := base + "/r/" + token
 
-		if tw != nil {
-			_ = tw.SendWhatsAppPDF(c.Context(), phone, "Your Vantro Expense Memory report: "+sum.Month, pdfURL)
+		if _, err := queue.Enqueue(c.Context(), outbox.Message{
+			To:       phone,
+			Body:     "Your Vantro Expense Memory report: " + sum.Month,
+			MediaURL: pdfURL,
+			Purpose:  outbox.PurposeMonthlyReport,
+		}); err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString("queue error")
 		}
 
 		return c.Status(fiber.StatusOK).SendString("ok")
 	}
 }
-
-func safePhone(p string) string {
-	out := make([]rune, 0, len(p))
-	for _, r := range p {
-		if (r >= '0' && r <= '9') || r == '+' {
-			out = append(out, r)
-		}
-	}
-	return string(out)
-}
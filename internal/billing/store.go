@@ -40,8 +40,8 @@ func (s *Store) IsActive(ctx context.Context, phone string) (bool, error) {
 
 func (s *Store) ActivateFor30Days(ctx context.Context, phone string, paymentLinkID string) error {
 	const q = `
-        INSERT INTO subscriptions (user_phone, status, current_period_end, razorpay_payment_link_id, updated_at)
-        VALUES ($1, 'active', NOW() + INTERVAL '30 days', $2, NOW())
+        INSERT INTO subscriptions (user_phone, status, current_period_end, razorpay_payment_link_id, updated_at, user_id)
+        VALUES ($1, 'active', NOW() + INTERVAL '30 days', $2, NOW(), (SELECT id FROM users WHERE phone = $1 AND phone_verified_at IS NOT NULL))
         ON CONFLICT (user_phone) DO UPDATE SET
             status = 'active',
             current_period_end = NOW() + INTERVAL '30 days',
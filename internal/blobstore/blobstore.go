@@ -0,0 +1,56 @@
+// Package blobstore keeps generated files (report PDFs) out of the API's local disk layout:
+// callers store bytes under an object key and keep the key, whichever backend holds them.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Store is a flat key/value store for files. Keys are slash-separated paths like
+// "reports/2026/10/ab12.pdf".
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get returns ErrNotFound when there is no object under key.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete succeeds when the object is already gone.
+	Delete(ctx context.Context, key string) error
+}
+
+// FromEnv picks the backend from BLOB_BACKEND: "local" (the default, under BLOB_DIR or
+// data/blobs), "s3" (see NewS3FromEnv) or "memory", which loses everything on restart.
+func FromEnv() (Store, error) {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("BLOB_BACKEND"))) {
+	case "", "local":
+		dir := strings.TrimSpace(os.Getenv("BLOB_DIR"))
+		if dir == "" {
+			dir = "data/blobs"
+		}
+		return &Local{Root: dir}, nil
+	case "s3":
+		return NewS3FromEnv()
+	case "memory":
+		return NewMemory(), nil
+	}
+	return nil, errors.New("BLOB_BACKEND must be local, s3 or memory")
+}
+
+// validKey rejects keys that could escape a directory or that S3 treats specially.
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package blobstore
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory keeps objects in process memory, for tests and local development.
+type Memory struct {
+	mu      sync.RWMutex
+	objects map[string][]byte
+}
+
+func NewMemory() *Memory {
+	return &Memory{objects: make(map[string][]byte)}
+}
+
+func (m *Memory) Put(_ context.Context, key string, data []byte, _ string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	return nil
+}
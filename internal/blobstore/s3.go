@@ -0,0 +1,204 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3 talks to any S3-compatible service (AWS S3, Cloudflare R2, MinIO, ...) with Signature
+// Version 4 signed requests.
+type S3 struct {
+	Endpoint  string // e.g. https://s3.ap-south-1.amazonaws.com
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle puts the bucket in the path (endpoint/bucket/key) instead of the host name;
+	// MinIO and most self-hosted services need it.
+	PathStyle bool
+	Client    *http.Client
+}
+
+// NewS3FromEnv reads S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY_ID,
+// S3_SECRET_ACCESS_KEY and S3_FORCE_PATH_STYLE.
+func NewS3FromEnv() (*S3, error) {
+	s := &S3{
+		Endpoint:  strings.TrimRight(strings.TrimSpace(os.Getenv("S3_ENDPOINT")), "/"),
+		Region:    strings.TrimSpace(os.Getenv("S3_REGION")),
+		Bucket:    strings.TrimSpace(os.Getenv("S3_BUCKET")),
+		AccessKey: strings.TrimSpace(os.Getenv("S3_ACCESS_KEY_ID")),
+		SecretKey: strings.TrimSpace(os.Getenv("S3_SECRET_ACCESS_KEY")),
+		PathStyle: strings.EqualFold(strings.TrimSpace(os.Getenv("S3_FORCE_PATH_STYLE")), "true"),
+		Client:    &http.Client{Timeout: 30 * time.Second},
+	}
+	if s.Region == "" {
+		s.Region = "us-east-1"
+	}
+	if s.Endpoint == "" {
+		s.Endpoint = "https://s3." + s.Region + ".amazonaws.com"
+	}
+	if s.Bucket == "" || s.AccessKey == "" || s.SecretKey == "" {
+		return nil, errors.New("S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required")
+	}
+	return s, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	res, err := s.do(ctx, http.MethodPut, key, data, contentType)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return s3Error(res)
+}
+
+func (s *S3) Get(ctx context.Context, key string) ([]byte, error) {
+	res, err := s.do(ctx, http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if err := s3Error(res); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(res.Body)
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	res, err := s.do(ctx, http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return s3Error(res)
+}
+
+type s3HTTPError struct {
+	Status int
+	Body   string
+}
+
+func (e *s3HTTPError) Error() string {
+	return "s3 request failed: " + http.StatusText(e.Status) + ": " + e.Body
+}
+
+func s3Error(res *http.Response) error {
+	if res.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+	return &s3HTTPError{Status: res.StatusCode, Body: strings.TrimSpace(string(body))}
+}
+
+func (s *S3) do(ctx context.Context, method, key string, body []byte, contentType string) (*http.Response, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	host, path := base.Host, "/"+s.Bucket+"/"+encodePath(key)
+	if !s.PathStyle {
+		host, path = s.Bucket+"."+base.Host, "/"+encodePath(key)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, base.Scheme+"://"+host+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, host, path, body, time.Now().UTC())
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+// sign adds the SigV4 date, content hash and Authorization headers, signing host and every
+// header already on the request.
+func (s *S3) sign(req *http.Request, host, path string, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payload := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payload)
+
+	values := map[string]string{"host": host}
+	for name, v := range req.Header {
+		values[strings.ToLower(name)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(values))
+	for n := range values {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, n := range names {
+		canonicalHeaders.WriteString(n + ":" + values[n] + "\n")
+	}
+	signed := strings.Join(names, ";")
+	canonical := strings.Join([]string{req.Method, path, "", canonicalHeaders.String(), signed, payload}, "\n")
+
+	scope := day + "/" + s.Region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), day)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKey+"/"+scope+
+		", SignedHeaders="+signed+", Signature="+signature)
+}
+
+// encodePath percent-encodes each segment of key as SigV4 expects: everything but the RFC 3986
+// unreserved characters.
+func encodePath(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			b.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, msg string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(msg))
+	return m.Sum(nil)
+}
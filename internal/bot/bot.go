@@ -0,0 +1,219 @@
+// Package bot is the Expense Memory chat bot: it logs expenses from free-text messages and answers
+// a few commands. It knows nothing about the transport; WebhookHandler feeds it messages from
+// any messaging channel and sends its replies back there.
+package bot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/expense"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/messaging"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/ocr"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/reminders"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/reports"
+)
+
+type subscriptionChecker interface {
+	IsActive(ctx context.Context, phone string) (bool, error)
+}
+
+type Bot struct {
+	Expenses *expense.Store
+	Sessions *Sessions
+	// Billing gates the report command and Reports shares the PDF under BaseURL/r/:token; the
+	// command is off while any of them is unset.
+	Billing subscriptionChecker
+	Reports *reports.Store
+	BaseURL string
+	// Directory and Channels back the channel command; it is off while either is unset.
+	Directory *messaging.Directory
+	Channels  *messaging.Registry
+	// OCR reads receipt photos; without it photos are stored and the amount is asked for.
+	OCR ocr.Engine
+	// Reminders backs the remind and streak commands and STOP/START; they are off while it is nil.
+	Reminders *reminders.Store
+	// Location decides what "today" and "this month" mean; Asia/Kolkata by default.
+	Location *time.Location
+	Now      func() time.Time
+}
+
+func New(expenses *expense.Store, sessions *Sessions, billing subscriptionChecker, links *reports.Store) *Bot {
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		loc = time.FixedZone("IST", 5*60*60+30*60)
+	}
+	return &Bot{
+		Expenses: expenses,
+		Sessions: sessions,
+		Billing:  billing,
+		Reports:  links,
+		BaseURL:  strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/"),
+		Location: loc,
+		Now:      time.Now,
+	}
+}
+
+// Reply is what the bot answers with; MediaURL is an optional attachment.
+type Reply struct {
+	Text     string
+	MediaURL string
+}
+
+func text(format string, args ...any) Reply {
+	return Reply{Text: fmt.Sprintf(format, args...)}
+}
+
+// Handle answers one inbound message from phone, received on channel.
+func (b *Bot) Handle(ctx context.Context, channel, phone, msg string) (Reply, error) {
+	now := b.Now().In(b.Location)
+	msg = strings.TrimSpace(msg)
+	sess, err := b.Sessions.Get(ctx, phone, now)
+	if err != nil {
+		return Reply{}, err
+	}
+	sess.Channel = channel
+	before := sess.Pending
+
+	reply, err := b.dispatch(ctx, &sess, msg, now)
+	if err != nil {
+		return Reply{}, err
+	}
+	if err := b.Sessions.Save(ctx, sess); err != nil {
+		return Reply{}, err
+	}
+	b.dropDraft(ctx, sess.Phone, before, sess.Pending)
+	return reply, nil
+}
+
+func (b *Bot) dispatch(ctx context.Context, sess *Session, msg string, now time.Time) (Reply, error) {
+	if msg == "" {
+		return helpReply(), nil
+	}
+	word, args, _ := strings.Cut(msg, " ")
+	args = strings.TrimSpace(args)
+
+	switch strings.ToLower(word) {
+	case "help", "hi", "hello", "menu", "?":
+		sess.clear()
+		return helpReply(), nil
+	case "stop", "stopall", "unsubscribe", "end", "quit":
+		sess.clear()
+		return b.optOut(ctx, sess.Phone)
+	case "start", "unstop", "subscribe":
+		sess.clear()
+		return b.optIn(ctx, sess.Phone)
+	case "remind", "reminder", "reminders":
+		sess.clear()
+		return b.remind(ctx, sess.Phone, args)
+	case "streak":
+		sess.clear()
+		return b.streak(ctx, sess.Phone, now)
+	case "summary":
+		sess.clear()
+		return b.summary(ctx, sess.Phone, now)
+	case "today":
+		sess.clear()
+		return b.today(ctx, sess.Phone, now)
+	case "undo":
+		sess.clear()
+		return b.undo(ctx, sess)
+	case "report":
+		sess.clear()
+		return b.report(ctx, sess.Phone, args, now)
+	case "budget", "budgets":
+		sess.clear()
+		return b.budget(ctx, sess.Phone, args, now)
+	case "channel":
+		sess.clear()
+		return b.channel(ctx, sess.Phone, args)
+	case "cancel":
+		sess.clear()
+		return text("Okay, cancelled."), nil
+	}
+
+	switch sess.State {
+	case stateAwaitCategory:
+		// a message with an amount is a new expense, not the answer
+		if _, ok := expense.ParseMessage(msg, now); ok {
+			sess.clear()
+			break
+		}
+		if cat, ok := expense.ParseCategory(msg); ok {
+			return b.recategorize(ctx, sess, cat)
+		}
+		return text("I didn't catch that category. Reply one of: %s (or cancel).", categoryList()), nil
+	case stateAwaitReceipt:
+		if _, ok := expense.ParseMessage(msg, now); ok && strings.Contains(msg, " ") {
+			sess.clear()
+			break
+		}
+		return b.answerReceipt(ctx, sess, msg, now)
+	case stateAwaitAmount:
+		if paise, err := money.ParseRupees(msg); err == nil && paise > 0 {
+			p := sess.Pending
+			sess.clear()
+			reply, err := b.log(ctx, sess, paise, p.Category, p.Note, nil, now)
+			if err != nil {
+				return Reply{}, err
+			}
+			return b.withStreak(ctx, sess, reply, now), nil
+		}
+		sess.clear()
+	}
+
+	if m, ok := expense.ParseMessage(msg, now); ok {
+		reply, err := b.logMessage(ctx, sess, m, now)
+		if err != nil || m.Intent == expense.IntentIncome {
+			return reply, err
+		}
+		return b.withStreak(ctx, sess, reply, now), nil
+	}
+	if cat := expense.Categorize(msg); cat != "MISC" {
+		sess.Pending = Pending{Note: msg, Category: cat}
+		sess.ask(stateAwaitAmount, now)
+		return text("How much was %s?", msg), nil
+	}
+	return text("I couldn't find an amount in that. Send something like \"250 lunch\" or \"uber 180\", or help for commands."), nil
+}
+
+func helpReply() Reply {
+	return text(`Send an expense like "250 lunch", "kal 2 chai 40 rs" or "lunch 250, cab 180" and I'll log it, or a photo of the bill. Money in works too: "received 5000 from Rahul".
+
+Commands:
+summary - this month so far
+today - what you spent today
+undo - remove what you logged last
+report - this month's PDF report
+budget food - food budget for this month
+budget food 5000 - set it (0 removes it)
+channel - where reports and reminders reach you
+remind 9pm - a daily nudge if you haven't logged (remind off stops it)
+streak - how many days in a row you've logged
+STOP - no more reminders (START turns them back on)`)
+}
+
+func categoryList() string {
+	return strings.ToLower(strings.Join(expense.Categories, ", "))
+}
+
+func inr(paise int64) string {
+	return "₹" + money.FormatINR(paise)
+}
+
+func label(cat string) string {
+	if cat == "" {
+		return ""
+	}
+	return strings.ToUpper(cat[:1]) + strings.ToLower(cat[1:])
+}
+
+// monthStart is the first instant of now's month. Expense months are UTC, as in
+// expense.MonthlySummary.
+func monthStart(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,398 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/expense"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/messaging"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/pdfkit"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/reports"
+)
+
+// logMessage records what a parsed message describes: money received, one expense or several.
+func (b *Bot) logMessage(ctx context.Context, sess *Session, m expense.ParsedMessage, now time.Time) (Reply, error) {
+	at := spentAt(m.Date, now)
+	switch {
+	case m.Intent == expense.IntentIncome:
+		return b.logIncome(ctx, sess, m, at, now)
+	case len(m.Items) == 1:
+		it := m.Items[0]
+		return b.log(ctx, sess, it.AmountPaise, it.Category, it.Note, at, now)
+	}
+	return b.logItems(ctx, sess, m.Items, at, now)
+}
+
+// log records an expense and confirms it. A MISC guess asks for the category. A nil at is now.
+func (b *Bot) log(ctx context.Context, sess *Session, paise int64, cat, note string, at *time.Time, now time.Time) (Reply, error) {
+	e, err := b.Expenses.AddExpense(ctx, expense.AddExpenseRequest{
+		UserPhone:   sess.Phone,
+		AmountPaise: paise,
+		Category:    cat,
+		Note:        note,
+		Source:      sess.Channel,
+		SpentAt:     at,
+	})
+	if errors.Is(err, expense.ErrBadRequest) {
+		return text("That amount doesn't look right. Send something like \"250 lunch\"."), nil
+	}
+	if err != nil {
+		return Reply{}, err
+	}
+	sess.Undo = Undo{ExpenseIDs: []int64{e.ID}}
+
+	what := inr(e.AmountPaise)
+	if e.Note != "" {
+		what += " for " + e.Note
+	}
+	what += onDay(at, now)
+	if e.Category == "MISC" {
+		sess.ExpenseID = e.ID
+		sess.ask(stateAwaitCategory, now)
+		return text("Logged %s. What category was that? Reply one of: %s.", what, categoryList()), nil
+	}
+	return text("Logged %s under %s. Reply undo to remove it.", what, label(e.Category)), nil
+}
+
+// logItems records several expenses from one message; undo removes them together.
+func (b *Bot) logItems(ctx context.Context, sess *Session, items []expense.ParsedItem, at *time.Time, now time.Time) (Reply, error) {
+	var ids []int64
+	var total int64
+	var sb strings.Builder
+	for _, it := range items {
+		e, err := b.Expenses.AddExpense(ctx, expense.AddExpenseRequest{
+			UserPhone:   sess.Phone,
+			AmountPaise: it.AmountPaise,
+			Category:    it.Category,
+			Note:        it.Note,
+			Source:      sess.Channel,
+			SpentAt:     at,
+		})
+		if err != nil {
+			// keep what was already logged undoable
+			sess.Undo = Undo{ExpenseIDs: ids}
+			return Reply{}, err
+		}
+		ids = append(ids, e.ID)
+		total += e.AmountPaise
+		line := inr(e.AmountPaise)
+		if e.Note != "" {
+			line += " " + e.Note
+		}
+		fmt.Fprintf(&sb, "\n%s (%s)", line, label(e.Category))
+	}
+	sess.Undo = Undo{ExpenseIDs: ids}
+	return text("Logged %d expenses%s, %s in total:%s\nReply undo to remove them.", len(ids), onDay(at, now), inr(total), sb.String()), nil
+}
+
+// logIncome records money received. A message with several amounts is one entry of their sum.
+func (b *Bot) logIncome(ctx context.Context, sess *Session, m expense.ParsedMessage, at *time.Time, now time.Time) (Reply, error) {
+	in := expense.Income{UserPhone: sess.Phone, Counterparty: m.Counterparty, Source: sess.Channel}
+	var notes []string
+	for _, it := range m.Items {
+		in.AmountPaise += it.AmountPaise
+		if it.Note != "" {
+			notes = append(notes, it.Note)
+		}
+	}
+	in.Note = strings.Join(notes, ", ")
+	if at != nil {
+		in.CreatedAt = *at
+	}
+	got, err := b.Expenses.AddIncome(ctx, in)
+	if errors.Is(err, expense.ErrBadRequest) {
+		return text("That amount doesn't look right. Send something like \"received 5000 from Rahul\"."), nil
+	}
+	if err != nil {
+		return Reply{}, err
+	}
+	sess.Undo = Undo{IncomeID: got.ID}
+	return text("Noted %s%s. Reply undo to remove it.", received(got), onDay(at, now)), nil
+}
+
+// spentAt is when an expense about date happened: nil for today (or no date), otherwise midday
+// UTC of that day, safely inside its month.
+func spentAt(date, now time.Time) *time.Time {
+	if date.IsZero() || date.Format("2006-01-02") == now.Format("2006-01-02") {
+		return nil
+	}
+	at := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, time.UTC)
+	return &at
+}
+
+// onDay names the day of at for a confirmation; empty for today.
+func onDay(at *time.Time, now time.Time) string {
+	if at == nil {
+		return ""
+	}
+	if at.Format("2006-01-02") == now.AddDate(0, 0, -1).Format("2006-01-02") {
+		return " yesterday"
+	}
+	return " on " + at.Format("Mon 2 Jan")
+}
+
+func received(in *expense.Income) string {
+	what := inr(in.AmountPaise) + " received"
+	if in.Counterparty != "" {
+		what += " from " + in.Counterparty
+	}
+	return what
+}
+
+func (b *Bot) recategorize(ctx context.Context, sess *Session, cat string) (Reply, error) {
+	id := sess.ExpenseID
+	sess.clear()
+	e, err := b.Expenses.SetCategory(ctx, sess.Phone, id, cat)
+	if errors.Is(err, expense.ErrNotFound) {
+		return text("I couldn't find that expense any more."), nil
+	}
+	if err != nil {
+		return Reply{}, err
+	}
+	return text("Got it: %s is now under %s.", inr(e.AmountPaise), label(e.Category)), nil
+}
+
+func (b *Bot) undo(ctx context.Context, sess *Session) (Reply, error) {
+	u := sess.Undo
+	sess.Undo = Undo{}
+	if u.IncomeID != 0 {
+		in, err := b.Expenses.DeleteIncome(ctx, sess.Phone, u.IncomeID)
+		if errors.Is(err, expense.ErrNotFound) {
+			return text("There's nothing to undo."), nil
+		}
+		if err != nil {
+			return Reply{}, err
+		}
+		return text("Removed %s.", received(in)), nil
+	}
+
+	var removed []*expense.Expense
+	var total int64
+	for _, id := range u.ExpenseIDs {
+		e, err := b.Expenses.DeleteExpense(ctx, sess.Phone, id)
+		if errors.Is(err, expense.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return Reply{}, err
+		}
+		removed = append(removed, e)
+		total += e.AmountPaise
+	}
+	switch len(removed) {
+	case 0:
+		return text("There's nothing to undo."), nil
+	case 1:
+		e := removed[0]
+		what := inr(e.AmountPaise)
+		if e.Note != "" {
+			what += " for " + e.Note
+		}
+		return text("Removed %s (%s).", what, label(e.Category)), nil
+	}
+	return text("Removed %d expenses, %s in total.", len(removed), inr(total)), nil
+}
+
+func (b *Bot) summary(ctx context.Context, phone string, now time.Time) (Reply, error) {
+	sum, err := b.Expenses.MonthlySummary(ctx, phone, now.Year(), int(now.Month()))
+	if err != nil {
+		return Reply{}, err
+	}
+	start := monthStart(now)
+	income, err := b.Expenses.IncomeTotal(ctx, phone, start, start.AddDate(0, 1, 0))
+	if err != nil {
+		return Reply{}, err
+	}
+	if sum.Transactions == 0 && income == 0 {
+		return text("Nothing logged in %s yet. Send something like \"250 lunch\" to start.", now.Format("January")), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s so far: %s across %d expenses.\n", now.Format("January"), inr(sum.TotalPaise), sum.Transactions)
+	for i, c := range sum.CategoryBreakup {
+		if i == 5 {
+			break
+		}
+		fmt.Fprintf(&sb, "\n%s: %s (%.0f%%)", label(c.Category), inr(c.TotalPaise), c.Percent)
+	}
+	if income > 0 {
+		fmt.Fprintf(&sb, "\n\nReceived: %s", inr(income))
+	}
+	if sum.Insight != "" {
+		sb.WriteString("\n\n" + sum.Insight)
+	}
+	return Reply{Text: sb.String()}, nil
+}
+
+func (b *Bot) today(ctx context.Context, phone string, now time.Time) (Reply, error) {
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	rows, err := b.Expenses.ListBetween(ctx, phone, start, now.Add(time.Minute))
+	if err != nil {
+		return Reply{}, err
+	}
+	if len(rows) == 0 {
+		return text("Nothing logged today yet."), nil
+	}
+
+	var total int64
+	var sb strings.Builder
+	for i, e := range rows {
+		total += e.AmountPaise
+		if i < 10 {
+			line := inr(e.AmountPaise) + " " + label(e.Category)
+			if e.Note != "" {
+				line += " - " + e.Note
+			}
+			sb.WriteString("\n" + line)
+		}
+	}
+	if len(rows) > 10 {
+		fmt.Fprintf(&sb, "\n...and %d more", len(rows)-10)
+	}
+	return text("Today: %s across %d expenses.\n%s", inr(total), len(rows), sb.String()), nil
+}
+
+// report sends this month's Expense Memory PDF to subscribers; "report hi" gets it in Hindi.
+func (b *Bot) report(ctx context.Context, phone, args string, now time.Time) (Reply, error) {
+	if b.Billing == nil || b.Reports == nil || b.BaseURL == "" {
+		return text("Reports aren't available right now."), nil
+	}
+	active, err := b.Billing.IsActive(ctx, phone)
+	if err != nil {
+		return Reply{}, err
+	}
+	if !active {
+		return text("The monthly PDF report is part of the paid plan. Reply help to see what's free."), nil
+	}
+
+	sum, err := b.Expenses.MonthlySummary(ctx, phone, now.Year(), int(now.Month()))
+	if err != nil {
+		return Reply{}, err
+	}
+	lang := pdfkit.ParseLang(args)
+	pdf, err := expense.BuildMonthlyPDF(sum, lang)
+	if err != nil {
+		return Reply{}, err
+	}
+	token, _, err := b.Reports.Create(ctx, reports.Report{
+		Phone:  phone,
+		Month:  sum.Month,
+		Kind:   reports.KindExpenseMemory,
+		Params: json.RawMessage(`{"lang":"` + lang + `"}`),
+	}, pdf, 7*24*time.Hour)
+	if err != nil {
+		return Reply{}, err
+	}
+	url := b.BaseURL + "/r/" + token
+	return Reply{Text: "Your " + now.Format("January") + " report: " + url, MediaURL: url}, nil
+}
+
+// budget shows or sets category budgets: "budget", "budget food", "budget food 5000".
+func (b *Bot) budget(ctx context.Context, phone, args string, now time.Time) (Reply, error) {
+	start := monthStart(now)
+	end := start.AddDate(0, 1, 0)
+	if args == "" {
+		list, err := b.Expenses.Budgets(ctx, phone, "", start, end)
+		if err != nil {
+			return Reply{}, err
+		}
+		if len(list) == 0 {
+			return text("No budgets yet. Set one with \"budget food 5000\"."), nil
+		}
+		var sb strings.Builder
+		sb.WriteString("Budgets for " + now.Format("January") + ":")
+		for _, bg := range list {
+			sb.WriteString("\n" + budgetLine(bg))
+		}
+		return Reply{Text: sb.String()}, nil
+	}
+
+	name, amount, _ := strings.Cut(args, " ")
+	cat, ok := expense.ParseCategory(name)
+	if !ok {
+		return text("I don't know the category %s. Try one of: %s.", name, categoryList()), nil
+	}
+	if amount = strings.TrimSpace(amount); amount != "" {
+		paise, err := money.ParseRupees(amount)
+		if err != nil || paise < 0 {
+			return text("That budget amount doesn't look right. Try \"budget %s 5000\".", strings.ToLower(cat)), nil
+		}
+		if err := b.Expenses.SetBudget(ctx, phone, cat, paise); err != nil {
+			return Reply{}, err
+		}
+		if paise == 0 {
+			return text("Removed your %s budget.", label(cat)), nil
+		}
+	}
+
+	list, err := b.Expenses.Budgets(ctx, phone, cat, start, end)
+	if err != nil {
+		return Reply{}, err
+	}
+	if len(list) == 0 || list[0].AmountPaise == 0 {
+		spent := int64(0)
+		if len(list) > 0 {
+			spent = list[0].SpentPaise
+		}
+		return text("No %s budget set. Spent %s this month. Set one with \"budget %s 5000\".",
+			label(cat), inr(spent), strings.ToLower(cat)), nil
+	}
+	return Reply{Text: budgetLine(list[0])}, nil
+}
+
+func budgetLine(bg expense.CategoryBudget) string {
+	line := fmt.Sprintf("%s: %s of %s", label(bg.Category), inr(bg.SpentPaise), inr(bg.AmountPaise))
+	if left := bg.AmountPaise - bg.SpentPaise; left >= 0 {
+		return line + " (" + inr(left) + " left)"
+	}
+	return line + " (over by " + inr(bg.SpentPaise-bg.AmountPaise) + ")"
+}
+
+var channelLabels = map[string]string{
+	messaging.ChannelWhatsApp: "WhatsApp",
+	messaging.ChannelSMS:      "SMS",
+	messaging.ChannelTelegram: "Telegram",
+	messaging.ChannelFake:     "the test channel",
+}
+
+// channel shows or switches where proactive messages (reports, reminders) go. Replies to
+// messages always go back where the message came from.
+func (b *Bot) channel(ctx context.Context, phone, args string) (Reply, error) {
+	if b.Directory == nil || b.Channels == nil {
+		return text("Changing channels isn't available right now."), nil
+	}
+	var others []string
+	current, err := b.Directory.Preferred(ctx, phone)
+	if err != nil {
+		return Reply{}, err
+	}
+	for _, name := range b.Channels.Names() {
+		if name != current && name != messaging.ChannelFake {
+			others = append(others, "channel "+name)
+		}
+	}
+
+	want := strings.ToLower(args)
+	if want == "" {
+		if len(others) == 0 {
+			return text("Reports and reminders reach you on %s.", channelLabels[current]), nil
+		}
+		return text("Reports and reminders reach you on %s. To switch, reply %s.", channelLabels[current], strings.Join(others, " or ")), nil
+	}
+	if _, err := b.Channels.Get(want); err != nil {
+		return text("I can't send there. Reply one of: %s.", strings.Join(others, ", ")), nil
+	}
+	err = b.Directory.SetPreferred(ctx, phone, want)
+	if errors.Is(err, messaging.ErrNotLinked) {
+		return text("Message our %s bot first and share your number when it asks, then try again.", channelLabels[want]), nil
+	}
+	if err != nil {
+		return Reply{}, err
+	}
+	return text("Done. Reports and reminders will reach you on %s.", channelLabels[want]), nil
+}
@@ -0,0 +1,175 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/expense"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/ocr"
+)
+
+// HandleReceipt stores a receipt photo sent by phone, reads it and asks the user to confirm the
+// expense. caption is the text sent with the photo, if any.
+func (b *Bot) HandleReceipt(ctx context.Context, channel, phone, caption string, image []byte, contentType string) (Reply, error) {
+	now := b.Now().In(b.Location)
+	sess, err := b.Sessions.Get(ctx, phone, now)
+	if err != nil {
+		return Reply{}, err
+	}
+	sess.Channel = channel
+	before := sess.Pending
+
+	reply, err := b.receipt(ctx, &sess, strings.TrimSpace(caption), image, contentType, now)
+	if err != nil {
+		return Reply{}, err
+	}
+	if err := b.Sessions.Save(ctx, sess); err != nil {
+		return Reply{}, err
+	}
+	b.dropDraft(ctx, sess.Phone, before, sess.Pending)
+	return reply, nil
+}
+
+func (b *Bot) receipt(ctx context.Context, sess *Session, caption string, image []byte, contentType string, now time.Time) (Reply, error) {
+	if b.Expenses.Blobs == nil {
+		return text("I can't take photos yet. Send the expense as text, like \"250 lunch\"."), nil
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		return text("Please send the receipt as a photo."), nil
+	}
+
+	var raw string
+	if b.OCR != nil {
+		var err error
+		if raw, err = b.OCR.Text(ctx, image); err != nil {
+			// the photo is still worth keeping; the user types the amount instead
+			log.Printf("[bot] ocr for %s: %v", sess.Phone, err)
+		}
+	}
+	r := ocr.ParseReceipt(raw)
+	// a misread year or a date on the wrong side of now is worse than none
+	if !r.Date.IsZero() && (r.Date.After(now) || r.Date.Before(now.AddDate(-1, 0, 0))) {
+		r.Date = time.Time{}
+	}
+
+	a := expense.Attachment{
+		UserPhone:   sess.Phone,
+		ContentType: contentType,
+		OCRText:     raw,
+		Merchant:    r.Merchant,
+		TotalPaise:  r.TotalPaise,
+	}
+	if !r.Date.IsZero() {
+		a.ReceiptDate = &r.Date
+	}
+	att, err := b.Expenses.AddAttachment(ctx, a, image)
+	if err != nil {
+		return Reply{}, err
+	}
+
+	p := Pending{AttachmentID: att.ID, AmountPaise: r.TotalPaise, Note: r.Merchant}
+	if !r.Date.IsZero() {
+		p.Date = r.Date.Format("2006-01-02")
+	}
+	// the caption wins: "lunch" names it, "450 lunch" also fixes the amount
+	if caption != "" {
+		if amount, _, note, ok := expense.ParseText(caption); ok {
+			p.AmountPaise = amount
+			if note != "" {
+				p.Note = note
+			}
+		} else {
+			p.Note = caption
+		}
+	}
+	p.Category = expense.Categorize(p.Note)
+
+	sess.clear()
+	sess.Pending = p
+	sess.ask(stateAwaitReceipt, now)
+	return receiptPrompt(p), nil
+}
+
+// dropDraft discards the receipt draft before held once the session moved on without it: a
+// command, a new expense or another photo replaced the question about it. Drafts that were
+// confirmed or discarded already are left alone.
+func (b *Bot) dropDraft(ctx context.Context, phone string, before, after Pending) {
+	if before.AttachmentID == 0 || after.AttachmentID == before.AttachmentID {
+		return
+	}
+	if err := b.Expenses.DiscardDraft(ctx, phone, before.AttachmentID); err != nil && !errors.Is(err, expense.ErrNotFound) {
+		log.Printf("[bot] discard draft %d of %s: %v", before.AttachmentID, phone, err)
+	}
+}
+
+func receiptPrompt(p Pending) Reply {
+	if p.AmountPaise <= 0 {
+		return text("I saved the receipt but couldn't read the total. How much was it? (Reply 2 to discard it.)")
+	}
+	what := inr(p.AmountPaise)
+	if p.Note != "" {
+		what += " at " + p.Note
+	}
+	if d, err := time.Parse("2006-01-02", p.Date); err == nil {
+		what += " on " + d.Format("2 Jan")
+	}
+	return text("Receipt: %s, under %s.\nReply 1 to save it, 2 to discard it, the right amount to correct it, or a category to change it.", what, label(p.Category))
+}
+
+// answerReceipt handles the reply to a receipt draft.
+func (b *Bot) answerReceipt(ctx context.Context, sess *Session, msg string, now time.Time) (Reply, error) {
+	p := sess.Pending
+	switch strings.ToLower(msg) {
+	case "1", "yes", "y", "ok", "save", "confirm":
+		if p.AmountPaise <= 0 {
+			return text("How much was it? Send the amount, like 450."), nil
+		}
+		return b.confirmReceipt(ctx, sess, now)
+	case "2", "no", "n", "discard", "delete":
+		sess.clear()
+		if err := b.Expenses.DiscardDraft(ctx, sess.Phone, p.AttachmentID); err != nil && !errors.Is(err, expense.ErrNotFound) {
+			return Reply{}, err
+		}
+		return text("Okay, discarded the receipt."), nil
+	}
+
+	if paise, err := money.ParseRupees(msg); err == nil && paise > 0 {
+		sess.Pending.AmountPaise = paise
+		return b.confirmReceipt(ctx, sess, now)
+	}
+	if cat, ok := expense.ParseCategory(msg); ok {
+		sess.Pending.Category = cat
+		sess.ask(stateAwaitReceipt, now)
+		return receiptPrompt(sess.Pending), nil
+	}
+	return receiptPrompt(p), nil
+}
+
+func (b *Bot) confirmReceipt(ctx context.Context, sess *Session, now time.Time) (Reply, error) {
+	p := sess.Pending
+	sess.clear()
+
+	req := expense.AddExpenseRequest{
+		AmountPaise: p.AmountPaise,
+		Category:    p.Category,
+		Note:        p.Note,
+		Source:      sess.Channel,
+	}
+	if d, err := time.Parse("2006-01-02", p.Date); err == nil {
+		req.SpentAt = spentAt(d, now)
+	}
+	e, err := b.Expenses.ConfirmDraft(ctx, sess.Phone, p.AttachmentID, req)
+	if errors.Is(err, expense.ErrNotFound) {
+		return text("That receipt was already handled."), nil
+	}
+	if err != nil {
+		return Reply{}, err
+	}
+	sess.Undo = Undo{ExpenseIDs: []int64{e.ID}}
+	reply := text("Logged %s under %s with the receipt. Reply undo to remove it.", inr(e.AmountPaise), label(e.Category))
+	return b.withStreak(ctx, sess, reply, now), nil
+}
@@ -0,0 +1,186 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/reminders"
+)
+
+// optOut handles STOP: no more reminders or streak messages until START.
+func (b *Bot) optOut(ctx context.Context, phone string) (Reply, error) {
+	if b.Reminders == nil {
+		return text("You won't get any reminders from us."), nil
+	}
+	if err := b.Reminders.SetOptedOut(ctx, phone, true); err != nil {
+		return Reply{}, err
+	}
+	return text("You won't get reminders from us any more. You can still log expenses here. Reply START to turn them back on."), nil
+}
+
+// optIn handles START, which is also a greeting: it lifts a STOP and shows the help.
+func (b *Bot) optIn(ctx context.Context, phone string) (Reply, error) {
+	reply := helpReply()
+	if b.Reminders == nil {
+		return reply, nil
+	}
+	p, err := b.Reminders.Get(ctx, phone)
+	if err != nil {
+		return Reply{}, err
+	}
+	if !p.OptedOut {
+		return reply, nil
+	}
+	if err := b.Reminders.SetOptedOut(ctx, phone, false); err != nil {
+		return Reply{}, err
+	}
+	reply.Text = "Reminders are back on.\n\n" + reply.Text
+	return reply, nil
+}
+
+// remind shows or changes the daily reminder: "remind 9pm", "remind 21:30 weekdays",
+// "remind off", "remind quiet 23:00-07:00".
+func (b *Bot) remind(ctx context.Context, phone, args string) (Reply, error) {
+	if b.Reminders == nil {
+		return text("Reminders aren't available right now."), nil
+	}
+	p, err := b.Reminders.Get(ctx, phone)
+	if err != nil {
+		return Reply{}, err
+	}
+
+	args = strings.ToLower(strings.TrimSpace(args))
+	word, rest, _ := strings.Cut(args, " ")
+	rest = strings.TrimSpace(rest)
+	switch word {
+	case "":
+		return text("%s\n\nTry remind 9pm, remind 21:30 weekdays, remind quiet 23:00-07:00 or remind off.", describe(p)), nil
+	case "off":
+		p.Enabled = false
+	case "on":
+		p.Enabled = true
+	case "quiet":
+		if rest == "off" || rest == "" {
+			p.QuietStart, p.QuietEnd = "", ""
+		} else {
+			start, end, ok := strings.Cut(rest, "-")
+			if !ok {
+				return text("Send quiet hours like remind quiet 23:00-07:00."), nil
+			}
+			p.QuietStart, p.QuietEnd = strings.TrimSpace(start), strings.TrimSpace(end)
+		}
+	default:
+		// "9 pm weekdays" has the time split in two
+		if rest == "am" || rest == "pm" || strings.HasPrefix(rest, "am ") || strings.HasPrefix(rest, "pm ") {
+			word += rest[:2]
+			rest = strings.TrimSpace(rest[2:])
+		}
+		clock, err := reminders.NormalizeClock(word)
+		if err != nil {
+			// "remind weekdays" keeps the time
+			if days, derr := reminders.ParseDays(args); derr == nil {
+				p.Days, p.Enabled = days, true
+				break
+			}
+			return text("I didn't get that time. Try remind 9pm or remind 21:30."), nil
+		}
+		p.Time, p.Enabled = clock, true
+		if rest != "" {
+			if p.Days, err = reminders.ParseDays(rest); err != nil {
+				return text("Days can be daily, weekdays, weekends or names like mon,wed,fri."), nil
+			}
+		}
+	}
+
+	saved, err := b.Reminders.Save(ctx, p)
+	if err != nil {
+		if errors.Is(err, reminders.ErrBadTime) || errors.Is(err, reminders.ErrBadQuiet) {
+			return text("I didn't get those times. Try remind quiet 23:00-07:00."), nil
+		}
+		return Reply{}, err
+	}
+	return text("Done. %s", describe(saved)), nil
+}
+
+func describe(p reminders.Prefs) string {
+	if p.OptedOut {
+		return "You've opted out of reminders. Reply START to turn them back on."
+	}
+	if !p.Enabled {
+		return "Daily reminders are off."
+	}
+	days := "every day"
+	if len(p.Days) < 7 {
+		days = "on " + strings.Join(p.Days, ", ")
+	}
+	s := fmt.Sprintf("I'll remind you at %s %s if you haven't logged anything that day.", p.Time, days)
+	if p.QuietStart != "" {
+		s += fmt.Sprintf(" Quiet hours: %s-%s.", p.QuietStart, p.QuietEnd)
+	}
+	return s
+}
+
+func (b *Bot) streak(ctx context.Context, phone string, now time.Time) (Reply, error) {
+	if b.Reminders == nil {
+		return text("Streaks aren't available right now."), nil
+	}
+	st, err := b.Reminders.Update(ctx, phone, now)
+	if err != nil {
+		return Reply{}, err
+	}
+	var s string
+	switch {
+	case st.Current == 0:
+		s = "No streak yet. Log something today to start one."
+	case st.Today:
+		s = fmt.Sprintf("🔥 %d-day logging streak, today included.", st.Current)
+	default:
+		s = fmt.Sprintf("🔥 %d-day logging streak. Log something today to keep it going.", st.Current)
+	}
+	if st.Best > st.Current {
+		s += fmt.Sprintf(" Your best is %d days.", st.Best)
+	}
+	if next := nextMilestone(st.Current); next.Days > 0 {
+		s += fmt.Sprintf("\n%d more days for %d bonus points.", next.Days-st.Current, next.Points)
+	}
+	return Reply{Text: s + earned(st)}, nil
+}
+
+// withStreak adds the streak to the reply for a freshly logged expense when it grew, and any
+// bonus it just earned. A failure only costs the note.
+func (b *Bot) withStreak(ctx context.Context, sess *Session, reply Reply, now time.Time) Reply {
+	if b.Reminders == nil || sess.State != "" {
+		return reply
+	}
+	st, err := b.Reminders.Update(ctx, sess.Phone, now)
+	if err != nil {
+		log.Printf("[bot] streak for %s: %v", sess.Phone, err)
+		return reply
+	}
+	if st.Extended && st.Current > 1 {
+		reply.Text += fmt.Sprintf("\n🔥 %d days in a row.", st.Current)
+	}
+	reply.Text += earned(st)
+	return reply
+}
+
+func earned(st reminders.Streak) string {
+	var s string
+	for _, m := range st.Earned {
+		s += fmt.Sprintf("\n🎉 %d-day streak: +%d bonus points.", m.Days, m.Points)
+	}
+	return s
+}
+
+func nextMilestone(days int) reminders.Milestone {
+	for _, m := range reminders.Milestones {
+		if m.Days > days {
+			return m
+		}
+	}
+	return reminders.Milestone{}
+}
@@ -0,0 +1,122 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Conversation states: what the bot asked last and expects the next message to answer.
+const (
+	stateIdle          = ""
+	stateAwaitCategory = "await_category" // Session.ExpenseID was logged as MISC
+	stateAwaitAmount   = "await_amount"   // Session.Pending holds the note of an amount-less message
+	stateAwaitReceipt  = "await_receipt"  // Session.Pending holds a draft read off a receipt photo
+)
+
+// stateTTL is how long a question stays open; after that the next message starts afresh.
+const stateTTL = 15 * time.Minute
+
+// Session is the per-phone conversation state.
+type Session struct {
+	Phone string
+	// Channel is where the message being handled came from; it isn't stored.
+	Channel   string
+	State     string
+	ExpenseID int64
+	Pending   Pending
+	// Undo is what the undo command removes; it outlives State.
+	Undo      Undo
+	ExpiresAt time.Time
+}
+
+// Undo holds what the last logging message added: one or more expenses, or an income entry.
+type Undo struct {
+	ExpenseIDs []int64 `json:"expense_ids,omitempty"`
+	IncomeID   int64   `json:"income_id,omitempty"`
+}
+
+func (u Undo) empty() bool { return len(u.ExpenseIDs) == 0 && u.IncomeID == 0 }
+
+type Pending struct {
+	Note     string `json:"note,omitempty"`
+	Category string `json:"category,omitempty"`
+	// receipt drafts only
+	AttachmentID int64  `json:"attachment_id,omitempty"`
+	AmountPaise  int64  `json:"amount_paise,omitempty"`
+	Date         string `json:"date,omitempty"` // YYYY-MM-DD on the receipt
+}
+
+// Sessions keeps conversation state in bot_sessions.
+type Sessions struct {
+	DB *sql.DB
+}
+
+// Get returns the phone's session, with an expired question already dropped.
+func (s *Sessions) Get(ctx context.Context, phone string, now time.Time) (Session, error) {
+	const q = `
+        SELECT state, COALESCE(expense_id, 0), pending, COALESCE(last_expense_id, 0), undo, expires_at
+        FROM bot_sessions WHERE phone = $1;
+    `
+	sess := Session{Phone: phone}
+	var pending, undo []byte
+	var lastExpenseID int64
+	err := s.DB.QueryRowContext(ctx, q, phone).
+		Scan(&sess.State, &sess.ExpenseID, &pending, &lastExpenseID, &undo, &sess.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return sess, nil
+	}
+	if err != nil {
+		return sess, err
+	}
+	_ = json.Unmarshal(pending, &sess.Pending)
+	_ = json.Unmarshal(undo, &sess.Undo)
+	// sessions saved before undo covered several expenses
+	if sess.Undo.empty() && lastExpenseID != 0 {
+		sess.Undo.ExpenseIDs = []int64{lastExpenseID}
+	}
+	if now.After(sess.ExpiresAt) {
+		sess.State, sess.ExpenseID, sess.Pending = stateIdle, 0, Pending{}
+	}
+	return sess, nil
+}
+
+func (s *Sessions) Save(ctx context.Context, sess Session) error {
+	pending, err := json.Marshal(sess.Pending)
+	if err != nil {
+		return err
+	}
+	undo, err := json.Marshal(sess.Undo)
+	if err != nil {
+		return err
+	}
+	var lastExpenseID int64
+	if n := len(sess.Undo.ExpenseIDs); n > 0 {
+		lastExpenseID = sess.Undo.ExpenseIDs[n-1]
+	}
+	const q = `
+        INSERT INTO bot_sessions (phone, state, expense_id, pending, last_expense_id, undo, expires_at, updated_at)
+        VALUES ($1, $2, NULLIF($3, 0), $4, NULLIF($5, 0), $6, $7, NOW())
+        ON CONFLICT (phone) DO UPDATE SET
+            state = EXCLUDED.state,
+            expense_id = EXCLUDED.expense_id,
+            pending = EXCLUDED.pending,
+            last_expense_id = EXCLUDED.last_expense_id,
+            undo = EXCLUDED.undo,
+            expires_at = EXCLUDED.expires_at,
+            updated_at = NOW();
+    `
+	_, err = s.DB.ExecContext(ctx, q, sess.Phone, sess.State, sess.ExpenseID, pending, lastExpenseID, undo, sess.ExpiresAt)
+	return err
+}
+
+// ask opens a question on the session.
+func (sess *Session) ask(state string, now time.Time) {
+	sess.State = state
+	sess.ExpiresAt = now.Add(stateTTL)
+}
+
+func (sess *Session) clear() {
+	sess.State, sess.ExpenseID, sess.Pending = stateIdle, 0, Pending{}
+}
@@ -0,0 +1,149 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/messaging"
+)
+
+const (
+	askForPhone  = "Hi! To keep your expenses in one place, share your phone number with the button below."
+	somethingBad = "Sorry, something went wrong on our side. Please try again in a minute."
+)
+
+// receiptTimeout bounds downloading, reading and answering one receipt photo.
+const receiptTimeout = 2 * time.Minute
+
+// WebhookHandler is the inbound webhook of ch: each message goes to the bot and the reply goes
+// back on the same channel, inline when the channel answers in the response (Twilio). A photo
+// goes to HandleReceipt in the background, since the download and OCR can outlast the
+// webhook's deadline, and its reply follows as a message of its own.
+// Messages from a chat that isn't linked to a phone yet get asked for the number first. A
+// message that fails with a server error is forgotten again, so the provider's retry is handled.
+func WebhookHandler(ch messaging.Channel, b *Bot, dir *messaging.Directory) fiber.Handler {
+	handle := webhook(ch, b, dir)
+	return func(c *fiber.Ctx) error {
+		err := handle(c)
+		if f, ok := ch.(messaging.Forgetter); ok {
+			if err != nil || c.Response().StatusCode() >= fiber.StatusInternalServerError {
+				f.ForgetWebhook(c)
+			}
+		}
+		return err
+	}
+}
+
+func webhook(ch messaging.Channel, b *Bot, dir *messaging.Directory) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := ch.VerifyWebhook(c)
+		var fe *fiber.Error
+		switch {
+		case errors.Is(err, messaging.ErrDuplicate):
+			return ack(c, ch, messaging.Inbound{})
+		case errors.As(err, &fe):
+			return c.Status(fe.Code).SendString(fe.Message)
+		case err != nil:
+			return err
+		}
+
+		in, ok, err := ch.ParseInbound(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("bad message: " + err.Error())
+		}
+		if !ok {
+			return ack(c, ch, in)
+		}
+		ctx := c.UserContext()
+
+		phone, linked := in.Phone, false
+		if phone != "" && phone != in.Address {
+			if err := dir.Link(ctx, in.Channel, in.Address, phone); err != nil {
+				log.Printf("[%s] link %s: %v", in.Channel, in.Address, err)
+				return c.Status(fiber.StatusInternalServerError).SendString("server error")
+			}
+			linked = true
+		}
+		if phone == "" {
+			phone, err = dir.PhoneFor(ctx, in.Channel, in.Address)
+			if errors.Is(err, messaging.ErrNotLinked) {
+				if pr, ok := ch.(messaging.PhoneRequester); ok {
+					if err := pr.RequestPhone(ctx, in.Address, askForPhone); err != nil {
+						log.Printf("[%s] ask %s for phone: %v", in.Channel, in.Address, err)
+					}
+				}
+				return ack(c, ch, in)
+			}
+			if err != nil {
+				log.Printf("[%s] look up %s: %v", in.Channel, in.Address, err)
+				return c.Status(fiber.StatusInternalServerError).SendString("server error")
+			}
+		}
+
+		var reply Reply
+		fetcher, canFetch := ch.(messaging.MediaFetcher)
+		switch {
+		case linked && in.Text == "":
+			reply = helpReply()
+			reply.Text = "Thanks, your number is linked.\n\n" + reply.Text
+		case len(in.MediaURLs) > 0 && canFetch:
+			go receipt(ch, fetcher, b, in, phone)
+			return ack(c, ch, in)
+		default:
+			reply, err = b.Handle(ctx, in.Channel, phone, in.Text)
+		}
+		if err != nil {
+			log.Printf("[%s] from=%s: %v", in.Channel, phone, err)
+			reply = Reply{Text: somethingBad}
+		}
+
+		if r, ok := ch.(messaging.Replier); ok {
+			return r.Reply(c, in, reply.Text, reply.MediaURL)
+		}
+		// the message was handled either way; a retried webhook would handle it twice
+		send(ctx, ch, in.Address, reply)
+		return c.SendStatus(fiber.StatusOK)
+	}
+}
+
+// receipt reads the photo of in outside the webhook request and sends the answer.
+func receipt(ch messaging.Channel, fetcher messaging.MediaFetcher, b *Bot, in messaging.Inbound, phone string) {
+	ctx, cancel := context.WithTimeout(context.Background(), receiptTimeout)
+	defer cancel()
+
+	// one receipt per message; WhatsApp sends an album as separate messages anyway
+	data, contentType, err := fetcher.FetchMedia(ctx, in.MediaURLs[0])
+	var reply Reply
+	if err == nil {
+		reply, err = b.HandleReceipt(ctx, in.Channel, phone, in.Text, data, contentType)
+	}
+	if err != nil {
+		log.Printf("[%s] receipt from=%s: %v", in.Channel, phone, err)
+		reply = Reply{Text: somethingBad}
+	}
+	send(ctx, ch, in.Address, reply)
+}
+
+func send(ctx context.Context, ch messaging.Channel, to string, reply Reply) {
+	var err error
+	if reply.MediaURL != "" {
+		_, err = ch.SendDocument(ctx, to, reply.Text, reply.MediaURL)
+	} else {
+		_, err = ch.SendText(ctx, to, reply.Text)
+	}
+	if err != nil {
+		log.Printf("[%s] reply to %s: %v", ch.Name(), to, err)
+	}
+}
+
+// ack answers a webhook without replying to the user.
+func ack(c *fiber.Ctx, ch messaging.Channel, in messaging.Inbound) error {
+	if r, ok := ch.(messaging.Replier); ok {
+		return r.Reply(c, in, "", "")
+	}
+	return c.SendStatus(fiber.StatusOK)
+}
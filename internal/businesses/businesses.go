@@ -0,0 +1,19 @@
+// Package businesses holds the checks other packages make against the businesses an account owns.
+package businesses
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// OwnedBy reports whether business id exists and belongs to userID. Handlers that accept a
+// business_id answer 404 "business not found" when it doesn't, so ids of other accounts' businesses
+// look the same as ids that don't exist.
+func OwnedBy(ctx context.Context, db *pgxpool.Pool, id int64, userID string) (bool, error) {
+	var ok bool
+	err := db.QueryRow(ctx, `
+SELECT EXISTS (SELECT 1 FROM businesses WHERE id = $1 AND owner_user_id = $2)
+`, id, userID).Scan(&ok)
+	return ok, err
+}
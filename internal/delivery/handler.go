@@ -0,0 +1,316 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/businesses"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/pdfkit"
+)
+
+type Handler struct {
+	Repo *Repository
+}
+
+func NewHandler(repo *Repository) *Handler {
+	return &Handler{Repo: repo}
+}
+
+func (h *Handler) List(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	items, err := h.Repo.List(userContext(c), userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to list subscriptions: "+err.Error())
+	}
+	return c.JSON(items)
+}
+
+// Create subscribes to a report. Email deliveries default to the account's address.
+func (h *Handler) Create(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	var req CreateSubscriptionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+
+	s := Subscription{
+		UserID:      userID,
+		Report:      strings.ToLower(strings.TrimSpace(req.Report)),
+		Format:      strings.ToLower(strings.TrimSpace(req.Format)),
+		Channel:     strings.ToLower(strings.TrimSpace(req.Channel)),
+		Destination: strings.TrimSpace(req.Destination),
+		Timezone:    strings.TrimSpace(req.Timezone),
+		Locale:      strings.ToLower(strings.TrimSpace(req.Locale)),
+		BusinessID:  req.BusinessID,
+		Active:      true,
+	}
+	if !validReport(s.Report) {
+		return fiber.NewError(fiber.StatusBadRequest, "report must be weekly_digest, monthly_statement or quarterly_pnl")
+	}
+	if s.Format == "" {
+		s.Format = FormatPDF
+	}
+	if s.Channel == "" {
+		s.Channel = ChannelEmail
+	}
+
+	ctx := userContext(c)
+	if s.Channel == ChannelEmail && s.Destination == "" {
+		if err := h.Repo.Pool.QueryRow(ctx, `SELECT email FROM users WHERE id = $1`, userID).Scan(&s.Destination); err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "failed to load account email: "+err.Error())
+		}
+	}
+	if s.BusinessID != nil {
+		if s.Report != ReportQuarterlyPnL {
+			return fiber.NewError(fiber.StatusBadRequest, "business_id only applies to quarterly_pnl")
+		}
+		ok, err := businesses.OwnedBy(ctx, h.Repo.Pool, *s.BusinessID, userID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "failed business lookup: "+err.Error())
+		}
+		if !ok {
+			return fiber.NewError(fiber.StatusNotFound, "business not found")
+		}
+	}
+	if err := validate(&s); err != nil {
+		return err
+	}
+
+	out, err := h.Repo.Create(ctx, s)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to create subscription: "+err.Error())
+	}
+	return c.Status(fiber.StatusCreated).JSON(out)
+}
+
+func (h *Handler) Update(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	var req UpdateSubscriptionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+
+	ctx := userContext(c)
+	s, err := h.Repo.Get(ctx, userID, id)
+	if errors.Is(err, ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load subscription: "+err.Error())
+	}
+
+	if req.Format != nil {
+		s.Format = strings.ToLower(strings.TrimSpace(*req.Format))
+	}
+	if req.Channel != nil {
+		s.Channel = strings.ToLower(strings.TrimSpace(*req.Channel))
+	}
+	if req.Destination != nil {
+		s.Destination = strings.TrimSpace(*req.Destination)
+	}
+	if req.Timezone != nil {
+		s.Timezone = strings.TrimSpace(*req.Timezone)
+	}
+	if req.Locale != nil {
+		s.Locale = strings.ToLower(strings.TrimSpace(*req.Locale))
+	}
+	wasActive := s.Active
+	if req.Active != nil {
+		s.Active = *req.Active
+	}
+	if err := validate(&s); err != nil {
+		return err
+	}
+	// a resumed subscription picks up from now instead of sending everything it missed
+	if req.Timezone != nil || (s.Active && !wasActive) {
+		loc, _ := loadLocation(s.Timezone)
+		s.NextRunAt = NextRun(s.Report, loc, time.Now())
+	}
+
+	out, err := h.Repo.Update(ctx, s)
+	if errors.Is(err, ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to update subscription: "+err.Error())
+	}
+	return c.JSON(out)
+}
+
+func (h *Handler) Delete(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	if err := h.Repo.Delete(userContext(c), userID, id); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to delete subscription: "+err.Error())
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Deliveries returns the subscription's delivery log, newest first.
+func (h *Handler) Deliveries(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	ctx := userContext(c)
+	if _, err := h.Repo.Get(ctx, userID, id); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load subscription: "+err.Error())
+	}
+	items, err := h.Repo.Deliveries(ctx, userID, id)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to list deliveries: "+err.Error())
+	}
+	return c.JSON(items)
+}
+
+// SendNow queues the last complete period for the scheduler's next tick.
+func (h *Handler) SendNow(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	ctx := userContext(c)
+	s, err := h.Repo.Get(ctx, userID, id)
+	if errors.Is(err, ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load subscription: "+err.Error())
+	}
+
+	loc, _ := loadLocation(s.Timezone)
+	from, to := Period(s.Report, loc, time.Now())
+	d, err := h.Repo.Enqueue(ctx, s, from, to, true)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to queue delivery: "+err.Error())
+	}
+	return c.Status(fiber.StatusAccepted).JSON(d)
+}
+
+// validate normalises s and checks the fields a request can set; next_run_at is recomputed
+// for new subscriptions.
+func validate(s *Subscription) error {
+	if !validFormat(s.Format) {
+		return fiber.NewError(fiber.StatusBadRequest, "format must be pdf or csv")
+	}
+	if !validChannel(s.Channel) {
+		return fiber.NewError(fiber.StatusBadRequest, "channel must be email, whatsapp, sms or telegram")
+	}
+	switch s.Channel {
+	case ChannelEmail:
+		addr, err := mail.ParseAddress(s.Destination)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "destination must be an email address")
+		}
+		s.Destination = addr.Address
+	default:
+		// chat channels are addressed by phone; Telegram looks the chat up when sending
+		phone := strings.ReplaceAll(s.Destination, " ", "")
+		if !validPhone(phone) {
+			return fiber.NewError(fiber.StatusBadRequest, "destination must be a phone number like +919876543210")
+		}
+		s.Destination = phone
+		if s.Format != FormatPDF {
+			return fiber.NewError(fiber.StatusBadRequest, s.Channel+" deliveries are pdf only")
+		}
+	}
+
+	if s.Locale == "" {
+		s.Locale = pdfkit.LangEnglish
+	}
+	if !pdfkit.ValidLang(s.Locale) {
+		return fiber.NewError(fiber.StatusBadRequest, "locale must be en or hi")
+	}
+
+	if s.Timezone == "" {
+		s.Timezone = defaultTimezone
+	}
+	loc, err := loadLocation(s.Timezone)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "timezone must be an IANA zone like Asia/Kolkata")
+	}
+	if s.ID == 0 {
+		s.NextRunAt = NextRun(s.Report, loc, time.Now())
+	}
+	return nil
+}
+
+func validPhone(p string) bool {
+	if len(p) < 11 || len(p) > 16 || p[0] != '+' {
+		return false
+	}
+	for _, r := range p[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func parseID(c *fiber.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimSpace(c.Params("id")), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "invalid subscription id")
+	}
+	return id, nil
+}
+
+func extractUserID(c *fiber.Ctx) (string, error) {
+	val := c.Locals("user_id")
+	if val == nil {
+		val = c.Locals("userID")
+	}
+	if val == nil {
+		return "", errors.New("user id missing")
+	}
+	if uid, ok := val.(string); ok && strings.TrimSpace(uid) != "" {
+		return uid, nil
+	}
+	return "", errors.New("user id missing")
+}
+
+func userContext(c *fiber.Ctx) context.Context {
+	if ctx := c.UserContext(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
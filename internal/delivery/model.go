@@ -0,0 +1,99 @@
+package delivery
+
+import (
+	"time"
+)
+
+// Reports a subscription can deliver.
+const (
+	ReportWeeklyDigest     = "weekly_digest"     // statement of the previous Monday-Sunday, every Monday
+	ReportMonthlyStatement = "monthly_statement" // statement of the previous month, on the 1st
+	ReportQuarterlyPnL     = "quarterly_pnl"     // P&L of the previous quarter, on Jan/Apr/Jul/Oct 1st
+)
+
+const (
+	FormatPDF = "pdf"
+	FormatCSV = "csv"
+)
+
+const (
+	ChannelEmail    = "email"
+	ChannelWhatsApp = "whatsapp"
+	ChannelSMS      = "sms"
+	ChannelTelegram = "telegram"
+)
+
+// Delivery statuses. A delivery is "sending" only while a scheduler instance holds it.
+const (
+	StatusPending = "pending"
+	StatusSending = "sending"
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+)
+
+const defaultTimezone = "Asia/Kolkata"
+
+type Subscription struct {
+	ID          int64      `json:"id"`
+	UserID      string     `json:"user_id"`
+	Report      string     `json:"report"`
+	Format      string     `json:"format"`
+	Channel     string     `json:"channel"`
+	Destination string     `json:"destination"` // email address or E.164 phone
+	Timezone    string     `json:"timezone"`
+	Locale      string     `json:"locale"` // PDF label language: en or hi
+	BusinessID  *int64     `json:"business_id,omitempty"`
+	Active      bool       `json:"active"`
+	NextRunAt   time.Time  `json:"next_run_at"`
+	LastRunAt   *time.Time `json:"last_run_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Delivery is one entry of the delivery log: a report period sent, or being retried, to the
+// channel and destination the subscription had when it was queued.
+type Delivery struct {
+	ID             int64      `json:"id"`
+	SubscriptionID int64      `json:"subscription_id"`
+	PeriodFrom     time.Time  `json:"period_from"`
+	PeriodTo       time.Time  `json:"period_to"`
+	Channel        string     `json:"channel"`
+	Destination    string     `json:"destination"`
+	Manual         bool       `json:"manual"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastError      *string    `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type CreateSubscriptionRequest struct {
+	Report      string `json:"report"`
+	Format      string `json:"format"`
+	Channel     string `json:"channel"`
+	Destination string `json:"destination"`
+	Timezone    string `json:"timezone"`
+	Locale      string `json:"locale"`
+	BusinessID  *int64 `json:"business_id"`
+}
+
+type UpdateSubscriptionRequest struct {
+	Format      *string `json:"format"`
+	Channel     *string `json:"channel"`
+	Destination *string `json:"destination"`
+	Timezone    *string `json:"timezone"`
+	Locale      *string `json:"locale"`
+	Active      *bool   `json:"active"`
+}
+
+func validReport(r string) bool {
+	return r == ReportWeeklyDigest || r == ReportMonthlyStatement || r == ReportQuarterlyPnL
+}
+
+func validFormat(f string) bool {
+	return f == FormatPDF || f == FormatCSV
+}
+
+func validChannel(c string) bool {
+	return c == ChannelEmail || c == ChannelWhatsApp || c == ChannelSMS || c == ChannelTelegram
+}
@@ -0,0 +1,283 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrNotFound = errors.New("subscription not found")
+
+type Repository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{Pool: pool}
+}
+
+const subscriptionColumns = `id, user_id::text, report, format, channel, destination, timezone, locale, business_id,
+  active, next_run_at, last_run_at, created_at`
+
+func scanSubscription(row pgx.Row) (Subscription, error) {
+	var s Subscription
+	err := row.Scan(&s.ID, &s.UserID, &s.Report, &s.Format, &s.Channel, &s.Destination, &s.Timezone,
+		&s.Locale, &s.BusinessID, &s.Active, &s.NextRunAt, &s.LastRunAt, &s.CreatedAt)
+	return s, err
+}
+
+func (r *Repository) Create(ctx context.Context, s Subscription) (Subscription, error) {
+	return scanSubscription(r.Pool.QueryRow(ctx, `
+INSERT INTO report_subscriptions (user_id, report, format, channel, destination, timezone, locale, business_id, next_run_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING `+subscriptionColumns,
+		s.UserID, s.Report, s.Format, s.Channel, s.Destination, s.Timezone, s.Locale, s.BusinessID, s.NextRunAt))
+}
+
+func (r *Repository) List(ctx context.Context, userID string) ([]Subscription, error) {
+	rows, err := r.Pool.Query(ctx, `
+SELECT `+subscriptionColumns+`
+FROM report_subscriptions
+WHERE user_id = $1
+ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Subscription, 0)
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) Get(ctx context.Context, userID string, id int64) (Subscription, error) {
+	s, err := scanSubscription(r.Pool.QueryRow(ctx, `
+SELECT `+subscriptionColumns+`
+FROM report_subscriptions
+WHERE user_id = $1 AND id = $2`, userID, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return s, ErrNotFound
+	}
+	return s, err
+}
+
+func (r *Repository) Update(ctx context.Context, s Subscription) (Subscription, error) {
+	out, err := scanSubscription(r.Pool.QueryRow(ctx, `
+UPDATE report_subscriptions
+SET format = $3, channel = $4, destination = $5, timezone = $6, locale = $7, active = $8, next_run_at = $9,
+    updated_at = now()
+WHERE user_id = $1 AND id = $2
+RETURNING `+subscriptionColumns,
+		s.UserID, s.ID, s.Format, s.Channel, s.Destination, s.Timezone, s.Locale, s.Active, s.NextRunAt))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return out, ErrNotFound
+	}
+	return out, err
+}
+
+func (r *Repository) Delete(ctx context.Context, userID string, id int64) error {
+	tag, err := r.Pool.Exec(ctx, `DELETE FROM report_subscriptions WHERE user_id = $1 AND id = $2`, userID, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+const deliveryColumns = `id, subscription_id, period_from, period_to, channel, destination, manual, status,
+  attempts, last_error, next_attempt_at, sent_at, created_at`
+
+func scanDelivery(row pgx.Row) (Delivery, error) {
+	var d Delivery
+	err := row.Scan(&d.ID, &d.SubscriptionID, &d.PeriodFrom, &d.PeriodTo, &d.Channel, &d.Destination,
+		&d.Manual, &d.Status, &d.Attempts, &d.LastError, &d.NextAttemptAt, &d.SentAt, &d.CreatedAt)
+	return d, err
+}
+
+func (r *Repository) Deliveries(ctx context.Context, userID string, subscriptionID int64) ([]Delivery, error) {
+	rows, err := r.Pool.Query(ctx, `
+SELECT `+deliveryColumns+`
+FROM report_deliveries
+WHERE user_id = $1 AND subscription_id = $2
+ORDER BY created_at DESC
+LIMIT 50`, userID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Delivery, 0)
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// Enqueue queues one delivery of the period with the subscription's current channel.
+func (r *Repository) Enqueue(ctx context.Context, s Subscription, from, to time.Time, manual bool) (Delivery, error) {
+	return scanDelivery(r.Pool.QueryRow(ctx, `
+INSERT INTO report_deliveries (subscription_id, user_id, period_from, period_to, channel, destination, manual)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING `+deliveryColumns,
+		s.ID, s.UserID, from, to, s.Channel, s.Destination, manual))
+}
+
+// EnqueueDue queues a delivery for every active subscription whose next run has passed and moves
+// next_run_at forward. Row locks with SKIP LOCKED let several API instances run the scheduler;
+// a run missed while no instance was up is sent once, not once per missed period.
+func (r *Repository) EnqueueDue(ctx context.Context, now time.Time) (int, error) {
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+SELECT `+subscriptionColumns+`
+FROM report_subscriptions
+WHERE active AND next_run_at <= $1
+ORDER BY next_run_at
+LIMIT 100
+FOR UPDATE SKIP LOCKED`, now)
+	if err != nil {
+		return 0, err
+	}
+	due := make([]Subscription, 0)
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, s := range due {
+		loc, err := loadLocation(s.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		from, to := Period(s.Report, loc, s.NextRunAt)
+		if _, err := tx.Exec(ctx, `
+INSERT INTO report_deliveries (subscription_id, user_id, period_from, period_to, channel, destination)
+VALUES ($1, $2, $3, $4, $5, $6)
+`, s.ID, s.UserID, from, to, s.Channel, s.Destination); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(ctx, `
+UPDATE report_subscriptions SET last_run_at = $2, next_run_at = $3 WHERE id = $1
+`, s.ID, s.NextRunAt, NextRun(s.Report, loc, now)); err != nil {
+			return 0, err
+		}
+	}
+	return len(due), tx.Commit(ctx)
+}
+
+// job is a claimed delivery with what's needed to build it.
+type job struct {
+	Delivery
+	userID     string
+	report     string
+	format     string
+	timezone   string
+	locale     string
+	businessID *int64
+}
+
+const jobColumns = `d.id, d.subscription_id, d.period_from, d.period_to, d.channel, d.destination, d.manual, d.status,
+  d.attempts, d.last_error, d.next_attempt_at, d.sent_at, d.created_at,
+  s.user_id::text, s.report, s.format, s.timezone, s.locale, s.business_id`
+
+func scanJob(row pgx.Row) (job, error) {
+	var j job
+	err := row.Scan(&j.ID, &j.SubscriptionID, &j.PeriodFrom, &j.PeriodTo, &j.Channel, &j.Destination,
+		&j.Manual, &j.Status, &j.Attempts, &j.LastError, &j.NextAttemptAt, &j.SentAt, &j.CreatedAt,
+		&j.userID, &j.report, &j.format, &j.timezone, &j.locale, &j.businessID)
+	return j, err
+}
+
+// Job loads a delivery as a job without claiming it, to rebuild its file.
+func (r *Repository) Job(ctx context.Context, id int64) (job, error) {
+	j, err := scanJob(r.Pool.QueryRow(ctx, `
+SELECT `+jobColumns+`
+FROM report_deliveries d
+JOIN report_subscriptions s ON s.id = d.subscription_id
+WHERE d.id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return j, ErrNotFound
+	}
+	return j, err
+}
+
+// Claim marks up to limit pending deliveries as sending and counts the attempt. Deliveries left
+// in sending by a crashed instance are picked up again after staleAfter.
+func (r *Repository) Claim(ctx context.Context, now time.Time, limit int, staleAfter time.Duration) ([]job, error) {
+	rows, err := r.Pool.Query(ctx, `
+UPDATE report_deliveries d
+SET status = 'sending', attempts = d.attempts + 1, updated_at = $1
+FROM report_subscriptions s
+WHERE s.id = d.subscription_id
+  AND d.id IN (
+    SELECT id FROM report_deliveries
+    WHERE (status = 'pending' AND next_attempt_at <= $1)
+       OR (status = 'sending' AND updated_at < $3)
+    ORDER BY next_attempt_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+  )
+RETURNING `+jobColumns, now, limit, now.Add(-staleAfter))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]job, 0)
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, j)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) MarkSent(ctx context.Context, id int64) error {
+	_, err := r.Pool.Exec(ctx, `
+UPDATE report_deliveries
+SET status = 'sent', sent_at = now(), next_attempt_at = NULL, last_error = NULL, updated_at = now()
+WHERE id = $1`, id)
+	return err
+}
+
+// MarkFailed records the error; a nil retryAt gives up on the delivery.
+func (r *Repository) MarkFailed(ctx context.Context, id int64, cause string, retryAt *time.Time) error {
+	status := StatusFailed
+	if retryAt != nil {
+		status = StatusPending
+	}
+	_, err := r.Pool.Exec(ctx, `
+UPDATE report_deliveries
+SET status = $2, last_error = $3, next_attempt_at = $4, updated_at = now()
+WHERE id = $1`, id, status, cause, retryAt)
+	return err
+}
@@ -0,0 +1,61 @@
+package delivery
+
+import (
+	"time"
+	_ "time/tzdata" // subscriptions name IANA zones; don't depend on the host's zoneinfo
+)
+
+// sendHour is the local hour scheduled reports go out at.
+const sendHour = 8
+
+// NextRun returns the first send time strictly after `after`, in the subscription's zone.
+func NextRun(report string, loc *time.Location, after time.Time) time.Time {
+	t := after.In(loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), sendHour, 0, 0, 0, loc)
+	switch report {
+	case ReportWeeklyDigest:
+		day = day.AddDate(0, 0, (int(time.Monday)-int(day.Weekday())+7)%7)
+		if !day.After(after) {
+			day = day.AddDate(0, 0, 7)
+		}
+	case ReportMonthlyStatement:
+		day = time.Date(t.Year(), t.Month(), 1, sendHour, 0, 0, 0, loc)
+		if !day.After(after) {
+			day = day.AddDate(0, 1, 0)
+		}
+	case ReportQuarterlyPnL:
+		q := (int(t.Month()) - 1) / 3 * 3
+		day = time.Date(t.Year(), time.Month(q+1), 1, sendHour, 0, 0, 0, loc)
+		if !day.After(after) {
+			day = day.AddDate(0, 3, 0)
+		}
+	}
+	return day
+}
+
+// Period is the last complete period before runAt's local date: the previous Monday-Sunday,
+// calendar month or quarter. Both bounds are inclusive dates.
+func Period(report string, loc *time.Location, runAt time.Time) (time.Time, time.Time) {
+	t := runAt.In(loc)
+	today := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch report {
+	case ReportWeeklyDigest:
+		// days since the last Monday, so a Monday run covers the week that just ended
+		back := (int(today.Weekday()) - int(time.Monday) + 7) % 7
+		to := today.AddDate(0, 0, -back-1)
+		return to.AddDate(0, 0, -6), to
+	case ReportQuarterlyPnL:
+		start := time.Date(today.Year(), time.Month((int(today.Month())-1)/3*3+1), 1, 0, 0, 0, 0, time.UTC)
+		return start.AddDate(0, -3, 0), start.AddDate(0, 0, -1)
+	default:
+		start := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start.AddDate(0, -1, 0), start.AddDate(0, 0, -1)
+	}
+}
+
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		name = defaultTimezone
+	}
+	return time.LoadLocation(name)
+}
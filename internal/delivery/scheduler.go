@@ -0,0 +1,218 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/messaging"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/reports"
+)
+
+// maxAttempts includes the first try; retries back off along retryBackoff.
+const maxAttempts = 5
+
+var retryBackoff = []time.Duration{5 * time.Minute, 30 * time.Minute, 2 * time.Hour, 6 * time.Hour}
+
+type mailer interface {
+	Send(ctx context.Context, to, subject, body string, files ...Attachment) error
+}
+
+// Scheduler queues due subscriptions and sends queued deliveries, once per Interval.
+type Scheduler struct {
+	Repo   *Repository
+	Mailer mailer
+	// Channels send chat deliveries to the phone's address in Directory.
+	Channels  *messaging.Registry
+	Directory *messaging.Directory
+	// Links stores chat delivery files behind /r/:token, which the provider fetches from BaseURL.
+	Links    *reports.Store
+	BaseURL  string
+	Interval time.Duration
+}
+
+func NewScheduler(repo *Repository, m mailer, channels *messaging.Registry, dir *messaging.Directory, links *reports.Store) *Scheduler {
+	return &Scheduler{
+		Repo:      repo,
+		Mailer:    m,
+		Channels:  channels,
+		Directory: dir,
+		Links:     links,
+		BaseURL:   strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/"),
+		Interval:  time.Minute,
+	}
+}
+
+// Run blocks until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	t := time.NewTicker(s.Interval)
+	defer t.Stop()
+	for {
+		s.Tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (s *Scheduler) Tick(ctx context.Context) {
+	now := time.Now()
+	if n, err := s.Repo.EnqueueDue(ctx, now); err != nil {
+		log.Printf("report scheduler: enqueue: %v", err)
+	} else if n > 0 {
+		log.Printf("report scheduler: queued %d deliveries", n)
+	}
+
+	jobs, err := s.Repo.Claim(ctx, now, 20, 15*time.Minute)
+	if err != nil {
+		log.Printf("report scheduler: claim: %v", err)
+		return
+	}
+	for _, j := range jobs {
+		sendCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		err := s.deliver(sendCtx, j)
+		cancel()
+		if err == nil {
+			if err := s.Repo.MarkSent(ctx, j.ID); err != nil {
+				log.Printf("report scheduler: delivery %d: %v", j.ID, err)
+			}
+			continue
+		}
+
+		var retryAt *time.Time
+		if j.Attempts < maxAttempts {
+			at := time.Now().Add(retryBackoff[min(j.Attempts, len(retryBackoff))-1])
+			retryAt = &at
+		}
+		log.Printf("report scheduler: delivery %d attempt %d: %v", j.ID, j.Attempts, err)
+		if err := s.Repo.MarkFailed(ctx, j.ID, err.Error(), retryAt); err != nil {
+			log.Printf("report scheduler: delivery %d: %v", j.ID, err)
+		}
+	}
+}
+
+type rendered struct {
+	name        string
+	contentType string
+	data        []byte
+	subject     string
+	body        string
+}
+
+func (s *Scheduler) deliver(ctx context.Context, j job) error {
+	r, err := s.render(ctx, j)
+	if err != nil {
+		return err
+	}
+
+	if j.Channel == ChannelEmail {
+		if s.Mailer == nil {
+			return errors.New("email delivery is not configured")
+		}
+		return s.Mailer.Send(ctx, j.Destination, r.subject, r.body, Attachment{Name: r.name, ContentType: r.contentType, Data: r.data})
+	}
+
+	ch, err := s.Channels.Get(j.Channel)
+	if err != nil || s.Directory == nil || s.Links == nil || s.BaseURL == "" {
+		return errors.New(j.Channel + " delivery is not configured")
+	}
+	to, err := s.Directory.AddressFor(ctx, j.Channel, j.Destination)
+	if err != nil {
+		return err
+	}
+	params, _ := json.Marshal(map[string]int64{"delivery_id": j.ID})
+	token, _, err := s.Links.Create(ctx, reports.Report{
+		Phone:  j.Destination,
+		UserID: j.userID,
+		Month:  j.PeriodFrom.Format("2006-01"),
+		Kind:   reports.KindScheduled,
+		Params: params,
+	}, r.data, 7*24*time.Hour)
+	if err != nil {
+		return err
+	}
+	_, err = ch.SendDocument(ctx, to, r.subject, s.BaseURL+"/r/"+token)
+	return err
+}
+
+// Regenerate rebuilds the file of a chat delivery whose stored copy is gone, for
+// reports.Store.
+func (s *Scheduler) Regenerate(ctx context.Context, rep reports.Report) ([]byte, error) {
+	var p struct {
+		DeliveryID int64 `json:"delivery_id"`
+	}
+	if err := json.Unmarshal(rep.Params, &p); err != nil || p.DeliveryID == 0 {
+		return nil, errors.New("scheduled report without a delivery id")
+	}
+	j, err := s.Repo.Job(ctx, p.DeliveryID)
+	if err != nil {
+		return nil, err
+	}
+	r, err := s.render(ctx, j)
+	return r.data, err
+}
+
+func (s *Scheduler) render(ctx context.Context, j job) (rendered, error) {
+	pool := s.Repo.Pool
+	from, to := j.PeriodFrom.Format("2006-01-02"), j.PeriodTo.Format("2006-01-02")
+	var r rendered
+	var err error
+
+	switch j.report {
+	case ReportQuarterlyPnL:
+		rep, perr := reports.BuildPnL(ctx, pool, j.userID, j.businessID, j.PeriodFrom, j.PeriodTo,
+			reports.ComparePrevious, reports.CompareLastYear, reports.CompareBudget)
+		if perr != nil {
+			return r, perr
+		}
+		r.name = "vantro-pnl-" + from + "-to-" + to
+		if j.format == FormatCSV {
+			r.data, err = rep.CSV()
+		} else {
+			r.data, err = rep.PDF(reports.BusinessName(ctx, pool, j.businessID), reports.BusinessLogo(ctx, pool, j.userID, j.businessID), j.locale)
+		}
+		r.subject = "Your Vantro P&L for " + quarterLabel(j.PeriodFrom)
+		r.body = "Net profit: Rs " + money.FormatINR(rep.NetProfit[0]) + "\n" +
+			"Income: Rs " + money.FormatINR(rep.Sections[0].Subtotal[0]) + "\n"
+	default:
+		r.name = "vantro-statement-" + from + "-to-" + to
+		if j.format == FormatCSV {
+			r.data, err = reports.BuildStatementCSV(ctx, pool, j.userID, from, to)
+		} else {
+			r.data, err = reports.BuildStatementPDF(ctx, pool, j.userID, from, to, j.businessID, j.locale)
+		}
+		r.subject = "Your Vantro statement for " + j.PeriodFrom.Format("January 2006")
+		if j.report == ReportWeeklyDigest {
+			r.subject = "Your Vantro weekly digest, " + j.PeriodFrom.Format("2 Jan") + " - " + j.PeriodTo.Format("2 Jan 2006")
+		}
+		income, expense, terr := reports.Totals(ctx, pool, j.userID, from, to)
+		if terr != nil {
+			return r, terr
+		}
+		r.body = "Income: Rs " + money.FormatINR(income) + "\n" +
+			"Expenses: Rs " + money.FormatINR(expense) + "\n" +
+			"Balance: Rs " + money.FormatINR(income-expense) + "\n"
+	}
+	if err != nil {
+		return r, err
+	}
+
+	r.name += "." + j.format
+	r.contentType = "application/pdf"
+	if j.format == FormatCSV {
+		r.contentType = "text/csv; charset=utf-8"
+	}
+	r.body = r.subject + " (" + from + " to " + to + ")\n\n" + r.body + "\nThe full report is attached.\n"
+	return r, nil
+}
+
+func quarterLabel(start time.Time) string {
+	return "Q" + string(rune('1'+(int(start.Month())-1)/3)) + " " + start.Format("2006")
+}
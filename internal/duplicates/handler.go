@@ -0,0 +1,183 @@
+package duplicates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/audit"
+)
+
+type Handler struct {
+	Repo *Repository
+}
+
+func NewHandler(repo *Repository) *Handler {
+	return &Handler{Repo: repo}
+}
+
+func (h *Handler) List(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	days := 90
+	if raw := strings.TrimSpace(c.Query("days")); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 || v > 365 {
+			return fiber.NewError(fiber.StatusBadRequest, "days must be between 1 and 365")
+		}
+		days = v
+	}
+
+	pairs, err := h.Repo.ListSuspected(userContext(c), userID, days)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to find duplicates: "+err.Error())
+	}
+	return c.JSON(fiber.Map{"items": pairs})
+}
+
+func (h *Handler) Merge(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	var req MergeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+	req.Keep, req.Drop = cleanRef(req.Keep), cleanRef(req.Drop)
+	if err := validateRefs(req.Keep, req.Drop); err != nil {
+		return err
+	}
+
+	if err := h.Repo.Merge(userContext(c), userID, req.Keep, req.Drop); err != nil {
+		return resolveError(err, "failed to merge")
+	}
+
+	h.audit(c, userID, "duplicate_merge", req.Drop, req)
+	return c.JSON(fiber.Map{"status": "ok", "kept": req.Keep, "removed": req.Drop})
+}
+
+func (h *Handler) Dismiss(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	var req DismissRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+	req.A, req.B = cleanRef(req.A), cleanRef(req.B)
+	if err := validateRefs(req.A, req.B); err != nil {
+		return err
+	}
+
+	if err := h.Repo.Dismiss(userContext(c), userID, req.A, req.B); err != nil {
+		return resolveError(err, "failed to dismiss")
+	}
+
+	h.audit(c, userID, "duplicate_dismiss", req.B, req)
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+func (h *Handler) Resolutions(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	items, err := h.Repo.ListResolutions(userContext(c), userID, limit)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to list resolutions: "+err.Error())
+	}
+	return c.JSON(fiber.Map{"items": items})
+}
+
+// Best-effort audit, same shape as the transaction create audit.
+func (h *Handler) audit(c *fiber.Ctx, userID, action string, target Ref, body any) {
+	uid := userID
+	entityID := target.Kind + ":" + target.ID
+	entry := audit.Entry{
+		UserID:     &uid,
+		Action:     action,
+		EntityType: "duplicate",
+		EntityID:   &entityID,
+	}
+	if buf, err := json.Marshal(body); err == nil {
+		entry.Metadata = buf
+	}
+	if ip := strings.TrimSpace(c.IP()); ip != "" {
+		entry.IP = &ip
+	}
+	if ua := strings.TrimSpace(c.Get("User-Agent")); ua != "" {
+		entry.UserAgent = &ua
+	}
+	_ = audit.Write(userContext(c), h.Repo.Pool, entry)
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func cleanRef(r Ref) Ref {
+	return Ref{Kind: strings.ToLower(strings.TrimSpace(r.Kind)), ID: strings.TrimSpace(r.ID)}
+}
+
+func validateRefs(refs ...Ref) error {
+	for _, r := range refs {
+		spec, ok := tables[r.Kind]
+		if !ok {
+			return fiber.NewError(fiber.StatusBadRequest, ErrInvalidKind.Error())
+		}
+		valid := false
+		if spec.idCast == "bigint" {
+			n, err := strconv.ParseInt(r.ID, 10, 64)
+			valid = err == nil && n > 0
+		} else {
+			valid = uuidPattern.MatchString(r.ID)
+		}
+		if !valid {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid id for "+r.Kind)
+		}
+	}
+	return nil
+}
+
+func resolveError(err error, msg string) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, ErrInvalidKind), errors.Is(err, ErrSameRecord):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, msg+": "+err.Error())
+}
+
+func extractUserID(c *fiber.Ctx) (string, error) {
+	val := c.Locals("user_id")
+	if val == nil {
+		val = c.Locals("userID")
+	}
+	if val == nil {
+		return "", errors.New("user id missing")
+	}
+	if uid, ok := val.(string); ok && strings.TrimSpace(uid) != "" {
+		return uid, nil
+	}
+	return "", errors.New("user id missing")
+}
+
+func userContext(c *fiber.Ctx) context.Context {
+	if ctx := c.UserContext(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
@@ -0,0 +1,173 @@
+package duplicates
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// entries further apart than this are treated as separate spends
+	maxDaysApart = 2
+	// minimum score for a pair to be reported
+	matchThreshold = 0.6
+)
+
+// words that show up in both WhatsApp and app entries without saying anything about the vendor
+var labelStopWords = map[string]bool{
+	"order": true, "payment": true, "paid": true, "pay": true, "to": true, "for": true,
+	"the": true, "via": true, "upi": true, "from": true, "at": true, "on": true,
+}
+
+// score rates how likely b is a re-entry of a. Only same-direction, same-amount rows a couple
+// of days apart are considered; the date gap and description similarity decide the rest.
+func score(a, b Record) (float64, []string, bool) {
+	if a.Kind == b.Kind && a.ID == b.ID {
+		return 0, nil, false
+	}
+	if a.Direction != b.Direction || a.Amount != b.Amount {
+		return 0, nil, false
+	}
+	days := daysApart(a.Date, b.Date)
+	if days > maxDaysApart {
+		return 0, nil, false
+	}
+
+	reasons := []string{"same amount"}
+	var dateScore float64
+	switch days {
+	case 0:
+		dateScore = 1
+		reasons = append(reasons, "same day")
+	case 1:
+		dateScore = 0.7
+		reasons = append(reasons, "1 day apart")
+	default:
+		dateScore = 0.4
+		reasons = append(reasons, "2 days apart")
+	}
+
+	ta, tb := labelTokens(a.Label), labelTokens(b.Label)
+	var labelScore float64
+	switch {
+	case len(ta) == 0 || len(tb) == 0:
+		// quick WhatsApp entries often have no note; neither evidence for nor against
+		labelScore = 0.5
+		reasons = append(reasons, "no description to compare")
+	default:
+		labelScore = similarity(ta, tb)
+		if labelScore >= 0.5 {
+			reasons = append(reasons, "similar description")
+		}
+	}
+	if a.Kind != b.Kind {
+		reasons = append(reasons, "logged in different places ("+a.Kind+", "+b.Kind+")")
+	}
+
+	s := 0.5*dateScore + 0.5*labelScore
+	if s < matchThreshold {
+		return s, reasons, false
+	}
+	return s, reasons, true
+}
+
+// findPairs reports every matching pair in records, skipping those already resolved.
+func findPairs(records []Record, resolved map[string]bool) []Pair {
+	sorted := make([]Record, len(records))
+	copy(sorted, records)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Amount != sorted[j].Amount {
+			return sorted[i].Amount < sorted[j].Amount
+		}
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	var out []Pair
+	for i := range sorted {
+		for j := i + 1; j < len(sorted) && sorted[j].Amount == sorted[i].Amount; j++ {
+			a, b := sorted[i], sorted[j]
+			if daysApart(a.Date, b.Date) > maxDaysApart {
+				break
+			}
+			if resolved[pairKey(a.Kind, a.ID, b.Kind, b.ID)] {
+				continue
+			}
+			s, reasons, ok := score(a, b)
+			if !ok {
+				continue
+			}
+			if b.CreatedAt.Before(a.CreatedAt) {
+				a, b = b, a
+			}
+			out = append(out, Pair{A: a, B: b, Score: round2(s), Reasons: reasons})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return out
+}
+
+func labelTokens(s string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	out := fields[:0]
+	for _, f := range fields {
+		if len(f) < 2 || labelStopWords[f] {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// similarity is 1 when one token set contains the other ("zomato" vs "zomato dinner"),
+// Jaccard otherwise.
+func similarity(a, b []string) float64 {
+	setA := map[string]bool{}
+	for _, t := range a {
+		setA[t] = true
+	}
+	setB := map[string]bool{}
+	for _, t := range b {
+		setB[t] = true
+	}
+	inter := 0
+	for t := range setA {
+		if setB[t] {
+			inter++
+		}
+	}
+	if inter == 0 {
+		return 0
+	}
+	if inter == len(setA) || inter == len(setB) {
+		return 1
+	}
+	return float64(inter) / float64(len(setA)+len(setB)-inter)
+}
+
+func daysApart(a, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	da := time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)
+	db := time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC)
+	d := int(da.Sub(db).Hours() / 24)
+	if d < 0 {
+		d = -d
+	}
+	return d
+}
+
+// pairKey is order-independent so a dismissal covers both directions.
+func pairKey(kindA, idA, kindB, idB string) string {
+	a, b := kindA+":"+idA, kindB+":"+idB
+	if b < a {
+		a, b = b, a
+	}
+	return a + "|" + b
+}
+
+func round2(f float64) float64 {
+	return float64(int64(f*100+0.5)) / 100
+}
@@ -0,0 +1,72 @@
+package duplicates
+
+import "time"
+
+// Record kinds, one per table a money movement can be logged in.
+const (
+	KindIncome          = "income"           // incomes
+	KindExpense         = "expense"          // expenses
+	KindTransaction     = "transaction"      // transactions_v1
+	KindUserTransaction = "user_transaction" // user_transactions
+)
+
+const (
+	ResolutionMerged    = "merged"
+	ResolutionDismissed = "dismissed"
+)
+
+// Record is a normalised view of a row from any of the four tables.
+type Record struct {
+	Kind      string    `json:"kind"`
+	ID        string    `json:"id"`
+	Direction string    `json:"direction"` // IN | OUT
+	Amount    int64     `json:"amount"`
+	Date      time.Time `json:"date"`
+	Label     string    `json:"label"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Candidate struct {
+	Record
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// Warning is attached to create responses when the new row looks like one already logged.
+type Warning struct {
+	Message    string      `json:"message"`
+	Candidates []Candidate `json:"candidates"`
+}
+
+// Pair is a suspected duplicate in the list endpoint. A is the older entry.
+type Pair struct {
+	A       Record   `json:"a"`
+	B       Record   `json:"b"`
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+type Resolution struct {
+	ID         int64     `json:"id"`
+	Resolution string    `json:"resolution"`
+	KeptKind   string    `json:"kept_kind"`
+	KeptID     string    `json:"kept_id"`
+	OtherKind  string    `json:"other_kind"`
+	OtherID    string    `json:"other_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Ref struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+}
+
+type MergeRequest struct {
+	Keep Ref `json:"keep"`
+	Drop Ref `json:"drop"`
+}
+
+type DismissRequest struct {
+	A Ref `json:"a"`
+	B Ref `json:"b"`
+}
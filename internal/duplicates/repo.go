@@ -0,0 +1,302 @@
+package duplicates
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrNotFound    = errors.New("record not found")
+	ErrInvalidKind = errors.New("kind must be income, expense, transaction or user_transaction")
+	ErrSameRecord  = errors.New("cannot merge a record with itself")
+)
+
+type Repository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{Pool: pool}
+}
+
+type tableSpec struct {
+	table  string
+	idCast string
+}
+
+var tables = map[string]tableSpec{
+	KindIncome:          {"incomes", "uuid"},
+	KindExpense:         {"expenses", "uuid"},
+	KindTransaction:     {"transactions_v1", "bigint"},
+	KindUserTransaction: {"user_transactions", "uuid"},
+}
+
+// recordsQuery normalises the four tables. $4 = 0 means any amount.
+const recordsQuery = `
+SELECT 'income', id::text, 'IN', amount, received_on, client_name || ' ' || COALESCE(note, ''), created_at
+FROM incomes
+WHERE user_id = $1 AND deleted_at IS NULL
+  AND received_on BETWEEN $2 AND $3
+  AND ($4::bigint = 0 OR amount = $4::bigint)
+
+UNION ALL
+
+SELECT 'expense', id::text, 'OUT', amount, spent_on, vendor_name || ' ' || COALESCE(note, ''), created_at
+FROM expenses
+WHERE user_id = $1 AND deleted_at IS NULL
+  AND spent_on BETWEEN $2 AND $3
+  AND ($4::bigint = 0 OR amount = $4::bigint)
+
+UNION ALL
+
+SELECT 'transaction', id::text, direction, amount, created_at::date, COALESCE(note, ''), created_at
+FROM transactions_v1
+WHERE user_id = $1 AND deleted_at IS NULL
+  AND created_at::date BETWEEN $2 AND $3
+  AND ($4::bigint = 0 OR amount = $4::bigint)
+
+UNION ALL
+
+SELECT 'user_transaction', id::text, direction, amount, created_at::date, COALESCE(note, ''), created_at
+FROM user_transactions
+WHERE user_id = $1 AND deleted_at IS NULL
+  AND created_at::date BETWEEN $2 AND $3
+  AND ($4::bigint = 0 OR amount = $4::bigint)
+`
+
+func loadRecords(ctx context.Context, db *pgxpool.Pool, userID string, from, to time.Time, amount int64) ([]Record, error) {
+	rows, err := db.Query(ctx, recordsQuery, userID, from, to, amount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Record, 0)
+	for rows.Next() {
+		var r Record
+		if err := rows.Scan(&r.Kind, &r.ID, &r.Direction, &r.Amount, &r.Date, &r.Label, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func resolvedPairs(ctx context.Context, db *pgxpool.Pool, userID string) (map[string]bool, error) {
+	rows, err := db.Query(ctx, `SELECT pair_key FROM duplicate_resolutions WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string]bool{}
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		out[k] = true
+	}
+	return out, rows.Err()
+}
+
+// Warn is Check for create handlers. The check is advisory: a failed lookup is logged and gives
+// no warning rather than failing a create that already happened.
+func Warn(ctx context.Context, db *pgxpool.Pool, userID string, rec Record) *Warning {
+	w, err := Check(ctx, db, userID, rec)
+	if err != nil {
+		log.Printf("duplicates: check %s %s: %v", rec.Kind, rec.ID, err)
+		return nil
+	}
+	return w
+}
+
+// Label is a record's name and optional note, the text Check compares.
+func Label(name string, note *string) string {
+	if note == nil {
+		return name
+	}
+	return strings.TrimSpace(name + " " + *note)
+}
+
+// Check looks for existing rows that rec may duplicate. Create handlers call it after the insert
+// and attach the result as a warning; it never blocks the write. Returns nil when nothing matches.
+func Check(ctx context.Context, db *pgxpool.Pool, userID string, rec Record) (*Warning, error) {
+	if db == nil || rec.Amount <= 0 {
+		return nil, nil
+	}
+	from := rec.Date.AddDate(0, 0, -maxDaysApart)
+	to := rec.Date.AddDate(0, 0, maxDaysApart)
+	records, err := loadRecords(ctx, db, userID, from, to, rec.Amount)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := resolvedPairs(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var cands []Candidate
+	for _, other := range records {
+		if resolved[pairKey(rec.Kind, rec.ID, other.Kind, other.ID)] {
+			continue
+		}
+		s, reasons, ok := score(rec, other)
+		if !ok {
+			continue
+		}
+		cands = append(cands, Candidate{Record: other, Score: round2(s), Reasons: reasons})
+	}
+	if len(cands) == 0 {
+		return nil, nil
+	}
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].Score > cands[j].Score })
+	if len(cands) > 5 {
+		cands = cands[:5]
+	}
+	return &Warning{
+		Message:    "This looks like an entry you already logged. Merge or dismiss it from /api/duplicates.",
+		Candidates: cands,
+	}, nil
+}
+
+// ListSuspected returns unresolved duplicate pairs from the last `days` days.
+func (r *Repository) ListSuspected(ctx context.Context, userID string, days int) ([]Pair, error) {
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -days)
+	records, err := loadRecords(ctx, r.Pool, userID, from, to, 0)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := resolvedPairs(ctx, r.Pool, userID)
+	if err != nil {
+		return nil, err
+	}
+	pairs := findPairs(records, resolved)
+	if pairs == nil {
+		pairs = []Pair{}
+	}
+	return pairs, nil
+}
+
+// Merge soft-deletes drop and keeps keep, copying drop's note onto keep when keep has none.
+func (r *Repository) Merge(ctx context.Context, userID string, keep, drop Ref) error {
+	keepSpec, ok := tables[keep.Kind]
+	if !ok {
+		return ErrInvalidKind
+	}
+	dropSpec, ok := tables[drop.Kind]
+	if !ok {
+		return ErrInvalidKind
+	}
+	if keep.Kind == drop.Kind && keep.ID == drop.ID {
+		return ErrSameRecord
+	}
+
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var keepNote string
+	err = tx.QueryRow(ctx, `SELECT COALESCE(note, '') FROM `+keepSpec.table+`
+		WHERE id = $1::`+keepSpec.idCast+` AND user_id = $2 AND deleted_at IS NULL
+		FOR UPDATE`, keep.ID, userID).Scan(&keepNote)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var dropNote string
+	err = tx.QueryRow(ctx, `UPDATE `+dropSpec.table+` SET deleted_at = now()
+		WHERE id = $1::`+dropSpec.idCast+` AND user_id = $2 AND deleted_at IS NULL
+		RETURNING COALESCE(note, '')`, drop.ID, userID).Scan(&dropNote)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if keepNote == "" && dropNote != "" {
+		if _, err := tx.Exec(ctx, `UPDATE `+keepSpec.table+` SET note = $3
+			WHERE id = $1::`+keepSpec.idCast+` AND user_id = $2`, keep.ID, userID, dropNote); err != nil {
+			return err
+		}
+	}
+
+	if err := saveResolution(ctx, tx, userID, ResolutionMerged, keep, drop); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Dismiss records that a and b are both genuine so the pair is not suggested again.
+func (r *Repository) Dismiss(ctx context.Context, userID string, a, b Ref) error {
+	if _, ok := tables[a.Kind]; !ok {
+		return ErrInvalidKind
+	}
+	if _, ok := tables[b.Kind]; !ok {
+		return ErrInvalidKind
+	}
+	if a.Kind == b.Kind && a.ID == b.ID {
+		return ErrSameRecord
+	}
+
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := saveResolution(ctx, tx, userID, ResolutionDismissed, a, b); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// saveResolution adds a decision to the pair's history; earlier decisions are kept.
+func saveResolution(ctx context.Context, tx pgx.Tx, userID, resolution string, kept, other Ref) error {
+	_, err := tx.Exec(ctx, `
+INSERT INTO duplicate_resolutions (user_id, resolution, kept_kind, kept_id, other_kind, other_id, pair_key)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+`, userID, resolution, kept.Kind, kept.ID, other.Kind, other.ID, pairKey(kept.Kind, kept.ID, other.Kind, other.ID))
+	return err
+}
+
+func (r *Repository) ListResolutions(ctx context.Context, userID string, limit int) ([]Resolution, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	rows, err := r.Pool.Query(ctx, `
+SELECT id, resolution, kept_kind, kept_id, other_kind, other_id, created_at
+FROM duplicate_resolutions
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2
+`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Resolution, 0)
+	for rows.Next() {
+		var res Resolution
+		if err := rows.Scan(&res.ID, &res.Resolution, &res.KeptKind, &res.KeptID, &res.OtherKind, &res.OtherID, &res.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, res)
+	}
+	return out, rows.Err()
+}
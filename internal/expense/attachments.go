@@ -0,0 +1,208 @@
+package expense
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+// Attachment statuses. A draft is a receipt waiting for the user to confirm the expense read
+// off it.
+const (
+	AttachmentDraft     = "draft"
+	AttachmentConfirmed = "confirmed"
+)
+
+// Attachment is a stored receipt photo with what OCR read from it.
+type Attachment struct {
+	ID          int64      `json:"id"`
+	UserPhone   string     `json:"user_phone"`
+	ExpenseID   *int64     `json:"expense_id,omitempty"`
+	ObjectKey   string     `json:"-"`
+	ContentType string     `json:"content_type"`
+	SizeBytes   int        `json:"size_bytes"`
+	OCRText     string     `json:"ocr_text,omitempty"`
+	Merchant    string     `json:"merchant,omitempty"`
+	TotalPaise  int64      `json:"total_paise,omitempty"`
+	ReceiptDate *time.Time `json:"receipt_date,omitempty"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+const attachmentColumns = `id, user_phone, expense_id, object_key, content_type, size_bytes, ocr_text, merchant,
+	total_paise, receipt_date, status, created_at`
+
+func scanAttachment(row interface{ Scan(...any) error }) (*Attachment, error) {
+	var a Attachment
+	var expenseID sql.NullInt64
+	var date sql.NullTime
+	err := row.Scan(&a.ID, &a.UserPhone, &expenseID, &a.ObjectKey, &a.ContentType, &a.SizeBytes, &a.OCRText,
+		&a.Merchant, &a.TotalPaise, &date, &a.Status, &a.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if expenseID.Valid {
+		a.ExpenseID = &expenseID.Int64
+	}
+	if date.Valid {
+		a.ReceiptDate = &date.Time
+	}
+	return &a, nil
+}
+
+// AddAttachment stores a receipt photo as a draft.
+func (s *Store) AddAttachment(ctx context.Context, a Attachment, data []byte) (*Attachment, error) {
+	a.UserPhone = strings.TrimSpace(a.UserPhone)
+	if a.UserPhone == "" || len(data) == 0 {
+		return nil, ErrBadRequest
+	}
+	if s.Blobs == nil {
+		return nil, errors.New("attachments are not configured")
+	}
+	var b [12]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+	key := "receipts/" + time.Now().UTC().Format("2006/01/02") + "/" + hex.EncodeToString(b[:])
+	if err := s.Blobs.Put(ctx, key, data, a.ContentType); err != nil {
+		return nil, err
+	}
+
+	const q = `
+        INSERT INTO expense_attachments (user_phone, object_key, content_type, size_bytes, ocr_text, merchant, total_paise, receipt_date)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING ` + attachmentColumns + `;
+    `
+	out, err := scanAttachment(s.DB.QueryRowContext(ctx, q, a.UserPhone, key, a.ContentType, len(data),
+		a.OCRText, a.Merchant, a.TotalPaise, a.ReceiptDate))
+	if err != nil {
+		_ = s.Blobs.Delete(ctx, key)
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetAttachment returns one of the phone's attachments.
+func (s *Store) GetAttachment(ctx context.Context, userPhone string, id int64) (*Attachment, error) {
+	return scanAttachment(s.DB.QueryRowContext(ctx, `
+        SELECT `+attachmentColumns+` FROM expense_attachments WHERE id = $1 AND user_phone = $2;
+    `, id, strings.TrimSpace(userPhone)))
+}
+
+// AttachmentData returns the stored file of an attachment.
+func (s *Store) AttachmentData(ctx context.Context, a *Attachment) ([]byte, error) {
+	if s.Blobs == nil {
+		return nil, errors.New("attachments are not configured")
+	}
+	return s.Blobs.Get(ctx, a.ObjectKey)
+}
+
+// ConfirmDraft logs the expense for a draft attachment and links the two. A draft that was
+// already confirmed or discarded is ErrNotFound.
+func (s *Store) ConfirmDraft(ctx context.Context, userPhone string, id int64, req AddExpenseRequest) (*Expense, error) {
+	amountPaise := req.amountPaise()
+	if amountPaise <= 0 {
+		return nil, ErrBadRequest
+	}
+	if req.Source == "" {
+		req.Source = "receipt"
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var draftID int64
+	err = tx.QueryRowContext(ctx, `
+        SELECT id FROM expense_attachments
+        WHERE id = $1 AND user_phone = $2 AND status = 'draft'
+        FOR UPDATE;
+    `, id, strings.TrimSpace(userPhone)).Scan(&draftID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := insertExpense(ctx, tx, strings.TrimSpace(userPhone), amountPaise, normalizeCategory(req.Category),
+		strings.TrimSpace(req.Note), req.Source, req.SpentAt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE expense_attachments SET status = 'confirmed', expense_id = $2 WHERE id = $1;
+    `, draftID, e.ID); err != nil {
+		return nil, err
+	}
+	return e, tx.Commit()
+}
+
+// DiscardDraft deletes a draft attachment and its file.
+func (s *Store) DiscardDraft(ctx context.Context, userPhone string, id int64) error {
+	var key string
+	err := s.DB.QueryRowContext(ctx, `
+        DELETE FROM expense_attachments
+        WHERE id = $1 AND user_phone = $2 AND status = 'draft'
+        RETURNING object_key;
+    `, id, strings.TrimSpace(userPhone)).Scan(&key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if s.Blobs != nil {
+		_ = s.Blobs.Delete(ctx, key)
+	}
+	return nil
+}
+
+// draftTTL is how long a receipt draft waits for its answer; the bot stops asking long before.
+const draftTTL = 24 * time.Hour
+
+// ExpireDrafts deletes up to limit drafts older than draftTTL at now, with their files, and
+// returns how many went: the drafts whose question expired or whose answer never came.
+func (s *Store) ExpireDrafts(ctx context.Context, now time.Time, limit int) (int, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+        DELETE FROM expense_attachments
+        WHERE id IN (
+            SELECT id FROM expense_attachments
+            WHERE status = 'draft' AND created_at < $1
+            ORDER BY created_at
+            LIMIT $2
+        ) AND status = 'draft'
+        RETURNING id, object_key;
+    `, now.Add(-draftTTL), limit)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		var id int64
+		var key string
+		if err := rows.Scan(&id, &key); err != nil {
+			return n, err
+		}
+		n++
+		if s.Blobs == nil {
+			continue
+		}
+		if err := s.Blobs.Delete(ctx, key); err != nil {
+			log.Printf("expense: draft attachment %d: %v", id, err)
+		}
+	}
+	return n, rows.Err()
+}
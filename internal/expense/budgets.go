@@ -0,0 +1,80 @@
+package expense
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// CategoryBudget is a monthly spending limit for one category of a phone's expenses.
+type CategoryBudget struct {
+	Category    string `json:"category"`
+	AmountPaise int64  `json:"amount_paise"`
+	SpentPaise  int64  `json:"spent_paise"`
+}
+
+// SetBudget sets the monthly limit for category; zero removes it.
+func (s *Store) SetBudget(ctx context.Context, userPhone, category string, amountPaise int64) error {
+	userPhone = strings.TrimSpace(userPhone)
+	if userPhone == "" || amountPaise < 0 {
+		return ErrBadRequest
+	}
+	category = normalizeCategory(category)
+	if amountPaise == 0 {
+		_, err := s.DB.ExecContext(ctx, `DELETE FROM expense_budgets WHERE user_phone = $1 AND category = $2;`, userPhone, category)
+		return err
+	}
+
+	const q = `
+        INSERT INTO expense_budgets (user_phone, category, amount_paise)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (user_phone, category) DO UPDATE SET
+            amount_paise = EXCLUDED.amount_paise,
+            updated_at = NOW();
+    `
+	_, err := s.DB.ExecContext(ctx, q, userPhone, category, amountPaise)
+	return err
+}
+
+// Budgets returns the phone's category budgets with what was spent in [start, end). With a
+// category only that one is returned, with a zero amount when it has no budget.
+func (s *Store) Budgets(ctx context.Context, userPhone, category string, start, end time.Time) ([]CategoryBudget, error) {
+	userPhone = strings.TrimSpace(userPhone)
+	if userPhone == "" {
+		return nil, ErrBadRequest
+	}
+	if category != "" {
+		category = normalizeCategory(category)
+	}
+
+	const q = `
+        WITH cats AS (
+            SELECT category, amount_paise FROM expense_budgets
+            WHERE user_phone = $1 AND ($4 = '' OR category = $4)
+            UNION ALL
+            SELECT $4, 0 WHERE $4 <> '' AND NOT EXISTS (
+                SELECT 1 FROM expense_budgets WHERE user_phone = $1 AND category = $4)
+        )
+        SELECT c.category, c.amount_paise, COALESCE(SUM(e.amount_paise), 0)
+        FROM cats c
+        LEFT JOIN expenses e
+          ON e.user_phone = $1 AND e.category = c.category AND e.created_at >= $2 AND e.created_at < $3
+        GROUP BY c.category, c.amount_paise
+        ORDER BY c.amount_paise DESC, c.category;
+    `
+	rows, err := s.DB.QueryContext(ctx, q, userPhone, start, end, category)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]CategoryBudget, 0)
+	for rows.Next() {
+		var b CategoryBudget
+		if err := rows.Scan(&b.Category, &b.AmountPaise, &b.SpentPaise); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
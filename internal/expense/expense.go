@@ -4,15 +4,18 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"regexp"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/blobstore"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
 )
 
 type Store struct {
 	DB *sql.DB
+	// Blobs holds receipt photos; attachments are off while it is nil.
+	Blobs blobstore.Store
 }
 
 type Expense struct {
@@ -30,11 +33,15 @@ type AddExpenseRequest struct {
 	UserPhone string `json:"user_phone"`
 	// amount in rupees (e.g. 250.50). We'll convert to paise.
 	AmountRupees float64 `json:"amount_rupees"`
-	Category     string  `json:"category,omitempty"`
-	Note         string  `json:"note,omitempty"`
-	Source       string  `json:"source,omitempty"` // manual by default
+	// AmountPaise is the exact amount; it wins over AmountRupees when set.
+	AmountPaise int64  `json:"amount_paise,omitempty"`
+	Category    string `json:"category,omitempty"`
+	Note        string `json:"note,omitempty"`
+	Source      string `json:"source,omitempty"` // manual by default
 	// Optional: raw text like "250 food pizza"
 	Text string `json:"text,omitempty"`
+	// SpentAt backdates the expense; now when unset.
+	SpentAt *time.Time `json:"spent_at,omitempty"`
 }
 
 type MonthlySummary struct {
@@ -44,8 +51,16 @@ type MonthlySummary struct {
 	TotalRupees     float64          `json:"total_rupees"`
 	TopCategory     string           `json:"top_category"`
 	CategoryBreakup []CategoryBucket `json:"category_breakup"`
-	Insight         string           `json:"insight"`
+	Insight         string           `json:"insight"` // top-ranked insight message, kept for older clients
+	Insights        []Insight        `json:"insights"`
 	Transactions    int64            `json:"transactions"`
+	Daily           []DaySpend       `json:"daily"`
+}
+
+// DaySpend is one calendar day of the month (UTC), zero when nothing was spent.
+type DaySpend struct {
+	Date       string `json:"date"` // YYYY-MM-DD
+	TotalPaise int64  `json:"total_paise"`
 }
 
 type CategoryBucket struct {
@@ -57,6 +72,7 @@ type CategoryBucket struct {
 
 var (
 	ErrBadRequest = errors.New("bad request")
+	ErrNotFound   = errors.New("expense not found")
 )
 
 // ---------------------------
@@ -77,57 +93,69 @@ func normalizeCategory(s string) string {
 	}
 }
 
-func categorizeFromText(text string) (amountRupees float64, category string, note string, ok bool) {
-	// Accept formats like:
-	// "250 food pizza"
-	// "uber 180"
-	// "Spent 99 coffee"
-	// We'll try to extract first number as amount, rest as note/category guess.
-
-	t := strings.TrimSpace(text)
-	if t == "" {
+func categorizeFromText(text string) (amountPaise int64, category string, note string, ok bool) {
+	m, ok := ParseMessage(text, time.Now())
+	if !ok {
 		return 0, "", "", false
 	}
+	it := m.Items[0]
+	return it.AmountPaise, it.Category, it.Note, true
+}
 
-	// find first number (integer or decimal)
-	re := regexp.MustCompile(`(\d+(\.\d+)?)`)
-	m := re.FindStringSubmatch(t)
-	if len(m) < 2 {
-		return 0, "", "", false
-	}
+// ParseText reads a chat message like "250 food pizza" or "uber 180" as one expense: the first
+// item ParseMessage finds. Use ParseMessage for several items, dates and income.
+func ParseText(text string) (amountPaise int64, category string, note string, ok bool) {
+	return categorizeFromText(text)
+}
 
-	// parse amount
-	var amt float64
-	_, err := fmtSscanfFloat(m[1], &amt)
-	if err != nil || amt <= 0 {
-		return 0, "", "", false
+// Categories lists the rule-based categories, most used first.
+var Categories = []string{"FOOD", "TRANSPORT", "BILLS", "SHOPPING", "HEALTH", "ENTERTAINMENT", "FIXED", "MISC"}
+
+// ParseCategory reads a category from a short reply: a category name ("food"), a word the rules
+// know ("zomato") or "other" for MISC.
+func ParseCategory(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	switch up := strings.ToUpper(s); up {
+	case "":
+		return "", false
+	case "OTHER", "OTHERS":
+		return "MISC", true
+	default:
+		if normalizeCategory(up) == up {
+			return up, true
+		}
 	}
+	if cat := Categorize(s); cat != "MISC" {
+		return cat, true
+	}
+	return "", false
+}
 
-	// remove the first number from text to get remaining tokens
-	idx := strings.Index(t, m[1])
-	rest := strings.TrimSpace(t[:idx] + t[idx+len(m[1]):])
-	restLower := strings.ToLower(rest)
-
-	// rule-based categories
+// Categorize maps free text (a note, a bank narration) to one of the rule-based categories.
+// Unknown text is MISC.
+func Categorize(text string) string {
+	restLower := strings.ToLower(text)
 	cat := "MISC"
 	switch {
-	case containsAny(restLower, "zomato", "swiggy", "food", "pizza", "burger", "coffee", "chai", "tea", "restaurant", "dinner", "lunch", "breakfast"):
+	case containsAny(restLower, "zomato", "swiggy", "food", "pizza", "burger", "coffee", "chai", "tea", "restaurant", "dinner", "lunch", "breakfast",
+		"samosa", "thali", "nashta", "khana", "khaana", "sabzi", "sabji", "doodh", "groceries", "grocery", "kirana", "snacks",
+		"चाय", "खाना", "नाश्ता", "सब्ज़ी", "सब्जी", "दूध", "समोसा", "किराना"):
 		cat = "FOOD"
-	case containsAny(restLower, "uber", "ola", "auto", "metro", "bus", "cab", "rapido", "petrol", "fuel"):
+	case containsAny(restLower, "uber", "ola", "auto", "metro", "bus", "cab", "rapido", "petrol", "fuel", "diesel", "rickshaw",
+		"taxi", "flight", "पेट्रोल", "ऑटो", "मेट्रो"):
 		cat = "TRANSPORT"
-	case containsAny(restLower, "rent", "emi", "loan", "school fee", "fees", "insurance"):
+	case containsAny(restLower, "rent", "emi", "loan", "school fee", "fees", "insurance", "kiraya", "किराया", "ईएमआई"):
 		cat = "FIXED"
-	case containsAny(restLower, "bill", "electricity", "gas", "water", "recharge", "wifi", "broadband"):
+	case containsAny(restLower, "bill", "electricity", "gas", "water", "recharge", "wifi", "broadband", "bijli", "बिजली", "रिचार्ज"):
 		cat = "BILLS"
-	case containsAny(restLower, "netflix", "prime", "hotstar", "movie", "game", "spotify"):
+	case containsAny(restLower, "netflix", "prime", "hotstar", "movie", "game", "spotify", "picture", "फ़िल्म", "फिल्म"):
 		cat = "ENTERTAINMENT"
-	case containsAny(restLower, "doctor", "medicine", "pharmacy", "gym", "protein"):
+	case containsAny(restLower, "doctor", "medicine", "pharmacy", "gym", "protein", "dawai", "dawa", "dava", "hospital", "दवाई", "दवा", "डॉक्टर"):
 		cat = "HEALTH"
-	case containsAny(restLower, "amazon", "flipkart", "shopping", "clothes", "shoes"):
+	case containsAny(restLower, "amazon", "flipkart", "shopping", "clothes", "shoes", "kapde", "kapda", "joote", "कपड़े", "जूते"):
 		cat = "SHOPPING"
 	}
-
-	return amt, cat, rest, true
+	return cat
 }
 
 func containsAny(s string, words ...string) bool {
@@ -139,24 +167,6 @@ func containsAny(s string, words ...string) bool {
 	return false
 }
 
-// Small helper: avoid importing fmt just for Sscanf issues in some setups
-func fmtSscanfFloat(str string, out *float64) (int, error) {
-	// minimal parse
-	// NOTE: using strconv is more standard; keeping it direct & safe:
-	return 1, parseFloat(str, out)
-}
-
-func parseFloat(str string, out *float64) error {
-	// use strconv for safety
-	// kept in a separate function so it’s easy to change.
-	f, err := strconv.ParseFloat(str, 64)
-	if err != nil {
-		return err
-	}
-	*out = f
-	return nil
-}
-
 // ---------------------------
 // Store methods
 // ---------------------------
@@ -167,7 +177,7 @@ func (s *Store) AddExpense(ctx context.Context, req AddExpenseRequest) (*Expense
 		return nil, ErrBadRequest
 	}
 
-	amountRupees := req.AmountRupees
+	amountPaise := req.amountPaise()
 	category := req.Category
 	note := strings.TrimSpace(req.Note)
 
@@ -175,7 +185,7 @@ func (s *Store) AddExpense(ctx context.Context, req AddExpenseRequest) (*Expense
 	if strings.TrimSpace(req.Text) != "" {
 		amt, cat, parsedNote, ok := categorizeFromText(req.Text)
 		if ok {
-			amountRupees = amt
+			amountPaise = amt
 			category = cat
 			// only set note if not explicitly given
 			if note == "" {
@@ -184,29 +194,42 @@ func (s *Store) AddExpense(ctx context.Context, req AddExpenseRequest) (*Expense
 		}
 	}
 
-	if amountRupees <= 0 {
+	if amountPaise <= 0 {
 		return nil, ErrBadRequest
 	}
 	if req.Source == "" {
 		req.Source = "manual"
 	}
 
-	amountPaise := int64(amountRupees * 100.0)
-	// protect against float rounding weirdness:
-	if amountPaise <= 0 {
-		return nil, ErrBadRequest
+	return insertExpense(ctx, s.DB, req.UserPhone, amountPaise, normalizeCategory(category), note, req.Source, req.SpentAt)
+}
+
+// amountPaise is AmountPaise, or AmountRupees rounded to the nearest paisa; 0 when neither is
+// a valid amount.
+func (r AddExpenseRequest) amountPaise() int64 {
+	if r.AmountPaise != 0 {
+		return r.AmountPaise
 	}
+	p, err := money.RupeesToPaise(r.AmountRupees)
+	if err != nil {
+		return 0
+	}
+	return p
+}
 
-	category = normalizeCategory(category)
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
 
+func insertExpense(ctx context.Context, db queryRower, phone string, amountPaise int64, category, note, source string, spentAt *time.Time) (*Expense, error) {
 	const q = `
-        INSERT INTO expenses (user_phone, amount_paise, currency, category, note, source)
-        VALUES ($1, $2, 'INR', $3, $4, $5)
+        INSERT INTO expenses (user_phone, amount_paise, currency, category, note, source, created_at, user_id)
+        VALUES ($1, $2, 'INR', $3, $4, $5, COALESCE($6, NOW()), (SELECT id FROM users WHERE phone = $1 AND phone_verified_at IS NOT NULL))
         RETURNING id, user_phone, amount_paise, currency, category, note, source, created_at;
     `
 
 	var e Expense
-	err := s.DB.QueryRowContext(ctx, q, req.UserPhone, amountPaise, category, note, req.Source).
+	err := db.QueryRowContext(ctx, q, phone, amountPaise, category, note, source, spentAt).
 		Scan(&e.ID, &e.UserPhone, &e.AmountPaise, &e.Currency, &e.Category, &e.Note, &e.Source, &e.CreatedAt)
 	if err != nil {
 		return nil, err
@@ -253,6 +276,40 @@ func (s *Store) ListExpenses(ctx context.Context, p ListExpensesParams) ([]Expen
 	return out, rows.Err()
 }
 
+// SetCategory recategorizes one of the phone's expenses.
+func (s *Store) SetCategory(ctx context.Context, userPhone string, id int64, category string) (*Expense, error) {
+	const q = `
+        UPDATE expenses SET category = $3
+        WHERE id = $1 AND user_phone = $2
+        RETURNING id, user_phone, amount_paise, currency, category, COALESCE(note, ''), source, created_at;
+    `
+	return s.scanOne(ctx, q, id, strings.TrimSpace(userPhone), normalizeCategory(category))
+}
+
+// DeleteExpense removes one of the phone's expenses and returns it.
+func (s *Store) DeleteExpense(ctx context.Context, userPhone string, id int64) (*Expense, error) {
+	const q = `
+        DELETE FROM expenses
+        WHERE id = $1 AND user_phone = $2
+        RETURNING id, user_phone, amount_paise, currency, category, COALESCE(note, ''), source, created_at;
+    `
+	return s.scanOne(ctx, q, id, strings.TrimSpace(userPhone))
+}
+
+// scanOne runs a single-row expense query; no row is ErrNotFound.
+func (s *Store) scanOne(ctx context.Context, q string, args ...any) (*Expense, error) {
+	var e Expense
+	err := s.DB.QueryRowContext(ctx, q, args...).
+		Scan(&e.ID, &e.UserPhone, &e.AmountPaise, &e.Currency, &e.Category, &e.Note, &e.Source, &e.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
 func monthRange(year int, month int) (time.Time, time.Time, error) {
 	if month < 1 || month > 12 {
 		return time.Time{}, time.Time{}, ErrBadRequest
@@ -329,7 +386,15 @@ func (s *Store) MonthlySummary(ctx context.Context, userPhone string, year int,
 		topCat = buckets[0].Category
 	}
 
-	insight := buildInsight(buckets, total)
+	monthRows, err := s.ListBetween(ctx, userPhone, start, end)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.ListBetween(ctx, userPhone, start.AddDate(0, -insightHistoryMonths, 0), start)
+	if err != nil {
+		return nil, err
+	}
+	insights := buildInsights(monthRows, history, buckets, total, start)
 
 	sum := &MonthlySummary{
 		UserPhone:       userPhone,
@@ -338,26 +403,54 @@ func (s *Store) MonthlySummary(ctx context.Context, userPhone string, year int,
 		TotalRupees:     float64(total) / 100.0,
 		TopCategory:     topCat,
 		CategoryBreakup: buckets,
-		Insight:         insight,
+		Insight:         insights[0].Message,
+		Insights:        insights,
 		Transactions:    txns,
+		Daily:           dailySpend(monthRows, start, end),
 	}
 	return sum, nil
 }
 
-func buildInsight(buckets []CategoryBucket, total int64) string {
-	if total <= 0 {
-		return "No spends logged this month. Calm wallet energy."
+func dailySpend(rows []Expense, start, end time.Time) []DaySpend {
+	days := make([]DaySpend, 0, 31)
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		days = append(days, DaySpend{Date: d.Format("2006-01-02")})
 	}
-	if len(buckets) == 0 {
-		return "No category data this month."
+	for _, e := range rows {
+		i := int(e.CreatedAt.UTC().Sub(start).Hours() / 24)
+		if i >= 0 && i < len(days) {
+			days[i].TotalPaise += e.AmountPaise
+		}
 	}
-	top := buckets[0]
-	// simple “roast but helpful”
-	if top.Percent >= 45 {
-		return "Reality check: almost half your money went to " + strings.ToLower(top.Category) + ". Tiny tweaks here = big savings."
+	return days
+}
+
+// how far back the insights engine looks for a user's "normal" spending
+const insightHistoryMonths = 6
+
+// ListBetween returns the phone's expenses in [start, end), oldest first.
+func (s *Store) ListBetween(ctx context.Context, userPhone string, start, end time.Time) ([]Expense, error) {
+	const q = `
+        SELECT id, user_phone, amount_paise, currency, category, COALESCE(note, ''), source, created_at
+        FROM expenses
+        WHERE user_phone = $1 AND created_at >= $2 AND created_at < $3
+        ORDER BY created_at ASC
+        LIMIT 5000;
+    `
+
+	rows, err := s.DB.QueryContext(ctx, q, userPhone, start, end)
+	if err != nil {
+		return nil, err
 	}
-	if top.Category == "MISC" && top.Percent >= 25 {
-		return "A lot is going into MISC. Add 1–2 words in your message so Vantro can categorize better."
+	defer rows.Close()
+
+	var out []Expense
+	for rows.Next() {
+		var e Expense
+		if err := rows.Scan(&e.ID, &e.UserPhone, &e.AmountPaise, &e.Currency, &e.Category, &e.Note, &e.Source, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
 	}
-	return "Good signal: your spend is fairly spread out. Keep logging — trends show up after 2–3 months."
+	return out, rows.Err()
 }
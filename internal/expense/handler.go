@@ -11,6 +11,8 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 
+	"github.com/ishantswami13-crypto/vantro-backend/internal/businesses"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/duplicates"
 	"github.com/ishantswami13-crypto/vantro-backend/internal/points"
 )
 
@@ -50,6 +52,16 @@ func (h *Handler) CreateExpense(c *fiber.Ctx) error {
 	}
 
 	ctx := userContext(c)
+	if req.BusinessID != nil {
+		ok, err := businesses.OwnedBy(ctx, h.Repo.Pool, *req.BusinessID, userID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "failed business lookup: "+err.Error())
+		}
+		if !ok {
+			return fiber.NewError(fiber.StatusNotFound, "business not found")
+		}
+	}
+
 	requestHash := ""
 	if idemKey != "" {
 		sum := sha256.Sum256(append([]byte(c.Method()+" "+c.Path()+" "), bodyBytes...))
@@ -78,6 +90,7 @@ func (h *Handler) CreateExpense(c *fiber.Ctx) error {
 		Currency:   "INR",
 		SpentOn:    spentOn,
 		Note:       req.Note,
+		BusinessID: req.BusinessID,
 	}
 
 	id, err := h.Repo.InsertExpense(ctx, exp)
@@ -95,6 +108,15 @@ func (h *Handler) CreateExpense(c *fiber.Ctx) error {
 		Message: "expense added",
 	}
 
+	resp.DuplicateWarning = duplicates.Warn(ctx, h.Repo.Pool, userID, duplicates.Record{
+		Kind:      duplicates.KindExpense,
+		ID:        id,
+		Direction: "OUT",
+		Amount:    exp.Amount,
+		Date:      spentOn,
+		Label:     duplicates.Label(exp.VendorName, exp.Note),
+	})
+
 	if idemKey != "" && requestHash != "" {
 		if buf, mErr := json.Marshal(resp); mErr == nil {
 			_, _ = h.Repo.Pool.Exec(
@@ -129,6 +151,22 @@ func (h *Handler) ListExpenses(c *fiber.Ctx) error {
 	return c.JSON(items)
 }
 
+// Subscriptions lists recurring charges detected in the user's last ~13 months of expenses.
+func (h *Handler) Subscriptions(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	now := time.Now().UTC()
+	charges, err := h.Repo.ListChargesSince(userContext(c), userID, now.AddDate(0, 0, -subscriptionLookbackDays))
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load expenses: "+err.Error())
+	}
+
+	return c.JSON(DetectRecurringCharges(charges, now))
+}
+
 func extractUserID(c *fiber.Ctx) (string, error) {
 	val := c.Locals("user_id")
 	if val == nil {
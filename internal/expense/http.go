@@ -88,3 +88,16 @@ func MonthlySummaryHandler(store *Store) fiber.Handler {
 		return c.JSON(sum)
 	}
 }
+
+func SubscriptionsHandler(store *Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		rep, err := store.DetectSubscriptions(c.Context(), c.Query("phone"))
+		if err != nil {
+			if err == ErrBadRequest {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "phone required"})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "server error"})
+		}
+		return c.JSON(rep)
+	}
+}
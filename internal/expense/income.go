@@ -0,0 +1,74 @@
+package expense
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Income is money a phone received, logged from a chat message like "received 5000 from Rahul".
+// It shares phone_incomes with captured UPI credits.
+type Income struct {
+	ID           int64     `json:"id"`
+	UserPhone    string    `json:"user_phone"`
+	AmountPaise  int64     `json:"amount_paise"`
+	Counterparty string    `json:"counterparty,omitempty"`
+	Note         string    `json:"note,omitempty"`
+	Source       string    `json:"source"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+const incomeColumns = `id, user_phone, amount_paise, COALESCE(counterparty, ''), COALESCE(note, ''), source, created_at`
+
+func scanIncome(row interface{ Scan(...any) error }) (*Income, error) {
+	var in Income
+	err := row.Scan(&in.ID, &in.UserPhone, &in.AmountPaise, &in.Counterparty, &in.Note, &in.Source, &in.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &in, nil
+}
+
+// AddIncome records money received; a zero CreatedAt is now.
+func (s *Store) AddIncome(ctx context.Context, in Income) (*Income, error) {
+	in.UserPhone = strings.TrimSpace(in.UserPhone)
+	if in.UserPhone == "" || in.AmountPaise <= 0 {
+		return nil, ErrBadRequest
+	}
+	if in.Source == "" {
+		in.Source = "manual"
+	}
+	var createdAt *time.Time
+	if !in.CreatedAt.IsZero() {
+		createdAt = &in.CreatedAt
+	}
+	const q = `
+        INSERT INTO phone_incomes (user_phone, amount_paise, currency, counterparty, note, source, created_at)
+        VALUES ($1, $2, 'INR', NULLIF($3, ''), NULLIF($4, ''), $5, COALESCE($6, NOW()))
+        RETURNING ` + incomeColumns + `;
+    `
+	return scanIncome(s.DB.QueryRowContext(ctx, q, in.UserPhone, in.AmountPaise, strings.TrimSpace(in.Counterparty),
+		strings.TrimSpace(in.Note), in.Source, createdAt))
+}
+
+// DeleteIncome removes one of the phone's income entries and returns it.
+func (s *Store) DeleteIncome(ctx context.Context, userPhone string, id int64) (*Income, error) {
+	return scanIncome(s.DB.QueryRowContext(ctx, `
+        DELETE FROM phone_incomes WHERE id = $1 AND user_phone = $2
+        RETURNING `+incomeColumns+`;
+    `, id, strings.TrimSpace(userPhone)))
+}
+
+// IncomeTotal sums what the phone received in [start, end).
+func (s *Store) IncomeTotal(ctx context.Context, userPhone string, start, end time.Time) (int64, error) {
+	var total int64
+	err := s.DB.QueryRowContext(ctx, `
+        SELECT COALESCE(SUM(amount_paise), 0) FROM phone_incomes
+        WHERE user_phone = $1 AND created_at >= $2 AND created_at < $3;
+    `, strings.TrimSpace(userPhone), start, end).Scan(&total)
+	return total, err
+}
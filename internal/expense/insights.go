@@ -0,0 +1,468 @@
+package expense
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Insight types are machine-readable so the frontend and PDF can pick their own rendering.
+const (
+	InsightCategoryChange   = "category_mom_change"
+	InsightLargeTransaction = "large_transaction"
+	InsightNewMerchant      = "new_merchant"
+	InsightWeekendPattern   = "weekend_vs_weekday"
+	InsightDuplicateCharge  = "duplicate_charge"
+	InsightConcentration    = "category_concentration"
+	InsightMiscHeavy        = "misc_heavy"
+	InsightSpreadOut        = "spread_out"
+	InsightNoSpend          = "no_spend"
+)
+
+// Insight is one ranked observation about a month of spending. Data carries the supporting
+// numbers (rupees, percentages, z-scores) keyed by name.
+type Insight struct {
+	Type       string             `json:"type"`
+	Severity   string             `json:"severity"` // info | warning | positive
+	Score      float64            `json:"score"`
+	Title      string             `json:"title"`
+	Message    string             `json:"message"`
+	Category   string             `json:"category,omitempty"`
+	Merchant   string             `json:"merchant,omitempty"`
+	ExpenseIDs []int64            `json:"expense_ids,omitempty"`
+	Data       map[string]float64 `json:"data,omitempty"`
+}
+
+const (
+	maxInsights = 8
+	// history below this size is too thin for outlier and new-merchant checks
+	minHistoryForStats = 8
+	// modified z-score cut-off suggested by Iglewicz & Hoaglin
+	madOutlierZ = 3.5
+	// duplicates are same merchant + amount within this window
+	duplicateWindow = 48 * time.Hour
+)
+
+// buildInsights compares the month's expenses with the user's earlier history and returns the
+// strongest observations first. history holds expenses from before monthStart.
+func buildInsights(month []Expense, history []Expense, buckets []CategoryBucket, total int64, monthStart time.Time) []Insight {
+	if total <= 0 {
+		return []Insight{{
+			Type:     InsightNoSpend,
+			Severity: "positive",
+			Score:    0,
+			Title:    "No spends logged",
+			Message:  "No spends logged this month. Calm wallet energy.",
+		}}
+	}
+
+	var out []Insight
+	out = append(out, categoryChangeInsights(buckets, history, monthStart)...)
+	out = append(out, largeTransactionInsights(month, history)...)
+	out = append(out, newMerchantInsights(month, history)...)
+	if in, ok := weekendInsight(month, monthStart); ok {
+		out = append(out, in)
+	}
+	out = append(out, duplicateInsights(month)...)
+	out = append(out, mixInsight(buckets))
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if len(out) > maxInsights {
+		out = out[:maxInsights]
+	}
+	return out
+}
+
+// categoryChangeInsights flags categories whose spend moved by at least 25% and ₹500 against last month.
+func categoryChangeInsights(buckets []CategoryBucket, history []Expense, monthStart time.Time) []Insight {
+	prevStart := monthStart.AddDate(0, -1, 0)
+	prev := map[string]int64{}
+	for _, e := range history {
+		if !e.CreatedAt.Before(prevStart) && e.CreatedAt.Before(monthStart) {
+			prev[e.Category] += e.AmountPaise
+		}
+	}
+
+	var out []Insight
+	seen := map[string]bool{}
+	for _, b := range buckets {
+		seen[b.Category] = true
+		before := prev[b.Category]
+		if before == 0 {
+			continue
+		}
+		diff := b.TotalPaise - before
+		pct := float64(diff) / float64(before) * 100
+		if math.Abs(pct) < 25 || abs64(diff) < 50000 {
+			continue
+		}
+
+		dir, sev := "up", "warning"
+		if diff < 0 {
+			dir, sev = "down", "positive"
+		}
+		cat := strings.ToLower(b.Category)
+		out = append(out, Insight{
+			Type:     InsightCategoryChange,
+			Severity: sev,
+			Score:    float64(abs64(diff)) / 100,
+			Title:    fmt.Sprintf("%s spend %s %.0f%%", titleCase(cat), dir, math.Abs(pct)),
+			Message:  fmt.Sprintf("You spent ₹%.0f on %s this month vs ₹%.0f last month.", rupees(b.TotalPaise), cat, rupees(before)),
+			Category: b.Category,
+			Data: map[string]float64{
+				"current_rupees":  rupees(b.TotalPaise),
+				"previous_rupees": rupees(before),
+				"change_percent":  round1(pct),
+			},
+		})
+	}
+
+	// categories that dropped to zero are good news worth surfacing
+	for cat, before := range prev {
+		if seen[cat] || before < 50000 {
+			continue
+		}
+		out = append(out, Insight{
+			Type:     InsightCategoryChange,
+			Severity: "positive",
+			Score:    float64(before) / 200,
+			Title:    fmt.Sprintf("No %s spend this month", strings.ToLower(cat)),
+			Message:  fmt.Sprintf("Last month you spent ₹%.0f on %s; nothing so far this month.", rupees(before), strings.ToLower(cat)),
+			Category: cat,
+			Data: map[string]float64{
+				"current_rupees":  0,
+				"previous_rupees": rupees(before),
+				"change_percent":  -100,
+			},
+		})
+	}
+	return out
+}
+
+// largeTransactionInsights uses the median absolute deviation of past amounts so one earlier
+// splurge does not hide the next one (as it would with mean/stddev).
+func largeTransactionInsights(month []Expense, history []Expense) []Insight {
+	if len(history) < minHistoryForStats {
+		return nil
+	}
+
+	amounts := make([]float64, len(history))
+	for i, e := range history {
+		amounts[i] = float64(e.AmountPaise)
+	}
+	med := median(amounts)
+	devs := make([]float64, len(amounts))
+	for i, a := range amounts {
+		devs[i] = math.Abs(a - med)
+	}
+	mad := median(devs)
+
+	var out []Insight
+	for _, e := range month {
+		x := float64(e.AmountPaise)
+		var z float64
+		if mad > 0 {
+			z = 0.6745 * (x - med) / mad
+		} else if med > 0 {
+			// every past amount identical: treat 3x the usual as an outlier
+			z = (x / med) * madOutlierZ / 3
+		}
+		if z < madOutlierZ {
+			continue
+		}
+
+		out = append(out, Insight{
+			Type:       InsightLargeTransaction,
+			Severity:   "warning",
+			Score:      rupees(e.AmountPaise) * math.Min(z/madOutlierZ, 3),
+			Title:      fmt.Sprintf("Unusually large spend: ₹%.0f", rupees(e.AmountPaise)),
+			Message:    fmt.Sprintf("₹%.0f on %s is far above your typical ₹%.0f per entry.", rupees(e.AmountPaise), describe(e), med/100),
+			Category:   e.Category,
+			Merchant:   merchantKey(e.Note),
+			ExpenseIDs: []int64{e.ID},
+			Data: map[string]float64{
+				"amount_rupees":        rupees(e.AmountPaise),
+				"median_rupees":        round1(med / 100),
+				"mad_rupees":           round1(mad / 100),
+				"modified_z_score":     round1(z),
+				"history_transactions": float64(len(history)),
+			},
+		})
+	}
+	return out
+}
+
+func newMerchantInsights(month []Expense, history []Expense) []Insight {
+	if len(history) < minHistoryForStats {
+		return nil
+	}
+
+	known := map[string]bool{}
+	for _, e := range history {
+		if k := merchantKey(e.Note); k != "" {
+			known[k] = true
+		}
+	}
+
+	type agg struct {
+		total int64
+		ids   []int64
+		cat   string
+	}
+	fresh := map[string]*agg{}
+	for _, e := range month {
+		k := merchantKey(e.Note)
+		if k == "" || known[k] {
+			continue
+		}
+		a := fresh[k]
+		if a == nil {
+			a = &agg{cat: e.Category}
+			fresh[k] = a
+		}
+		a.total += e.AmountPaise
+		a.ids = append(a.ids, e.ID)
+	}
+
+	out := make([]Insight, 0, len(fresh))
+	for k, a := range fresh {
+		out = append(out, Insight{
+			Type:       InsightNewMerchant,
+			Severity:   "info",
+			Score:      rupees(a.total) * 0.5,
+			Title:      "New place: " + k,
+			Message:    fmt.Sprintf("First time spending at %s: ₹%.0f across %d entries.", k, rupees(a.total), len(a.ids)),
+			Category:   a.cat,
+			Merchant:   k,
+			ExpenseIDs: a.ids,
+			Data: map[string]float64{
+				"total_rupees": rupees(a.total),
+				"transactions": float64(len(a.ids)),
+			},
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if len(out) > 3 {
+		out = out[:3]
+	}
+	return out
+}
+
+// weekendInsight compares average spend per weekend day and per weekday for the elapsed part of the month.
+func weekendInsight(month []Expense, monthStart time.Time) (Insight, bool) {
+	end := monthStart.AddDate(0, 1, 0)
+	if now := time.Now().UTC(); now.Before(end) {
+		end = now
+	}
+
+	var weekendDays, weekDays int
+	for d := monthStart; d.Before(end); d = d.AddDate(0, 0, 1) {
+		if isWeekend(d) {
+			weekendDays++
+		} else {
+			weekDays++
+		}
+	}
+	if weekendDays < 2 || weekDays < 5 {
+		return Insight{}, false
+	}
+
+	var weekend, weekday int64
+	for _, e := range month {
+		if isWeekend(e.CreatedAt) {
+			weekend += e.AmountPaise
+		} else {
+			weekday += e.AmountPaise
+		}
+	}
+	weekendAvg := float64(weekend) / float64(weekendDays)
+	weekdayAvg := float64(weekday) / float64(weekDays)
+	if weekdayAvg <= 0 && weekendAvg <= 0 {
+		return Insight{}, false
+	}
+
+	ratio := weekendAvg / math.Max(weekdayAvg, 1)
+	if ratio < 1.5 && ratio > 1/1.5 {
+		return Insight{}, false
+	}
+
+	in := Insight{
+		Type:     InsightWeekendPattern,
+		Severity: "info",
+		Score:    math.Abs(weekendAvg-weekdayAvg) / 100 * float64(weekendDays),
+		Data: map[string]float64{
+			"weekend_daily_avg_rupees": round1(weekendAvg / 100),
+			"weekday_daily_avg_rupees": round1(weekdayAvg / 100),
+			"ratio":                    round1(ratio),
+			"weekend_days":             float64(weekendDays),
+			"weekdays":                 float64(weekDays),
+		},
+	}
+	if ratio >= 1.5 {
+		in.Title = fmt.Sprintf("Weekends cost %.1fx more", ratio)
+		in.Message = fmt.Sprintf("You average ₹%.0f per weekend day vs ₹%.0f on weekdays.", weekendAvg/100, weekdayAvg/100)
+	} else {
+		in.Title = "Weekday spending dominates"
+		in.Message = fmt.Sprintf("You average ₹%.0f per weekday vs ₹%.0f on weekends.", weekdayAvg/100, weekendAvg/100)
+	}
+	return in, true
+}
+
+// duplicateInsights looks for the same amount at the same merchant (or the same category when
+// there is no note) within 48 hours.
+func duplicateInsights(month []Expense) []Insight {
+	sorted := make([]Expense, len(month))
+	copy(sorted, month)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+
+	var out []Insight
+	used := map[int64]bool{}
+	for i := 0; i < len(sorted); i++ {
+		a := sorted[i]
+		if used[a.ID] {
+			continue
+		}
+		for j := i + 1; j < len(sorted); j++ {
+			b := sorted[j]
+			if b.CreatedAt.Sub(a.CreatedAt) > duplicateWindow {
+				break
+			}
+			if used[b.ID] || a.AmountPaise != b.AmountPaise || !sameMerchant(a, b) {
+				continue
+			}
+			used[a.ID], used[b.ID] = true, true
+			gap := b.CreatedAt.Sub(a.CreatedAt)
+			out = append(out, Insight{
+				Type:       InsightDuplicateCharge,
+				Severity:   "warning",
+				Score:      rupees(a.AmountPaise) * 1.5,
+				Title:      fmt.Sprintf("Possible double entry: ₹%.0f", rupees(a.AmountPaise)),
+				Message:    fmt.Sprintf("%s for ₹%.0f was logged twice within %s. Delete one if it is a duplicate.", titleCase(describe(a)), rupees(a.AmountPaise), humanGap(gap)),
+				Category:   a.Category,
+				Merchant:   merchantKey(a.Note),
+				ExpenseIDs: []int64{a.ID, b.ID},
+				Data: map[string]float64{
+					"amount_rupees": rupees(a.AmountPaise),
+					"gap_hours":     round1(gap.Hours()),
+				},
+			})
+			break
+		}
+	}
+	return out
+}
+
+// mixInsight keeps the original top-category guidance as a low-ranked baseline.
+func mixInsight(buckets []CategoryBucket) Insight {
+	if len(buckets) == 0 {
+		return Insight{Type: InsightSpreadOut, Severity: "info", Title: "No category data", Message: "No category data this month."}
+	}
+	top := buckets[0]
+	data := map[string]float64{"top_percent": round1(top.Percent), "top_rupees": top.TotalRs}
+	switch {
+	case top.Percent >= 45:
+		return Insight{
+			Type:     InsightConcentration,
+			Severity: "warning",
+			Score:    top.TotalRs * 0.1,
+			Title:    fmt.Sprintf("%.0f%% went to %s", top.Percent, strings.ToLower(top.Category)),
+			Message:  "Reality check: almost half your money went to " + strings.ToLower(top.Category) + ". Tiny tweaks here = big savings.",
+			Category: top.Category,
+			Data:     data,
+		}
+	case top.Category == "MISC" && top.Percent >= 25:
+		return Insight{
+			Type:     InsightMiscHeavy,
+			Severity: "info",
+			Score:    top.TotalRs * 0.05,
+			Title:    "Lots of uncategorised spend",
+			Message:  "A lot is going into MISC. Add 1–2 words in your message so Vantro can categorize better.",
+			Category: top.Category,
+			Data:     data,
+		}
+	}
+	return Insight{
+		Type:     InsightSpreadOut,
+		Severity: "positive",
+		Score:    0,
+		Title:    "Spend is well spread",
+		Message:  "Good signal: your spend is fairly spread out. Keep logging — trends show up after 2–3 months.",
+		Data:     data,
+	}
+}
+
+// merchantKey reduces a free-text note to a comparable merchant name: lowercase letters only,
+// first two words ("Zomato order 2" -> "zomato order").
+func merchantKey(note string) string {
+	fields := strings.FieldsFunc(strings.ToLower(note), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if len(fields) > 2 {
+		fields = fields[:2]
+	}
+	return strings.Join(fields, " ")
+}
+
+func sameMerchant(a, b Expense) bool {
+	ka, kb := merchantKey(a.Note), merchantKey(b.Note)
+	if ka == "" && kb == "" {
+		return a.Category == b.Category
+	}
+	return ka == kb
+}
+
+func describe(e Expense) string {
+	if k := merchantKey(e.Note); k != "" {
+		return k
+	}
+	return strings.ToLower(e.Category)
+}
+
+func median(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	s := make([]float64, len(xs))
+	copy(s, xs)
+	sort.Float64s(s)
+	mid := len(s) / 2
+	if len(s)%2 == 0 {
+		return (s[mid-1] + s[mid]) / 2
+	}
+	return s[mid]
+}
+
+func isWeekend(t time.Time) bool {
+	wd := t.Weekday()
+	return wd == time.Saturday || wd == time.Sunday
+}
+
+func humanGap(d time.Duration) string {
+	if d < time.Hour {
+		return fmt.Sprintf("%d minutes", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%.0f hours", d.Hours())
+}
+
+func titleCase(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+func rupees(paise int64) float64 { return float64(paise) / 100.0 }
+
+func round1(f float64) float64 { return math.Round(f*10) / 10 }
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
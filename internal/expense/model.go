@@ -1,6 +1,10 @@
 package expense
 
-import "time"
+import (
+	"time"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/duplicates"
+)
 
 type LegacyExpense struct {
 	ID         string    `db:"id" json:"id"`
@@ -10,6 +14,7 @@ type LegacyExpense struct {
 	Currency   string    `db:"currency" json:"currency"`
 	SpentOn    time.Time `db:"spent_on" json:"spent_on"`
 	Note       *string   `db:"note" json:"note,omitempty"`
+	BusinessID *int64    `db:"business_id" json:"business_id,omitempty"`
 	CreatedAt  time.Time `db:"created_at" json:"created_at"`
 }
 
@@ -18,9 +23,11 @@ type CreateExpenseRequest struct {
 	Amount     int64   `json:"amount"`
 	SpentOn    string  `json:"spent_on"` // YYYY-MM-DD
 	Note       *string `json:"note"`
+	BusinessID *int64  `json:"business_id"`
 }
 
 type CreateExpenseResponse struct {
-	ID      string `json:"id"`
-	Message string `json:"message"`
+	ID               string              `json:"id"`
+	Message          string              `json:"message"`
+	DuplicateWarning *duplicates.Warning `json:"duplicate_warning,omitempty"`
 }
@@ -0,0 +1,553 @@
+package expense
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
+)
+
+// Message intents.
+const (
+	IntentExpense = "expense"
+	IntentIncome  = "income"
+)
+
+// ParsedItem is one amount in a message with the words that go with it.
+type ParsedItem struct {
+	AmountPaise int64
+	Category    string
+	Note        string
+}
+
+// ParsedMessage is a chat message read as one or more expenses, or as money received.
+type ParsedMessage struct {
+	Intent string
+	Items  []ParsedItem
+	// Date is the day the message is about, midnight in now's location; zero when it names none.
+	Date time.Time
+	// Counterparty is who the money came from ("from Rahul", "Rahul se"), for income.
+	Counterparty string
+}
+
+// ParseMessage reads free text in English, Hinglish or Hindi (Devanagari):
+//
+//	"250 lunch", "uber 180", "petrol ke 500", "kal 2 chai 40 rs", "lunch 250, cab 180",
+//	"1.5k rent", "परसों दवाई 300", "received 5000 from Rahul", "Rahul se 2k mile", "5000 from Rahul"
+//
+// Numeric amounts take k, lakh, cr, hazar and sau suffixes; a small number right before a word
+// ("2 chai") is a quantity when another amount follows, or when it is a single digit ("2 samosa"),
+// and a number before a unit ("3 months", "2 kg") is part of the note. With a currency marker
+// (rs, ₹, /-) on one number, the other numbers are part of the note. Words that mix digits with
+// other letters ("1e5", "2nd") are words. Amounts over MaxParsedPaise are not amounts. ok is
+// false without any amount.
+func ParseMessage(text string, now time.Time) (ParsedMessage, bool) {
+	toks := tokenize(text)
+	out := ParsedMessage{Intent: IntentExpense}
+	toks = takeDate(toks, now, &out.Date)
+	toks = takeIntent(toks, &out)
+
+	var pending []token // words of a segment without an amount, for the next item
+	for _, seg := range splitSegments(toks) {
+		items := segmentItems(seg)
+		if len(items) == 0 {
+			pending = append(pending, seg...)
+			continue
+		}
+		if len(pending) > 0 {
+			items[0].words = append(pending, items[0].words...)
+			pending = nil
+		}
+		for _, it := range items {
+			note := noteOf(it.words)
+			out.Items = append(out.Items, ParsedItem{AmountPaise: it.paise, Category: Categorize(note), Note: note})
+		}
+	}
+	if len(out.Items) == 0 {
+		return ParsedMessage{}, false
+	}
+	if len(pending) > 0 {
+		last := &out.Items[len(out.Items)-1]
+		last.Note = strings.TrimSpace(last.Note + " " + noteOf(pending))
+		last.Category = Categorize(last.Note)
+	}
+	return out, true
+}
+
+// MaxParsedPaise caps an amount read from a message at ₹10 crore; anything bigger is a typo.
+const MaxParsedPaise int64 = 100_000_000 * 100
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokNum
+	tokSep
+)
+
+type token struct {
+	kind tokenKind
+	raw  string // as typed, for notes and names
+	low  string
+	// numbers
+	paise    int64
+	currency bool // rs, ₹, /- or rupees next to it
+	scaled   bool // had a k/lakh/... suffix
+	integer  bool
+	glued    bool // typed against the token before it, like the "l" of "2l"
+}
+
+var devanagariDigits = strings.NewReplacer("०", "0", "१", "1", "२", "2", "३", "3", "४", "4", "५", "5", "६", "6", "७", "7", "८", "8", "९", "9")
+
+var (
+	currencyWords = set("rs", "rs.", "inr", "rupees", "rupee", "rupaye", "rupay", "rupiya", "rupye", "₹", "/-", "रुपये", "रुपए", "रुपया", "रु", "रु.")
+	multipliers   = map[string]int64{
+		"k": 1000, "thousand": 1000, "hazar": 1000, "hazaar": 1000, "hajar": 1000, "हज़ार": 1000, "हजार": 1000,
+		"lakh": 100000, "lakhs": 100000, "lac": 100000, "lacs": 100000, "लाख": 100000,
+		"cr": 10000000, "crore": 10000000, "करोड़": 10000000,
+		"sau": 100, "hundred": 100, "सौ": 100,
+	}
+	// unitWords after a number make it a measure, not a price: "1500 for 3 months", "2 kg"
+	unitWords = set("month", "months", "mahina", "mahine", "mahino", "महीना", "महीने", "year", "years", "yr", "yrs", "saal", "साल",
+		"week", "weeks", "hafta", "hafte", "हफ्ता", "हफ्ते", "day", "days", "din", "दिन", "hour", "hours", "hr", "hrs", "ghanta", "ghante", "घंटे",
+		"kg", "kgs", "kilo", "g", "gm", "gms", "gram", "grams", "l", "ltr", "litre", "litres", "liter", "liters", "ml",
+		"km", "kms", "dozen", "darjan", "pcs", "pieces", "packet", "packets", "plate", "plates")
+	separatorWords = set("and", "aur", "और", "n", "&", "+")
+	stopWords      = set("ke", "ka", "ki", "ko", "me", "mein", "mai", "pe", "par", "for", "on", "at", "in", "to", "of",
+		"the", "a", "an", "spent", "spend", "paid", "pay", "diye", "diya", "di", "kharch", "kharcha", "kharche",
+		"liye", "lie", "kiya", "kiye", "ne", "maine", "mene", "i", "my", "was", "hua", "hue", "gaye", "gye", "-", "x",
+		"के", "का", "की", "को", "में", "पर", "लिए", "ने", "मैंने", "खर्च", "दिए", "दिया", "किया", "किए", "हुए", "गए")
+)
+
+func set(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
+
+// tokenize splits text into words, numbers and item separators. Numbers glued to a currency
+// word, multiplier or unit ("250rs", "rs250", "1.5k", "2kg") are split off, and currency words
+// and multiplier suffixes are folded into the number next to them; glued to anything else
+// ("1e5", "4th") the whole is one word. A bare "l" is lakh only when glued to the message's
+// last number ("rent 2l"); otherwise it is litres.
+func tokenize(text string) []token {
+	text = devanagariDigits.Replace(text)
+	text = strings.NewReplacer("₹", " ₹ ", "/-", " /- ", ";", " , ", "\n", " , ").Replace(text)
+
+	var b strings.Builder
+	rs := []rune(text)
+	for i, r := range rs {
+		if i > 0 {
+			prev := rs[i-1]
+			// "250rs" and "rs250" become two tokens; "1,500" and "1.5" stay one
+			afterAbbrev := prev == '.' && i > 1 && isWordRune(rs[i-2]) // "rs.250"
+			if (isDigit(prev) && isWordRune(r)) || ((isWordRune(prev) || afterAbbrev) && isDigit(r)) {
+				b.WriteString(glueMark + " ")
+			}
+			// a comma ends an item unless it groups digits
+			if r == ',' && !(isDigit(prev) && i+1 < len(rs) && isDigit(rs[i+1])) {
+				b.WriteString(" , ")
+				continue
+			}
+		}
+		b.WriteRune(r)
+	}
+
+	var toks []token
+	glued := false
+	for _, f := range strings.Fields(b.String()) {
+		f, glueNext := strings.CutSuffix(f, glueMark)
+		low := strings.ToLower(strings.Trim(f, "!?\"'()"))
+		switch {
+		case low == "":
+			continue
+		case low == ",", separatorWords[low]:
+			toks = append(toks, token{kind: tokSep, raw: f, low: low})
+		case isDigit(rune(low[0])):
+			num := strings.TrimRight(low, ".")
+			p, err := money.ParseRupees(num)
+			if err != nil || p <= 0 {
+				toks = append(toks, token{kind: tokWord, raw: f, low: low, glued: glued})
+				break
+			}
+			toks = append(toks, token{kind: tokNum, raw: f, low: low, paise: p, integer: !strings.Contains(num, "."), glued: glued})
+		default:
+			toks = append(toks, token{kind: tokWord, raw: strings.Trim(f, "!?\"'().,:"), low: strings.Trim(low, ".,:"), glued: glued})
+		}
+		glued = glueNext
+	}
+	toks = joinMixed(toks)
+
+	// fold suffixes and currency words into their numbers
+	out := make([]token, 0, len(toks))
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		if t.kind == tokNum {
+			if i+1 < len(toks) && toks[i+1].kind == tokWord {
+				m, ok := multipliers[toks[i+1].low]
+				if !ok && toks[i+1].low == "l" && toks[i+1].glued && !numberAfter(toks[i+2:]) {
+					m, ok = 100000, true
+				}
+				if ok {
+					if t.paise > MaxParsedPaise/m {
+						// too big to be an expense: keep it as words
+						out = append(out, token{kind: tokWord, raw: t.raw, low: t.low}, toks[i+1])
+						i++
+						continue
+					}
+					t.paise *= m
+					t.scaled, t.integer = true, true
+					i++
+				}
+			}
+			if t.paise > MaxParsedPaise {
+				t = token{kind: tokWord, raw: t.raw, low: t.low}
+			}
+			if i+1 < len(toks) && currencyWords[toks[i+1].low] {
+				t.currency = true
+				i++
+			}
+			if len(out) > 0 && currencyWords[out[len(out)-1].low] {
+				t.currency = true
+				out = out[:len(out)-1]
+			}
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// joinMixed turns each run of glued tokens back into the one word the user typed, unless every
+// word in it is a currency word, multiplier or unit that can go with a number.
+func joinMixed(toks []token) []token {
+	out := make([]token, 0, len(toks))
+	for i := 0; i < len(toks); {
+		j := i + 1
+		for j < len(toks) && toks[j].glued {
+			j++
+		}
+		run := toks[i:j]
+		i = j
+		if len(run) == 1 || gluable(run) {
+			out = append(out, run...)
+			continue
+		}
+		w := token{kind: tokWord}
+		for _, t := range run {
+			w.raw += t.raw
+			w.low += t.low
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+func gluable(run []token) bool {
+	for _, t := range run {
+		if t.kind == tokWord && !currencyWords[t.low] && multipliers[t.low] == 0 && !unitWords[t.low] {
+			return false
+		}
+	}
+	return true
+}
+
+// glueMark ends a token that was typed against the next one, so the split can be told apart
+// from a space the user typed.
+const glueMark = "\x00"
+
+func numberAfter(toks []token) bool {
+	for _, t := range toks {
+		if t.kind == tokNum {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigit(r rune) bool { return r >= '0' && r <= '9' }
+
+// isWordRune is true for letters and the Devanagari vowel signs, which aren't letters to unicode.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r) || r == '₹'
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday, "ravivar": time.Sunday, "raviwar": time.Sunday, "itvaar": time.Sunday, "itwar": time.Sunday, "रविवार": time.Sunday, "इतवार": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday, "somvar": time.Monday, "somwar": time.Monday, "सोमवार": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "mangalvar": time.Tuesday, "mangalwar": time.Tuesday, "मंगलवार": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday, "budhvar": time.Wednesday, "budhwar": time.Wednesday, "बुधवार": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "guruvar": time.Thursday, "guruwar": time.Thursday, "veervar": time.Thursday, "गुरुवार": time.Thursday, "वीरवार": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday, "shukravar": time.Friday, "shukrawar": time.Friday, "शुक्रवार": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday, "shanivar": time.Saturday, "shaniwar": time.Saturday, "शनिवार": time.Saturday,
+}
+
+var (
+	lastWords  = set("last", "pichle", "pichhle", "pichla", "पिछले", "पिछला")
+	agoWords   = set("ago", "pehle", "pahle", "पहले")
+	dayWords   = set("day", "days", "din", "दिन")
+	daysBefore = map[string]int{
+		"aaj": 0, "today": 0, "आज": 0,
+		"kal": 1, "yesterday": 1, "कल": 1,
+		"parso": 2, "parson": 2, "परसों": 2,
+	}
+)
+
+// takeDate removes the first relative date from toks and sets date. "kal" is read as
+// yesterday: expenses are logged after the fact.
+func takeDate(toks []token, now time.Time, date *time.Time) []token {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for i, t := range toks {
+		// "day before yesterday"
+		if t.low == "day" && i+2 < len(toks) && toks[i+1].low == "before" && toks[i+2].low == "yesterday" {
+			*date = today.AddDate(0, 0, -2)
+			return cut(toks, i, i+3)
+		}
+		if n, ok := daysBefore[t.low]; ok {
+			*date = today.AddDate(0, 0, -n)
+			return cut(toks, i, i+1)
+		}
+		// "3 days ago", "2 din pehle", up to a year back
+		if t.kind == tokNum && !t.currency && !t.scaled && t.integer && i+2 < len(toks) && dayWords[toks[i+1].low] && agoWords[toks[i+2].low] {
+			if n := t.paise / 100; n <= 366 {
+				*date = today.AddDate(0, 0, -int(n))
+				return cut(toks, i, i+3)
+			}
+		}
+		if wd, ok := weekdays[t.low]; ok {
+			start, last := i, false
+			if i > 0 && lastWords[toks[i-1].low] {
+				start, last = i-1, true
+			}
+			back := (int(today.Weekday()) - int(wd) + 7) % 7
+			if back == 0 && last {
+				back = 7
+			}
+			*date = today.AddDate(0, 0, -back)
+			return cut(toks, start, i+1)
+		}
+	}
+	return toks
+}
+
+func cut(toks []token, from, to int) []token {
+	return append(append([]token(nil), toks[:from]...), toks[to:]...)
+}
+
+var (
+	incomeWords = set("received", "recieved", "receive", "credited", "credit", "salary", "income", "earned", "refund", "cashback",
+		"mila", "mile", "mili", "mil", "aaya", "aaye", "aayi", "aya", "aye", "ayi", "मिला", "मिले", "मिली", "आया", "आए", "आई", "आये")
+	// words that make it money going out even with an income word ("paid maid salary")
+	outgoingWords = set("paid", "gave", "diye", "diya", "di", "sent", "bheje", "bheja", "दिए", "दिया", "भेजे")
+	fromWords     = set("from", "frm")
+	seWords       = set("se", "से")
+)
+
+// takeIntent decides expense or income and, for income, removes the intent words and the
+// counterparty from toks. "from <name>" alone is income when no other word says what the money
+// was for ("5000 from Rahul", not "shoes 2000 from Myntra").
+func takeIntent(toks []token, m *ParsedMessage) []token {
+	income, outgoing := onlyFrom(toks), false
+	for _, t := range toks {
+		income = income || incomeWords[t.low]
+		outgoing = outgoing || outgoingWords[t.low]
+		// "got 500 from Rahul"
+		if t.low == "got" {
+			for _, u := range toks {
+				income = income || fromWords[u.low]
+			}
+		}
+	}
+	if !income || outgoing {
+		return toks
+	}
+	m.Intent = IntentIncome
+
+	out := make([]token, 0, len(toks))
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		switch {
+		case incomeWords[t.low] && t.low != "salary" && t.low != "refund" && t.low != "cashback", t.low == "got":
+			continue
+		case fromWords[t.low]:
+			var name []string
+			for i+1 < len(toks) && toks[i+1].kind == tokWord && !stopWords[toks[i+1].low] && !incomeWords[toks[i+1].low] && len(name) < 3 {
+				name = append(name, toks[i+1].raw)
+				i++
+			}
+			if m.Counterparty == "" {
+				m.Counterparty = strings.Join(name, " ")
+			}
+			continue
+		case seWords[t.low] && len(out) > 0 && out[len(out)-1].kind == tokWord:
+			if m.Counterparty == "" {
+				m.Counterparty = out[len(out)-1].raw
+			}
+			out = out[:len(out)-1]
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// onlyFrom reports whether toks name who the money came from and nothing else: besides
+// numbers, a from-word with its name, stop words and currency words.
+func onlyFrom(toks []token) bool {
+	named := false
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		switch {
+		case t.kind != tokWord || stopWords[t.low] || currencyWords[t.low]:
+		case fromWords[t.low]:
+			// the name is read the way takeIntent reads it
+			n := 0
+			for i+1 < len(toks) && toks[i+1].kind == tokWord && !stopWords[toks[i+1].low] && !incomeWords[toks[i+1].low] && n < 3 {
+				i++
+				n++
+			}
+			named = named || n > 0
+		default:
+			return false
+		}
+	}
+	return named
+}
+
+func splitSegments(toks []token) [][]token {
+	var segs [][]token
+	var cur []token
+	for _, t := range toks {
+		if t.kind == tokSep {
+			if len(cur) > 0 {
+				segs = append(segs, cur)
+			}
+			cur = nil
+			continue
+		}
+		cur = append(cur, t)
+	}
+	if len(cur) > 0 {
+		segs = append(segs, cur)
+	}
+	return segs
+}
+
+type rawItem struct {
+	paise int64
+	words []token
+}
+
+// segmentItems splits one segment into items. Words go with the amount they come before when
+// the segment starts with a word ("lunch 250 cab 180"), otherwise with the amount before them
+// ("250 lunch 180 cab").
+func segmentItems(seg []token) []rawItem {
+	marked, nums := 0, 0
+	for _, t := range seg {
+		if t.kind == tokNum {
+			nums++
+			if t.currency {
+				marked++
+			}
+		}
+	}
+	if nums == 0 {
+		return nil
+	}
+
+	// pick out quantities and, with one currency-marked number, the numbers that belong to the note
+	isAmount := make([]bool, len(seg))
+	amounts := 0
+	for i, t := range seg {
+		if t.kind != tokNum {
+			continue
+		}
+		switch {
+		case marked == 1:
+			isAmount[i] = t.currency
+		case marked == 0 && (isQuantity(seg, i) || isMeasure(seg, i)):
+		default:
+			isAmount[i] = true
+		}
+		if isAmount[i] {
+			amounts++
+		}
+	}
+	if amounts == 0 {
+		return nil
+	}
+
+	wordFirst := seg[0].kind == tokWord && !isAmount[0]
+	var items []rawItem
+	var words []token
+	for i, t := range seg {
+		if !isAmount[i] {
+			words = append(words, t)
+			continue
+		}
+		// the first amount takes the words before it either way ("2 chai 40")
+		if wordFirst || len(items) == 0 {
+			items = append(items, rawItem{paise: t.paise, words: words})
+			words = nil
+			continue
+		}
+		items[len(items)-1].words = append(items[len(items)-1].words, words...)
+		items = append(items, rawItem{paise: t.paise})
+		words = nil
+	}
+	if len(words) > 0 {
+		items[len(items)-1].words = append(items[len(items)-1].words, words...)
+	}
+	return items
+}
+
+// isQuantity reports whether seg[i] counts something ("2 chai") rather than costing it: a
+// small whole number that starts its item, is followed by a word and has an amount after it.
+// Without an amount after it only a single digit counts: "2 samosa" is two samosas, while
+// "20 chai" cost 20.
+func isQuantity(seg []token, i int) bool {
+	t := seg[i]
+	if t.scaled || t.currency || !t.integer || t.paise > 20*100 {
+		return false
+	}
+	if i > 0 && seg[i-1].kind == tokWord && !stopWords[seg[i-1].low] {
+		return false
+	}
+	if i+1 >= len(seg) || seg[i+1].kind != tokWord {
+		return false
+	}
+	for _, u := range seg[i+1:] {
+		if u.kind == tokNum {
+			return true
+		}
+	}
+	return t.paise < 10*100
+}
+
+// isMeasure reports whether seg[i] is a count of units ("3 months", "2 kg") in a segment that
+// has another number to be the amount.
+func isMeasure(seg []token, i int) bool {
+	t := seg[i]
+	if t.scaled || t.currency || i+1 >= len(seg) || !unitWords[seg[i+1].low] {
+		return false
+	}
+	for j, u := range seg {
+		if j != i && u.kind == tokNum && !(j+1 < len(seg) && unitWords[seg[j+1].low]) {
+			return true
+		}
+	}
+	return false
+}
+
+func noteOf(words []token) string {
+	parts := make([]string, 0, len(words))
+	for _, w := range words {
+		if w.kind == tokWord && (stopWords[w.low] || currencyWords[w.low]) {
+			continue
+		}
+		parts = append(parts, w.raw)
+	}
+	return strings.Join(parts, " ")
+}
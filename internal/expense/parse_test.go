@@ -0,0 +1,116 @@
+package expense
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseMessage(t *testing.T) {
+	ist := time.FixedZone("IST", 5*60*60+30*60)
+	// a Wednesday
+	now := time.Date(2024, time.March, 13, 20, 30, 0, 0, ist)
+	day := func(d int) time.Time { return time.Date(2024, time.March, d, 0, 0, 0, 0, ist) }
+
+	type item = ParsedItem
+	tests := []struct {
+		text         string
+		ok           bool
+		intent       string
+		items        []item
+		date         time.Time
+		counterparty string
+	}{
+		// amounts and notes
+		{text: "250 lunch", ok: true, items: []item{{25000, "FOOD", "lunch"}}},
+		{text: "uber 180", ok: true, items: []item{{18000, "TRANSPORT", "uber"}}},
+		{text: "petrol ke 500", ok: true, items: []item{{50000, "TRANSPORT", "petrol"}}},
+		{text: "250rs lunch", ok: true, items: []item{{25000, "FOOD", "lunch"}}},
+		{text: "₹1,500 shoes", ok: true, items: []item{{150000, "SHOPPING", "shoes"}}},
+		{text: "medicine 1.13", ok: true, items: []item{{113, "HEALTH", "medicine"}}},
+		{text: "coffee 99.99/-", ok: true, items: []item{{9999, "FOOD", "coffee"}}},
+
+		// unit suffixes
+		{text: "1.5k rent", ok: true, items: []item{{150000, "FIXED", "rent"}}},
+		{text: "rent 2l", ok: true, items: []item{{20000000, "FIXED", "rent"}}},
+		{text: "flight 2 lakh", ok: true, items: []item{{20000000, "TRANSPORT", "flight"}}},
+		{text: "car 1 cr", ok: true, items: []item{{1000000000, "MISC", "car"}}},
+		{text: "kiraya 5 hazar", ok: true, items: []item{{500000, "FIXED", "kiraya"}}},
+		{text: "5 sau chai", ok: true, items: []item{{50000, "FOOD", "chai"}}},
+
+		// quantities, measures and currency markers
+		{text: "kal 2 chai 40 rs", ok: true, items: []item{{4000, "FOOD", "2 chai"}}, date: day(12)},
+		{text: "2 l milk 120", ok: true, items: []item{{12000, "MISC", "2 l milk"}}},
+		{text: "2l doodh 120", ok: true, items: []item{{12000, "FOOD", "2 l doodh"}}},
+		{text: "gym 1500 for 3 months", ok: true, items: []item{{150000, "HEALTH", "gym 3 months"}}},
+		{text: "petrol 5 ltr 520", ok: true, items: []item{{52000, "TRANSPORT", "petrol 5 ltr"}}},
+		{text: "800 rs room 204", ok: true, items: []item{{80000, "MISC", "room 204"}}},
+		{text: "2 x chai 40", ok: true, items: []item{{4000, "FOOD", "2 chai"}}},
+		{text: "20 chai", ok: true, items: []item{{2000, "FOOD", "chai"}}},
+		{text: "2nd floor rent 5000", ok: true, items: []item{{500000, "FIXED", "2nd floor rent"}}},
+		{text: "2kg aloo 60", ok: true, items: []item{{6000, "MISC", "2 kg aloo"}}},
+
+		// several items
+		{text: "lunch 250, cab 180", ok: true, items: []item{{25000, "FOOD", "lunch"}, {18000, "TRANSPORT", "cab"}}},
+		{text: "250 lunch 180 cab", ok: true, items: []item{{25000, "FOOD", "lunch"}, {18000, "TRANSPORT", "cab"}}},
+		{text: "chai 20 aur samosa 30", ok: true, items: []item{{2000, "FOOD", "chai"}, {3000, "FOOD", "samosa"}}},
+
+		// dates
+		{text: "yesterday dinner 600", ok: true, items: []item{{60000, "FOOD", "dinner"}}, date: day(12)},
+		{text: "aaj auto 60", ok: true, items: []item{{6000, "TRANSPORT", "auto"}}, date: day(13)},
+		{text: "day before yesterday bill 900", ok: true, items: []item{{90000, "BILLS", "bill"}}, date: day(11)},
+		{text: "last friday movie 400", ok: true, items: []item{{40000, "ENTERTAINMENT", "movie"}}, date: day(8)},
+		{text: "3 days ago metro 45", ok: true, items: []item{{4500, "TRANSPORT", "metro"}}, date: day(10)},
+		{text: "100 days ago lunch 50", ok: true, items: []item{{5000, "FOOD", "lunch"}}, date: time.Date(2023, time.December, 4, 0, 0, 0, 0, ist)},
+
+		// Devanagari
+		{text: "परसों दवाई 300", ok: true, items: []item{{30000, "HEALTH", "दवाई"}}, date: day(11)},
+		{text: "चाय ४० रुपये", ok: true, items: []item{{4000, "FOOD", "चाय"}}},
+
+		// income
+		{text: "received 5000 from Rahul", ok: true, intent: IntentIncome, items: []item{{500000, "MISC", ""}}, counterparty: "Rahul"},
+		{text: "Rahul se 2k mile", ok: true, intent: IntentIncome, items: []item{{200000, "MISC", ""}}, counterparty: "Rahul"},
+		{text: "5000 from Rahul", ok: true, intent: IntentIncome, items: []item{{500000, "MISC", ""}}, counterparty: "Rahul"},
+		{text: "shoes 2000 from Myntra", ok: true, items: []item{{200000, "SHOPPING", "shoes from Myntra"}}},
+		{text: "salary 45000 credited", ok: true, intent: IntentIncome, items: []item{{4500000, "MISC", "salary"}}},
+		{text: "paid maid salary 3000", ok: true, items: []item{{300000, "MISC", "maid salary"}}},
+
+		// no amount, or an amount too big to be real
+		{text: "hello", ok: false},
+		{text: "2 samosa", ok: false},
+		{text: "1e5 lunch", ok: false},
+		{text: "lunch", ok: false},
+		{text: "kal", ok: false},
+		{text: "99999999999 cr", ok: false},
+		{text: "car 20 cr", ok: false},
+		{text: "99999999999999999999", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, ok := ParseMessage(tt.text, now)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v (got %+v)", ok, tt.ok, got)
+			}
+			if !ok {
+				return
+			}
+			intent := tt.intent
+			if intent == "" {
+				intent = IntentExpense
+			}
+			if got.Intent != intent {
+				t.Errorf("intent = %q, want %q", got.Intent, intent)
+			}
+			if !reflect.DeepEqual(got.Items, tt.items) {
+				t.Errorf("items = %+v, want %+v", got.Items, tt.items)
+			}
+			if !got.Date.Equal(tt.date) {
+				t.Errorf("date = %v, want %v", got.Date, tt.date)
+			}
+			if got.Counterparty != tt.counterparty {
+				t.Errorf("counterparty = %q, want %q", got.Counterparty, tt.counterparty)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package expense
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -18,8 +19,8 @@ func (r *Repository) InsertExpense(ctx context.Context, exp *LegacyExpense) (str
 	var id string
 	err := r.Pool.QueryRow(
 		ctx,
-		`INSERT INTO expenses (user_id, vendor_name, amount, currency, spent_on, note)
-         VALUES ($1, $2, $3, COALESCE($4,'INR'), $5, $6)
+		`INSERT INTO expenses (user_id, vendor_name, amount, currency, spent_on, note, business_id)
+         VALUES ($1, $2, $3, COALESCE($4,'INR'), $5, $6, $7)
          RETURNING id`,
 		exp.UserID,
 		exp.VendorName,
@@ -27,6 +28,7 @@ func (r *Repository) InsertExpense(ctx context.Context, exp *LegacyExpense) (str
 		exp.Currency,
 		exp.SpentOn,
 		exp.Note,
+		exp.BusinessID,
 	).Scan(&id)
 	if err != nil {
 		return "", err
@@ -66,3 +68,29 @@ func (r *Repository) ListExpensesByUser(ctx context.Context, userID string) ([]L
 	}
 	return out, rows.Err()
 }
+
+// ListChargesSince returns the user's expenses on or after since as detector input.
+func (r *Repository) ListChargesSince(ctx context.Context, userID string, since time.Time) ([]Charge, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT id::text, vendor_name, amount, spent_on
+		FROM expenses
+		WHERE user_id = $1
+		  AND deleted_at IS NULL
+		  AND spent_on >= $2
+		ORDER BY spent_on ASC
+	`, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Charge, 0)
+	for rows.Next() {
+		var c Charge
+		if err := rows.Scan(&c.ID, &c.Label, &c.AmountPaise, &c.At); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
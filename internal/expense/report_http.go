@@ -5,6 +5,8 @@ import (
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/pdfkit"
 )
 
 type subscriptionChecker interface {
@@ -40,7 +42,7 @@ func MonthlyPDFHandler(expStore *Store, checker subscriptionChecker) fiber.Handl
 			return c.Status(fiber.StatusInternalServerError).SendString("server error")
 		}
 
-		pdfBytes, err := BuildMonthlyPDF(sum)
+		pdfBytes, err := BuildMonthlyPDF(sum, pdfkit.ParseLang(c.Query("lang", c.Get(fiber.HeaderAcceptLanguage))))
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).SendString("pdf error")
 		}
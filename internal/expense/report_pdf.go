@@ -2,52 +2,66 @@ package expense
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"time"
 
-	"github.com/phpdave11/gofpdf"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/pdfkit"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/reports"
 )
 
-func BuildMonthlyPDF(sum *MonthlySummary) ([]byte, error) {
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	pdf.SetTitle("Vantro Expense Memory Report", false)
-	pdf.AddPage()
-
-	pdf.SetFont("Helvetica", "B", 18)
-	pdf.Cell(0, 10, "Vantro Expense Memory")
-	pdf.Ln(10)
+// BuildMonthlyPDF renders the month's Expense Memory report with labels in lang ("en" or "hi").
+func BuildMonthlyPDF(sum *MonthlySummary, lang string) ([]byte, error) {
+	pdf := pdfkit.New(pdfkit.Brand{Title: pdfkit.T(lang, "expense_memory"), Subtitle: sum.Month + "  |  " + sum.UserPhone, Lang: lang})
 
-	pdf.SetFont("Helvetica", "", 12)
-	pdf.Cell(0, 8, fmt.Sprintf("Report Month: %s", sum.Month))
-	pdf.Ln(6)
-	pdf.Cell(0, 8, fmt.Sprintf("User: %s", sum.UserPhone))
-	pdf.Ln(10)
+	pdf.SetFont("B", 14)
+	pdf.Cell(0, 8, pdf.T("total_spend")+": ₹"+money.FormatINR(sum.TotalPaise))
+	pdf.Ln(8)
 
-	pdf.SetFont("Helvetica", "B", 14)
-	pdf.Cell(0, 8, fmt.Sprintf("Total Spend: ₹%.2f", sum.TotalRupees))
+	pdf.SetFont("B", 13)
+	pdf.Cell(0, 8, pdf.T("insights"))
 	pdf.Ln(8)
 
-	pdf.SetFont("Helvetica", "", 12)
-	pdf.MultiCell(0, 7, "Insight: "+sum.Insight, "", "L", false)
+	for i, in := range sum.Insights {
+		if in.Severity == "warning" {
+			pdf.SetTextColor(180, 60, 20)
+		}
+		pdf.SetFont("B", 11)
+		pdf.MultiCell(0, 6, fmt.Sprintf("%d. %s", i+1, in.Title), "", "L", false)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetFont("", 11)
+		pdf.MultiCell(0, 6, in.Message, "", "L", false)
+		pdf.Ln(2)
+	}
+	if len(sum.Insights) == 0 {
+		pdf.SetFont("", 12)
+		pdf.MultiCell(0, 7, pdf.T("insight")+": "+sum.Insight, "", "L", false)
+	}
 	pdf.Ln(4)
 
-	pdf.SetFont("Helvetica", "B", 13)
-	pdf.Cell(0, 8, "Category Breakdown")
+	pdf.SetFont("B", 13)
+	pdf.Cell(0, 8, pdf.T("breakdown"))
 	pdf.Ln(8)
 
-	pdf.SetFont("Helvetica", "B", 11)
-	pdf.Cell(70, 7, "Category")
-	pdf.Cell(50, 7, "Amount")
+	pdf.SetFont("B", 11)
+	pdf.Cell(70, 7, pdf.T("category"))
+	pdf.Cell(50, 7, pdf.T("amount"))
 	pdf.Cell(30, 7, "%")
 	pdf.Ln(7)
 
-	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetFont("", 11)
 	for _, b := range sum.CategoryBreakup {
 		pdf.Cell(70, 7, b.Category)
-		pdf.Cell(50, 7, fmt.Sprintf("₹%.2f", b.TotalRs))
+		pdf.Cell(50, 7, "₹"+money.FormatINR(b.TotalPaise))
 		pdf.Cell(30, 7, fmt.Sprintf("%.1f%%", b.Percent))
 		pdf.Ln(7)
 	}
 
+	drawMonthlyCharts(pdf, sum)
+
 	var buf bytes.Buffer
 	err := pdf.Output(&buf)
 	if err != nil {
@@ -55,3 +69,56 @@ func BuildMonthlyPDF(sum *MonthlySummary) ([]byte, error) {
 	}
 	return buf.Bytes(), nil
 }
+
+// drawMonthlyCharts adds a page with the category split, spend per day and the month's
+// cumulative spend.
+func drawMonthlyCharts(pdf *pdfkit.Doc, sum *MonthlySummary) {
+	pdf.AddPage()
+
+	slices := make([]pdfkit.Slice, 0, len(sum.CategoryBreakup))
+	for _, b := range sum.CategoryBreakup {
+		slices = append(slices, pdfkit.Slice{Label: b.Category, Value: b.TotalPaise})
+	}
+	pdfkit.Heading(pdf, pdf.T("money_went"))
+	pdfkit.Donut(pdf, 14, pdf.GetY(), 56, slices, pdfkit.Compact(sum.TotalPaise))
+	pdf.Ln(8)
+
+	labels := make([]string, len(sum.Daily))
+	spend := make([]int64, len(sum.Daily))
+	cumulative := make([]int64, len(sum.Daily))
+	var running int64
+	for i, d := range sum.Daily {
+		labels[i] = strconv.Itoa(i + 1)
+		spend[i] = d.TotalPaise
+		running += d.TotalPaise
+		cumulative[i] = running
+	}
+	pdfkit.Heading(pdf, pdf.T("spend_per_day"))
+	pdfkit.BarChart(pdf, 14, pdf.GetY(), 182, 58, labels, pdfkit.Series{Name: pdf.T("spend"), Values: spend})
+	pdf.Ln(8)
+
+	pdfkit.Heading(pdf, pdf.T("spend_so_far"))
+	pdfkit.LineChart(pdf, 14, pdf.GetY(), 182, 50, labels, pdfkit.Series{Name: pdf.T("cumulative"), Values: cumulative})
+}
+
+// MonthlyRegenerator rebuilds shared Expense Memory reports (reports.KindExpenseMemory) from the
+// expenses, for links whose stored copy is gone.
+type MonthlyRegenerator struct {
+	Store *Store
+}
+
+func (g MonthlyRegenerator) Regenerate(ctx context.Context, rep reports.Report) ([]byte, error) {
+	var p struct {
+		Lang string `json:"lang"`
+	}
+	_ = json.Unmarshal(rep.Params, &p)
+	month, err := time.Parse("2006-01", rep.Month)
+	if err != nil {
+		return nil, err
+	}
+	sum, err := g.Store.MonthlySummary(ctx, rep.Phone, month.Year(), int(month.Month()))
+	if err != nil {
+		return nil, err
+	}
+	return BuildMonthlyPDF(sum, pdfkit.ParseLang(p.Lang))
+}
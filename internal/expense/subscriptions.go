@@ -0,0 +1,264 @@
+package expense
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Charge is the minimal view of a spend the subscription detector needs. Both the phone-based
+// Expense Memory rows and the JWT user's legacy expenses are mapped onto it.
+type Charge struct {
+	ID          string
+	Label       string // vendor name or note, shown to the user
+	AmountPaise int64
+	At          time.Time
+}
+
+// RecurringCharge is a subscription or other periodic payment detected from charge history.
+type RecurringCharge struct {
+	Merchant           string       `json:"merchant"`
+	Label              string       `json:"label"`
+	Cadence            string       `json:"cadence"` // weekly | monthly | quarterly | annual
+	KnownService       bool         `json:"known_service"`
+	Occurrences        int          `json:"occurrences"`
+	TypicalAmountPaise int64        `json:"typical_amount_paise"`
+	LastAmountPaise    int64        `json:"last_amount_paise"`
+	LastChargedOn      time.Time    `json:"last_charged_on"`
+	NextExpectedOn     time.Time    `json:"next_expected_on"`
+	AnnualCostPaise    int64        `json:"annual_cost_paise"`
+	Status             string       `json:"status"` // active | late | missed
+	DaysOverdue        int          `json:"days_overdue,omitempty"`
+	PriceIncrease      *PriceChange `json:"price_increase,omitempty"`
+	ChargeIDs          []string     `json:"charge_ids"`
+}
+
+type PriceChange struct {
+	FromPaise int64     `json:"from_paise"`
+	ToPaise   int64     `json:"to_paise"`
+	Percent   float64   `json:"percent"`
+	On        time.Time `json:"on"`
+}
+
+type SubscriptionReport struct {
+	Subscriptions    []RecurringCharge `json:"subscriptions"`
+	MonthlyCostPaise int64             `json:"monthly_cost_paise"`
+	AnnualCostPaise  int64             `json:"annual_cost_paise"`
+	PriceIncreases   int               `json:"price_increases"`
+	LateOrMissed     int               `json:"late_or_missed"`
+}
+
+const (
+	SubscriptionActive = "active"
+	SubscriptionLate   = "late"
+	SubscriptionMissed = "missed"
+)
+
+// how much history is scanned; long enough to see two annual renewals
+const subscriptionLookbackDays = 400
+
+// knownSubscriptionKeywords mirrors the ENTERTAINMENT keywords in categorizeFromText plus the
+// other services people commonly pay for monthly. A match lowers the evidence needed to two charges.
+var knownSubscriptionKeywords = []string{
+	"netflix", "prime", "hotstar", "spotify", "youtube", "apple", "icloud", "google one",
+	"zee", "sonyliv", "jiocinema", "gaana", "audible", "linkedin", "chatgpt", "gym", "cult",
+	"wifi", "broadband", "recharge", "airtel", "jio",
+}
+
+type cadence struct {
+	name      string
+	minDays   float64
+	maxDays   float64
+	perYear   float64
+	graceDays int
+	next      func(time.Time) time.Time
+}
+
+var cadences = []cadence{
+	{"weekly", 6, 8, 52, 2, func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }},
+	{"monthly", 26, 35, 12, 5, func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }},
+	{"quarterly", 84, 98, 4, 10, func(t time.Time) time.Time { return t.AddDate(0, 3, 0) }},
+	{"annual", 350, 380, 1, 20, func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }},
+}
+
+// DetectRecurringCharges groups charges by merchant and keeps the groups that repeat on a
+// steady cadence with a similar amount. now decides whether the next charge is late or missed.
+func DetectRecurringCharges(charges []Charge, now time.Time) SubscriptionReport {
+	groups := map[string][]Charge{}
+	for _, c := range charges {
+		k := merchantKey(c.Label)
+		if k == "" || c.AmountPaise <= 0 {
+			continue
+		}
+		groups[k] = append(groups[k], c)
+	}
+
+	rep := SubscriptionReport{Subscriptions: []RecurringCharge{}}
+	for key, list := range groups {
+		rc, ok := detectGroup(key, list, now)
+		if !ok {
+			continue
+		}
+		rep.Subscriptions = append(rep.Subscriptions, rc)
+		if rc.Status == SubscriptionMissed {
+			// probably cancelled; keep it visible but out of the running cost
+			rep.LateOrMissed++
+			continue
+		}
+		if rc.Status == SubscriptionLate {
+			rep.LateOrMissed++
+		}
+		if rc.PriceIncrease != nil {
+			rep.PriceIncreases++
+		}
+		rep.AnnualCostPaise += rc.AnnualCostPaise
+	}
+	rep.MonthlyCostPaise = rep.AnnualCostPaise / 12
+
+	sort.Slice(rep.Subscriptions, func(i, j int) bool {
+		return rep.Subscriptions[i].AnnualCostPaise > rep.Subscriptions[j].AnnualCostPaise
+	})
+	return rep
+}
+
+func detectGroup(key string, list []Charge, now time.Time) (RecurringCharge, bool) {
+	known := isKnownService(key)
+	minCharges := 3
+	if known {
+		minCharges = 2
+	}
+	if len(list) < minCharges {
+		return RecurringCharge{}, false
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].At.Before(list[j].At) })
+
+	intervals := make([]float64, 0, len(list)-1)
+	for i := 1; i < len(list); i++ {
+		intervals = append(intervals, list[i].At.Sub(list[i-1].At).Hours()/24)
+	}
+	cad, ok := matchCadence(intervals)
+	if !ok {
+		return RecurringCharge{}, false
+	}
+
+	amounts := make([]float64, len(list))
+	for i, c := range list {
+		amounts[i] = float64(c.AmountPaise)
+	}
+	typical := median(amounts)
+	similar := 0
+	for _, a := range amounts {
+		if math.Abs(a-typical) <= typical*0.15 {
+			similar++
+		}
+	}
+	if float64(similar)/float64(len(amounts)) < 0.6 {
+		return RecurringCharge{}, false
+	}
+
+	last := list[len(list)-1]
+	ids := make([]string, len(list))
+	for i, c := range list {
+		ids[i] = c.ID
+	}
+
+	rc := RecurringCharge{
+		Merchant:           key,
+		Label:              strings.TrimSpace(last.Label),
+		Cadence:            cad.name,
+		KnownService:       known,
+		Occurrences:        len(list),
+		TypicalAmountPaise: int64(math.Round(typical)),
+		LastAmountPaise:    last.AmountPaise,
+		LastChargedOn:      last.At,
+		NextExpectedOn:     cad.next(last.At),
+		AnnualCostPaise:    int64(math.Round(float64(last.AmountPaise) * cad.perYear)),
+		Status:             SubscriptionActive,
+		ChargeIDs:          ids,
+	}
+
+	// price increase: latest charge is noticeably above the one before it
+	prev := list[len(list)-2]
+	if prev.AmountPaise > 0 && float64(last.AmountPaise) >= float64(prev.AmountPaise)*1.03 {
+		rc.PriceIncrease = &PriceChange{
+			FromPaise: prev.AmountPaise,
+			ToPaise:   last.AmountPaise,
+			Percent:   round1(float64(last.AmountPaise-prev.AmountPaise) / float64(prev.AmountPaise) * 100),
+			On:        last.At,
+		}
+	}
+
+	overdue := int(now.Sub(rc.NextExpectedOn).Hours() / 24)
+	switch {
+	case now.After(cad.next(rc.NextExpectedOn).AddDate(0, 0, cad.graceDays)):
+		rc.Status = SubscriptionMissed
+		rc.DaysOverdue = overdue
+	case overdue > cad.graceDays:
+		rc.Status = SubscriptionLate
+		rc.DaysOverdue = overdue
+	}
+	return rc, true
+}
+
+// matchCadence picks the cadence most intervals fall into. An interval of about two periods
+// counts too, so one forgotten log entry doesn't hide a subscription.
+func matchCadence(intervals []float64) (cadence, bool) {
+	if len(intervals) == 0 {
+		return cadence{}, false
+	}
+	best, bestHits := cadence{}, 0
+	for _, cad := range cadences {
+		hits := 0
+		for _, d := range intervals {
+			if (d >= cad.minDays && d <= cad.maxDays) || (d >= 2*cad.minDays && d <= 2*cad.maxDays) {
+				hits++
+			}
+		}
+		if hits > bestHits {
+			best, bestHits = cad, hits
+		}
+	}
+	if bestHits == 0 || float64(bestHits)/float64(len(intervals)) < 0.7 {
+		return cadence{}, false
+	}
+	return best, true
+}
+
+func isKnownService(key string) bool {
+	for _, w := range knownSubscriptionKeywords {
+		if strings.Contains(key, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectSubscriptions runs the detector over a phone user's Expense Memory history.
+func (s *Store) DetectSubscriptions(ctx context.Context, userPhone string) (*SubscriptionReport, error) {
+	userPhone = strings.TrimSpace(userPhone)
+	if userPhone == "" {
+		return nil, ErrBadRequest
+	}
+
+	now := time.Now().UTC()
+	rows, err := s.ListBetween(ctx, userPhone, now.AddDate(0, 0, -subscriptionLookbackDays), now.Add(time.Minute))
+	if err != nil {
+		return nil, err
+	}
+
+	charges := make([]Charge, 0, len(rows))
+	for _, e := range rows {
+		charges = append(charges, Charge{
+			ID:          strconv.FormatInt(e.ID, 10),
+			Label:       e.Note,
+			AmountPaise: e.AmountPaise,
+			At:          e.CreatedAt,
+		})
+	}
+	rep := DetectRecurringCharges(charges, now)
+	return &rep, nil
+}
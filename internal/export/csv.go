@@ -0,0 +1,42 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// writeCSV streams rows as they are scanned; memory stays flat however long the ledger is.
+func writeCSV(w io.Writer, src rowSource, d *Dataset, cols []Column) error {
+	cw := csv.NewWriter(w)
+
+	header := make([]string, len(cols))
+	for i, col := range cols {
+		header[i] = col.Header
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	rec := newRecord(d)
+	line := make([]string, len(cols))
+	for src.Next() {
+		if err := rec.scan(src); err != nil {
+			return err
+		}
+		for i, col := range cols {
+			s := text(col, rec.value(col.Field))
+			if col.Kind == KindText {
+				s = csvSafe(s)
+			}
+			line[i] = s
+		}
+		if err := cw.Write(line); err != nil {
+			return err
+		}
+	}
+	if err := src.Err(); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,187 @@
+package export
+
+// Datasets available to signed-in users, by the name used in the URL.
+var Datasets = map[string]*Dataset{
+	"incomes":      incomesDataset,
+	"expenses":     expensesDataset,
+	"transactions": transactionsDataset,
+	"points":       pointsDataset,
+	"audit":        auditDataset,
+	"memory":       linkedMemoryDataset,
+}
+
+var incomesDataset = &Dataset{
+	Name:  "incomes",
+	Title: "Incomes",
+	from: `SELECT id::text AS id, received_on AS date, client_name AS client, amount,
+  currency, COALESCE(note,'') AS note, created_at
+FROM incomes
+WHERE user_id = $1 AND deleted_at IS NULL`,
+	fields: []field{
+		{"id", KindText}, {"date", KindDate}, {"client", KindText}, {"amount", KindInt},
+		{"currency", KindText}, {"note", KindText}, {"created_at", KindTime},
+	},
+	Columns: []Column{
+		{Key: "date", Header: "Date", Field: "date", Kind: KindDate},
+		{Key: "client", Header: "Client", Field: "client", Kind: KindText},
+		{Key: "amount", Header: "Amount (INR)", Field: "amount", Kind: KindMoney},
+		{Key: "amount_paise", Header: "Amount (paise)", Field: "amount", Kind: KindInt},
+		{Key: "currency", Header: "Currency", Field: "currency", Kind: KindText},
+		{Key: "note", Header: "Note", Field: "note", Kind: KindText},
+		{Key: "id", Header: "ID", Field: "id", Kind: KindText},
+		{Key: "created_at", Header: "Created at", Field: "created_at", Kind: KindTime},
+	},
+	dateField:   "date",
+	amountField: "amount",
+}
+
+var expensesDataset = &Dataset{
+	Name:  "expenses",
+	Title: "Expenses",
+	from: `SELECT id::text AS id, spent_on AS date, vendor_name AS vendor, category, amount,
+  currency, COALESCE(note,'') AS note, created_at
+FROM expenses
+WHERE user_id = $1 AND deleted_at IS NULL`,
+	fields: []field{
+		{"id", KindText}, {"date", KindDate}, {"vendor", KindText}, {"category", KindText},
+		{"amount", KindInt}, {"currency", KindText}, {"note", KindText}, {"created_at", KindTime},
+	},
+	Columns: []Column{
+		{Key: "date", Header: "Date", Field: "date", Kind: KindDate},
+		{Key: "vendor", Header: "Vendor", Field: "vendor", Kind: KindText},
+		{Key: "category", Header: "Category", Field: "category", Kind: KindText},
+		{Key: "amount", Header: "Amount (INR)", Field: "amount", Kind: KindMoney},
+		{Key: "amount_paise", Header: "Amount (paise)", Field: "amount", Kind: KindInt},
+		{Key: "currency", Header: "Currency", Field: "currency", Kind: KindText},
+		{Key: "note", Header: "Note", Field: "note", Kind: KindText},
+		{Key: "id", Header: "ID", Field: "id", Kind: KindText},
+		{Key: "created_at", Header: "Created at", Field: "created_at", Kind: KindTime},
+	},
+	dateField:     "date",
+	categoryField: "category",
+	amountField:   "amount",
+}
+
+// transactions is incomes and expenses together, the same rows /api/transactions lists.
+var transactionsDataset = &Dataset{
+	Name:  "transactions",
+	Title: "Transactions",
+	Types: []string{"income", "expense"},
+	from: `SELECT 'income' AS type, id::text AS id, received_on AS date, client_name AS title,
+  '' AS category, amount, currency, COALESCE(note,'') AS note, created_at
+FROM incomes
+WHERE user_id = $1 AND deleted_at IS NULL
+UNION ALL
+SELECT 'expense', id::text, spent_on, vendor_name, category, amount, currency, COALESCE(note,''), created_at
+FROM expenses
+WHERE user_id = $1 AND deleted_at IS NULL`,
+	fields: []field{
+		{"type", KindText}, {"id", KindText}, {"date", KindDate}, {"title", KindText}, {"category", KindText},
+		{"amount", KindInt}, {"currency", KindText}, {"note", KindText}, {"created_at", KindTime},
+	},
+	Columns: []Column{
+		{Key: "type", Header: "Type", Field: "type", Kind: KindText},
+		{Key: "date", Header: "Date", Field: "date", Kind: KindDate},
+		{Key: "title", Header: "Title", Field: "title", Kind: KindText},
+		{Key: "category", Header: "Category", Field: "category", Kind: KindText},
+		{Key: "amount", Header: "Amount (INR)", Field: "amount", Kind: KindMoney},
+		{Key: "amount_paise", Header: "Amount (paise)", Field: "amount", Kind: KindInt},
+		{Key: "currency", Header: "Currency", Field: "currency", Kind: KindText},
+		{Key: "note", Header: "Note", Field: "note", Kind: KindText},
+		{Key: "id", Header: "ID", Field: "id", Kind: KindText},
+		{Key: "created_at", Header: "Created at", Field: "created_at", Kind: KindTime},
+	},
+	dateField:     "date",
+	typeField:     "type",
+	categoryField: "category",
+	amountField:   "amount",
+	outflowType:   "expense",
+}
+
+var pointsDataset = &Dataset{
+	Name:  "points",
+	Title: "Points",
+	from: `SELECT id::text AS id, created_at, reason, points_delta::bigint AS points,
+  COALESCE(source_txn_id,'') AS source
+FROM points_ledger
+WHERE user_id = $1`,
+	fields: []field{
+		{"id", KindText}, {"created_at", KindTime}, {"reason", KindText}, {"points", KindInt}, {"source", KindText},
+	},
+	Columns: []Column{
+		{Key: "created_at", Header: "Date", Field: "created_at", Kind: KindTime},
+		{Key: "reason", Header: "Reason", Field: "reason", Kind: KindText},
+		{Key: "points", Header: "Points", Field: "points", Kind: KindInt},
+		{Key: "source", Header: "Source transaction", Field: "source", Kind: KindText},
+		{Key: "id", Header: "ID", Field: "id", Kind: KindText},
+	},
+	dateField:   "created_at",
+	typeField:   "reason",
+	amountField: "points",
+}
+
+var auditDataset = &Dataset{
+	Name:  "audit",
+	Title: "Audit log",
+	from: `SELECT id::text AS id, created_at, action, entity_type, COALESCE(entity_id,'') AS entity_id,
+  COALESCE(ip,'') AS ip, COALESCE(user_agent,'') AS user_agent, COALESCE(metadata::text,'') AS metadata
+FROM audit_logs
+WHERE user_id = $1`,
+	fields: []field{
+		{"id", KindText}, {"created_at", KindTime}, {"action", KindText}, {"entity_type", KindText},
+		{"entity_id", KindText}, {"ip", KindText}, {"user_agent", KindText}, {"metadata", KindText},
+	},
+	Columns: []Column{
+		{Key: "created_at", Header: "Time", Field: "created_at", Kind: KindTime},
+		{Key: "action", Header: "Action", Field: "action", Kind: KindText},
+		{Key: "entity_type", Header: "Entity", Field: "entity_type", Kind: KindText},
+		{Key: "entity_id", Header: "Entity ID", Field: "entity_id", Kind: KindText},
+		{Key: "ip", Header: "IP", Field: "ip", Kind: KindText},
+		{Key: "user_agent", Header: "User agent", Field: "user_agent", Kind: KindText},
+		{Key: "metadata", Header: "Details", Field: "metadata", Kind: KindText},
+		{Key: "id", Header: "ID", Field: "id", Kind: KindText},
+	},
+	dateField:     "created_at",
+	typeField:     "action",
+	categoryField: "entity_type",
+}
+
+// MemoryDataset is the phone-keyed Expense Memory ledger; its owner is the phone number.
+var MemoryDataset = &Dataset{
+	Name:  "memory",
+	Title: "Expense Memory",
+	Types: []string{"manual", "whatsapp", "app", "upi"},
+	from: `SELECT id::text AS id, created_at, category, amount_paise AS amount, currency,
+  COALESCE(note,'') AS note, source
+FROM expenses
+WHERE user_phone = $1`,
+	fields: []field{
+		{"id", KindText}, {"created_at", KindTime}, {"category", KindText}, {"amount", KindInt},
+		{"currency", KindText}, {"note", KindText}, {"source", KindText},
+	},
+	Columns: []Column{
+		{Key: "created_at", Header: "Date", Field: "created_at", Kind: KindTime},
+		{Key: "category", Header: "Category", Field: "category", Kind: KindText},
+		{Key: "amount", Header: "Amount (INR)", Field: "amount", Kind: KindMoney},
+		{Key: "amount_paise", Header: "Amount (paise)", Field: "amount", Kind: KindInt},
+		{Key: "currency", Header: "Currency", Field: "currency", Kind: KindText},
+		{Key: "note", Header: "Note", Field: "note", Kind: KindText},
+		{Key: "source", Header: "Source", Field: "source", Kind: KindText},
+		{Key: "id", Header: "ID", Field: "id", Kind: KindText},
+	},
+	dateField:     "created_at",
+	typeField:     "source",
+	categoryField: "category",
+	amountField:   "amount",
+}
+
+// linkedMemoryDataset is MemoryDataset for a signed-in user: the entries of the phone verified
+// for the account.
+var linkedMemoryDataset = func() *Dataset {
+	d := *MemoryDataset
+	d.from = `SELECT id::text AS id, created_at, category, amount_paise AS amount, currency,
+  COALESCE(note,'') AS note, source
+FROM expenses
+WHERE user_phone = (SELECT phone FROM users WHERE id = $1::uuid AND phone_verified_at IS NOT NULL)`
+	return &d
+}()
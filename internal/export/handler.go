@@ -0,0 +1,216 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// a full ledger export should finish well inside this even on a slow client
+const exportTimeout = 10 * time.Minute
+
+type Handler struct {
+	Pool *pgxpool.Pool
+}
+
+func NewHandler(pool *pgxpool.Pool) *Handler {
+	return &Handler{Pool: pool}
+}
+
+// List describes the datasets and their columns so clients can build an export form.
+func (h *Handler) List(c *fiber.Ctx) error {
+	names := make([]string, 0, len(Datasets))
+	for name := range Datasets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make([]*Dataset, 0, len(names))
+	for _, name := range names {
+		out = append(out, Datasets[name])
+	}
+	return c.JSON(out)
+}
+
+// Export streams one of the signed-in user's ledgers: GET /api/export/:dataset where dataset
+// may carry the format as an extension (expenses.csv, transactions.xlsx). Query parameters:
+// format, from, to (YYYY-MM-DD), type, category, columns (comma-separated keys).
+// /api/export/transactions.csv keeps its legacy columns (transactions.Handler.ExportCSV).
+func (h *Handler) Export(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	name := strings.ToLower(strings.TrimSpace(c.Params("dataset")))
+	format := strings.TrimPrefix(path.Ext(name), ".")
+	name = strings.TrimSuffix(name, path.Ext(name))
+	d, ok := Datasets[name]
+	if !ok {
+		return fiber.NewError(fiber.StatusNotFound, "unknown export "+name)
+	}
+	return h.stream(c, d, userID, format)
+}
+
+// Memory streams phone's Expense Memory entries with the same query parameters as Export. It
+// does no authentication: the caller resolves phone from the signed-in account.
+func (h *Handler) Memory(c *fiber.Ctx, phone string) error {
+	return h.stream(c, MemoryDataset, phone, "")
+}
+
+func (h *Handler) stream(c *fiber.Ctx, d *Dataset, owner, format string) error {
+	if q := strings.ToLower(strings.TrimSpace(c.Query("format"))); q != "" {
+		format = q
+	}
+	if format == "" {
+		format = FormatCSV
+	}
+	if format != FormatCSV && format != FormatXLSX {
+		return fiber.NewError(fiber.StatusBadRequest, "format must be csv or xlsx")
+	}
+
+	f, err := parseFilter(c, d)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	cols, err := d.SelectColumns(c.Query("columns"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// the query outlives this handler: rows are read inside the body stream writer
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	sql, args := d.query(owner, f)
+	rows, err := h.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		cancel()
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to export: "+err.Error())
+	}
+	// read the first row now so query errors still get a proper status code
+	src := &peekedRows{Rows: rows, peeked: rows.Next()}
+	if !src.peeked && rows.Err() != nil {
+		err := rows.Err()
+		rows.Close()
+		cancel()
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to export: "+err.Error())
+	}
+
+	if format == FormatXLSX {
+		c.Set(fiber.HeaderContentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	} else {
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	}
+	c.Attachment(fileName(d, f, format))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		defer rows.Close()
+
+		var err error
+		if format == FormatXLSX {
+			err = writeXLSX(w, src, d, cols, f)
+		} else {
+			err = writeCSV(w, src, d, cols)
+		}
+		if err != nil {
+			// headers are gone; a truncated file is the only signal the client gets
+			log.Printf("[export] %s for %s failed: %v", d.Name, owner, err)
+		}
+		_ = w.Flush()
+	})
+	return nil
+}
+
+// peekedRows replays the row read before streaming started.
+type peekedRows struct {
+	pgx.Rows
+	peeked bool
+}
+
+func (p *peekedRows) Next() bool {
+	if p.peeked {
+		p.peeked = false
+		return true
+	}
+	return p.Rows.Next()
+}
+
+func parseFilter(c *fiber.Ctx, d *Dataset) (Filter, error) {
+	var f Filter
+	for _, p := range []struct {
+		key string
+		dst **time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		raw := strings.TrimSpace(c.Query(p.key))
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return f, errors.New(p.key + " must be YYYY-MM-DD")
+		}
+		*p.dst = &t
+	}
+	if f.From != nil && f.To != nil && f.To.Before(*f.From) {
+		return f, errors.New("to must not be before from")
+	}
+
+	if f.Type = strings.TrimSpace(c.Query("type")); f.Type != "" {
+		if d.typeField == "" {
+			return f, errors.New(d.Name + " export has no type filter")
+		}
+		if len(d.Types) > 0 && !containsFold(d.Types, f.Type) {
+			return f, errors.New("type must be one of " + strings.Join(d.Types, ", "))
+		}
+	}
+	if f.Category = strings.TrimSpace(c.Query("category")); f.Category != "" && d.categoryField == "" {
+		return f, errors.New(d.Name + " export has no category filter")
+	}
+	return f, nil
+}
+
+func fileName(d *Dataset, f Filter, format string) string {
+	name := "vantro-" + d.Name
+	if f.From != nil {
+		name += "-from-" + f.From.Format("2006-01-02")
+	}
+	if f.To != nil {
+		name += "-to-" + f.To.Format("2006-01-02")
+	}
+	if f.From == nil && f.To == nil {
+		name += "-" + time.Now().In(ist).Format("2006-01-02")
+	}
+	return name + "." + format
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func extractUserID(c *fiber.Ctx) (string, error) {
+	val := c.Locals("user_id")
+	if val == nil {
+		val = c.Locals("userID")
+	}
+	if val == nil {
+		return "", errors.New("user id missing")
+	}
+	if uid, ok := val.(string); ok && strings.TrimSpace(uid) != "" {
+		return uid, nil
+	}
+	return "", errors.New("user id missing")
+}
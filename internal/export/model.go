@@ -0,0 +1,161 @@
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Kind says how a value is stored and rendered. Money is paise in the database and rupees in
+// the file; every money field can be exported formatted, raw, or both.
+type Kind int
+
+const (
+	KindText Kind = iota
+	KindDate
+	KindTime
+	KindMoney
+	KindInt
+)
+
+// Column is one exportable column. Several columns may read the same field (amount and
+// amount_paise both read the amount field).
+type Column struct {
+	Key    string `json:"key"`
+	Header string `json:"header"`
+	Field  string `json:"-"`
+	Kind   Kind   `json:"-"`
+}
+
+type field struct {
+	name string
+	kind Kind // KindText, KindDate, KindTime or KindInt
+}
+
+// Filter narrows an export. Zero values mean no filter; dates are inclusive.
+type Filter struct {
+	From     *time.Time
+	To       *time.Time
+	Type     string
+	Category string
+}
+
+// Dataset describes one exportable ledger. from is a subquery selecting fields by name with $1
+// bound to the owner (user id or phone); filters and ordering are applied around it.
+type Dataset struct {
+	Name    string   `json:"name"`
+	Title   string   `json:"title"`
+	Columns []Column `json:"columns"`
+	Types   []string `json:"types,omitempty"` // allowed type filter values; empty means free-form
+
+	from          string
+	fields        []field
+	dateField     string
+	typeField     string // empty: type filter not supported
+	categoryField string // empty: category filter not supported
+	amountField   string // summed on the summary sheet; empty: counts only
+	outflowType   string // type whose amounts count negative in the summary total
+}
+
+func (d *Dataset) fieldIndex(name string) int {
+	for i, f := range d.fields {
+		if f.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (d *Dataset) fieldKind(name string) Kind {
+	if i := d.fieldIndex(name); i >= 0 {
+		return d.fields[i].kind
+	}
+	return KindText
+}
+
+// SelectColumns resolves a comma-separated list of column keys. Empty selects every column.
+func (d *Dataset) SelectColumns(keys string) ([]Column, error) {
+	keys = strings.TrimSpace(keys)
+	if keys == "" {
+		return d.Columns, nil
+	}
+	var out []Column
+	for _, k := range strings.Split(keys, ",") {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k == "" {
+			continue
+		}
+		found := false
+		for _, col := range d.Columns {
+			if col.Key == k {
+				out = append(out, col)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q", k)
+		}
+	}
+	if len(out) == 0 {
+		return d.Columns, nil
+	}
+	return out, nil
+}
+
+// query builds the SELECT for one export, ordered oldest first.
+func (d *Dataset) query(owner string, f Filter) (string, []any) {
+	names := make([]string, len(d.fields))
+	for i, fl := range d.fields {
+		names[i] = "x." + fl.name
+	}
+
+	args := []any{owner}
+	var where []string
+	add := func(expr string, v any) {
+		args = append(args, v)
+		where = append(where, strings.ReplaceAll(expr, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	dateCol := "x." + d.dateField
+	if d.fieldKind(d.dateField) == KindDate {
+		if f.From != nil {
+			add(dateCol+" >= ?::date", f.From.Format("2006-01-02"))
+		}
+		if f.To != nil {
+			add(dateCol+" <= ?::date", f.To.Format("2006-01-02"))
+		}
+	} else {
+		// timestamps: the filter dates are IST calendar days
+		if f.From != nil {
+			add(dateCol+" >= ?", istDay(*f.From))
+		}
+		if f.To != nil {
+			add(dateCol+" < ?", istDay(*f.To).AddDate(0, 0, 1))
+		}
+	}
+	if f.Type != "" && d.typeField != "" {
+		add("lower(x."+d.typeField+") = lower(?)", f.Type)
+	}
+	if f.Category != "" && d.categoryField != "" {
+		add("lower(x."+d.categoryField+") = lower(?)", f.Category)
+	}
+
+	q := "SELECT " + strings.Join(names, ", ") + "\nFROM (" + d.from + ") x"
+	if len(where) > 0 {
+		q += "\nWHERE " + strings.Join(where, " AND ")
+	}
+	return q + "\nORDER BY " + dateCol, args
+}
+
+var ist = time.FixedZone("IST", 5*3600+1800)
+
+func istDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, ist)
+}
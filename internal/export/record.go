@@ -0,0 +1,92 @@
+package export
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
+)
+
+// rowSource is the part of pgx.Rows the writers need.
+type rowSource interface {
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+}
+
+// record is reused for every row so streaming allocates per field, not per row.
+type record struct {
+	d    *Dataset
+	ptrs []any
+}
+
+func newRecord(d *Dataset) *record {
+	r := &record{d: d, ptrs: make([]any, len(d.fields))}
+	for i, f := range d.fields {
+		switch f.kind {
+		case KindDate, KindTime:
+			r.ptrs[i] = new(time.Time)
+		case KindInt:
+			r.ptrs[i] = new(int64)
+		default:
+			r.ptrs[i] = new(string)
+		}
+	}
+	return r
+}
+
+func (r *record) scan(src rowSource) error {
+	return src.Scan(r.ptrs...)
+}
+
+func (r *record) value(name string) any {
+	i := r.d.fieldIndex(name)
+	if i < 0 {
+		return nil
+	}
+	switch p := r.ptrs[i].(type) {
+	case *time.Time:
+		return *p
+	case *int64:
+		return *p
+	case *string:
+		return *p
+	}
+	return nil
+}
+
+// text renders a column value for CSV and for the XLSX inline strings.
+func text(col Column, v any) string {
+	switch col.Kind {
+	case KindDate:
+		if t, ok := v.(time.Time); ok {
+			return t.Format("2006-01-02")
+		}
+	case KindTime:
+		if t, ok := v.(time.Time); ok {
+			return t.In(ist).Format("2006-01-02 15:04:05")
+		}
+	case KindMoney:
+		if n, ok := v.(int64); ok {
+			return money.FormatINR(n)
+		}
+	case KindInt:
+		if n, ok := v.(int64); ok {
+			return strconv.FormatInt(n, 10)
+		}
+	default:
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// csvSafe stops spreadsheet apps from evaluating free text (notes, vendor names) as formulas.
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,121 @@
+package export
+
+import (
+	"sort"
+	"time"
+)
+
+// summary is built while rows stream past, so it holds totals only, never rows.
+type summary struct {
+	Rows        int64
+	First, Last time.Time
+	Total       int64
+	ByType      *groupTotals
+	ByCategory  *groupTotals
+}
+
+// groups beyond this are folded into "Other" so a free-form column cannot grow memory
+const maxSummaryGroups = 100
+
+type groupTotal struct {
+	Name   string
+	Count  int64
+	Amount int64
+}
+
+type groupTotals struct {
+	m map[string]*groupTotal
+}
+
+func newGroupTotals() *groupTotals {
+	return &groupTotals{m: map[string]*groupTotal{}}
+}
+
+func (g *groupTotals) add(name string, amount int64) {
+	if name == "" {
+		name = "(none)"
+	}
+	t, ok := g.m[name]
+	if !ok {
+		if len(g.m) >= maxSummaryGroups {
+			name = "Other"
+			if t, ok = g.m[name]; !ok {
+				t = &groupTotal{Name: name}
+				g.m[name] = t
+			}
+		} else {
+			t = &groupTotal{Name: name}
+			g.m[name] = t
+		}
+	}
+	t.Count++
+	t.Amount += amount
+}
+
+// sorted returns groups by amount, then count, largest first.
+func (g *groupTotals) sorted() []groupTotal {
+	out := make([]groupTotal, 0, len(g.m))
+	for _, t := range g.m {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Amount != out[j].Amount {
+			return abs64(out[i].Amount) > abs64(out[j].Amount)
+		}
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func newSummary(d *Dataset) *summary {
+	s := &summary{}
+	if d.typeField != "" {
+		s.ByType = newGroupTotals()
+	}
+	if d.categoryField != "" {
+		s.ByCategory = newGroupTotals()
+	}
+	return s
+}
+
+func (s *summary) add(d *Dataset, r *record) {
+	s.Rows++
+	if at, ok := r.value(d.dateField).(time.Time); ok {
+		if s.First.IsZero() || at.Before(s.First) {
+			s.First = at
+		}
+		if at.After(s.Last) {
+			s.Last = at
+		}
+	}
+	var amount int64
+	if d.amountField != "" {
+		amount, _ = r.value(d.amountField).(int64)
+		if t, _ := r.value(d.typeField).(string); d.outflowType != "" && t == d.outflowType {
+			s.Total -= amount
+		} else {
+			s.Total += amount
+		}
+	}
+	if s.ByType != nil {
+		name, _ := r.value(d.typeField).(string)
+		s.ByType.add(name, amount)
+	}
+	if s.ByCategory != nil {
+		name, _ := r.value(d.categoryField).(string)
+		// income rows have no category in the combined ledger
+		if d.typeField == "" || name != "" {
+			s.ByCategory.add(name, amount)
+		}
+	}
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,387 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
+)
+
+// The workbook is written by hand rather than through a spreadsheet library so rows can go
+// straight from the database cursor into the zip stream. Data sheets are written first, the
+// summary once the totals are known, and the workbook index last; zip entry order does not
+// matter to spreadsheet apps.
+
+// Excel's row limit, less the header row. Longer exports continue on another sheet.
+const xlsxRowsPerSheet = 1048575
+
+// cell styles, indexes into cellXfs in xlsxStyles
+const (
+	styleDefault = iota
+	styleBold
+	styleMoney
+	styleDate
+	styleTime
+)
+
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="2"><numFmt numFmtId="164" formatCode="yyyy-mm-dd"/><numFmt numFmtId="165" formatCode="yyyy-mm-dd hh:mm"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="5">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
+
+const (
+	xmlHeader   = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+	sheetOpen   = xmlHeader + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`
+	frozenPane  = `<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`
+	sheetClose  = `</sheetData></worksheet>`
+	relsNS      = "http://schemas.openxmlformats.org/package/2006/relationships"
+	officeRelNS = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+)
+
+type xlsxWriter struct {
+	zw     *zip.Writer
+	sheets []string // sheet names in tab order; sheet i is xl/worksheets/sheet<i+1>.xml
+	files  []string // sheet file names, parallel to sheets
+}
+
+func writeXLSX(w io.Writer, src rowSource, d *Dataset, cols []Column, f Filter) error {
+	x := &xlsxWriter{zw: zip.NewWriter(w)}
+	sum := newSummary(d)
+
+	// summary is the first tab but is written last
+	x.sheets = append(x.sheets, "Summary")
+	x.files = append(x.files, "sheet1.xml")
+
+	rec := newRecord(d)
+	var (
+		sheet *bufio.Writer
+		row   int
+	)
+	startSheet := func() error {
+		n := len(x.sheets)
+		name := d.Title
+		if n > 1 {
+			name = fmt.Sprintf("%s (%d)", d.Title, n)
+		}
+		file := fmt.Sprintf("sheet%d.xml", n+1)
+		x.sheets = append(x.sheets, name)
+		x.files = append(x.files, file)
+
+		fw, err := x.zw.Create("xl/worksheets/" + file)
+		if err != nil {
+			return err
+		}
+		sheet = bufio.NewWriterSize(fw, 64<<10)
+		sheet.WriteString(sheetOpen + frozenPane + columnWidths(cols) + `<sheetData>`)
+		row = 1
+		sheet.WriteString(`<row r="1">`)
+		for i, col := range cols {
+			writeText(sheet, cellRef(i, row), col.Header, styleBold)
+		}
+		sheet.WriteString(`</row>`)
+		return nil
+	}
+	endSheet := func() error {
+		sheet.WriteString(sheetClose)
+		return sheet.Flush()
+	}
+
+	if err := startSheet(); err != nil {
+		return err
+	}
+	for src.Next() {
+		if err := rec.scan(src); err != nil {
+			return err
+		}
+		sum.add(d, rec)
+
+		if row > xlsxRowsPerSheet {
+			if err := endSheet(); err != nil {
+				return err
+			}
+			if err := startSheet(); err != nil {
+				return err
+			}
+		}
+		row++
+		sheet.WriteString(`<row r="` + strconv.Itoa(row) + `">`)
+		for i, col := range cols {
+			writeCell(sheet, cellRef(i, row), col, rec.value(col.Field))
+		}
+		sheet.WriteString(`</row>`)
+	}
+	if err := src.Err(); err != nil {
+		return err
+	}
+	if err := endSheet(); err != nil {
+		return err
+	}
+
+	if err := x.writeSummary(d, f, sum); err != nil {
+		return err
+	}
+	if err := x.writePackage(); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
+
+func (x *xlsxWriter) writeSummary(d *Dataset, f Filter, s *summary) error {
+	fw, err := x.zw.Create("xl/worksheets/" + x.files[0])
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(fw)
+	w.WriteString(sheetOpen + `<cols><col min="1" max="1" width="28" customWidth="1"/><col min="2" max="3" width="18" customWidth="1"/></cols><sheetData>`)
+
+	row := 0
+	line := func(cells ...func(ref int)) {
+		row++
+		w.WriteString(`<row r="` + strconv.Itoa(row) + `">`)
+		for i, c := range cells {
+			c(i)
+		}
+		w.WriteString(`</row>`)
+	}
+	label := func(s string, style int) func(int) {
+		return func(col int) { writeText(w, cellRef(col, row), s, style) }
+	}
+	number := func(n int64) func(int) {
+		return func(col int) { writeNumber(w, cellRef(col, row), strconv.FormatInt(n, 10), styleDefault) }
+	}
+	amount := func(n int64) func(int) {
+		if d.amountField == "" {
+			return func(int) {}
+		}
+		if d.fieldKind(d.amountField) == KindInt && !isMoneyField(d, d.amountField) {
+			return number(n)
+		}
+		return func(col int) { writeNumber(w, cellRef(col, row), money.PaiseToRupeesString(n), styleMoney) }
+	}
+	skip := func() { row++ }
+
+	line(label(d.Title+" export", styleBold))
+	line(label("Generated", styleDefault), label(time.Now().In(ist).Format("2006-01-02 15:04"), styleDefault))
+	if f.From != nil {
+		line(label("From", styleDefault), label(f.From.Format("2006-01-02"), styleDefault))
+	}
+	if f.To != nil {
+		line(label("To", styleDefault), label(f.To.Format("2006-01-02"), styleDefault))
+	}
+	if f.Type != "" {
+		line(label("Type", styleDefault), label(f.Type, styleDefault))
+	}
+	if f.Category != "" {
+		line(label("Category", styleDefault), label(f.Category, styleDefault))
+	}
+	skip()
+	line(label("Rows", styleDefault), number(s.Rows))
+	if !s.First.IsZero() {
+		line(label("First entry", styleDefault), label(text(Column{Kind: d.fieldKind(d.dateField)}, s.First), styleDefault))
+		line(label("Last entry", styleDefault), label(text(Column{Kind: d.fieldKind(d.dateField)}, s.Last), styleDefault))
+	}
+	if d.amountField != "" {
+		total := "Total"
+		if d.outflowType != "" {
+			total = "Net"
+		}
+		line(label(total, styleBold), amount(s.Total))
+	}
+
+	groups := func(title string, g *groupTotals) {
+		if g == nil || len(g.m) == 0 {
+			return
+		}
+		skip()
+		if d.amountField != "" {
+			line(label(title, styleBold), label("Count", styleBold), label("Total", styleBold))
+		} else {
+			line(label(title, styleBold), label("Count", styleBold))
+		}
+		for _, t := range g.sorted() {
+			line(label(t.Name, styleDefault), number(t.Count), amount(t.Amount))
+		}
+	}
+	groups("By "+fieldLabel(d, d.typeField), s.ByType)
+	groups("By "+fieldLabel(d, d.categoryField), s.ByCategory)
+
+	w.WriteString(sheetClose)
+	return w.Flush()
+}
+
+// writePackage writes the workbook index and content types once every sheet is known.
+func (x *xlsxWriter) writePackage() error {
+	var types, sheets, rels strings.Builder
+	types.WriteString(xmlHeader + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	rels.WriteString(xmlHeader + `<Relationships xmlns="` + relsNS + `">`)
+	for i, name := range x.sheets {
+		id := strconv.Itoa(i + 1)
+		types.WriteString(`<Override PartName="/xl/worksheets/` + x.files[i] + `" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`)
+		sheets.WriteString(`<sheet name="` + escape(sheetName(name)) + `" sheetId="` + id + `" r:id="rId` + id + `"/>`)
+		rels.WriteString(`<Relationship Id="rId` + id + `" Type="` + officeRelNS + `/worksheet" Target="worksheets/` + x.files[i] + `"/>`)
+	}
+	types.WriteString(`</Types>`)
+	rels.WriteString(`<Relationship Id="rId` + strconv.Itoa(len(x.sheets)+1) + `" Type="` + officeRelNS + `/styles" Target="styles.xml"/></Relationships>`)
+
+	files := []struct{ name, body string }{
+		{"[Content_Types].xml", types.String()},
+		{"_rels/.rels", xmlHeader + `<Relationships xmlns="` + relsNS + `"><Relationship Id="rId1" Type="` + officeRelNS + `/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", xmlHeader + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="` + officeRelNS + `"><sheets>` + sheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", rels.String()},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, f := range files {
+		fw, err := x.zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, f.body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCell(w *bufio.Writer, ref string, col Column, v any) {
+	switch col.Kind {
+	case KindDate, KindTime:
+		t, ok := v.(time.Time)
+		if !ok || t.IsZero() {
+			return
+		}
+		style := styleDate
+		if col.Kind == KindTime {
+			t, style = t.In(ist), styleTime
+		}
+		writeNumber(w, ref, strconv.FormatFloat(excelSerial(t), 'f', -1, 64), style)
+	case KindMoney:
+		if n, ok := v.(int64); ok {
+			writeNumber(w, ref, money.PaiseToRupeesString(n), styleMoney)
+		}
+	case KindInt:
+		if n, ok := v.(int64); ok {
+			writeNumber(w, ref, strconv.FormatInt(n, 10), styleDefault)
+		}
+	default:
+		if s, _ := v.(string); s != "" {
+			writeText(w, ref, s, styleDefault)
+		}
+	}
+}
+
+func writeNumber(w *bufio.Writer, ref, v string, style int) {
+	w.WriteString(`<c r="` + ref + `"`)
+	if style != styleDefault {
+		w.WriteString(` s="` + strconv.Itoa(style) + `"`)
+	}
+	w.WriteString(`><v>` + v + `</v></c>`)
+}
+
+func writeText(w *bufio.Writer, ref, s string, style int) {
+	w.WriteString(`<c r="` + ref + `" t="inlineStr"`)
+	if style != styleDefault {
+		w.WriteString(` s="` + strconv.Itoa(style) + `"`)
+	}
+	w.WriteString(`><is><t xml:space="preserve">`)
+	// Excel rejects cells over 32767 characters
+	if r := []rune(s); len(r) > 32767 {
+		s = string(r[:32767])
+	}
+	_ = xml.EscapeText(w, []byte(s))
+	w.WriteString(`</t></is></c>`)
+}
+
+// excelSerial converts a wall-clock time to Excel's day count (1900 date system).
+func excelSerial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	return wall.Sub(time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)).Hours() / 24
+}
+
+// cellRef returns the A1 reference for a zero-based column and one-based row.
+func cellRef(col, row int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name + strconv.Itoa(row)
+}
+
+func columnWidths(cols []Column) string {
+	var b strings.Builder
+	b.WriteString(`<cols>`)
+	for i, col := range cols {
+		width := 14
+		switch {
+		case col.Kind == KindTime:
+			width = 18
+		case col.Kind == KindText && (col.Key == "note" || col.Key == "metadata" || col.Key == "user_agent"):
+			width = 40
+		case col.Kind == KindText && col.Key != "currency":
+			width = 24
+		}
+		n := strconv.Itoa(i + 1)
+		b.WriteString(`<col min="` + n + `" max="` + n + `" width="` + strconv.Itoa(width) + `" customWidth="1"/>`)
+	}
+	b.WriteString(`</cols>`)
+	return b.String()
+}
+
+func isMoneyField(d *Dataset, name string) bool {
+	for _, col := range d.Columns {
+		if col.Field == name && col.Kind == KindMoney {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldLabel(d *Dataset, name string) string {
+	for _, col := range d.Columns {
+		if col.Field == name {
+			return strings.ToLower(col.Header)
+		}
+	}
+	return name
+}
+
+// sheetName strips the characters Excel forbids in tab names and keeps it within 31 runes.
+func sheetName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return -1
+		}
+		return r
+	}, s)
+	if r := []rune(s); len(r) > 31 {
+		s = string(r[:31])
+	}
+	return s
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,239 @@
+package goals
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	Repo *Repository
+}
+
+func NewHandler(repo *Repository) *Handler {
+	return &Handler{Repo: repo}
+}
+
+func (h *Handler) List(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	includeArchived := strings.EqualFold(c.Query("include_archived"), "true")
+	items, err := h.Repo.ListGoals(userContext(c), userID, includeArchived)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to list goals: "+err.Error())
+	}
+	return c.JSON(items)
+}
+
+func (h *Handler) Create(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	var req CreateGoalRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "name required")
+	}
+	if req.TargetAmount <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "target_amount must be greater than zero")
+	}
+
+	var targetDate *time.Time
+	if strings.TrimSpace(req.TargetDate) != "" {
+		d, err := time.Parse("2006-01-02", strings.TrimSpace(req.TargetDate))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "target_date must be YYYY-MM-DD")
+		}
+		targetDate = &d
+	}
+
+	g, err := h.Repo.CreateGoal(userContext(c), userID, req, targetDate)
+	if err != nil {
+		return goalError(err, "failed to create goal")
+	}
+	return c.Status(fiber.StatusCreated).JSON(g)
+}
+
+func (h *Handler) Get(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	id, err := goalID(c)
+	if err != nil {
+		return err
+	}
+
+	g, err := h.Repo.GetGoal(userContext(c), userID, id)
+	if err != nil {
+		return goalError(err, "failed to load goal")
+	}
+	return c.JSON(g)
+}
+
+func (h *Handler) Update(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	id, err := goalID(c)
+	if err != nil {
+		return err
+	}
+
+	var req UpdateGoalRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "name cannot be empty")
+		}
+		req.Name = &name
+	}
+	if req.TargetAmount != nil && *req.TargetAmount <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "target_amount must be greater than zero")
+	}
+
+	var targetDate *time.Time
+	clearDate := false
+	if req.TargetDate != nil {
+		raw := strings.TrimSpace(*req.TargetDate)
+		if raw == "" {
+			clearDate = true
+		} else {
+			d, err := time.Parse("2006-01-02", raw)
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "target_date must be YYYY-MM-DD")
+			}
+			targetDate = &d
+		}
+	}
+
+	g, err := h.Repo.UpdateGoal(userContext(c), userID, id, req, targetDate, clearDate)
+	if err != nil {
+		return goalError(err, "failed to update goal")
+	}
+	return c.JSON(g)
+}
+
+func (h *Handler) Archive(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	id, err := goalID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.Repo.ArchiveGoal(userContext(c), userID, id); err != nil {
+		return goalError(err, "failed to archive goal")
+	}
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+func (h *Handler) Contribute(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	id, err := goalID(c)
+	if err != nil {
+		return err
+	}
+
+	var req ContributionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+	if req.Amount <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "amount must be greater than zero")
+	}
+
+	contrib, g, err := h.Repo.AddContribution(userContext(c), userID, id, req)
+	if err != nil {
+		return goalError(err, "failed to add contribution")
+	}
+
+	resp := fiber.Map{
+		"contribution": contrib,
+		"goal":         g,
+	}
+	if g.Status == StatusCompleted {
+		resp["bonus_points"] = CompletionBonusPoints
+	}
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+func (h *Handler) Contributions(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	id, err := goalID(c)
+	if err != nil {
+		return err
+	}
+
+	items, err := h.Repo.ListContributions(userContext(c), userID, id)
+	if err != nil {
+		return goalError(err, "failed to list contributions")
+	}
+	return c.JSON(items)
+}
+
+func goalID(c *fiber.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimSpace(c.Params("id")), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "invalid goal id")
+	}
+	return id, nil
+}
+
+func goalError(err error, msg string) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, "goal not found")
+	case errors.Is(err, ErrNotActive):
+		return fiber.NewError(fiber.StatusConflict, "goal is not active")
+	case errors.Is(err, ErrAllocationExceeded):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, msg+": "+err.Error())
+}
+
+func extractUserID(c *fiber.Ctx) (string, error) {
+	val := c.Locals("user_id")
+	if val == nil {
+		val = c.Locals("userID")
+	}
+	if val == nil {
+		return "", errors.New("user id missing")
+	}
+	if uid, ok := val.(string); ok && strings.TrimSpace(uid) != "" {
+		return uid, nil
+	}
+	return "", errors.New("user id missing")
+}
+
+func userContext(c *fiber.Ctx) context.Context {
+	if ctx := c.UserContext(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
@@ -0,0 +1,71 @@
+package goals
+
+import "time"
+
+const (
+	StatusActive    = "ACTIVE"
+	StatusCompleted = "COMPLETED"
+	StatusArchived  = "ARCHIVED"
+)
+
+type Goal struct {
+	ID                  int64      `json:"id"`
+	UserID              string     `json:"user_id"`
+	Name                string     `json:"name"`
+	TargetAmount        int64      `json:"target_amount"`
+	SavedAmount         int64      `json:"saved_amount"`
+	Currency            string     `json:"currency"`
+	TargetDate          *time.Time `json:"target_date,omitempty"`
+	AutoAllocatePercent float64    `json:"auto_allocate_percent"`
+	Status              string     `json:"status"`
+	CompletedAt         *time.Time `json:"completed_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+	Progress            Progress   `json:"progress"`
+}
+
+// Progress is derived from the goal on read; it is never stored.
+type Progress struct {
+	Percent         float64  `json:"percent"`
+	Remaining       int64    `json:"remaining"`
+	MonthsLeft      *float64 `json:"months_left,omitempty"`
+	RequiredMonthly int64    `json:"required_monthly"`
+	Overdue         bool     `json:"overdue"`
+}
+
+type Contribution struct {
+	ID        int64     `json:"id"`
+	GoalID    int64     `json:"goal_id"`
+	Amount    int64     `json:"amount"`
+	Source    string    `json:"source"` // manual | auto
+	IncomeID  *string   `json:"income_id,omitempty"`
+	Note      *string   `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Allocation describes how much of an income was moved into a goal by its auto-allocation rule.
+type Allocation struct {
+	GoalID    int64  `json:"goal_id"`
+	GoalName  string `json:"goal_name"`
+	Amount    int64  `json:"amount"`
+	Completed bool   `json:"completed"`
+}
+
+type CreateGoalRequest struct {
+	Name                string  `json:"name"`
+	TargetAmount        int64   `json:"target_amount"`
+	TargetDate          string  `json:"target_date"` // YYYY-MM-DD, optional
+	AutoAllocatePercent float64 `json:"auto_allocate_percent"`
+}
+
+type UpdateGoalRequest struct {
+	Name                *string  `json:"name"`
+	TargetAmount        *int64   `json:"target_amount"`
+	TargetDate          *string  `json:"target_date"` // "" clears the date
+	AutoAllocatePercent *float64 `json:"auto_allocate_percent"`
+}
+
+type ContributionRequest struct {
+	Amount int64   `json:"amount"`
+	Note   *string `json:"note"`
+}
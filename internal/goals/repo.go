@@ -0,0 +1,416 @@
+package goals
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/points"
+)
+
+// CompletionBonusPoints is credited to the points ledger once per completed goal.
+const CompletionBonusPoints int64 = 250
+
+var (
+	ErrNotFound           = errors.New("goal not found")
+	ErrNotActive          = errors.New("goal not active")
+	ErrAllocationExceeded = errors.New("auto allocation across goals cannot exceed 100 percent")
+)
+
+type Repository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{Pool: pool}
+}
+
+const goalColumns = `id, user_id::text, name, target_amount, saved_amount, currency, target_date,
+       auto_allocate_percent::float8, status, completed_at, created_at, updated_at`
+
+func scanGoal(row pgx.Row) (Goal, error) {
+	var g Goal
+	err := row.Scan(
+		&g.ID,
+		&g.UserID,
+		&g.Name,
+		&g.TargetAmount,
+		&g.SavedAmount,
+		&g.Currency,
+		&g.TargetDate,
+		&g.AutoAllocatePercent,
+		&g.Status,
+		&g.CompletedAt,
+		&g.CreatedAt,
+		&g.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Goal{}, ErrNotFound
+		}
+		return Goal{}, err
+	}
+	g.Progress = computeProgress(g, time.Now())
+	return g, nil
+}
+
+// computeProgress derives completion percentage and the monthly saving needed to hit the target date.
+func computeProgress(g Goal, now time.Time) Progress {
+	p := Progress{}
+	remaining := g.TargetAmount - g.SavedAmount
+	if remaining < 0 {
+		remaining = 0
+	}
+	p.Remaining = remaining
+	if g.TargetAmount > 0 {
+		p.Percent = math.Min(100, math.Round(float64(g.SavedAmount)/float64(g.TargetAmount)*10000)/100)
+	}
+
+	if g.TargetDate == nil || remaining == 0 {
+		return p
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	target := time.Date(g.TargetDate.Year(), g.TargetDate.Month(), g.TargetDate.Day(), 0, 0, 0, 0, time.UTC)
+	days := target.Sub(today).Hours() / 24
+	if days <= 0 {
+		p.Overdue = true
+		zero := 0.0
+		p.MonthsLeft = &zero
+		p.RequiredMonthly = remaining
+		return p
+	}
+
+	months := days / 30.4375
+	rounded := math.Round(months*10) / 10
+	p.MonthsLeft = &rounded
+	p.RequiredMonthly = int64(math.Ceil(float64(remaining) / math.Max(months, 1)))
+	return p
+}
+
+func (r *Repository) CreateGoal(ctx context.Context, userID string, req CreateGoalRequest, targetDate *time.Time) (Goal, error) {
+	if err := r.checkAllocation(ctx, userID, 0, req.AutoAllocatePercent); err != nil {
+		return Goal{}, err
+	}
+
+	row := r.Pool.QueryRow(ctx, `
+INSERT INTO savings_goals (user_id, name, target_amount, target_date, auto_allocate_percent)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING `+goalColumns,
+		userID, req.Name, req.TargetAmount, targetDate, req.AutoAllocatePercent,
+	)
+	return scanGoal(row)
+}
+
+func (r *Repository) ListGoals(ctx context.Context, userID string, includeArchived bool) ([]Goal, error) {
+	rows, err := r.Pool.Query(ctx, `
+SELECT `+goalColumns+`
+FROM savings_goals
+WHERE user_id = $1
+  AND ($2 OR status <> 'ARCHIVED')
+ORDER BY created_at DESC
+`, userID, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Goal, 0)
+	for rows.Next() {
+		g, err := scanGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) GetGoal(ctx context.Context, userID string, id int64) (Goal, error) {
+	row := r.Pool.QueryRow(ctx, `SELECT `+goalColumns+` FROM savings_goals WHERE id = $1 AND user_id = $2`, id, userID)
+	return scanGoal(row)
+}
+
+// UpdateGoal applies the non-nil fields of req. Lowering the target to or below the saved amount completes the goal.
+func (r *Repository) UpdateGoal(ctx context.Context, userID string, id int64, req UpdateGoalRequest, targetDate *time.Time, clearDate bool) (Goal, error) {
+	g, err := r.GetGoal(ctx, userID, id)
+	if err != nil {
+		return Goal{}, err
+	}
+	if g.Status == StatusArchived {
+		return Goal{}, ErrNotActive
+	}
+
+	if req.Name != nil {
+		g.Name = *req.Name
+	}
+	if req.TargetAmount != nil {
+		g.TargetAmount = *req.TargetAmount
+	}
+	if targetDate != nil {
+		g.TargetDate = targetDate
+	} else if clearDate {
+		g.TargetDate = nil
+	}
+	if req.AutoAllocatePercent != nil {
+		if err := r.checkAllocation(ctx, userID, id, *req.AutoAllocatePercent); err != nil {
+			return Goal{}, err
+		}
+		g.AutoAllocatePercent = *req.AutoAllocatePercent
+	}
+
+	wasActive := g.Status == StatusActive
+	row := r.Pool.QueryRow(ctx, `
+UPDATE savings_goals
+SET name = $3,
+    target_amount = $4,
+    target_date = $5,
+    auto_allocate_percent = $6,
+    status = CASE WHEN status = 'ACTIVE' AND saved_amount >= $4 THEN 'COMPLETED' ELSE status END,
+    completed_at = CASE WHEN status = 'ACTIVE' AND saved_amount >= $4 THEN NOW() ELSE completed_at END,
+    updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING `+goalColumns,
+		id, userID, g.Name, g.TargetAmount, g.TargetDate, g.AutoAllocatePercent,
+	)
+	updated, err := scanGoal(row)
+	if err != nil {
+		return Goal{}, err
+	}
+
+	if wasActive && updated.Status == StatusCompleted {
+		if _, err := awardCompletion(ctx, r.Pool, userID, updated.ID); err != nil {
+			return Goal{}, err
+		}
+	}
+	return updated, nil
+}
+
+// ArchiveGoal hides a goal and switches off its auto-allocation; contributions are kept.
+func (r *Repository) ArchiveGoal(ctx context.Context, userID string, id int64) error {
+	ct, err := r.Pool.Exec(ctx, `
+UPDATE savings_goals
+SET status = 'ARCHIVED', auto_allocate_percent = 0, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND status <> 'ARCHIVED'
+`, id, userID)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repository) AddContribution(ctx context.Context, userID string, goalID int64, req ContributionRequest) (Contribution, Goal, error) {
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return Contribution{}, Goal{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	contrib, goal, completed, err := addContributionTx(ctx, tx, userID, goalID, req.Amount, "manual", nil, req.Note)
+	if err != nil {
+		return Contribution{}, Goal{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Contribution{}, Goal{}, err
+	}
+
+	if completed {
+		if _, err := awardCompletion(ctx, r.Pool, userID, goalID); err != nil {
+			return Contribution{}, Goal{}, err
+		}
+	}
+	return contrib, goal, nil
+}
+
+func (r *Repository) ListContributions(ctx context.Context, userID string, goalID int64) ([]Contribution, error) {
+	if _, err := r.GetGoal(ctx, userID, goalID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.Pool.Query(ctx, `
+SELECT id, goal_id, amount, source, income_id, note, created_at
+FROM savings_goal_contributions
+WHERE goal_id = $1 AND user_id = $2
+ORDER BY created_at DESC
+LIMIT 200
+`, goalID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Contribution, 0)
+	for rows.Next() {
+		var ct Contribution
+		if err := rows.Scan(&ct.ID, &ct.GoalID, &ct.Amount, &ct.Source, &ct.IncomeID, &ct.Note, &ct.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, ct)
+	}
+	return out, rows.Err()
+}
+
+// checkAllocation rejects a percentage that would push the user's active auto-allocation rules past 100%.
+func (r *Repository) checkAllocation(ctx context.Context, userID string, excludeID int64, pct float64) error {
+	if pct < 0 || pct > 100 {
+		return ErrAllocationExceeded
+	}
+	if pct == 0 {
+		return nil
+	}
+
+	var used float64
+	err := r.Pool.QueryRow(ctx, `
+SELECT COALESCE(SUM(auto_allocate_percent), 0)::float8
+FROM savings_goals
+WHERE user_id = $1 AND status = 'ACTIVE' AND id <> $2
+`, userID, excludeID).Scan(&used)
+	if err != nil {
+		return err
+	}
+	if used+pct > 100 {
+		return ErrAllocationExceeded
+	}
+	return nil
+}
+
+// AllocateIncome moves each active goal's auto-allocation share of a new income into that goal.
+// Shares are capped at what the goal still needs; goals completed by the allocation earn the bonus.
+func AllocateIncome(ctx context.Context, db *pgxpool.Pool, userID string, incomeID string, amount int64) ([]Allocation, error) {
+	if amount <= 0 {
+		return nil, nil
+	}
+
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+SELECT id, name, target_amount - saved_amount, auto_allocate_percent::float8
+FROM savings_goals
+WHERE user_id = $1 AND status = 'ACTIVE' AND auto_allocate_percent > 0
+ORDER BY created_at ASC
+FOR UPDATE
+`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	type rule struct {
+		ID        int64
+		Name      string
+		Remaining int64
+		Percent   float64
+	}
+	var rules []rule
+	for rows.Next() {
+		var rl rule
+		if err := rows.Scan(&rl.ID, &rl.Name, &rl.Remaining, &rl.Percent); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		rules = append(rules, rl)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	var out []Allocation
+	for _, rl := range rules {
+		share := int64(math.Floor(float64(amount) * rl.Percent / 100))
+		if share > rl.Remaining {
+			share = rl.Remaining
+		}
+		if share <= 0 {
+			continue
+		}
+
+		incID := incomeID
+		_, _, completed, err := addContributionTx(ctx, tx, userID, rl.ID, share, "auto", &incID, nil)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Allocation{GoalID: rl.ID, GoalName: rl.Name, Amount: share, Completed: completed})
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	for _, a := range out {
+		if a.Completed {
+			if _, err := awardCompletion(ctx, db, userID, a.GoalID); err != nil {
+				return out, err
+			}
+		}
+	}
+	return out, nil
+}
+
+func addContributionTx(ctx context.Context, tx pgx.Tx, userID string, goalID int64, amount int64, source string, incomeID *string, note *string) (Contribution, Goal, bool, error) {
+	var status string
+	err := tx.QueryRow(ctx, `SELECT status FROM savings_goals WHERE id = $1 AND user_id = $2 FOR UPDATE`, goalID, userID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Contribution{}, Goal{}, false, ErrNotFound
+		}
+		return Contribution{}, Goal{}, false, err
+	}
+	if status != StatusActive {
+		return Contribution{}, Goal{}, false, ErrNotActive
+	}
+
+	if note != nil {
+		trimmed := strings.TrimSpace(*note)
+		note = &trimmed
+	}
+
+	var contrib Contribution
+	err = tx.QueryRow(ctx, `
+INSERT INTO savings_goal_contributions (goal_id, user_id, amount, source, income_id, note)
+VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+RETURNING id, goal_id, amount, source, income_id, note, created_at
+`, goalID, userID, amount, source, incomeID, note).Scan(
+		&contrib.ID, &contrib.GoalID, &contrib.Amount, &contrib.Source, &contrib.IncomeID, &contrib.Note, &contrib.CreatedAt,
+	)
+	if err != nil {
+		return Contribution{}, Goal{}, false, err
+	}
+
+	row := tx.QueryRow(ctx, `
+UPDATE savings_goals
+SET saved_amount = saved_amount + $3,
+    status = CASE WHEN saved_amount + $3 >= target_amount THEN 'COMPLETED' ELSE status END,
+    completed_at = CASE WHEN saved_amount + $3 >= target_amount THEN NOW() ELSE completed_at END,
+    updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING `+goalColumns,
+		goalID, userID, amount,
+	)
+	goal, err := scanGoal(row)
+	if err != nil {
+		return Contribution{}, Goal{}, false, err
+	}
+
+	return contrib, goal, goal.Status == StatusCompleted, nil
+}
+
+func awardCompletion(ctx context.Context, db *pgxpool.Pool, userID string, goalID int64) (int64, error) {
+	return points.AwardBonusPoints(ctx, db, userID, "goal:"+strconv.FormatInt(goalID, 10), CompletionBonusPoints, "goal_completed")
+}
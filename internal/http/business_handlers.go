@@ -1,12 +1,22 @@
 package http
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/pdfkit"
 )
 
 type BusinessHandler struct {
@@ -93,3 +103,124 @@ func (h *BusinessHandler) List(c *fiber.Ctx) error {
 	}
 	return c.JSON(out)
 }
+
+// maxLogoBytes keeps logos small enough to embed in every generated PDF.
+const maxLogoBytes = 512 << 10
+
+// logoTypes maps the sniffed image type to the content type served back.
+var logoTypes = map[string]string{"PNG": "image/png", "JPG": "image/jpeg"}
+
+// UploadLogo stores a PNG or JPEG logo that generated PDFs show in their header. It takes a
+// multipart "logo" file or the raw image as the body.
+func (h *BusinessHandler) UploadLogo(c *fiber.Ctx) error {
+	userID, bizID, err := businessParams(c)
+	if err != nil {
+		return err
+	}
+
+	data := c.Body()
+	if fh, ferr := c.FormFile("logo"); ferr == nil {
+		if fh.Size > maxLogoBytes {
+			return fiber.NewError(fiber.StatusRequestEntityTooLarge, "logo must be 512KB or smaller")
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "could not read logo")
+		}
+		defer f.Close()
+		if data, err = io.ReadAll(io.LimitReader(f, maxLogoBytes+1)); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "could not read logo")
+		}
+	}
+	if len(data) == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "logo required")
+	}
+	if len(data) > maxLogoBytes {
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, "logo must be 512KB or smaller")
+	}
+	contentType, ok := logoTypes[pdfkit.SniffImageType(data)]
+	if !ok {
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, "logo must be a PNG or JPEG image")
+	}
+	if _, _, err := image.DecodeConfig(bytes.NewReader(data)); err != nil {
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, "logo is not a valid image")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tag, err := h.DB.Exec(ctx,
+		`UPDATE businesses SET logo = $3, logo_content_type = $4 WHERE id = $1 AND owner_user_id = $2`,
+		bizID, userID, data, contentType,
+	)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "could not save logo")
+	}
+	if tag.RowsAffected() == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "business not found")
+	}
+	return c.JSON(fiber.Map{"id": bizID, "content_type": contentType, "size": len(data)})
+}
+
+func (h *BusinessHandler) GetLogo(c *fiber.Ctx) error {
+	userID, bizID, err := businessParams(c)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var data []byte
+	var contentType *string
+	err = h.DB.QueryRow(ctx,
+		`SELECT logo, logo_content_type FROM businesses WHERE id = $1 AND owner_user_id = $2`,
+		bizID, userID,
+	).Scan(&data, &contentType)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fiber.NewError(fiber.StatusNotFound, "business not found")
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "could not load logo")
+	}
+	if len(data) == 0 || contentType == nil {
+		return fiber.NewError(fiber.StatusNotFound, "no logo uploaded")
+	}
+	c.Set(fiber.HeaderContentType, *contentType)
+	c.Set(fiber.HeaderCacheControl, "private, max-age=300")
+	return c.Send(data)
+}
+
+func (h *BusinessHandler) DeleteLogo(c *fiber.Ctx) error {
+	userID, bizID, err := businessParams(c)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tag, err := h.DB.Exec(ctx,
+		`UPDATE businesses SET logo = NULL, logo_content_type = NULL WHERE id = $1 AND owner_user_id = $2`,
+		bizID, userID,
+	)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "could not remove logo")
+	}
+	if tag.RowsAffected() == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "business not found")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func businessParams(c *fiber.Ctx) (string, int64, error) {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || strings.TrimSpace(userID) == "" {
+		return "", 0, fiber.NewError(fiber.StatusUnauthorized, "missing user")
+	}
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return "", 0, fiber.NewError(fiber.StatusBadRequest, "invalid business id")
+	}
+	return userID, id, nil
+}
@@ -0,0 +1,288 @@
+package imports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/expense"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
+)
+
+// Exports from other expense apps. They go through the same preview/commit batches as bank
+// statements; only the parsing differs.
+const (
+	FormatSplitwise    = "splitwise"
+	FormatMoneyManager = "money_manager"
+	FormatWalnut       = "walnut"
+)
+
+type appCells func(col string) string
+
+type appFormat struct {
+	// normalised headers that must all be present
+	required []string
+	// header checks the columns beyond required against opts; nil accepts any
+	header func(rec []string, opts ParseOptions) error
+	row    func(get appCells, opts ParseOptions) Row
+}
+
+var appFormats = map[string]appFormat{
+	// Splitwise group/friend export: Date,Description,Category,Cost,Currency,<member>,<member>...
+	// Cost is the full bill and each member column is that member's net balance on it (what
+	// they paid less their share), so the user's share comes from their own column.
+	FormatSplitwise: {
+		required: []string{"date", "description", "category", "cost", "currency"},
+		header:   splitwiseHeader,
+		row: func(get appCells, opts ParseOptions) Row {
+			r := Row{Description: get("description"), Direction: "OUT"}
+			if strings.EqualFold(get("category"), "payment") {
+				r.Error = "settle-up payment between friends, not an expense"
+				return r
+			}
+			if cur := strings.ToUpper(get("currency")); cur != "" && cur != "INR" {
+				r.Error = fmt.Sprintf("currency %s not supported", cur)
+				return r
+			}
+			r.Category = mapAppCategory(get("category"), r.Description)
+			appDateAmount(&r, get("date"), get("cost"), []string{"2006-01-02"})
+			if r.Error != "" {
+				return r
+			}
+			net, err := money.ParseRupees(firstNonEmpty(get(normalizeHeader(opts.Member)), "0"))
+			if err != nil {
+				r.Error = fmt.Sprintf("invalid balance %q for %s", get(normalizeHeader(opts.Member)), opts.Member)
+				return r
+			}
+			share := splitwiseShare(r.Amount, net)
+			if share <= 0 {
+				r.Error = "you have no share in this expense"
+				return r
+			}
+			if share != r.Amount {
+				r.Note = "your share of ₹" + money.FormatINR(r.Amount)
+			}
+			r.Amount = share
+			return r
+		},
+	},
+	// Money Manager (Realbyte) export: Date,Account,Category,Subcategory,Note,INR,Income/Expense,Description,...
+	FormatMoneyManager: {
+		required: []string{"date", "category", "note", "incomeexpense"},
+		row: func(get appCells, _ ParseOptions) Row {
+			r := Row{
+				Description: firstNonEmpty(get("note"), get("category")),
+				Note:        get("description"),
+				Reference:   get("account"),
+			}
+			switch kind := strings.ToLower(get("incomeexpense")); {
+			case strings.HasPrefix(kind, "exp"):
+				r.Direction = "OUT"
+			case strings.HasPrefix(kind, "inc"):
+				r.Direction = "IN"
+			default:
+				r.Error = fmt.Sprintf("%q entries are transfers between your own accounts", get("incomeexpense"))
+				return r
+			}
+			if r.Direction == "OUT" {
+				r.Category = mapAppCategory(get("category")+" "+get("subcategory"), r.Description)
+			}
+			appDateAmount(&r, get("date"), firstNonEmpty(get("inr"), get("amount")),
+				[]string{"01/02/2006 15:04:05", "01/02/2006", "2006-01-02 15:04:05", "2006-01-02", "02/01/2006"})
+			return r
+		},
+	},
+	// Walnut export: DATE,TIME,PLACE,AMOUNT,DR/CR,ACCOUNT,EXPENSE,INCOME,CATEGORY,TAGS,NOTE
+	FormatWalnut: {
+		required: []string{"date", "place", "amount", "drcr"},
+		row: func(get appCells, _ ParseOptions) Row {
+			r := Row{
+				Description: get("place"),
+				Note:        strings.TrimSpace(get("note") + " " + get("tags")),
+				Reference:   get("account"),
+				Direction:   "OUT",
+			}
+			if strings.EqualFold(get("drcr"), "cr") {
+				r.Direction = "IN"
+			}
+			if strings.EqualFold(get("expense"), "no") && strings.EqualFold(get("income"), "no") {
+				r.Error = "marked as neither expense nor income in Walnut"
+				return r
+			}
+			if r.Direction == "OUT" {
+				r.Category = mapAppCategory(get("category"), r.Description)
+			}
+			appDateAmount(&r, get("date"), get("amount"), []string{"02-01-06", "02-01-2006", "02/01/2006", "2006-01-02"})
+			return r
+		},
+	},
+}
+
+// splitwiseHeader needs opts.Member to name one of the member columns: the balances are per
+// member and only the user's own share is their expense.
+func splitwiseHeader(rec []string, opts ParseOptions) error {
+	var members []string
+	found := false
+	for _, h := range rec {
+		k := normalizeHeader(h)
+		if k == "" || indexOf([]string{"date", "description", "category", "cost", "currency"}, k) >= 0 {
+			continue
+		}
+		members = append(members, strings.TrimSpace(h))
+		found = found || k == normalizeHeader(opts.Member)
+	}
+	if len(members) == 0 {
+		return errors.New("no member columns in the splitwise export")
+	}
+	if strings.TrimSpace(opts.Member) == "" || !found {
+		return fmt.Errorf("set member to your name in the splitwise export, one of: %s", strings.Join(members, ", "))
+	}
+	return nil
+}
+
+// splitwiseShare is the user's share of a bill of cost from their net balance on it. A positive
+// balance means they paid the bill and are owed the rest; a negative one is what they owe the
+// payer, which is their share.
+func splitwiseShare(cost, net int64) int64 {
+	if net > 0 {
+		return cost - net
+	}
+	return -net
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func parseAppExport(data []byte, format string, opts ParseOptions) ([]Row, error) {
+	f, ok := appFormats[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+
+	var (
+		cols map[string]int
+		rows []Row
+	)
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := r.FieldPos(0)
+		if err != nil {
+			rows = append(rows, Row{Line: line, Error: "unreadable line: " + err.Error()})
+			continue
+		}
+		if cols == nil {
+			cols = appHeader(rec, f.required)
+			if cols == nil && line > maxHeaderScan {
+				break
+			}
+			if cols != nil && f.header != nil {
+				if err := f.header(rec, opts); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		if skipCSVRecord(rec) {
+			continue
+		}
+
+		get := func(col string) string {
+			i, ok := cols[col]
+			if !ok || i >= len(rec) {
+				return ""
+			}
+			return collapseSpaces(rec[i])
+		}
+		// Splitwise ends with a "Total balance" line that has no date
+		if strings.TrimSpace(get("date")) == "" {
+			continue
+		}
+		row := f.row(get, opts)
+		row.Line = line
+		rows = append(rows, row)
+	}
+	if cols == nil {
+		return nil, errors.New("header row not found; is this the right export format?")
+	}
+	return rows, nil
+}
+
+func appHeader(rec []string, required []string) map[string]int {
+	cols := map[string]int{}
+	for i, h := range rec {
+		k := normalizeHeader(h)
+		if _, dup := cols[k]; !dup {
+			cols[k] = i
+		}
+	}
+	for _, req := range required {
+		if _, ok := cols[req]; !ok {
+			return nil
+		}
+	}
+	return cols
+}
+
+func appDateAmount(r *Row, rawDate, rawAmount string, layouts []string) {
+	d, err := parseDate(rawDate, layouts)
+	if err != nil {
+		r.Error = fmt.Sprintf("unrecognised date %q", rawDate)
+		return
+	}
+	r.Date = d
+
+	paise, err := money.ParseRupees(rawAmount)
+	if err != nil || paise == 0 {
+		r.Error = fmt.Sprintf("invalid amount %q", rawAmount)
+		return
+	}
+	r.Amount = abs(paise)
+}
+
+// appCategoryRules maps the other apps' category names onto ours. Checked in order against the
+// lowercased "category subcategory" text.
+var appCategoryRules = []struct {
+	category string
+	words    []string
+}{
+	{"FOOD", []string{"food", "dining", "restaurant", "groceries", "grocery", "drink", "snack", "coffee"}},
+	{"TRANSPORT", []string{"transport", "taxi", "cab", "fuel", "petrol", "parking", "travel", "bus", "train", "flight"}},
+	{"FIXED", []string{"rent", "mortgage", "emi", "loan", "insurance", "education", "fees"}},
+	{"BILLS", []string{"utilities", "electricity", "bill", "phone", "mobile", "internet", "water", "gas"}},
+	{"ENTERTAINMENT", []string{"entertainment", "movie", "games", "music", "sports", "subscription", "culture"}},
+	{"HEALTH", []string{"health", "medical", "medicine", "doctor", "pharmacy", "fitness", "gym", "beauty"}},
+	{"SHOPPING", []string{"shopping", "clothing", "apparel", "household", "electronics", "gift", "furniture", "home"}},
+}
+
+func mapAppCategory(appCategory, desc string) string {
+	lc := strings.ToLower(appCategory)
+	for _, rule := range appCategoryRules {
+		for _, w := range rule.words {
+			if strings.Contains(lc, w) {
+				return rule.category
+			}
+		}
+	}
+	// fall back to the same keyword rules bank narrations get
+	if c := expense.Categorize(desc); c != "MISC" {
+		return c
+	}
+	return "General"
+}
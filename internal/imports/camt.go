@@ -0,0 +1,145 @@
+package imports
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
+)
+
+// ISO 20022 camt.053 bank-to-customer statement. Field tags carry no namespace so any
+// camt.053.001.xx version matches; the party name moved under Pty in .08, both are read.
+type camtDocument struct {
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	IBAN    string      `xml:"Acct>Id>IBAN"`
+	OtherID string      `xml:"Acct>Id>Othr>Id"`
+	Entries []camtEntry `xml:"Ntry"`
+}
+
+type camtEntry struct {
+	NtryRef string `xml:"NtryRef"`
+	Amt     string `xml:"Amt"`
+	Ind     string `xml:"CdtDbtInd"`
+	Status  struct {
+		Text string `xml:",chardata"`
+		Code string `xml:"Cd"`
+	} `xml:"Sts"`
+	BookingDate  camtDate        `xml:"BookgDt"`
+	ValueDate    camtDate        `xml:"ValDt"`
+	AcctSvcrRef  string          `xml:"AcctSvcrRef"`
+	AddtlNtryInf string          `xml:"AddtlNtryInf"`
+	Details      []camtTxDetails `xml:"NtryDtls>TxDtls"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+type camtTxDetails struct {
+	EndToEndID   string   `xml:"Refs>EndToEndId"`
+	AcctSvcrRef  string   `xml:"Refs>AcctSvcrRef"`
+	Unstructured []string `xml:"RmtInf>Ustrd"`
+	Creditor     string   `xml:"RltdPties>Cdtr>Nm"`
+	CreditorPty  string   `xml:"RltdPties>Cdtr>Pty>Nm"`
+	Debtor       string   `xml:"RltdPties>Dbtr>Nm"`
+	DebtorPty    string   `xml:"RltdPties>Dbtr>Pty>Nm"`
+}
+
+func parseCAMT(data []byte) ([]Row, error) {
+	if !bytes.Contains(data, []byte("BkToCstmrStmt")) {
+		return nil, errors.New("not a camt.053 statement")
+	}
+	var doc camtDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid camt.053 XML: %w", err)
+	}
+
+	var rows []Row
+	line := 0
+	for _, st := range doc.Statements {
+		account := firstNonEmpty(st.IBAN, st.OtherID)
+		for _, e := range st.Entries {
+			line++
+			status := strings.ToUpper(firstNonEmpty(e.Status.Code, e.Status.Text))
+			if status != "" && status != "BOOK" {
+				// pending/info entries can still change; only booked ones are imported
+				continue
+			}
+
+			row := Row{Line: line}
+			var parts []string
+			ref := firstNonEmpty(e.AcctSvcrRef, e.NtryRef)
+			for _, d := range e.Details {
+				if e.Ind == "DBIT" {
+					parts = append(parts, firstNonEmpty(d.CreditorPty, d.Creditor))
+				} else {
+					parts = append(parts, firstNonEmpty(d.DebtorPty, d.Debtor))
+				}
+				parts = append(parts, d.Unstructured...)
+				if ref == "" {
+					ref = firstNonEmpty(d.AcctSvcrRef, endToEnd(d.EndToEndID))
+				}
+			}
+			parts = append(parts, e.AddtlNtryInf)
+			row.Description = collapseSpaces(strings.Join(parts, " "))
+			row.Reference = ref
+			if ref != "" {
+				row.ExternalID = "camt:" + account + ":" + ref
+			}
+
+			d, err := camtEntryDate(e)
+			if err != nil {
+				row.Error = "missing or invalid booking date"
+				rows = append(rows, row)
+				continue
+			}
+			row.Date = d
+
+			amt, err := money.ParseRupees(e.Amt)
+			if err != nil || amt <= 0 {
+				row.Error = fmt.Sprintf("invalid amount %q", e.Amt)
+				rows = append(rows, row)
+				continue
+			}
+			row.Amount = amt
+			switch e.Ind {
+			case "DBIT":
+				row.Direction = "OUT"
+			case "CRDT":
+				row.Direction = "IN"
+			default:
+				row.Error = fmt.Sprintf("unknown CdtDbtInd %q", e.Ind)
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func camtEntryDate(e camtEntry) (time.Time, error) {
+	for _, d := range []camtDate{e.BookingDate, e.ValueDate} {
+		if d.Date != "" {
+			return time.Parse("2006-01-02", strings.TrimSpace(d.Date))
+		}
+		if len(d.DateTime) >= 10 {
+			return time.Parse("2006-01-02", d.DateTime[:10])
+		}
+	}
+	return time.Time{}, errors.New("no date")
+}
+
+// endToEnd ignores the placeholder banks use when the payer sent no reference.
+func endToEnd(id string) string {
+	if strings.EqualFold(strings.TrimSpace(id), "NOTPROVIDED") {
+		return ""
+	}
+	return id
+}
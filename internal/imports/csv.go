@@ -0,0 +1,344 @@
+package imports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
+)
+
+// CSVMapping says which columns hold what. Each field lists header names to try in order;
+// headers are compared lowercased with everything except letters and digits removed, so
+// "Withdrawal Amt." matches "withdrawalamt".
+type CSVMapping struct {
+	Name        string   `json:"name,omitempty"`
+	Date        []string `json:"date"`
+	DateFormats []string `json:"date_formats,omitempty"` // Go layouts; defaults cover common Indian formats
+	Description []string `json:"description"`
+	Reference   []string `json:"reference,omitempty"`
+	Debit       []string `json:"debit,omitempty"`  // withdrawal column
+	Credit      []string `json:"credit,omitempty"` // deposit column
+	Amount      []string `json:"amount,omitempty"` // single amount column, signed or paired with DrCr
+	DrCr        []string `json:"dr_cr,omitempty"`  // column holding Dr/Cr
+	Delimiter   string   `json:"delimiter,omitempty"`
+}
+
+var defaultDateFormats = []string{
+	"02/01/2006", "02/01/06", "02-01-2006", "02-01-06", "2006-01-02",
+	"02 Jan 2006", "2 Jan 2006", "02-Jan-2006", "02-Jan-06", "02 Jan 06", "2-Jan-2006",
+	"02.01.2006", "Jan 2, 2006",
+}
+
+// Presets for the statement downloads of major Indian banks (net-banking "detailed statement" CSV/XLS saved as CSV).
+var Presets = map[string]CSVMapping{
+	"hdfc": {
+		Name:        "HDFC Bank",
+		Date:        []string{"date", "transactiondate"},
+		DateFormats: []string{"02/01/06", "02/01/2006"},
+		Description: []string{"narration"},
+		Reference:   []string{"chqrefno", "refno"},
+		Debit:       []string{"withdrawalamt", "withdrawalamount", "debitamount"},
+		Credit:      []string{"depositamt", "depositamount", "creditamount"},
+	},
+	"icici": {
+		Name:        "ICICI Bank",
+		Date:        []string{"transactiondate", "valuedate", "date"},
+		DateFormats: []string{"02/01/2006", "02-01-2006", "02-Jan-2006"},
+		Description: []string{"transactionremarks", "remarks", "particulars"},
+		Reference:   []string{"chequenumber", "chqno"},
+		Debit:       []string{"withdrawalamountinr", "withdrawalamount", "debit"},
+		Credit:      []string{"depositamountinr", "depositamount", "credit"},
+	},
+	"sbi": {
+		Name:        "State Bank of India",
+		Date:        []string{"txndate", "transactiondate", "date"},
+		DateFormats: []string{"2 Jan 2006", "02 Jan 2006", "02-01-2006", "02/01/2006"},
+		Description: []string{"description", "narration"},
+		Reference:   []string{"refnochequeno", "refno", "chequeno"},
+		Debit:       []string{"debit", "withdrawal"},
+		Credit:      []string{"credit", "deposit"},
+	},
+	"axis": {
+		Name:        "Axis Bank",
+		Date:        []string{"trandate", "transactiondate", "date"},
+		DateFormats: []string{"02-01-2006", "02/01/2006"},
+		Description: []string{"particulars", "description"},
+		Reference:   []string{"chqno", "chequeno"},
+		Debit:       []string{"dr", "debit"},
+		Credit:      []string{"cr", "credit"},
+	},
+	"kotak": {
+		Name:        "Kotak Mahindra Bank",
+		Date:        []string{"transactiondate", "date"},
+		DateFormats: []string{"02-01-2006", "02/01/2006", "02 Jan 2006"},
+		Description: []string{"description", "narration"},
+		Reference:   []string{"chqrefno", "refno"},
+		Amount:      []string{"amount"},
+		DrCr:        []string{"drcr"},
+	},
+	"generic": {
+		Name:        "Generic CSV",
+		Date:        []string{"date", "transactiondate", "txndate", "valuedate", "postingdate"},
+		Description: []string{"description", "narration", "particulars", "remarks", "details", "payee"},
+		Reference:   []string{"reference", "refno", "chqrefno", "id"},
+		Debit:       []string{"debit", "withdrawal", "withdrawalamount", "dr"},
+		Credit:      []string{"credit", "deposit", "depositamount", "cr"},
+		Amount:      []string{"amount"},
+		DrCr:        []string{"drcr", "type"},
+	},
+}
+
+// how far down the file we look for the header row; banks prepend account details
+const maxHeaderScan = 30
+
+type csvColumns struct {
+	date, desc, ref, debit, credit, amount, drcr int
+}
+
+func parseCSV(data []byte, m CSVMapping) ([]Row, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+	if m.Delimiter != "" {
+		r.Comma = []rune(m.Delimiter)[0]
+	}
+	formats := m.DateFormats
+	if len(formats) == 0 {
+		formats = defaultDateFormats
+	}
+
+	var (
+		cols    *csvColumns
+		rows    []Row
+		lineNo  int
+		scanned int
+	)
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			lineNo++
+			rows = append(rows, Row{Line: lineNo, Error: "unreadable line: " + err.Error()})
+			continue
+		}
+		// file line, not record count: the csv reader skips blank lines
+		lineNo, _ = r.FieldPos(0)
+
+		if cols == nil {
+			scanned++
+			if scanned > maxHeaderScan {
+				return nil, errors.New("header row not found; check the preset or column mapping")
+			}
+			cols = matchHeader(rec, m)
+			continue
+		}
+
+		if skipCSVRecord(rec) {
+			continue
+		}
+		row, ok := csvRow(rec, *cols, formats)
+		if !ok {
+			continue
+		}
+		row.Line = lineNo
+		rows = append(rows, row)
+	}
+	if cols == nil {
+		return nil, errors.New("header row not found; check the preset or column mapping")
+	}
+	return rows, nil
+}
+
+// matchHeader returns the column layout when rec is the header row, nil otherwise.
+func matchHeader(rec []string, m CSVMapping) *csvColumns {
+	norm := make([]string, len(rec))
+	for i, h := range rec {
+		norm[i] = normalizeHeader(h)
+	}
+	find := func(candidates []string) int {
+		for _, c := range candidates {
+			for i, h := range norm {
+				if h == c {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+
+	cols := csvColumns{
+		date:   find(m.Date),
+		desc:   find(m.Description),
+		ref:    find(m.Reference),
+		debit:  find(m.Debit),
+		credit: find(m.Credit),
+		amount: find(m.Amount),
+		drcr:   find(m.DrCr),
+	}
+	if cols.date < 0 || cols.desc < 0 {
+		return nil
+	}
+	if cols.debit < 0 && cols.credit < 0 && cols.amount < 0 {
+		return nil
+	}
+	return &cols
+}
+
+// csvRow converts one data record. ok is false for lines that are not transactions at all
+// (blank date cell: separators, "opening balance" rows, wrapped narration).
+func csvRow(rec []string, cols csvColumns, formats []string) (Row, bool) {
+	cell := func(i int) string {
+		if i < 0 || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+
+	rawDate := cell(cols.date)
+	if rawDate == "" {
+		return Row{}, false
+	}
+	row := Row{
+		Description: collapseSpaces(cell(cols.desc)),
+		Reference:   cleanReference(cell(cols.ref)),
+	}
+
+	d, err := parseDate(rawDate, formats)
+	if err != nil {
+		row.Error = fmt.Sprintf("unrecognised date %q", rawDate)
+		return row, true
+	}
+	row.Date = d
+
+	debit, credit := cell(cols.debit), cell(cols.credit)
+	if cols.debit >= 0 || cols.credit >= 0 {
+		if amt, err := parseAmountCell(debit); err == nil && amt != 0 {
+			row.Amount, row.Direction = abs(amt), "OUT"
+		} else if amt, err := parseAmountCell(credit); err == nil && amt != 0 {
+			row.Amount, row.Direction = abs(amt), "IN"
+		} else if cols.amount < 0 {
+			row.Error = "no debit or credit amount"
+			return row, true
+		}
+	}
+	if row.Direction == "" && cols.amount >= 0 {
+		raw := cell(cols.amount)
+		amt, err := parseAmountCell(raw)
+		if err != nil || amt == 0 {
+			row.Error = fmt.Sprintf("invalid amount %q", raw)
+			return row, true
+		}
+		row.Amount = abs(amt)
+		indicator := strings.ToLower(cell(cols.drcr))
+		if indicator == "" {
+			indicator = strings.ToLower(drCrSuffix(raw))
+		}
+		switch {
+		case strings.HasPrefix(indicator, "d"):
+			row.Direction = "OUT"
+		case strings.HasPrefix(indicator, "c"):
+			row.Direction = "IN"
+		case amt < 0:
+			row.Direction = "OUT"
+		default:
+			row.Direction = "IN"
+		}
+	}
+	return row, true
+}
+
+func parseDate(s string, formats []string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, f := range formats {
+		if t, err := time.Parse(f, s); err == nil {
+			return t, nil
+		}
+	}
+	// some exports include a time part after the date
+	if i := strings.IndexByte(s, ' '); i > 0 && strings.Contains(s[i:], ":") {
+		return parseDate(s[:i], formats)
+	}
+	return time.Time{}, errors.New("bad date")
+}
+
+// parseAmountCell handles "1,23,456.78", "(500.00)", "500.00 Dr" and empty/"-" cells (zero).
+func parseAmountCell(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "-" || s == "0" {
+		return 0, nil
+	}
+	neg := false
+	if suffix := drCrSuffix(s); suffix != "" {
+		neg = strings.EqualFold(suffix, "dr")
+		s = strings.TrimSpace(s[:len(s)-len(suffix)])
+	}
+	paise, err := money.ParseRupees(s)
+	if err != nil {
+		return 0, err
+	}
+	if neg && paise > 0 {
+		paise = -paise
+	}
+	return paise, nil
+}
+
+func drCrSuffix(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return ""
+	}
+	tail := s[len(s)-2:]
+	if strings.EqualFold(tail, "dr") || strings.EqualFold(tail, "cr") {
+		return tail
+	}
+	return ""
+}
+
+func skipCSVRecord(rec []string) bool {
+	for _, c := range rec {
+		c = strings.TrimSpace(c)
+		if c != "" && strings.Trim(c, "*-=") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeHeader(h string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(h) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func cleanReference(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.Trim(s, "0-") == "" {
+		return ""
+	}
+	return s
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
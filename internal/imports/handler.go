@@ -0,0 +1,264 @@
+package imports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/audit"
+)
+
+// statements bigger than this are almost certainly the wrong file
+const maxStatementBytes = 5 << 20
+
+type Handler struct {
+	Repo *Repository
+}
+
+func NewHandler(repo *Repository) *Handler {
+	return &Handler{Repo: repo}
+}
+
+// appExports are the other-app formats Preview accepts in the "format" field.
+var appExports = []fiber.Map{
+	{"id": FormatSplitwise, "name": "Splitwise"},
+	{"id": FormatMoneyManager, "name": "Money Manager"},
+	{"id": FormatWalnut, "name": "Walnut"},
+}
+
+// Presets lists the built-in CSV column mappings.
+func (h *Handler) Presets(c *fiber.Ctx) error {
+	keys := make([]string, 0, len(Presets))
+	for k := range Presets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make([]fiber.Map, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, fiber.Map{"id": k, "name": Presets[k].Name, "mapping": Presets[k]})
+	}
+	return c.JSON(out)
+}
+
+// Apps lists the other apps whose exports Preview accepts: GET /api/imports/apps.
+func (h *Handler) Apps(c *fiber.Ctx) error {
+	return c.JSON(appExports)
+}
+
+// Preview parses an uploaded statement (multipart field "file") and stores the result as a
+// PREVIEW batch. Optional form fields: format, preset, mapping (CSVMapping JSON) and, for
+// Splitwise, member (the user's name in the export).
+func (h *Handler) Preview(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "file required")
+	}
+	if fh.Size > maxStatementBytes {
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, "statement file too large")
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "cannot read file")
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxStatementBytes))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "cannot read file")
+	}
+
+	opts := ParseOptions{
+		Format: c.FormValue("format"),
+		Preset: c.FormValue("preset"),
+		Member: c.FormValue("member"),
+	}
+	if raw := strings.TrimSpace(c.FormValue("mapping")); raw != "" {
+		var m CSVMapping
+		if err := json.Unmarshal([]byte(raw), &m); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "mapping must be valid JSON")
+		}
+		opts.Mapping = &m
+	}
+
+	rows, format, err := Parse(data, fh.Filename, opts)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "could not parse statement: "+err.Error())
+	}
+	if len(rows) == 0 {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "no transactions found in statement")
+	}
+
+	ctx := userContext(c)
+	if err := h.Repo.MarkDuplicates(ctx, userID, rows); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to check existing entries: "+err.Error())
+	}
+
+	b := &Batch{
+		UserID:   userID,
+		Format:   format,
+		FileName: fh.Filename,
+		Rows:     rows,
+	}
+	if format == FormatCSV && opts.Mapping == nil {
+		b.Preset = strings.ToLower(strings.TrimSpace(opts.Preset))
+	}
+	if err := h.Repo.CreatePreview(ctx, b); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to save preview: "+err.Error())
+	}
+	return c.Status(fiber.StatusCreated).JSON(b)
+}
+
+func (h *Handler) List(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	items, err := h.Repo.List(userContext(c), userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to list imports: "+err.Error())
+	}
+	return c.JSON(items)
+}
+
+func (h *Handler) Get(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	id, err := batchID(c)
+	if err != nil {
+		return err
+	}
+
+	b, err := h.Repo.Get(userContext(c), userID, id)
+	if err != nil {
+		return batchError(err, "failed to load import")
+	}
+	return c.JSON(b)
+}
+
+func (h *Handler) Commit(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	id, err := batchID(c)
+	if err != nil {
+		return err
+	}
+
+	var req CommitRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+		}
+	}
+	skip := make(map[int]bool, len(req.SkipLines))
+	for _, l := range req.SkipLines {
+		skip[l] = true
+	}
+
+	b, err := h.Repo.Commit(userContext(c), userID, id, skip)
+	if err != nil {
+		return batchError(err, "failed to commit import")
+	}
+
+	h.audit(c, userID, "import_commit", b)
+	b.Rows = nil
+	return c.JSON(b)
+}
+
+func (h *Handler) Rollback(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	id, err := batchID(c)
+	if err != nil {
+		return err
+	}
+
+	b, err := h.Repo.Rollback(userContext(c), userID, id)
+	if err != nil {
+		return batchError(err, "failed to roll back import")
+	}
+
+	h.audit(c, userID, "import_rollback", b)
+	return c.JSON(b)
+}
+
+func (h *Handler) audit(c *fiber.Ctx, userID, action string, b *Batch) {
+	uid := userID
+	entityID := strconv.FormatInt(b.ID, 10)
+	meta, _ := json.Marshal(fiber.Map{
+		"format":            b.Format,
+		"file_name":         b.FileName,
+		"incomes_imported":  b.Incomes,
+		"expenses_imported": b.Expenses,
+	})
+	entry := audit.Entry{
+		UserID:     &uid,
+		Action:     action,
+		EntityType: "import_batch",
+		EntityID:   &entityID,
+		Metadata:   meta,
+	}
+	if ip := strings.TrimSpace(c.IP()); ip != "" {
+		entry.IP = &ip
+	}
+	if ua := strings.TrimSpace(c.Get("User-Agent")); ua != "" {
+		entry.UserAgent = &ua
+	}
+	_ = audit.Write(userContext(c), h.Repo.Pool, entry)
+}
+
+func batchID(c *fiber.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimSpace(c.Params("id")), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "invalid import id")
+	}
+	return id, nil
+}
+
+func batchError(err error, msg string) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, ErrWrongStatus):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, msg+": "+err.Error())
+}
+
+func extractUserID(c *fiber.Ctx) (string, error) {
+	val := c.Locals("user_id")
+	if val == nil {
+		val = c.Locals("userID")
+	}
+	if val == nil {
+		return "", errors.New("user id missing")
+	}
+	if uid, ok := val.(string); ok && strings.TrimSpace(uid) != "" {
+		return uid, nil
+	}
+	return "", errors.New("user id missing")
+}
+
+func userContext(c *fiber.Ctx) context.Context {
+	if ctx := c.UserContext(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
@@ -0,0 +1,53 @@
+package imports
+
+import "time"
+
+const (
+	FormatCSV  = "csv"
+	FormatOFX  = "ofx"
+	FormatCAMT = "camt053"
+)
+
+const (
+	StatusPreview    = "PREVIEW"
+	StatusCommitted  = "COMMITTED"
+	StatusRolledBack = "ROLLED_BACK"
+)
+
+// Row is one parsed statement line. Rows with Error set are shown in the preview but never imported.
+type Row struct {
+	Line        int       `json:"line"`
+	Date        time.Time `json:"date"`
+	Amount      int64     `json:"amount"`    // paise, always positive
+	Direction   string    `json:"direction"` // IN | OUT
+	Description string    `json:"description"`
+	Reference   string    `json:"reference,omitempty"`
+	Note        string    `json:"note,omitempty"` // free-text note carried over from app exports
+	ExternalID  string    `json:"external_id"`
+	Category    string    `json:"category,omitempty"` // expenses only
+	Duplicate   bool      `json:"duplicate"`          // external id already imported
+	Error       string    `json:"error,omitempty"`
+}
+
+type Batch struct {
+	ID            int64      `json:"id"`
+	UserID        string     `json:"user_id"`
+	Format        string     `json:"format"`
+	Preset        string     `json:"preset,omitempty"`
+	FileName      string     `json:"file_name"`
+	Status        string     `json:"status"`
+	TotalRows     int        `json:"total_rows"`
+	ErrorRows     int        `json:"error_rows"`
+	DuplicateRows int        `json:"duplicate_rows"`
+	Incomes       int        `json:"incomes_imported"`
+	Expenses      int        `json:"expenses_imported"`
+	CreatedAt     time.Time  `json:"created_at"`
+	CommittedAt   *time.Time `json:"committed_at,omitempty"`
+	RolledBackAt  *time.Time `json:"rolled_back_at,omitempty"`
+	Rows          []Row      `json:"rows,omitempty"`
+}
+
+type CommitRequest struct {
+	// lines the user unticked in the preview
+	SkipLines []int `json:"skip_lines"`
+}
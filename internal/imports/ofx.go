@@ -0,0 +1,90 @@
+package imports
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
+)
+
+var (
+	ofxTxnBlock = regexp.MustCompile(`(?is)<STMTTRN>(.*?)</STMTTRN>`)
+	// leaf elements; OFX 1.x SGML leaves them unclosed, so stop at the next tag or line break
+	ofxLeaf    = regexp.MustCompile(`(?i)<([A-Z0-9.]+)>([^<\r\n]*)`)
+	ofxAccount = regexp.MustCompile(`(?i)<ACCTID>([^<\r\n]*)`)
+)
+
+// parseOFX reads OFX 1.x (SGML) and 2.x (XML) bank and credit-card statements. QFX is OFX
+// with Intuit headers, so it goes through here too.
+func parseOFX(data []byte) ([]Row, error) {
+	text := string(data)
+	if !strings.Contains(strings.ToUpper(text), "<OFX>") {
+		return nil, errors.New("not an OFX file")
+	}
+
+	account := ""
+	if m := ofxAccount.FindStringSubmatch(text); m != nil {
+		account = strings.TrimSpace(m[1])
+	}
+
+	blocks := ofxTxnBlock.FindAllStringSubmatch(text, -1)
+	rows := make([]Row, 0, len(blocks))
+	for i, b := range blocks {
+		fields := map[string]string{}
+		for _, kv := range ofxLeaf.FindAllStringSubmatch(b[1], -1) {
+			fields[strings.ToUpper(kv[1])] = strings.TrimSpace(kv[2])
+		}
+
+		row := Row{
+			Line:        i + 1,
+			Description: collapseSpaces(strings.TrimSpace(fields["NAME"] + " " + fields["MEMO"])),
+			Reference:   firstNonEmpty(fields["CHECKNUM"], fields["REFNUM"]),
+		}
+		if fitid := fields["FITID"]; fitid != "" {
+			row.ExternalID = "ofx:" + account + ":" + fitid
+		}
+
+		d, err := parseOFXDate(fields["DTPOSTED"])
+		if err != nil {
+			row.Error = fmt.Sprintf("invalid DTPOSTED %q", fields["DTPOSTED"])
+			rows = append(rows, row)
+			continue
+		}
+		row.Date = d
+
+		amt, err := money.ParseRupees(fields["TRNAMT"])
+		if err != nil || amt == 0 {
+			row.Error = fmt.Sprintf("invalid TRNAMT %q", fields["TRNAMT"])
+			rows = append(rows, row)
+			continue
+		}
+		row.Amount = abs(amt)
+		row.Direction = "IN"
+		if amt < 0 {
+			row.Direction = "OUT"
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseOFXDate accepts YYYYMMDD with any time/zone suffix ("20240105120000.000[-5:EST]").
+func parseOFXDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 8 {
+		return time.Time{}, errors.New("short date")
+	}
+	return time.Parse("20060102", s[:8])
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,150 @@
+package imports
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/expense"
+)
+
+var ErrUnknownPreset = errors.New("unknown preset")
+
+type ParseOptions struct {
+	Format  string      // csv | ofx | camt053 | splitwise | money_manager | walnut; empty detects
+	Preset  string      // CSV only
+	Mapping *CSVMapping // CSV only; overrides Preset
+	Member  string      // Splitwise only; the user's own member column
+}
+
+// Parse turns a statement file into rows ready for preview. Every row gets a stable external id
+// (the bank's own id when the format has one) and expenses get a category.
+func Parse(data []byte, fileName string, opts ParseOptions) ([]Row, string, error) {
+	format := strings.ToLower(strings.TrimSpace(opts.Format))
+	if format == "" {
+		format = DetectFormat(data, fileName)
+	}
+
+	var (
+		rows []Row
+		err  error
+	)
+	switch format {
+	case FormatCSV:
+		m, mErr := csvMapping(opts)
+		if mErr != nil {
+			return nil, format, mErr
+		}
+		rows, err = parseCSV(data, m)
+	case FormatOFX, "qfx":
+		format = FormatOFX
+		rows, err = parseOFX(data)
+	case FormatCAMT, "camt":
+		format = FormatCAMT
+		rows, err = parseCAMT(data)
+	case FormatSplitwise, FormatMoneyManager, FormatWalnut:
+		rows, err = parseAppExport(data, format, opts)
+	default:
+		return nil, format, fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return nil, format, err
+	}
+
+	finalizeRows(rows, format)
+	return rows, format, nil
+}
+
+// DetectFormat guesses the format from the file extension, falling back to content. CSV files
+// whose header matches a known app export are treated as that app.
+func DetectFormat(data []byte, fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".ofx", ".qfx":
+		return FormatOFX
+	case ".xml":
+		return FormatCAMT
+	}
+	head := strings.ToUpper(string(data[:min(len(data), 4096)]))
+	switch {
+	case strings.Contains(head, "OFXHEADER") || strings.Contains(head, "<OFX>"):
+		return FormatOFX
+	case strings.Contains(head, "BKTOCSTMRSTMT"):
+		return FormatCAMT
+	}
+
+	firstLine, _, _ := strings.Cut(strings.TrimPrefix(head, "\ufeff"), "\n")
+	rec := strings.Split(strings.TrimSpace(firstLine), ",")
+	for _, name := range []string{FormatSplitwise, FormatMoneyManager, FormatWalnut} {
+		if appHeader(rec, appFormats[name].required) != nil {
+			return name
+		}
+	}
+	return FormatCSV
+}
+
+func csvMapping(opts ParseOptions) (CSVMapping, error) {
+	if opts.Mapping != nil {
+		m := *opts.Mapping
+		for _, list := range []*[]string{&m.Date, &m.Description, &m.Reference, &m.Debit, &m.Credit, &m.Amount, &m.DrCr} {
+			for i, h := range *list {
+				(*list)[i] = normalizeHeader(h)
+			}
+		}
+		return m, nil
+	}
+	name := strings.ToLower(strings.TrimSpace(opts.Preset))
+	if name == "" {
+		name = "generic"
+	}
+	m, ok := Presets[name]
+	if !ok {
+		return CSVMapping{}, fmt.Errorf("%w %q", ErrUnknownPreset, opts.Preset)
+	}
+	return m, nil
+}
+
+// finalizeRows fills external ids and categories. Rows without a bank id are keyed on their
+// content; identical lines in one file (two ₹20 chai on the same day) get an occurrence suffix
+// so both survive, and the same file imported twice produces the same ids.
+func finalizeRows(rows []Row, format string) {
+	seen := map[string]int{}
+	for i := range rows {
+		r := &rows[i]
+		if r.Error != "" {
+			continue
+		}
+		if r.ExternalID == "" {
+			base := strings.Join([]string{
+				r.Date.Format("2006-01-02"),
+				strconv.FormatInt(r.Amount, 10),
+				r.Direction,
+				strings.ToLower(r.Description),
+				r.Reference,
+			}, "|")
+			seen[base]++
+			sum := sha256.Sum256([]byte(base + "#" + strconv.Itoa(seen[base])))
+			r.ExternalID = format + ":" + hex.EncodeToString(sum[:16])
+		} else {
+			seen[r.ExternalID]++
+			if n := seen[r.ExternalID]; n > 1 {
+				r.ExternalID += "#" + strconv.Itoa(n)
+			}
+		}
+		if r.Direction == "OUT" && r.Category == "" {
+			r.Category = category(r.Description)
+		}
+	}
+}
+
+// category reuses the Expense Memory keyword rules; uncategorised spends keep the table default.
+func category(desc string) string {
+	c := expense.Categorize(desc)
+	if c == "MISC" {
+		return "General"
+	}
+	return c
+}
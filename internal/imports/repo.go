@@ -0,0 +1,273 @@
+package imports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrNotFound    = errors.New("import batch not found")
+	ErrWrongStatus = errors.New("import batch is not in the right state for this action")
+)
+
+type Repository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{Pool: pool}
+}
+
+// MarkDuplicates flags rows whose external id is already on an income or expense, including
+// soft-deleted ones so a deleted entry is not silently re-imported.
+func (r *Repository) MarkDuplicates(ctx context.Context, userID string, rows []Row) error {
+	ids := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.ExternalID != "" {
+			ids = append(ids, row.ExternalID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	dbRows, err := r.Pool.Query(ctx, `
+SELECT external_id FROM incomes WHERE user_id = $1 AND external_id = ANY($2)
+UNION
+SELECT external_id FROM expenses WHERE user_id = $1 AND external_id = ANY($2)
+`, userID, ids)
+	if err != nil {
+		return err
+	}
+	defer dbRows.Close()
+
+	existing := map[string]bool{}
+	for dbRows.Next() {
+		var id string
+		if err := dbRows.Scan(&id); err != nil {
+			return err
+		}
+		existing[id] = true
+	}
+	if err := dbRows.Err(); err != nil {
+		return err
+	}
+
+	for i := range rows {
+		rows[i].Duplicate = existing[rows[i].ExternalID]
+	}
+	return nil
+}
+
+// CreatePreview stores the parsed rows so commit imports exactly what the user reviewed.
+func (r *Repository) CreatePreview(ctx context.Context, b *Batch) error {
+	raw, err := json.Marshal(b.Rows)
+	if err != nil {
+		return err
+	}
+	b.Status = StatusPreview
+	b.TotalRows, b.ErrorRows, b.DuplicateRows = len(b.Rows), 0, 0
+	for _, row := range b.Rows {
+		if row.Error != "" {
+			b.ErrorRows++
+		} else if row.Duplicate {
+			b.DuplicateRows++
+		}
+	}
+
+	return r.Pool.QueryRow(ctx, `
+INSERT INTO import_batches (user_id, format, preset, file_name, status, total_rows, error_rows, duplicate_rows, rows)
+VALUES ($1, $2, NULLIF($3,''), $4, $5, $6, $7, $8, $9)
+RETURNING id, created_at
+`, b.UserID, b.Format, b.Preset, b.FileName, b.Status, b.TotalRows, b.ErrorRows, b.DuplicateRows, raw).Scan(&b.ID, &b.CreatedAt)
+}
+
+const batchColumns = `id, user_id::text, format, COALESCE(preset,''), file_name, status, total_rows, error_rows,
+  duplicate_rows, incomes_imported, expenses_imported, created_at, committed_at, rolled_back_at`
+
+func scanBatch(row pgx.Row, b *Batch, extra ...any) error {
+	dest := []any{&b.ID, &b.UserID, &b.Format, &b.Preset, &b.FileName, &b.Status, &b.TotalRows, &b.ErrorRows,
+		&b.DuplicateRows, &b.Incomes, &b.Expenses, &b.CreatedAt, &b.CommittedAt, &b.RolledBackAt}
+	return row.Scan(append(dest, extra...)...)
+}
+
+func (r *Repository) List(ctx context.Context, userID string) ([]Batch, error) {
+	rows, err := r.Pool.Query(ctx, `SELECT `+batchColumns+`
+FROM import_batches
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT 100`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Batch, 0)
+	for rows.Next() {
+		var b Batch
+		if err := scanBatch(rows, &b); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) Get(ctx context.Context, userID string, id int64) (*Batch, error) {
+	var b Batch
+	var raw []byte
+	err := scanBatch(r.Pool.QueryRow(ctx, `SELECT `+batchColumns+`, rows
+FROM import_batches
+WHERE id = $1 AND user_id = $2`, id, userID), &b, &raw)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &b.Rows); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Commit writes the batch's valid, non-duplicate rows as incomes and expenses in one
+// transaction. Imported rows earn no points and are not auto-allocated to goals: they are history.
+func (r *Repository) Commit(ctx context.Context, userID string, id int64, skip map[int]bool) (*Batch, error) {
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var b Batch
+	var raw []byte
+	err = scanBatch(tx.QueryRow(ctx, `SELECT `+batchColumns+`, rows
+FROM import_batches
+WHERE id = $1 AND user_id = $2
+FOR UPDATE`, id, userID), &b, &raw)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if b.Status != StatusPreview {
+		return nil, ErrWrongStatus
+	}
+	if err := json.Unmarshal(raw, &b.Rows); err != nil {
+		return nil, err
+	}
+
+	for _, row := range b.Rows {
+		if row.Error != "" || row.Duplicate || skip[row.Line] {
+			continue
+		}
+		// the unique external id index makes a concurrent import of the same file a no-op
+		if row.Direction == "IN" {
+			ct, err := tx.Exec(ctx, `
+INSERT INTO incomes (user_id, client_name, amount, currency, received_on, note, external_id, import_batch_id)
+VALUES ($1, $2, $3, 'INR', $4, NULLIF($5,''), $6, $7)
+ON CONFLICT (user_id, external_id) WHERE external_id IS NOT NULL DO NOTHING
+`, userID, title(row.Description, "Bank credit"), row.Amount, row.Date, row.noteText(), row.ExternalID, b.ID)
+			if err != nil {
+				return nil, err
+			}
+			b.Incomes += int(ct.RowsAffected())
+			continue
+		}
+		ct, err := tx.Exec(ctx, `
+INSERT INTO expenses (user_id, vendor_name, category, amount, currency, spent_on, note, external_id, import_batch_id)
+VALUES ($1, $2, $3, $4, 'INR', $5, NULLIF($6,''), $7, $8)
+ON CONFLICT (user_id, external_id) WHERE external_id IS NOT NULL DO NOTHING
+`, userID, title(row.Description, "Bank debit"), row.Category, row.Amount, row.Date, row.noteText(), row.ExternalID, b.ID)
+		if err != nil {
+			return nil, err
+		}
+		b.Expenses += int(ct.RowsAffected())
+	}
+
+	now := time.Now().UTC()
+	b.Status = StatusCommitted
+	b.CommittedAt = &now
+	if _, err := tx.Exec(ctx, `
+UPDATE import_batches
+SET status = $3, incomes_imported = $4, expenses_imported = $5, committed_at = $6
+WHERE id = $1 AND user_id = $2
+`, b.ID, userID, b.Status, b.Incomes, b.Expenses, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Rollback deletes everything a committed batch imported. Rows are hard-deleted so the same
+// statement can be imported again after fixing a mapping mistake.
+func (r *Repository) Rollback(ctx context.Context, userID string, id int64) (*Batch, error) {
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var b Batch
+	err = scanBatch(tx.QueryRow(ctx, `SELECT `+batchColumns+`
+FROM import_batches
+WHERE id = $1 AND user_id = $2
+FOR UPDATE`, id, userID), &b)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if b.Status != StatusCommitted {
+		return nil, ErrWrongStatus
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM incomes WHERE user_id = $1 AND import_batch_id = $2`, userID, b.ID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM expenses WHERE user_id = $1 AND import_batch_id = $2`, userID, b.ID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	b.Status = StatusRolledBack
+	b.RolledBackAt = &now
+	if _, err := tx.Exec(ctx, `
+UPDATE import_batches SET status = $3, rolled_back_at = $4
+WHERE id = $1 AND user_id = $2
+`, b.ID, userID, b.Status, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// noteText keeps the app note and the bank reference, whichever the source had.
+func (r Row) noteText() string {
+	return strings.TrimSpace(strings.Join([]string{r.Note, r.Reference}, " "))
+}
+
+// title fits a bank narration into the client/vendor name column.
+func title(desc, fallback string) string {
+	desc = collapseSpaces(desc)
+	if desc == "" {
+		return fallback
+	}
+	if r := []rune(desc); len(r) > 120 {
+		return string(r[:120])
+	}
+	return desc
+}
@@ -6,10 +6,15 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"log"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/businesses"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/duplicates"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/goals"
 )
 
 type Handler struct {
@@ -49,6 +54,16 @@ func (h *Handler) CreateIncome(c *fiber.Ctx) error {
 	}
 
 	ctx := userContext(c)
+	if req.BusinessID != nil {
+		ok, err := businesses.OwnedBy(ctx, h.Repo.Pool, *req.BusinessID, userID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "failed business lookup: "+err.Error())
+		}
+		if !ok {
+			return fiber.NewError(fiber.StatusNotFound, "business not found")
+		}
+	}
+
 	requestHash := ""
 	if idemKey != "" {
 		sum := sha256.Sum256(append([]byte(c.Method()+" "+c.Path()+" "), bodyBytes...))
@@ -77,6 +92,7 @@ func (h *Handler) CreateIncome(c *fiber.Ctx) error {
 		Currency:   "INR",
 		ReceivedOn: receivedOn,
 		Note:       req.Note,
+		BusinessID: req.BusinessID,
 	}
 
 	id, err := h.Repo.InsertIncome(ctx, inc)
@@ -86,11 +102,29 @@ func (h *Handler) CreateIncome(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, "failed to add income: "+err.Error())
 	}
 
-	resp := CreateIncomeResponse{
-		ID:      id,
-		Message: "income added",
+	// Move auto-allocation shares of this income into savings goals. The income is already
+	// saved, so a failure here must not fail the create: a retry would add it twice.
+	allocations, err := goals.AllocateIncome(ctx, h.Repo.Pool, userID, id, inc.Amount)
+	if err != nil {
+		log.Printf("income %s: allocate to goals: %v", id, err)
+		allocations = nil
 	}
 
+	resp := CreateIncomeResponse{
+		ID:              id,
+		Message:         "income added",
+		GoalAllocations: allocations,
+	}
+
+	resp.DuplicateWarning = duplicates.Warn(ctx, h.Repo.Pool, userID, duplicates.Record{
+		Kind:      duplicates.KindIncome,
+		ID:        id,
+		Direction: "IN",
+		Amount:    inc.Amount,
+		Date:      receivedOn,
+		Label:     duplicates.Label(inc.ClientName, inc.Note),
+	})
+
 	if idemKey != "" && requestHash != "" {
 		if buf, mErr := json.Marshal(resp); mErr == nil {
 			_, _ = h.Repo.Pool.Exec(
@@ -1,6 +1,11 @@
 package income
 
-import "time"
+import (
+	"time"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/duplicates"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/goals"
+)
 
 type Income struct {
 	ID         string    `db:"id" json:"id"`
@@ -10,6 +15,7 @@ type Income struct {
 	Currency   string    `db:"currency" json:"currency"`
 	ReceivedOn time.Time `db:"received_on" json:"received_on"`
 	Note       *string   `db:"note" json:"note,omitempty"`
+	BusinessID *int64    `db:"business_id" json:"business_id,omitempty"`
 	CreatedAt  time.Time `db:"created_at" json:"created_at"`
 }
 
@@ -18,9 +24,12 @@ type CreateIncomeRequest struct {
 	Amount     int64   `json:"amount"`
 	ReceivedOn string  `json:"received_on"`
 	Note       *string `json:"note"`
+	BusinessID *int64  `json:"business_id"`
 }
 
 type CreateIncomeResponse struct {
-	ID      string `json:"id"`
-	Message string `json:"message"`
+	ID               string              `json:"id"`
+	Message          string              `json:"message"`
+	GoalAllocations  []goals.Allocation  `json:"goal_allocations,omitempty"`
+	DuplicateWarning *duplicates.Warning `json:"duplicate_warning,omitempty"`
 }
@@ -18,8 +18,8 @@ func (r *Repository) InsertIncome(ctx context.Context, inc *Income) (string, err
 	var id string
 	err := r.Pool.QueryRow(
 		ctx,
-		`INSERT INTO incomes (user_id, client_name, amount, currency, received_on, note)
-         VALUES ($1, $2, $3, COALESCE($4, 'INR'), $5, $6)
+		`INSERT INTO incomes (user_id, client_name, amount, currency, received_on, note, business_id)
+         VALUES ($1, $2, $3, COALESCE($4, 'INR'), $5, $6, $7)
          RETURNING id`,
 		inc.UserID,
 		inc.ClientName,
@@ -27,6 +27,7 @@ func (r *Repository) InsertIncome(ctx context.Context, inc *Income) (string, err
 		inc.Currency,
 		inc.ReceivedOn,
 		inc.Note,
+		inc.BusinessID,
 	).Scan(&id)
 	if err != nil {
 		return "", err
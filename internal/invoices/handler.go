@@ -0,0 +1,203 @@
+package invoices
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/businesses"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/goals"
+)
+
+type Handler struct {
+	Repo *Repository
+}
+
+func NewHandler(repo *Repository) *Handler {
+	return &Handler{Repo: repo}
+}
+
+func getUserID(c *fiber.Ctx) string {
+	if v := c.Locals("user_id"); v != nil {
+		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+			return strings.TrimSpace(s)
+		}
+	}
+	return ""
+}
+
+func (h *Handler) List(c *fiber.Ctx) error {
+	userID := getUserID(c)
+	if userID == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	status := strings.ToUpper(strings.TrimSpace(c.Query("status")))
+	if status != "" && status != StatusOpen && status != StatusPaid && status != StatusVoid {
+		return fiber.NewError(fiber.StatusBadRequest, "status must be OPEN, PAID or VOID")
+	}
+
+	items, err := h.Repo.List(c.UserContext(), userID, status)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to list invoices: "+err.Error())
+	}
+	return c.JSON(items)
+}
+
+func (h *Handler) Create(c *fiber.Ctx) error {
+	userID := getUserID(c)
+	if userID == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	var req CreateInvoiceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+
+	req.ClientName = strings.TrimSpace(req.ClientName)
+	if req.ClientName == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "client_name required")
+	}
+	if req.Amount <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "amount must be greater than zero")
+	}
+
+	issuedOn := time.Now().UTC()
+	if strings.TrimSpace(req.IssuedOn) != "" {
+		d, err := time.Parse("2006-01-02", strings.TrimSpace(req.IssuedOn))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "issued_on must be YYYY-MM-DD")
+		}
+		issuedOn = d
+	}
+	dueOn, err := optionalDate(req.DueOn, "due_on")
+	if err != nil {
+		return err
+	}
+	expectedPayOn, err := optionalDate(req.ExpectedPayOn, "expected_pay_on")
+	if err != nil {
+		return err
+	}
+
+	ctx := c.UserContext()
+	if req.BusinessID != nil {
+		ok, err := businesses.OwnedBy(ctx, h.Repo.Pool, *req.BusinessID, userID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "failed business lookup: "+err.Error())
+		}
+		if !ok {
+			return fiber.NewError(fiber.StatusNotFound, "business not found")
+		}
+	}
+
+	inv, err := h.Repo.Create(ctx, Invoice{
+		UserID:        userID,
+		BusinessID:    req.BusinessID,
+		ClientName:    req.ClientName,
+		Amount:        req.Amount,
+		IssuedOn:      issuedOn,
+		DueOn:         dueOn,
+		ExpectedPayOn: expectedPayOn,
+		Note:          req.Note,
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to create invoice: "+err.Error())
+	}
+	return c.Status(fiber.StatusCreated).JSON(inv)
+}
+
+func (h *Handler) MarkPaid(c *fiber.Ctx) error {
+	userID := getUserID(c)
+	if userID == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	id, err := invoiceID(c)
+	if err != nil {
+		return err
+	}
+
+	var req MarkPaidRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+		}
+	}
+	paidOn := time.Now().UTC()
+	if strings.TrimSpace(req.PaidOn) != "" {
+		d, err := time.Parse("2006-01-02", strings.TrimSpace(req.PaidOn))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "paid_on must be YYYY-MM-DD")
+		}
+		paidOn = d
+	}
+
+	ctx := c.UserContext()
+	inv, err := h.Repo.MarkPaid(ctx, userID, id, paidOn)
+	if err != nil {
+		return invoiceError(err, "failed to mark invoice paid")
+	}
+
+	resp := fiber.Map{"invoice": inv}
+	if inv.IncomeID != nil {
+		// the invoice is already paid; failing now would leave a retry nothing to do
+		allocations, err := goals.AllocateIncome(ctx, h.Repo.Pool, userID, *inv.IncomeID, inv.Amount)
+		if err != nil {
+			log.Printf("invoice %d: allocate to goals: %v", inv.ID, err)
+		}
+		if len(allocations) > 0 {
+			resp["goal_allocations"] = allocations
+		}
+	}
+	return c.JSON(resp)
+}
+
+func (h *Handler) Void(c *fiber.Ctx) error {
+	userID := getUserID(c)
+	if userID == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	id, err := invoiceID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.Repo.Void(c.UserContext(), userID, id); err != nil {
+		return invoiceError(err, "failed to void invoice")
+	}
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+func optionalDate(raw string, field string) (*time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	d, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, field+" must be YYYY-MM-DD")
+	}
+	return &d, nil
+}
+
+func invoiceID(c *fiber.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimSpace(c.Params("id")), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "invalid invoice id")
+	}
+	return id, nil
+}
+
+func invoiceError(err error, msg string) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, "invoice not found")
+	case errors.Is(err, ErrNotOpen):
+		return fiber.NewError(fiber.StatusConflict, "invoice is not open")
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, msg+": "+err.Error())
+}
@@ -0,0 +1,54 @@
+package invoices
+
+import "time"
+
+const (
+	StatusOpen = "OPEN"
+	StatusPaid = "PAID"
+	StatusVoid = "VOID"
+)
+
+// DefaultPaymentTermDays is assumed when an invoice has neither an expected pay date nor a due date.
+const DefaultPaymentTermDays = 30
+
+type Invoice struct {
+	ID            int64      `json:"id"`
+	UserID        string     `json:"user_id"`
+	BusinessID    *int64     `json:"business_id,omitempty"`
+	ClientName    string     `json:"client_name"`
+	Amount        int64      `json:"amount"` // paise
+	Currency      string     `json:"currency"`
+	IssuedOn      time.Time  `json:"issued_on"`
+	DueOn         *time.Time `json:"due_on,omitempty"`
+	ExpectedPayOn *time.Time `json:"expected_pay_on,omitempty"`
+	Status        string     `json:"status"` // OPEN | PAID | VOID
+	PaidOn        *time.Time `json:"paid_on,omitempty"`
+	IncomeID      *string    `json:"income_id,omitempty"`
+	Note          *string    `json:"note,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ExpectedDate is when the money is expected to land: expected_pay_on, else due_on, else issue date plus default terms.
+func (inv Invoice) ExpectedDate() time.Time {
+	if inv.ExpectedPayOn != nil {
+		return *inv.ExpectedPayOn
+	}
+	if inv.DueOn != nil {
+		return *inv.DueOn
+	}
+	return inv.IssuedOn.AddDate(0, 0, DefaultPaymentTermDays)
+}
+
+type CreateInvoiceRequest struct {
+	BusinessID    *int64  `json:"business_id"`
+	ClientName    string  `json:"client_name"`
+	Amount        int64   `json:"amount"`
+	IssuedOn      string  `json:"issued_on"`       // YYYY-MM-DD, defaults to today
+	DueOn         string  `json:"due_on"`          // YYYY-MM-DD, optional
+	ExpectedPayOn string  `json:"expected_pay_on"` // YYYY-MM-DD, optional
+	Note          *string `json:"note"`
+}
+
+type MarkPaidRequest struct {
+	PaidOn string `json:"paid_on"` // YYYY-MM-DD, defaults to today
+}
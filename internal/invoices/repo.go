@@ -0,0 +1,143 @@
+package invoices
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrNotFound = errors.New("invoice not found")
+	ErrNotOpen  = errors.New("invoice is not open")
+)
+
+type Repository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{Pool: pool}
+}
+
+const invoiceColumns = `id, user_id::text, business_id, client_name, amount, currency, issued_on, due_on,
+       expected_pay_on, status, paid_on, income_id, note, created_at`
+
+func scanInvoice(row pgx.Row) (Invoice, error) {
+	var inv Invoice
+	err := row.Scan(
+		&inv.ID,
+		&inv.UserID,
+		&inv.BusinessID,
+		&inv.ClientName,
+		&inv.Amount,
+		&inv.Currency,
+		&inv.IssuedOn,
+		&inv.DueOn,
+		&inv.ExpectedPayOn,
+		&inv.Status,
+		&inv.PaidOn,
+		&inv.IncomeID,
+		&inv.Note,
+		&inv.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Invoice{}, ErrNotFound
+	}
+	return inv, err
+}
+
+func (r *Repository) Create(ctx context.Context, inv Invoice) (Invoice, error) {
+	row := r.Pool.QueryRow(ctx, `
+INSERT INTO invoices (user_id, business_id, client_name, amount, issued_on, due_on, expected_pay_on, note)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING `+invoiceColumns,
+		inv.UserID, inv.BusinessID, inv.ClientName, inv.Amount, inv.IssuedOn, inv.DueOn, inv.ExpectedPayOn, inv.Note,
+	)
+	return scanInvoice(row)
+}
+
+// List returns the user's invoices, optionally filtered by status.
+func (r *Repository) List(ctx context.Context, userID string, status string) ([]Invoice, error) {
+	rows, err := r.Pool.Query(ctx, `
+SELECT `+invoiceColumns+`
+FROM invoices
+WHERE user_id = $1
+  AND ($2 = '' OR status = $2)
+ORDER BY issued_on DESC, created_at DESC
+LIMIT 500
+`, userID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Invoice, 0)
+	for rows.Next() {
+		inv, err := scanInvoice(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, inv)
+	}
+	return out, rows.Err()
+}
+
+// MarkPaid closes an open invoice and books the matching income, under the invoice's business,
+// in the same transaction.
+func (r *Repository) MarkPaid(ctx context.Context, userID string, id int64, paidOn time.Time) (Invoice, error) {
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return Invoice{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	inv, err := scanInvoice(tx.QueryRow(ctx, `SELECT `+invoiceColumns+` FROM invoices WHERE id = $1 AND user_id = $2 FOR UPDATE`, id, userID))
+	if err != nil {
+		return Invoice{}, err
+	}
+	if inv.Status != StatusOpen {
+		return Invoice{}, ErrNotOpen
+	}
+
+	note := "Invoice #" + strconv.FormatInt(inv.ID, 10)
+	var incomeID string
+	err = tx.QueryRow(ctx, `
+INSERT INTO incomes (user_id, business_id, client_name, amount, currency, received_on, note)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id
+`, userID, inv.BusinessID, inv.ClientName, inv.Amount, inv.Currency, paidOn, note).Scan(&incomeID)
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	inv, err = scanInvoice(tx.QueryRow(ctx, `
+UPDATE invoices
+SET status = 'PAID', paid_on = $3, income_id = $4
+WHERE id = $1 AND user_id = $2
+RETURNING `+invoiceColumns,
+		id, userID, paidOn, incomeID,
+	))
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Invoice{}, err
+	}
+	return inv, nil
+}
+
+func (r *Repository) Void(ctx context.Context, userID string, id int64) error {
+	ct, err := r.Pool.Exec(ctx, `UPDATE invoices SET status = 'VOID' WHERE id = $1 AND user_id = $2 AND status = 'OPEN'`, id, userID)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrNotOpen
+	}
+	return nil
+}
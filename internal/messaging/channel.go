@@ -0,0 +1,121 @@
+// Package messaging puts the chat transports (WhatsApp and SMS over Twilio, Telegram) behind one
+// Channel interface, and remembers which one each phone number prefers.
+package messaging
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Channel names, as stored in preferences and queued messages.
+const (
+	ChannelWhatsApp = "whatsapp"
+	ChannelSMS      = "sms"
+	ChannelTelegram = "telegram"
+	ChannelFake     = "fake"
+)
+
+var (
+	// ErrDuplicate is returned by VerifyWebhook for a message that was already handled.
+	ErrDuplicate      = errors.New("duplicate inbound message")
+	ErrUnknownChannel = errors.New("unknown channel")
+	// ErrNotLinked means the phone has no address on the channel yet (Telegram before the user
+	// shares their number with the bot).
+	ErrNotLinked = errors.New("phone is not linked to this channel")
+)
+
+// Inbound is one message a user sent us.
+type Inbound struct {
+	Channel string
+	// Address is who to answer on the channel: a phone number, or a Telegram chat id.
+	Address string
+	// Phone is the sender's phone number when the channel vouches for it: always on WhatsApp and
+	// SMS, on Telegram only in a contact the user shared of themselves.
+	Phone     string
+	Text      string
+	MessageID string
+	// MediaURLs reference attached files, for the channel's FetchMedia.
+	MediaURLs []string
+}
+
+// Sent is the provider's answer to a send.
+type Sent struct {
+	ID     string
+	Status string
+}
+
+// Channel is one chat transport: sending, and reading its inbound webhook.
+type Channel interface {
+	Name() string
+	SendText(ctx context.Context, to, body string) (Sent, error)
+	// SendDocument sends a file the provider fetches from url; channels without attachments send
+	// the link instead.
+	SendDocument(ctx context.Context, to, caption, url string) (Sent, error)
+	// VerifyWebhook authenticates an inbound webhook request; ErrDuplicate means it was handled
+	// before and should only be acknowledged.
+	VerifyWebhook(c *fiber.Ctx) error
+	// ParseInbound reads the message out of a verified webhook request. ok is false for updates
+	// that aren't user messages.
+	ParseInbound(c *fiber.Ctx) (in Inbound, ok bool, err error)
+}
+
+// Replier is implemented by channels that answer in the webhook response itself (Twilio's
+// TwiML) rather than with a separate send.
+type Replier interface {
+	Reply(c *fiber.Ctx, in Inbound, text, mediaURL string) error
+}
+
+// Forgetter is implemented by channels whose VerifyWebhook records the message as handled; the
+// webhook calls ForgetWebhook when handling fails, so the provider's retry isn't dropped as a
+// duplicate.
+type Forgetter interface {
+	ForgetWebhook(c *fiber.Ctx)
+}
+
+// PhoneRequester is implemented by channels that can ask the user to share their phone number.
+type PhoneRequester interface {
+	RequestPhone(ctx context.Context, to, prompt string) error
+}
+
+// MediaFetcher is implemented by channels that can download the media of an inbound message;
+// ref is one of Inbound.MediaURLs.
+type MediaFetcher interface {
+	FetchMedia(ctx context.Context, ref string) (data []byte, contentType string, err error)
+}
+
+// Registry holds the configured channels by name.
+type Registry struct {
+	channels map[string]Channel
+}
+
+func NewRegistry(channels ...Channel) *Registry {
+	r := &Registry{channels: map[string]Channel{}}
+	for _, ch := range channels {
+		if ch != nil {
+			r.channels[ch.Name()] = ch
+		}
+	}
+	return r
+}
+
+func (r *Registry) Get(name string) (Channel, error) {
+	if r != nil {
+		if ch, ok := r.channels[name]; ok {
+			return ch, nil
+		}
+	}
+	return nil, ErrUnknownChannel
+}
+
+// Names lists the configured channels in name order.
+func (r *Registry) Names() []string {
+	out := make([]string, 0, len(r.channels))
+	for n := range r.channels {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
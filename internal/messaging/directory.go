@@ -0,0 +1,98 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// Directory maps phone numbers to channel addresses and remembers each phone's preferred
+// channel. WhatsApp and SMS address a user by phone, so only other channels need a link.
+type Directory struct {
+	DB *sql.DB
+}
+
+// byPhone reports whether channel addresses users by their phone number.
+func byPhone(channel string) bool {
+	return channel == ChannelWhatsApp || channel == ChannelSMS || channel == ChannelFake
+}
+
+// PhoneFor returns the phone behind address on channel, or ErrNotLinked.
+func (d *Directory) PhoneFor(ctx context.Context, channel, address string) (string, error) {
+	if byPhone(channel) {
+		return address, nil
+	}
+	var phone string
+	err := d.DB.QueryRowContext(ctx, `
+		SELECT phone FROM messaging_links WHERE channel = $1 AND address = $2;
+	`, channel, address).Scan(&phone)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotLinked
+	}
+	return phone, err
+}
+
+// AddressFor returns where to reach phone on channel, or ErrNotLinked.
+func (d *Directory) AddressFor(ctx context.Context, channel, phone string) (string, error) {
+	if byPhone(channel) {
+		return phone, nil
+	}
+	var address string
+	err := d.DB.QueryRowContext(ctx, `
+		SELECT address FROM messaging_links WHERE channel = $1 AND phone = $2;
+	`, channel, phone).Scan(&address)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotLinked
+	}
+	return address, err
+}
+
+// Link ties address on channel to phone, replacing whatever either was linked to before.
+func (d *Directory) Link(ctx context.Context, channel, address, phone string) error {
+	if byPhone(channel) {
+		return nil
+	}
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM messaging_links WHERE channel = $1 AND (address = $2 OR phone = $3);
+	`, channel, address, phone); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO messaging_links (channel, address, phone) VALUES ($1, $2, $3);
+	`, channel, address, phone); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Preferred returns the channel phone wants proactive messages on; WhatsApp by default.
+func (d *Directory) Preferred(ctx context.Context, phone string) (string, error) {
+	var channel string
+	err := d.DB.QueryRowContext(ctx, `
+		SELECT channel FROM messaging_preferences WHERE phone = $1;
+	`, strings.TrimSpace(phone)).Scan(&channel)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ChannelWhatsApp, nil
+	}
+	return channel, err
+}
+
+// SetPreferred switches phone's preferred channel; a linked channel must be linked first.
+func (d *Directory) SetPreferred(ctx context.Context, phone, channel string) error {
+	if _, err := d.AddressFor(ctx, channel, phone); err != nil {
+		return err
+	}
+	_, err := d.DB.ExecContext(ctx, `
+		INSERT INTO messaging_preferences (phone, channel)
+		VALUES ($1, $2)
+		ON CONFLICT (phone) DO UPDATE SET channel = EXCLUDED.channel, updated_at = now();
+	`, phone, channel)
+	return err
+}
@@ -0,0 +1,77 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Recorded is one message a Recorder was asked to send.
+type Recorded struct {
+	To       string `json:"to"`
+	Text     string `json:"text"`
+	MediaURL string `json:"media_url,omitempty"`
+}
+
+// Recorder is a channel that sends nothing and keeps what it was asked to send, for local
+// development without provider accounts. Its webhook takes {"from": "+91...", "text": "..."}
+// unauthenticated, so it is only registered with ENV=dev.
+type Recorder struct {
+	mu   sync.Mutex
+	sent []Recorded
+}
+
+func (r *Recorder) Name() string { return ChannelFake }
+
+func (r *Recorder) SendText(ctx context.Context, to, body string) (Sent, error) {
+	return r.record(Recorded{To: to, Text: body}), nil
+}
+
+func (r *Recorder) SendDocument(ctx context.Context, to, caption, url string) (Sent, error) {
+	return r.record(Recorded{To: to, Text: caption, MediaURL: url}), nil
+}
+
+func (r *Recorder) record(m Recorded) Sent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, m)
+	return Sent{ID: "fake-" + strconv.Itoa(len(r.sent)), Status: "delivered"}
+}
+
+// Sent returns a copy of everything sent so far, oldest first.
+func (r *Recorder) Sent() []Recorded {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Recorded(nil), r.sent...)
+}
+
+func (r *Recorder) VerifyWebhook(c *fiber.Ctx) error { return nil }
+
+func (r *Recorder) ParseInbound(c *fiber.Ctx) (Inbound, bool, error) {
+	var body struct {
+		From string `json:"from"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(c.Body(), &body); err != nil {
+		return Inbound{}, false, err
+	}
+	from := strings.TrimSpace(body.From)
+	if from == "" {
+		return Inbound{}, false, fiber.NewError(fiber.StatusBadRequest, "missing from")
+	}
+	return Inbound{Channel: ChannelFake, Address: from, Phone: from, Text: body.Text}, true, nil
+}
+
+// Reply records the answer and returns it in the webhook response, so a curl shows it.
+func (r *Recorder) Reply(c *fiber.Ctx, in Inbound, text, mediaURL string) error {
+	if text == "" && mediaURL == "" {
+		return c.SendStatus(fiber.StatusOK)
+	}
+	m := Recorded{To: in.Address, Text: text, MediaURL: mediaURL}
+	r.record(m)
+	return c.JSON(m)
+}
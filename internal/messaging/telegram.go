@@ -0,0 +1,316 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/whatsapp"
+)
+
+// Telegram is a channel over the Telegram Bot API. Telegram addresses users by chat id and
+// never tells us their phone unless they share their own contact, so chats are linked to phones
+// through the Directory.
+type Telegram struct {
+	Token string
+	// WebhookSecret is the secret_token given to setWebhook; Telegram echoes it in the
+	// X-Telegram-Bot-Api-Secret-Token header.
+	WebhookSecret string
+	// Required rejects every webhook while WebhookSecret is unset instead of letting them through.
+	Required bool
+	// DB records update ids; nil disables duplicate detection.
+	DB      *sql.DB
+	APIBase string
+	HTTP    *http.Client
+}
+
+// NewTelegramFromEnv reads TELEGRAM_BOT_TOKEN and TELEGRAM_WEBHOOK_SECRET; it returns nil
+// while the token is unset. The secret is required unless ENV=dev.
+func NewTelegramFromEnv(db *sql.DB) *Telegram {
+	token := strings.TrimSpace(os.Getenv("TELEGRAM_BOT_TOKEN"))
+	if token == "" {
+		return nil
+	}
+	return &Telegram{
+		Token:         token,
+		WebhookSecret: strings.TrimSpace(os.Getenv("TELEGRAM_WEBHOOK_SECRET")),
+		Required:      !strings.EqualFold(strings.TrimSpace(os.Getenv("ENV")), "dev"),
+		DB:            db,
+		APIBase:       "https://api.telegram.org",
+		HTTP:          &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (t *Telegram) Name() string { return ChannelTelegram }
+
+type telegramKeyboard struct {
+	Keyboard        [][]telegramButton `json:"keyboard"`
+	OneTimeKeyboard bool               `json:"one_time_keyboard"`
+	ResizeKeyboard  bool               `json:"resize_keyboard"`
+}
+
+type telegramButton struct {
+	Text           string `json:"text"`
+	RequestContact bool   `json:"request_contact,omitempty"`
+}
+
+func (t *Telegram) SendText(ctx context.Context, to, body string) (Sent, error) {
+	return t.call(ctx, to, "sendMessage", map[string]any{"chat_id": to, "text": body})
+}
+
+// SendDocument has Telegram fetch url itself, which works for PDFs up to 20 MB.
+func (t *Telegram) SendDocument(ctx context.Context, to, caption, url string) (Sent, error) {
+	return t.call(ctx, to, "sendDocument", map[string]any{"chat_id": to, "document": url, "caption": caption})
+}
+
+// RequestPhone shows a one-tap button that shares the user's own contact with the bot.
+func (t *Telegram) RequestPhone(ctx context.Context, to, prompt string) error {
+	_, err := t.call(ctx, to, "sendMessage", map[string]any{
+		"chat_id": to,
+		"text":    prompt,
+		"reply_markup": telegramKeyboard{
+			Keyboard:        [][]telegramButton{{{Text: "Share my number", RequestContact: true}}},
+			OneTimeKeyboard: true,
+			ResizeKeyboard:  true,
+		},
+	})
+	return err
+}
+
+// call posts a Bot API method. Message ids are only unique within a chat, so the sent id is
+// "chat:message".
+func (t *Telegram) call(ctx context.Context, chat, method string, payload map[string]any) (Sent, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Sent{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.APIBase+"/bot"+t.Token+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return Sent{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := t.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return Sent{}, err
+	}
+	defer res.Body.Close()
+
+	raw, _ := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	var out struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+		Result      struct {
+			MessageID int64 `json:"message_id"`
+		} `json:"result"`
+	}
+	_ = json.Unmarshal(raw, &out)
+	if res.StatusCode >= 300 || !out.OK {
+		return Sent{}, &telegramError{Status: res.StatusCode, Description: out.Description}
+	}
+	return Sent{ID: chat + ":" + strconv.FormatInt(out.Result.MessageID, 10), Status: "sent"}, nil
+}
+
+type telegramError struct {
+	Status      int
+	Description string
+}
+
+func (e *telegramError) Error() string {
+	return fmt.Sprintf("telegram send failed: %d %s", e.Status, e.Description)
+}
+
+// Retryable reports whether trying again later may succeed: flood limits and Telegram-side errors.
+func (e *telegramError) Retryable() bool {
+	return e.Status == http.StatusTooManyRequests || e.Status >= 500
+}
+
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		MessageID int64 `json:"message_id"`
+		From      *struct {
+			ID int64 `json:"id"`
+		} `json:"from"`
+		Chat struct {
+			ID   int64  `json:"id"`
+			Type string `json:"type"`
+		} `json:"chat"`
+		Text    string `json:"text"`
+		Caption string `json:"caption"`
+		Photo   []struct {
+			FileID string `json:"file_id"`
+		} `json:"photo"`
+		Document *struct {
+			FileID   string `json:"file_id"`
+			MimeType string `json:"mime_type"`
+		} `json:"document"`
+		Contact *struct {
+			PhoneNumber string `json:"phone_number"`
+			UserID      int64  `json:"user_id"`
+		} `json:"contact"`
+	} `json:"message"`
+}
+
+func (t *Telegram) VerifyWebhook(c *fiber.Ctx) error {
+	if t.WebhookSecret == "" {
+		if t.Required {
+			return fiber.NewError(fiber.StatusForbidden, "webhook validation is not configured")
+		}
+	} else if subtle.ConstantTimeCompare([]byte(c.Get("X-Telegram-Bot-Api-Secret-Token")), []byte(t.WebhookSecret)) != 1 {
+		return fiber.NewError(fiber.StatusForbidden, "invalid secret token")
+	}
+
+	if t.DB == nil {
+		return nil
+	}
+	var u telegramUpdate
+	if err := json.Unmarshal(c.Body(), &u); err != nil || u.UpdateID == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "bad update")
+	}
+	res, err := t.DB.ExecContext(c.UserContext(), `
+		INSERT INTO telegram_updates (update_id) VALUES ($1)
+		ON CONFLICT (update_id) DO NOTHING;
+	`, u.UpdateID)
+	if err != nil {
+		log.Printf("[telegram] record update %d: %v", u.UpdateID, err)
+		return fiber.NewError(fiber.StatusInternalServerError, "server error")
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrDuplicate
+	}
+	c.Locals(updateLocal, u.UpdateID)
+	return nil
+}
+
+// updateLocal is the fiber.Ctx local VerifyWebhook leaves the recorded update id in.
+const updateLocal = "telegram_update_id"
+
+// ForgetWebhook removes the update id VerifyWebhook recorded, so Telegram's redelivery of an
+// update whose handling failed goes through.
+func (t *Telegram) ForgetWebhook(c *fiber.Ctx) {
+	id, _ := c.Locals(updateLocal).(int64)
+	if t.DB == nil || id == 0 {
+		return
+	}
+	if _, err := t.DB.ExecContext(context.Background(), `
+		DELETE FROM telegram_updates WHERE update_id = $1;
+	`, id); err != nil {
+		log.Printf("[telegram] forget update %d: %v", id, err)
+	}
+}
+
+// ParseInbound accepts messages in private chats. A shared contact counts as the sender's phone
+// only when it is their own; anyone can forward someone else's contact card.
+func (t *Telegram) ParseInbound(c *fiber.Ctx) (Inbound, bool, error) {
+	var u telegramUpdate
+	if err := json.Unmarshal(c.Body(), &u); err != nil {
+		return Inbound{}, false, err
+	}
+	m := u.Message
+	if m == nil || m.From == nil || m.Chat.Type != "private" {
+		return Inbound{}, false, nil
+	}
+	in := Inbound{
+		Channel:   ChannelTelegram,
+		Address:   strconv.FormatInt(m.Chat.ID, 10),
+		Text:      m.Text,
+		MessageID: strconv.FormatInt(m.MessageID, 10),
+	}
+	if in.Text == "" {
+		in.Text = m.Caption
+	}
+	// photos come in several sizes, smallest first
+	if len(m.Photo) > 0 {
+		in.MediaURLs = []string{m.Photo[len(m.Photo)-1].FileID}
+	} else if m.Document != nil && strings.HasPrefix(m.Document.MimeType, "image/") {
+		in.MediaURLs = []string{m.Document.FileID}
+	}
+	if m.Contact != nil && m.Contact.UserID == m.From.ID {
+		in.Phone = normalizePhone(m.Contact.PhoneNumber)
+	}
+	return in, true, nil
+}
+
+// normalizePhone turns Telegram's "919876543210" into the "+919876543210" used everywhere else.
+func normalizePhone(p string) string {
+	p = strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, p)
+	if p == "" {
+		return ""
+	}
+	return "+" + p
+}
+
+// FetchMedia downloads a file by its file_id; getFile resolves it to a path that stays valid
+// for an hour.
+func (t *Telegram) FetchMedia(ctx context.Context, fileID string) ([]byte, string, error) {
+	body, _ := json.Marshal(map[string]string{"file_id": fileID})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.APIBase+"/bot"+t.Token+"/getFile", bytes.NewReader(body))
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := t.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	var out struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+		Result      struct {
+			FilePath string `json:"file_path"`
+		} `json:"result"`
+	}
+	err = json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&out)
+	res.Body.Close()
+	if err != nil || !out.OK || out.Result.FilePath == "" {
+		return nil, "", &telegramError{Status: res.StatusCode, Description: out.Description}
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, t.APIBase+"/file/bot"+t.Token+"/"+out.Result.FilePath, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	res, err = client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return nil, "", &telegramError{Status: res.StatusCode, Description: "file download failed"}
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, whatsapp.MaxMediaBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > whatsapp.MaxMediaBytes {
+		return nil, "", errors.New("media is too large")
+	}
+	return data, http.DetectContentType(data), nil
+}
@@ -0,0 +1,129 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/whatsapp"
+)
+
+// Twilio is a channel over Twilio's Messages API: WhatsApp, or plain SMS when From is an SMS
+// number. Both share the account, the webhook signature and the status callback.
+type Twilio struct {
+	Client *whatsapp.TwilioClient
+	Guard  *whatsapp.WebhookGuard
+	// From is the sending address: "whatsapp:+1..." or an SMS number like "+1...".
+	From string
+	// StatusCallback is where Twilio reports delivery; "" asks for no callbacks.
+	StatusCallback string
+
+	name string
+}
+
+// NewTwilioWhatsApp sends from TWILIO_WHATSAPP_FROM.
+func NewTwilioWhatsApp(client *whatsapp.TwilioClient, guard *whatsapp.WebhookGuard) *Twilio {
+	return &Twilio{Client: client, Guard: guard, From: client.FromWA, StatusCallback: statusCallbackFromEnv(), name: ChannelWhatsApp}
+}
+
+// NewTwilioSMS sends from TWILIO_SMS_FROM; it returns nil while that is unset.
+func NewTwilioSMS(client *whatsapp.TwilioClient, guard *whatsapp.WebhookGuard) *Twilio {
+	from := strings.TrimSpace(os.Getenv("TWILIO_SMS_FROM"))
+	if from == "" {
+		return nil
+	}
+	return &Twilio{Client: client, Guard: guard, From: from, StatusCallback: statusCallbackFromEnv(), name: ChannelSMS}
+}
+
+func statusCallbackFromEnv() string {
+	if base := strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/"); base != "" {
+		return base + "/v1/whatsapp/status"
+	}
+	return ""
+}
+
+func (t *Twilio) Name() string { return t.name }
+
+// address puts phone in the form Twilio expects on this channel.
+func (t *Twilio) address(phone string) string {
+	phone = strings.TrimPrefix(phone, "whatsapp:")
+	if t.name == ChannelWhatsApp {
+		return "whatsapp:" + phone
+	}
+	return phone
+}
+
+func (t *Twilio) SendText(ctx context.Context, to, body string) (Sent, error) {
+	return t.SendFrom(ctx, "", to, body, "")
+}
+
+// SendDocument attaches url on WhatsApp; an SMS carries the link after the caption.
+func (t *Twilio) SendDocument(ctx context.Context, to, caption, url string) (Sent, error) {
+	if t.name == ChannelSMS {
+		return t.SendFrom(ctx, "", to, strings.TrimSpace(caption+"\n"+url), "")
+	}
+	return t.SendFrom(ctx, "", to, caption, url)
+}
+
+// SendFrom sends from another of the account's numbers; "" is From.
+func (t *Twilio) SendFrom(ctx context.Context, from, to, body, mediaURL string) (Sent, error) {
+	if from == "" {
+		from = t.From
+	}
+	sent, err := t.Client.Send(ctx, t.address(from), t.address(to), body, mediaURL, t.StatusCallback)
+	return Sent{ID: sent.SID, Status: sent.Status}, err
+}
+
+func (t *Twilio) VerifyWebhook(c *fiber.Ctx) error {
+	err := t.Guard.Check(c)
+	if errors.Is(err, whatsapp.ErrReplayed) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+func (t *Twilio) ForgetWebhook(c *fiber.Ctx) {
+	t.Guard.Forget(c)
+}
+
+func (t *Twilio) ParseInbound(c *fiber.Ctx) (Inbound, bool, error) {
+	form, err := url.ParseQuery(string(c.Body()))
+	if err != nil {
+		return Inbound{}, false, err
+	}
+	from := strings.TrimSpace(strings.TrimPrefix(form.Get("From"), "whatsapp:"))
+	if from == "" {
+		return Inbound{}, false, errors.New("missing From")
+	}
+	in := Inbound{
+		Channel:   t.name,
+		Address:   from,
+		Phone:     from,
+		Text:      form.Get("Body"),
+		MessageID: form.Get("MessageSid"),
+	}
+	n, _ := strconv.Atoi(form.Get("NumMedia"))
+	for i := 0; i < n; i++ {
+		if u := form.Get("MediaUrl" + strconv.Itoa(i)); u != "" {
+			in.MediaURLs = append(in.MediaURLs, u)
+		}
+	}
+	return in, true, nil
+}
+
+// Reply answers in the webhook response as TwiML.
+func (t *Twilio) Reply(c *fiber.Ctx, in Inbound, text, mediaURL string) error {
+	if t.name == ChannelSMS && mediaURL != "" {
+		text, mediaURL = strings.TrimSpace(text+"\n"+mediaURL), ""
+	}
+	return whatsapp.SendTwiML(c, text, mediaURL)
+}
+
+func (t *Twilio) FetchMedia(ctx context.Context, ref string) ([]byte, string, error) {
+	return t.Client.FetchMedia(ctx, ref)
+}
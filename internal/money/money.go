@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 )
 
 var (
@@ -42,3 +44,73 @@ func PaiseToRupeesString(paise int64) string {
 	ps := paise % 100
 	return fmt.Sprintf("%s%d.%02d", sign, rs, ps)
 }
+
+// FormatINR renders paise with Indian digit grouping: 123456789 -> "12,34,567.89".
+func FormatINR(paise int64) string {
+	s := PaiseToRupeesString(paise)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	if len(whole) <= 3 {
+		return sign + whole + "." + frac
+	}
+	head, tail := whole[:len(whole)-3], whole[len(whole)-3:]
+	var groups []string
+	for len(head) > 2 {
+		groups = append([]string{head[len(head)-2:]}, groups...)
+		head = head[:len(head)-2]
+	}
+	groups = append([]string{head}, groups...)
+	return sign + strings.Join(groups, ",") + "," + tail + "." + frac
+}
+
+// ParseRupees parses a decimal rupee string exactly (no float) into paise.
+// Accepts grouping commas (Indian or western), a leading ₹/Rs/INR and at most two decimals.
+// "(123.45)" and "-123.45" come back negative.
+func ParseRupees(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	neg := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		neg = true
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+	for _, p := range []string{"₹", "INR", "Rs.", "Rs", "rs.", "rs"} {
+		s = strings.TrimSpace(strings.TrimPrefix(s, p))
+	}
+	if strings.HasPrefix(s, "-") {
+		neg = !neg
+		s = s[1:]
+	} else if strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+	s = strings.ReplaceAll(strings.ReplaceAll(s, ",", ""), " ", "")
+	if s == "" {
+		return 0, ErrInvalidMoney
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" {
+		whole = "0"
+	}
+	if len(frac) > 2 || len(whole) > 16 {
+		return 0, ErrInvalidMoney
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+	rs, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidMoney
+	}
+	ps, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidMoney
+	}
+	paise := rs*100 + ps
+	if neg {
+		paise = -paise
+	}
+	return paise, nil
+}
@@ -0,0 +1,86 @@
+// Package ocr reads text out of receipt photos and picks the total, merchant and date from it.
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Engine turns an image into text.
+type Engine interface {
+	Text(ctx context.Context, image []byte) (string, error)
+}
+
+// FromEnv picks the engine from OCR_ENGINE: "tesseract" (the default when the binary is on
+// PATH, or at TESSERACT_PATH) or "none".
+func FromEnv() (Engine, error) {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("OCR_ENGINE"))) {
+	case "":
+		t := NewTesseractFromEnv()
+		if _, err := exec.LookPath(t.Path); err != nil {
+			return Noop{}, nil
+		}
+		return t, nil
+	case "tesseract":
+		t := NewTesseractFromEnv()
+		if _, err := exec.LookPath(t.Path); err != nil {
+			return nil, fmt.Errorf("tesseract: %w", err)
+		}
+		return t, nil
+	case "none":
+		return Noop{}, nil
+	}
+	return nil, errors.New("OCR_ENGINE must be tesseract or none")
+}
+
+// Tesseract runs the tesseract command line, which must have the Lang traineddata installed.
+type Tesseract struct {
+	Path    string
+	Lang    string
+	Timeout time.Duration
+}
+
+// NewTesseractFromEnv reads TESSERACT_PATH (default "tesseract") and TESSERACT_LANG (default
+// "eng").
+func NewTesseractFromEnv() *Tesseract {
+	t := &Tesseract{
+		Path:    strings.TrimSpace(os.Getenv("TESSERACT_PATH")),
+		Lang:    strings.TrimSpace(os.Getenv("TESSERACT_LANG")),
+		Timeout: 10 * time.Second,
+	}
+	if t.Path == "" {
+		t.Path = "tesseract"
+	}
+	if t.Lang == "" {
+		t.Lang = "eng"
+	}
+	return t
+}
+
+func (t *Tesseract) Text(ctx context.Context, image []byte) (string, error) {
+	if t.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.Timeout)
+		defer cancel()
+	}
+	// psm 4: a single column of text of variable sizes, which is what a receipt is
+	cmd := exec.CommandContext(ctx, t.Path, "stdin", "stdout", "-l", t.Lang, "--psm", "4")
+	cmd.Stdin = bytes.NewReader(image)
+	var out, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("tesseract: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out.String(), nil
+}
+
+// Noop reads nothing; receipts then go through with the amount asked of the user.
+type Noop struct{}
+
+func (Noop) Text(ctx context.Context, image []byte) (string, error) { return "", nil }
@@ -0,0 +1,185 @@
+package ocr
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
+)
+
+// Receipt is what could be read off a receipt; zero fields weren't found.
+type Receipt struct {
+	TotalPaise int64
+	Merchant   string
+	Date       time.Time
+}
+
+// totalLabels, best first: a "grand total" beats a plain "total", which beats an amount due.
+var totalLabels = []string{"grand total", "net amount", "net payable", "amount payable", "total amount", "bill amount", "total", "amount due", "to pay", "amount"}
+
+// notTotal marks lines that mention a total but aren't the bill's.
+var notTotal = []string{"sub total", "subtotal", "sub-total", "total qty", "total quantity", "total items", "total item", "total tax", "total gst", "total savings", "total discount"}
+
+var (
+	amountRe = regexp.MustCompile(`(?:₹|rs\.?|inr)?\s*(\d{1,3}(?:,\d{2,3})+(?:\.\d{1,2})?|\d+(?:\.\d{1,2})?)`)
+	// an amount printed with its currency, for receipts whose total line didn't survive OCR
+	currencyRe = regexp.MustCompile(`(?:₹|rs\.?|inr)\s*(\d{1,3}(?:,\d{2,3})+(?:\.\d{1,2})?|\d+(?:\.\d{1,2})?)`)
+
+	numericDateRe = regexp.MustCompile(`\b(\d{1,2})[/.-](\d{1,2})[/.-](\d{2}|\d{4})\b`)
+	isoDateRe     = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+	wordDateRe    = regexp.MustCompile(`(?i)\b(\d{1,2})[\s-]*(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*[\s,-]*(\d{2}|\d{4})\b`)
+
+	letterRe = regexp.MustCompile(`\p{L}`)
+	digitRe  = regexp.MustCompile(`\d`)
+)
+
+var months = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+	"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+// ParseReceipt picks the total, merchant and date out of OCR text. Numeric dates are read
+// day first, as Indian receipts print them.
+func ParseReceipt(text string) Receipt {
+	lines := make([]string, 0)
+	for _, l := range strings.Split(text, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return Receipt{
+		TotalPaise: findTotal(lines),
+		Merchant:   findMerchant(lines),
+		Date:       findDate(lines),
+	}
+}
+
+func findTotal(lines []string) int64 {
+	for _, label := range totalLabels {
+		for i := len(lines) - 1; i >= 0; i-- {
+			l := strings.ToLower(lines[i])
+			if !strings.Contains(l, label) || containsAny(l, notTotal) {
+				continue
+			}
+			_, after, _ := strings.Cut(l, label)
+			if p := lastAmount(after); p > 0 {
+				return p
+			}
+			// the amount is often printed on the next line
+			if i+1 < len(lines) {
+				if p := lastAmount(strings.ToLower(lines[i+1])); p > 0 && !letterRe.MatchString(stripCurrency(lines[i+1])) {
+					return p
+				}
+			}
+		}
+	}
+
+	var best int64
+	for _, l := range lines {
+		for _, m := range currencyRe.FindAllStringSubmatch(strings.ToLower(l), -1) {
+			if p, err := money.ParseRupees(m[1]); err == nil && p > best {
+				best = p
+			}
+		}
+	}
+	return best
+}
+
+func lastAmount(s string) int64 {
+	matches := amountRe.FindAllStringSubmatch(s, -1)
+	if len(matches) == 0 {
+		return 0
+	}
+	p, err := money.ParseRupees(matches[len(matches)-1][1])
+	if err != nil {
+		return 0
+	}
+	return p
+}
+
+func stripCurrency(s string) string {
+	s = strings.ToLower(s)
+	for _, c := range []string{"₹", "rs.", "rs", "inr"} {
+		s = strings.ReplaceAll(s, c, "")
+	}
+	return s
+}
+
+// merchantSkip are header lines that name the document, not the shop.
+var merchantSkip = []string{"tax invoice", "invoice", "receipt", "bill of supply", "cash memo", "gstin", "gst no", "welcome", "duplicate", "original"}
+
+// findMerchant takes the first line near the top that reads like a name.
+func findMerchant(lines []string) string {
+	for i, l := range lines {
+		if i >= 6 {
+			break
+		}
+		lower := strings.ToLower(l)
+		if containsAny(lower, merchantSkip) || len(letterRe.FindAllString(l, -1)) < 3 {
+			continue
+		}
+		// dates, phone numbers and addresses with a PIN code
+		if numericDateRe.MatchString(l) || len(digitRe.FindAllString(l, -1)) >= 6 {
+			continue
+		}
+		return strings.Trim(l, " *-=#:")
+	}
+	return ""
+}
+
+func findDate(lines []string) time.Time {
+	for _, l := range lines {
+		if m := isoDateRe.FindStringSubmatch(l); m != nil {
+			if t, ok := date(atoi(m[1]), atoi(m[2]), atoi(m[3])); ok {
+				return t
+			}
+		}
+		if m := numericDateRe.FindStringSubmatch(l); m != nil {
+			if t, ok := date(year(m[3]), atoi(m[2]), atoi(m[1])); ok {
+				return t
+			}
+		}
+		if m := wordDateRe.FindStringSubmatch(l); m != nil {
+			if t, ok := date(year(m[3]), int(months[strings.ToLower(m[2])[:3]]), atoi(m[1])); ok {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// date builds a UTC date, rejecting ones time.Date would silently normalise (31/02).
+func date(y, m, d int) (time.Time, bool) {
+	if m < 1 || m > 12 || d < 1 || y < 2000 {
+		return time.Time{}, false
+	}
+	t := time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+	return t, t.Day() == d
+}
+
+func year(s string) int {
+	y := atoi(s)
+	if len(s) == 2 {
+		y += 2000
+	}
+	return y
+}
+
+func atoi(s string) int {
+	n := 0
+	for _, r := range s {
+		n = n*10 + int(r-'0')
+	}
+	return n
+}
+
+func containsAny(s string, words []string) bool {
+	for _, w := range words {
+		if strings.Contains(s, w) {
+			return true
+		}
+	}
+	return false
+}
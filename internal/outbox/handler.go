@@ -0,0 +1,47 @@
+package outbox
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	Repo *Repository
+}
+
+func NewHandler(repo *Repository) *Handler {
+	return &Handler{Repo: repo}
+}
+
+// History lists sent and queued messages, newest first: GET ?to=&channel=&purpose=&status=&limit=.
+func (h *Handler) History(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	items, err := h.Repo.List(c.UserContext(), ListParams{
+		To:      c.Query("to"),
+		Channel: strings.ToLower(strings.TrimSpace(c.Query("channel"))),
+		Purpose: strings.TrimSpace(c.Query("purpose")),
+		Status:  strings.ToLower(strings.TrimSpace(c.Query("status"))),
+		Limit:   limit,
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to list messages: "+err.Error())
+	}
+	return c.JSON(items)
+}
+
+// StatusCallback takes Twilio's delivery status callbacks. Statuses we don't track (accepted,
+// scheduled) are acknowledged so Twilio doesn't retry them.
+func (h *Handler) StatusCallback(c *fiber.Ctx) error {
+	errMsg := strings.TrimSpace(c.FormValue("ErrorMessage"))
+	if code := strings.TrimSpace(c.FormValue("ErrorCode")); code != "" {
+		errMsg = strings.TrimSpace("twilio error " + code + ": " + errMsg)
+	}
+	err := h.Repo.ApplyStatus(c.UserContext(), c.FormValue("MessageSid"), c.FormValue("MessageStatus"), errMsg)
+	if err != nil && !errors.Is(err, ErrBadRequest) {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to record status: "+err.Error())
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
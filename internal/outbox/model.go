@@ -0,0 +1,48 @@
+// Package outbox queues outbound messages, sends them on the recipient's messaging channel from a
+// worker with retries and tracks what the provider reports back about each one.
+package outbox
+
+import "time"
+
+// Message statuses. pending and sending are ours; the rest mirror Twilio's MessageStatus.
+const (
+	StatusPending     = "pending"
+	StatusSending     = "sending"
+	StatusQueued      = "queued"
+	StatusSent        = "sent"
+	StatusDelivered   = "delivered"
+	StatusRead        = "read"
+	StatusUndelivered = "undelivered"
+	StatusFailed      = "failed"
+)
+
+// statusOrder ranks statuses; a callback never moves a message backwards, since Twilio doesn't
+// promise to deliver callbacks in order.
+var statusOrder = []string{StatusPending, StatusSending, StatusQueued, StatusSent, StatusDelivered, StatusRead, StatusUndelivered, StatusFailed}
+
+// Purposes label what a message was for in the history.
+const (
+	PurposeMonthlyReport = "monthly_report"
+	PurposeReminder      = "reminder"
+	PurposeStreakBonus   = "streak_bonus"
+)
+
+type Message struct {
+	ID            int64      `json:"id"`
+	To            string     `json:"to"`               // the recipient's phone, whatever the channel
+	Channel       string     `json:"channel"`          // "" on Enqueue picks the phone's preferred channel
+	Sender        string     `json:"sender,omitempty"` // "" sends from the channel's default number
+	Body          string     `json:"body"`
+	MediaURL      string     `json:"media_url,omitempty"`
+	Purpose       string     `json:"purpose,omitempty"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	ProviderSID   string     `json:"provider_sid,omitempty"`
+	LastError     *string    `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	ReadAt        *time.Time `json:"read_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,219 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/messaging"
+)
+
+var ErrBadRequest = errors.New("bad request")
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+const messageColumns = `id, to_phone, channel, sender, body, media_url, purpose, status, attempts, provider_sid, last_error,
+	next_attempt_at, sent_at, delivered_at, read_at, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMessage(row rowScanner) (Message, error) {
+	var m Message
+	var lastError sql.NullString
+	var next, sent, delivered, read sql.NullTime
+	err := row.Scan(&m.ID, &m.To, &m.Channel, &m.Sender, &m.Body, &m.MediaURL, &m.Purpose, &m.Status, &m.Attempts,
+		&m.ProviderSID, &lastError, &next, &sent, &delivered, &read, &m.CreatedAt, &m.UpdatedAt)
+	if lastError.Valid {
+		m.LastError = &lastError.String
+	}
+	m.NextAttemptAt = nullTime(next)
+	m.SentAt = nullTime(sent)
+	m.DeliveredAt = nullTime(delivered)
+	m.ReadAt = nullTime(read)
+	return m, err
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// Enqueue queues m for the worker's next tick, on the recipient's preferred channel unless
+// m.Channel is set.
+func (r *Repository) Enqueue(ctx context.Context, m Message) (Message, error) {
+	m.To = strings.TrimSpace(m.To)
+	if m.To == "" || (strings.TrimSpace(m.Body) == "" && m.MediaURL == "") {
+		return Message{}, ErrBadRequest
+	}
+	if m.Channel == "" {
+		var err error
+		if m.Channel, err = (&messaging.Directory{DB: r.DB}).Preferred(ctx, m.To); err != nil {
+			return Message{}, err
+		}
+	}
+	return scanMessage(r.DB.QueryRowContext(ctx, `
+		INSERT INTO outbound_messages (to_phone, channel, sender, body, media_url, purpose)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+messageColumns+`;
+	`, m.To, m.Channel, m.Sender, m.Body, m.MediaURL, m.Purpose))
+}
+
+// Claim marks up to limit due messages as sending and counts the attempt. Messages left in
+// sending by a crashed instance are picked up again after staleAfter.
+func (r *Repository) Claim(ctx context.Context, now time.Time, limit int, staleAfter time.Duration) ([]Message, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		UPDATE outbound_messages
+		SET status = 'sending', attempts = attempts + 1, updated_at = $1
+		WHERE id IN (
+			SELECT id FROM outbound_messages
+			WHERE (status = 'pending' AND next_attempt_at <= $1)
+			   OR (status = 'sending' AND updated_at < $3)
+			ORDER BY next_attempt_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+messageColumns+`;
+	`, now, limit, now.Add(-staleAfter))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Message, 0)
+	for rows.Next() {
+		m, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// MarkAccepted records that the provider took the message; Twilio's callbacks carry it on from
+// queued, other channels answer sent straight away.
+func (r *Repository) MarkAccepted(ctx context.Context, id int64, sid, status string) error {
+	if !knownStatus(status) || status == StatusPending || status == StatusSending {
+		status = StatusQueued
+	}
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE outbound_messages
+		SET provider_sid = $2, status = $3, next_attempt_at = NULL, last_error = NULL,
+		    sent_at = CASE WHEN $3 IN ('sent', 'delivered', 'read') THEN now() ELSE sent_at END,
+		    updated_at = now()
+		WHERE id = $1 AND status = 'sending';
+	`, id, sid, status)
+	return err
+}
+
+// MarkFailed records a failed attempt: back to pending at retryAt, or failed for good when
+// retryAt is nil.
+func (r *Repository) MarkFailed(ctx context.Context, id int64, cause string, retryAt *time.Time) error {
+	status := StatusFailed
+	if retryAt != nil {
+		status = StatusPending
+	}
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE outbound_messages
+		SET status = $2, last_error = $3, next_attempt_at = $4, updated_at = now()
+		WHERE id = $1;
+	`, id, status, trimError(cause), retryAt)
+	return err
+}
+
+// statusRank is statusOrder as an SQL array literal, for array_position.
+var statusRank = "ARRAY['" + strings.Join(statusOrder, "','") + "']"
+
+// ApplyStatus records a Twilio status callback. A stale callback (an earlier status arriving
+// late) and an unknown sid change nothing.
+func (r *Repository) ApplyStatus(ctx context.Context, sid, status, errMsg string) error {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if sid == "" || !knownStatus(status) {
+		return ErrBadRequest
+	}
+	var lastError *string
+	if errMsg != "" {
+		e := trimError(errMsg)
+		lastError = &e
+	}
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE outbound_messages
+		SET status = $2,
+		    last_error = COALESCE($3, last_error),
+		    sent_at = CASE WHEN $2 IN ('sent', 'delivered', 'read') THEN COALESCE(sent_at, now()) ELSE sent_at END,
+		    delivered_at = CASE WHEN $2 IN ('delivered', 'read') THEN COALESCE(delivered_at, now()) ELSE delivered_at END,
+		    read_at = CASE WHEN $2 = 'read' THEN COALESCE(read_at, now()) ELSE read_at END,
+		    updated_at = now()
+		WHERE provider_sid = $1
+		  AND array_position(`+statusRank+`, status) < array_position(`+statusRank+`, $2::text);
+	`, sid, status, lastError)
+	return err
+}
+
+// ListParams filters the history; zero values match everything.
+type ListParams struct {
+	To      string
+	Channel string
+	Purpose string
+	Status  string
+	Limit   int
+}
+
+// List returns messages newest first.
+func (r *Repository) List(ctx context.Context, p ListParams) ([]Message, error) {
+	if p.Limit <= 0 || p.Limit > 500 {
+		p.Limit = 100
+	}
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT `+messageColumns+`
+		FROM outbound_messages
+		WHERE ($1 = '' OR to_phone = $1)
+		  AND ($2 = '' OR purpose = $2)
+		  AND ($3 = '' OR status = $3)
+		  AND ($5 = '' OR channel = $5)
+		ORDER BY created_at DESC
+		LIMIT $4;
+	`, strings.TrimSpace(p.To), p.Purpose, p.Status, p.Limit, p.Channel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Message, 0)
+	for rows.Next() {
+		m, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+func knownStatus(s string) bool {
+	for _, v := range statusOrder {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func trimError(s string) string {
+	if len(s) > 500 {
+		return s[:500]
+	}
+	return s
+}
@@ -0,0 +1,167 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/messaging"
+)
+
+const (
+	maxAttempts = 6
+	// retries wait retryBase, doubling per attempt up to retryCap, with ±20% jitter
+	retryBase = 30 * time.Second
+	retryCap  = time.Hour
+)
+
+// fromSender is implemented by channels that can send from another of the account's numbers.
+type fromSender interface {
+	SendFrom(ctx context.Context, from, to, body, mediaURL string) (messaging.Sent, error)
+}
+
+// Worker sends queued messages once per Interval, at most PerSecond messages per second from
+// each sender.
+type Worker struct {
+	Repo      *Repository
+	Channels  *messaging.Registry
+	Directory *messaging.Directory
+	Interval  time.Duration
+	// PerSecond paces each sender; Twilio queues (and eventually fails) messages sent faster than
+	// the number's throughput. The pace is per instance.
+	PerSecond float64
+
+	next map[string]time.Time
+}
+
+// NewWorker reads OUTBOX_RATE_PER_SECOND (default 1).
+func NewWorker(repo *Repository, channels *messaging.Registry, dir *messaging.Directory) *Worker {
+	rate := 1.0
+	if v, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv("OUTBOX_RATE_PER_SECOND")), 64); err == nil && v > 0 {
+		rate = v
+	}
+	return &Worker{
+		Repo:      repo,
+		Channels:  channels,
+		Directory: dir,
+		Interval:  5 * time.Second,
+		PerSecond: rate,
+		next:      map[string]time.Time{},
+	}
+}
+
+// Run blocks until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	t := time.NewTicker(w.Interval)
+	defer t.Stop()
+	for {
+		w.Tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (w *Worker) Tick(ctx context.Context) {
+	msgs, err := w.Repo.Claim(ctx, time.Now(), 20, 5*time.Minute)
+	if err != nil {
+		log.Printf("outbox: claim: %v", err)
+		return
+	}
+	for _, m := range msgs {
+		if err := w.pace(ctx, m.Channel+":"+m.Sender); err != nil {
+			return // shutting down; the claimed rest goes stale and is picked up again
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		sent, err := w.send(sendCtx, m)
+		cancel()
+		if err == nil {
+			if err := w.Repo.MarkAccepted(ctx, m.ID, sent.ID, sent.Status); err != nil {
+				log.Printf("outbox: message %d: %v", m.ID, err)
+			}
+			continue
+		}
+
+		var retryAt *time.Time
+		if m.Attempts < maxAttempts && retryable(err) {
+			at := time.Now().Add(backoff(m.Attempts))
+			retryAt = &at
+		}
+		log.Printf("outbox: message %d attempt %d: %v", m.ID, m.Attempts, err)
+		if err := w.Repo.MarkFailed(ctx, m.ID, err.Error(), retryAt); err != nil {
+			log.Printf("outbox: message %d: %v", m.ID, err)
+		}
+	}
+}
+
+// send delivers m on its channel. A channel that isn't configured, or a phone that was unlinked
+// from it, fails the message for good.
+func (w *Worker) send(ctx context.Context, m Message) (messaging.Sent, error) {
+	ch, err := w.Channels.Get(m.Channel)
+	if err != nil {
+		return messaging.Sent{}, permanent{fmt.Errorf("%w %q", err, m.Channel)}
+	}
+	to, err := w.Directory.AddressFor(ctx, m.Channel, m.To)
+	if errors.Is(err, messaging.ErrNotLinked) {
+		return messaging.Sent{}, permanent{err}
+	}
+	if err != nil {
+		return messaging.Sent{}, err
+	}
+
+	if fs, ok := ch.(fromSender); ok && m.Sender != "" {
+		return fs.SendFrom(ctx, m.Sender, to, m.Body, m.MediaURL)
+	}
+	if m.MediaURL != "" {
+		return ch.SendDocument(ctx, to, m.Body, m.MediaURL)
+	}
+	return ch.SendText(ctx, to, m.Body)
+}
+
+type permanent struct{ error }
+
+func (p permanent) Unwrap() error   { return p.error }
+func (p permanent) Retryable() bool { return false }
+
+// pace waits for sender's next free slot.
+func (w *Worker) pace(ctx context.Context, sender string) error {
+	if w.next == nil {
+		w.next = map[string]time.Time{}
+	}
+	if wait := time.Until(w.next[sender]); wait > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	w.next[sender] = time.Now().Add(time.Duration(float64(time.Second) / w.PerSecond))
+	return nil
+}
+
+func backoff(attempt int) time.Duration {
+	d := retryBase
+	for i := 1; i < attempt && d < retryCap; i++ {
+		d *= 2
+	}
+	d = min(d, retryCap)
+	return time.Duration(float64(d) * (0.8 + 0.4*rand.Float64()))
+}
+
+// retryable is false for errors the provider will repeat, like an invalid number; network errors
+// are worth another try.
+func retryable(err error) bool {
+	var r interface{ Retryable() bool }
+	if errors.As(err, &r) {
+		return r.Retryable()
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+// Package pdfkit holds the drawing helpers shared by Vantro's generated PDFs: the branded
+// page frame and charts drawn with gofpdf primitives.
+package pdfkit
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phpdave11/gofpdf"
+)
+
+// Brand is what the header shows on every page.
+type Brand struct {
+	Title    string // e.g. "Statement"
+	Subtitle string // e.g. the period or business name
+	Logo     []byte // optional PNG or JPEG
+	LogoType string // "PNG" or "JPG"; sniffed from Logo when empty
+	Lang     string // label language, LangEnglish when empty
+}
+
+const (
+	marginX    = 14.0
+	headerH    = 22.0
+	pageNumTag = "{nb}"
+)
+
+// Accent is the brand colour used for the header rule and the first chart series.
+var Accent = RGB{R: 37, G: 99, B: 235}
+
+// New returns an A4 portrait document whose pages carry the Vantro header (wordmark, title,
+// optional logo) and a footer with the generation time and "Page n of N". The bundled Unicode
+// fonts are registered, the first page is already added and the cursor sits below the header.
+func New(b Brand) *Doc {
+	pdf := &Doc{Fpdf: gofpdf.New("P", "mm", "A4", ""), Lang: ParseLang(b.Lang)}
+	registerFonts(pdf)
+	pdf.SetMargins(marginX, marginX+headerH, marginX)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AliasNbPages(pageNumTag)
+	pdf.SetTitle("VANTRO "+b.Title, true)
+	pdf.SetCreator("Vantro", true)
+
+	logo := registerLogo(pdf, b)
+	generated := time.Now().Format("02 Jan 2006 15:04 MST")
+
+	pdf.SetHeaderFunc(pdf.keepFont(func() {
+		pageW, _ := pdf.GetPageSize()
+		pdf.SetTextColor(20, 20, 20)
+		pdf.SetFont("B", 16)
+		pdf.SetXY(marginX, marginX-2)
+		pdf.CellFormat(0, 8, "VANTRO", "", 0, "L", false, 0, "")
+		pdf.SetXY(marginX, marginX+5)
+		pdf.SetFont("", 10)
+		pdf.SetTextColor(80, 80, 80)
+		line := b.Title
+		if b.Subtitle != "" {
+			line += "  |  " + b.Subtitle
+		}
+		pdf.CellFormat(0, 6, line, "", 0, "L", false, 0, "")
+
+		if logo != "" {
+			// fit in a 40x14 box, right aligned
+			info := pdf.GetImageInfo(logo)
+			w, h := 40.0, 40.0*info.Height()/info.Width()
+			if h > 14 {
+				w, h = 14*info.Width()/info.Height(), 14
+			}
+			pdf.ImageOptions(logo, pageW-marginX-w, marginX-3, w, h, false, gofpdf.ImageOptions{}, 0, "")
+		}
+
+		pdf.SetDrawColor(Accent.R, Accent.G, Accent.B)
+		pdf.SetLineWidth(0.6)
+		pdf.Line(marginX, marginX+headerH-6, pageW-marginX, marginX+headerH-6)
+		pdf.SetLineWidth(0.2)
+		pdf.SetXY(marginX, marginX+headerH)
+	}))
+
+	pdf.SetFooterFunc(pdf.keepFont(func() {
+		pdf.SetY(-14)
+		pdf.SetFont("", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(0, 8, pdf.T("generated_by")+" - "+generated, "", 0, "L", false, 0, "")
+		pdf.SetX(marginX)
+		page := strings.NewReplacer("%n", strconv.Itoa(pdf.PageNo()), "%N", pageNumTag).Replace(pdf.T("page_of"))
+		pdf.CellFormat(0, 8, page, "", 0, "R", false, 0, "")
+	}))
+
+	pdf.AddPage()
+	return pdf
+}
+
+// registerLogo returns the image name to draw, or "" when there is no usable logo. A logo
+// gofpdf can't parse is dropped rather than failing the whole document.
+func registerLogo(pdf *Doc, b Brand) string {
+	if len(b.Logo) == 0 {
+		return ""
+	}
+	typ := b.LogoType
+	if typ == "" {
+		typ = SniffImageType(b.Logo)
+	}
+	if typ == "" {
+		return ""
+	}
+	pdf.RegisterImageOptionsReader("logo", gofpdf.ImageOptions{ImageType: typ}, bytes.NewReader(b.Logo))
+	if !pdf.Ok() {
+		pdf.ClearError()
+		return ""
+	}
+	return "logo"
+}
+
+// SniffImageType returns "PNG" or "JPG" from the file's magic bytes, or "" for anything else.
+func SniffImageType(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "PNG"
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return "JPG"
+	}
+	return ""
+}
@@ -0,0 +1,340 @@
+package pdfkit
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/phpdave11/gofpdf"
+)
+
+type RGB struct{ R, G, B int }
+
+// Palette colours slices and series in order; it repeats past its length.
+var Palette = []RGB{
+	Accent,
+	{R: 234, G: 88, B: 12},
+	{R: 22, G: 163, B: 74},
+	{R: 147, G: 51, B: 234},
+	{R: 219, G: 39, B: 119},
+	{R: 8, G: 145, B: 178},
+	{R: 202, G: 138, B: 4},
+	{R: 100, G: 116, B: 139},
+}
+
+func color(i int) RGB { return Palette[i%len(Palette)] }
+
+// Slice is one wedge of a donut.
+type Slice struct {
+	Label string
+	Value int64
+}
+
+// Series is one set of values for a bar or line chart, aligned with the chart's labels.
+type Series struct {
+	Name   string
+	Values []int64
+	Color  *RGB // nil picks from Palette
+}
+
+// maxSlices keeps legends readable; smaller wedges are folded into "Other".
+const maxSlices = 7
+
+// Donut draws a donut of diameter size at (x, y) with a legend to its right showing each
+// share. center is printed in the hole, typically the total. The cursor is left below it.
+func Donut(pdf *Doc, x, y, size float64, slices []Slice, center string) {
+	defer pdf.SetXY(x, y+size)
+	slices = foldSlices(slices, pdf.T("other"))
+	var total int64
+	for _, s := range slices {
+		total += s.Value
+	}
+	r := size / 2
+	cx, cy := x+r, y+r
+	if total <= 0 {
+		pdf.SetDrawColor(220, 220, 220)
+		pdf.Circle(cx, cy, r, "D")
+		noData(pdf, x, cy-3, size)
+		return
+	}
+
+	start := -90.0 // 12 o'clock, clockwise
+	for i, s := range slices {
+		sweep := 360 * float64(s.Value) / float64(total)
+		c := color(i)
+		pdf.SetFillColor(c.R, c.G, c.B)
+		pdf.Polygon(wedge(cx, cy, r, start, start+sweep), "F")
+		start += sweep
+	}
+	pdf.SetFillColor(255, 255, 255)
+	pdf.Circle(cx, cy, r*0.58, "F")
+
+	if center != "" {
+		pdf.SetFont("B", 9)
+		pdf.SetTextColor(20, 20, 20)
+		pdf.SetXY(cx-r*0.55, cy-3)
+		pdf.CellFormat(r*1.1, 6, center, "", 0, "C", false, 0, "")
+	}
+
+	ly := y + math.Max(0, (size-float64(len(slices))*6)/2)
+	for i, s := range slices {
+		c := color(i)
+		pdf.SetFillColor(c.R, c.G, c.B)
+		pdf.Rect(x+size+6, ly+1.2, 3.5, 3.5, "F")
+		pdf.SetXY(x+size+11, ly)
+		pdf.SetFont("", 8.5)
+		pdf.SetTextColor(40, 40, 40)
+		pct := 100 * float64(s.Value) / float64(total)
+		pdf.CellFormat(60, 6, truncate(s.Label, 28), "", 0, "L", false, 0, "")
+		pdf.CellFormat(14, 6, strconv.FormatFloat(pct, 'f', 1, 64)+"%", "", 0, "R", false, 0, "")
+		ly += 6
+	}
+}
+
+func foldSlices(in []Slice, otherLabel string) []Slice {
+	out := make([]Slice, 0, len(in))
+	for _, s := range in {
+		if s.Value > 0 {
+			out = append(out, s)
+		}
+	}
+	if len(out) <= maxSlices {
+		return out
+	}
+	other := Slice{Label: otherLabel}
+	for _, s := range out[maxSlices-1:] {
+		other.Value += s.Value
+	}
+	return append(out[:maxSlices-1], other)
+}
+
+// wedge approximates a pie slice with a polygon, one vertex every 2 degrees.
+func wedge(cx, cy, r, from, to float64) []gofpdf.PointType {
+	pts := []gofpdf.PointType{{X: cx, Y: cy}}
+	for a := from; ; a += 2 {
+		if a > to {
+			a = to
+		}
+		rad := a * math.Pi / 180
+		pts = append(pts, gofpdf.PointType{X: cx + r*math.Cos(rad), Y: cy + r*math.Sin(rad)})
+		if a >= to {
+			break
+		}
+	}
+	return pts
+}
+
+// BarChart draws grouped vertical bars, one group per label, with a value axis in rupees.
+// Like LineChart, it leaves the cursor below the chart.
+func BarChart(pdf *Doc, x, y, w, h float64, labels []string, series ...Series) {
+	defer pdf.SetXY(x, y+h)
+	plot, ok := newPlot(pdf, x, y, w, h, labels, series)
+	if !ok {
+		return
+	}
+	groupW := plot.w / float64(len(labels))
+	barW := groupW * 0.8 / float64(len(series))
+	for si, s := range series {
+		c := seriesColor(s, si)
+		pdf.SetFillColor(c.R, c.G, c.B)
+		for i, v := range s.Values {
+			if i >= len(labels) || v == 0 {
+				continue
+			}
+			bx := plot.x + float64(i)*groupW + groupW*0.1 + float64(si)*barW
+			y0, y1 := plot.yOf(0), plot.yOf(v)
+			pdf.Rect(bx, math.Min(y0, y1), math.Max(barW-0.3, 0.2), math.Abs(y1-y0), "F")
+		}
+	}
+	plot.legend(series)
+}
+
+// LineChart draws one polyline per series over the labels, with a zero line when values go
+// negative (a running balance, say).
+func LineChart(pdf *Doc, x, y, w, h float64, labels []string, series ...Series) {
+	defer pdf.SetXY(x, y+h)
+	plot, ok := newPlot(pdf, x, y, w, h, labels, series)
+	if !ok {
+		return
+	}
+	// points sit mid-slot, under their x labels
+	slot := plot.w / float64(len(labels))
+	xOf := func(i int) float64 { return plot.x + (float64(i)+0.5)*slot }
+	pdf.SetLineWidth(0.5)
+	for si, s := range series {
+		c := seriesColor(s, si)
+		pdf.SetDrawColor(c.R, c.G, c.B)
+		for i := 1; i < len(s.Values) && i < len(labels); i++ {
+			pdf.Line(xOf(i-1), plot.yOf(s.Values[i-1]), xOf(i), plot.yOf(s.Values[i]))
+		}
+		if len(s.Values) == 1 {
+			pdf.SetFillColor(c.R, c.G, c.B)
+			pdf.Circle(xOf(0), plot.yOf(s.Values[0]), 0.8, "F")
+		}
+	}
+	pdf.SetLineWidth(0.2)
+	plot.legend(series)
+}
+
+type plot struct {
+	pdf        *Doc
+	x, y, w, h float64 // the plotting area, inside the axes
+	lo, hi     int64
+}
+
+func (p plot) yOf(v int64) float64 {
+	return p.y + p.h - p.h*float64(v-p.lo)/float64(p.hi-p.lo)
+}
+
+// newPlot draws the frame, gridlines and axis labels and returns the inner area. ok is false
+// when there is nothing to plot; a "No data" note is drawn instead.
+func newPlot(pdf *Doc, x, y, w, h float64, labels []string, series []Series) (plot, bool) {
+	var lo, hi int64
+	has := false
+	for _, s := range series {
+		for _, v := range s.Values {
+			lo, hi = min(lo, v), max(hi, v)
+			has = has || v != 0
+		}
+	}
+	if !has || len(labels) == 0 {
+		pdf.SetDrawColor(220, 220, 220)
+		pdf.Rect(x, y, w, h, "D")
+		noData(pdf, x, y+h/2-3, w)
+		return plot{}, false
+	}
+	step := niceStep(hi-lo, 4)
+	lo = floorTo(lo, step)
+	hi = ceilTo(hi, step)
+	if hi == lo {
+		hi = lo + step
+	}
+
+	p := plot{pdf: pdf, x: x + 16, y: y + 2, w: w - 18, h: h - 14, lo: lo, hi: hi}
+
+	pdf.SetFont("", 7)
+	pdf.SetTextColor(110, 110, 110)
+	pdf.SetLineWidth(0.1)
+	for v := lo; v <= hi; v += step {
+		gy := p.yOf(v)
+		if v == 0 {
+			pdf.SetDrawColor(150, 150, 150)
+		} else {
+			pdf.SetDrawColor(225, 225, 225)
+		}
+		pdf.Line(p.x, gy, p.x+p.w, gy)
+		pdf.SetXY(x, gy-2)
+		pdf.CellFormat(15, 4, Compact(v), "", 0, "R", false, 0, "")
+	}
+	pdf.SetLineWidth(0.2)
+
+	// at most ~10 x labels, evenly spaced
+	every := (len(labels) + 9) / 10
+	slot := p.w / float64(len(labels))
+	for i, l := range labels {
+		if i%every != 0 {
+			continue
+		}
+		pdf.SetXY(p.x+float64(i)*slot-4, p.y+p.h+1)
+		pdf.CellFormat(slot+8, 4, l, "", 0, "C", false, 0, "")
+	}
+	return p, true
+}
+
+func (p plot) legend(series []Series) {
+	if len(series) < 2 {
+		return
+	}
+	lx := p.x
+	ly := p.y + p.h + 6
+	p.pdf.SetFont("", 7.5)
+	p.pdf.SetTextColor(60, 60, 60)
+	for si, s := range series {
+		c := seriesColor(s, si)
+		p.pdf.SetFillColor(c.R, c.G, c.B)
+		p.pdf.Rect(lx, ly+0.8, 3, 3, "F")
+		p.pdf.SetXY(lx+4, ly)
+		wid := p.pdf.GetStringWidth(s.Name) + 2
+		p.pdf.CellFormat(wid, 4.5, s.Name, "", 0, "L", false, 0, "")
+		lx += wid + 8
+	}
+}
+
+func seriesColor(s Series, i int) RGB {
+	if s.Color != nil {
+		return *s.Color
+	}
+	return color(i)
+}
+
+// Heading prints a small section title and moves below it.
+func Heading(pdf *Doc, text string) {
+	pdf.SetFont("B", 11)
+	pdf.SetTextColor(20, 20, 20)
+	pdf.CellFormat(0, 7, text, "", 1, "L", false, 0, "")
+}
+
+func noData(pdf *Doc, x, y, w float64) {
+	pdf.SetFont("I", 9)
+	pdf.SetTextColor(140, 140, 140)
+	pdf.SetXY(x, y)
+	pdf.CellFormat(w, 6, pdf.T("no_data"), "", 0, "C", false, 0, "")
+}
+
+// Compact renders paise as a short rupee figure for axis labels: 1.2K, 3.4L, 1.1Cr.
+func Compact(paise int64) string {
+	rs := float64(paise) / 100
+	sign := ""
+	if rs < 0 {
+		sign, rs = "-", -rs
+	}
+	unit := ""
+	switch {
+	case rs >= 1e7:
+		rs, unit = rs/1e7, "Cr"
+	case rs >= 1e5:
+		rs, unit = rs/1e5, "L"
+	case rs >= 1e3:
+		rs, unit = rs/1e3, "K"
+	}
+	s := strconv.FormatFloat(rs, 'f', 1, 64)
+	s = strings.TrimSuffix(s, ".0")
+	return sign + s + unit
+}
+
+// niceStep picks a 1/2/5 x 10^n step giving about n gridlines over span.
+func niceStep(span int64, n int) int64 {
+	if span <= 0 {
+		return 100
+	}
+	raw := float64(span) / float64(n)
+	mag := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if raw <= m*mag {
+			return max(int64(m*mag), 1)
+		}
+	}
+	return max(int64(10*mag), 1)
+}
+
+func floorTo(v, step int64) int64 {
+	if v >= 0 {
+		return v / step * step
+	}
+	return -ceilTo(-v, step)
+}
+
+func ceilTo(v, step int64) int64 {
+	if v <= 0 {
+		return -floorTo(-v, step)
+	}
+	return (v + step - 1) / step * step
+}
+
+func truncate(s string, n int) string {
+	r := []rune(strings.TrimSpace(s))
+	if len(r) <= n {
+		return string(r)
+	}
+	return string(r[:n-1]) + "..."
+}
@@ -0,0 +1,213 @@
+package pdfkit
+
+import (
+	"strings"
+
+	"github.com/phpdave11/gofpdf"
+)
+
+// Doc is a gofpdf document whose text methods write Unicode: each string is shaped, split into
+// runs by the font of the fallback chain that has its glyphs, and drawn run by run. Callers use
+// it like a *gofpdf.Fpdf, except that SetFont takes no family.
+type Doc struct {
+	*gofpdf.Fpdf
+	Lang  string
+	style string
+	size  float64
+}
+
+// SetFont selects the document font in style "", "B" or "I" at size points.
+func (d *Doc) SetFont(style string, size float64) {
+	d.style, d.size = styleOf(style), size
+	d.Fpdf.SetFont(Font, d.style, size)
+}
+
+// T returns the label for key in the document's language.
+func (d *Doc) T(key string) string { return T(d.Lang, key) }
+
+// keepFont runs fn, typically a header or footer, and restores the font state it changed.
+// gofpdf restores the real font after a page break; this keeps Doc's copy in step.
+func (d *Doc) keepFont(fn func()) func() {
+	return func() {
+		style, size := d.style, d.size
+		fn()
+		d.style, d.size = style, size
+	}
+}
+
+type run struct {
+	face *face
+	text string
+}
+
+func runs(s string) []run {
+	var out []run
+	var cur *face
+	var b strings.Builder
+	for _, r := range shape(s) {
+		f := faceFor(r, cur)
+		if f != cur && b.Len() > 0 {
+			out = append(out, run{face: cur, text: b.String()})
+			b.Reset()
+		}
+		cur = f
+		b.WriteRune(r)
+	}
+	if b.Len() > 0 {
+		out = append(out, run{face: cur, text: b.String()})
+	}
+	return out
+}
+
+// useFace switches the underlying font without touching Doc's state; restore with useFace(nil).
+func (d *Doc) useFace(f *face) {
+	family := Font
+	if f != nil {
+		family = f.family
+	}
+	d.Fpdf.SetFont(family, d.style, d.size)
+}
+
+func (d *Doc) width(rs []run) float64 {
+	var w float64
+	for _, r := range rs {
+		d.useFace(r.face)
+		w += d.Fpdf.GetStringWidth(r.text)
+	}
+	d.useFace(nil)
+	return w
+}
+
+// GetStringWidth returns the width of s as drawn, across fonts.
+func (d *Doc) GetStringWidth(s string) float64 {
+	return d.width(runs(s))
+}
+
+// CellFormat is gofpdf's CellFormat for Unicode text.
+func (d *Doc) CellFormat(w, h float64, txt, border string, ln int, align string, fill bool, link int, linkStr string) {
+	rs := runs(txt)
+	if len(rs) <= 1 {
+		if len(rs) == 1 {
+			d.useFace(rs[0].face)
+			txt = rs[0].text
+		}
+		d.Fpdf.CellFormat(w, h, txt, border, ln, align, fill, link, linkStr)
+		d.useFace(nil)
+		return
+	}
+
+	if w == 0 {
+		pageW, _ := d.GetPageSize()
+		_, _, right, _ := d.GetMargins()
+		w = pageW - right - d.GetX()
+	}
+	// the box first: it may break the page, which moves the row
+	d.Fpdf.CellFormat(w, h, "", border, 0, "", fill, link, linkStr)
+	x, y := d.GetX()-w, d.GetY()
+
+	total := d.width(rs)
+	tx := x + d.GetCellMargin()
+	switch {
+	case strings.Contains(align, "R"):
+		tx = x + w - d.GetCellMargin() - total
+	case strings.Contains(align, "C"):
+		tx = x + (w-total)/2
+	}
+	_, unit := d.GetFontSize()
+	baseline := y + h/2 + 0.3*unit
+	for _, r := range rs {
+		d.useFace(r.face)
+		d.Fpdf.Text(tx, baseline, r.text)
+		tx += d.Fpdf.GetStringWidth(r.text)
+	}
+	d.useFace(nil)
+
+	switch ln {
+	case 1:
+		left, _, _, _ := d.GetMargins()
+		d.SetXY(left, y+h)
+	case 2:
+		d.SetXY(x, y+h)
+	}
+}
+
+// Cell is gofpdf's Cell for Unicode text.
+func (d *Doc) Cell(w, h float64, txt string) {
+	d.CellFormat(w, h, txt, "", 0, "L", false, 0, "")
+}
+
+// MultiCell wraps txt to width w, one CellFormat per line. A border of "1" frames the block.
+func (d *Doc) MultiCell(w, h float64, txt, border, align string, fill bool) {
+	left, _, right, _ := d.GetMargins()
+	if w == 0 {
+		pageW, _ := d.GetPageSize()
+		w = pageW - right - d.GetX()
+	}
+	x := d.GetX()
+	lines := d.wrap(txt, w-2*d.GetCellMargin())
+	for i, line := range lines {
+		b := ""
+		if border != "" {
+			b = "LR"
+			if i == 0 {
+				b += "T"
+			}
+			if i == len(lines)-1 {
+				b += "B"
+			}
+		}
+		d.SetX(x)
+		d.CellFormat(w, h, line, b, 2, align, fill, 0, "")
+	}
+	d.SetX(left)
+}
+
+// wrap breaks txt into lines no wider than w, at spaces where possible.
+func (d *Doc) wrap(txt string, w float64) []string {
+	var lines []string
+	for _, para := range strings.Split(strings.ReplaceAll(txt, "\r", ""), "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			next := word
+			if line != "" {
+				next = line + " " + word
+			}
+			if d.GetStringWidth(next) <= w {
+				line = next
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			// a word wider than the cell is cut wherever it overflows
+			for d.GetStringWidth(word) > w {
+				cut := fitRunes(d, word, w)
+				lines = append(lines, word[:cut])
+				word = word[cut:]
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// fitRunes returns the byte length of the longest prefix of s that fits in w, at least one rune.
+func fitRunes(d *Doc, s string, w float64) int {
+	end := 0
+	for i := range s {
+		if i > 0 && d.GetStringWidth(s[:i]) > w {
+			break
+		}
+		end = i
+	}
+	if end == 0 {
+		for i := range s {
+			if i > 0 {
+				return i
+			}
+		}
+		return len(s)
+	}
+	return end
+}
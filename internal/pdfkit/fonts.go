@@ -0,0 +1,235 @@
+package pdfkit
+
+import (
+	"embed"
+	"encoding/binary"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed fonts/*.ttf
+var bundled embed.FS
+
+// Font is the family every generated PDF writes in. It is DejaVu Sans Condensed, which has ₹
+// and most European scripts; runes it lacks fall back along the chain built by faces.
+const Font = "Vantro"
+
+// face is one font in the fallback chain. Bold and italic reuse the regular file when the
+// font has no such style.
+type face struct {
+	family  string
+	regular []byte
+	bold    []byte
+	italic  []byte
+	runes   map[rune]bool
+}
+
+func (f *face) has(r rune) bool { return f.runes[r] }
+
+var (
+	facesOnce sync.Once
+	faces     []*face
+)
+
+// chain returns the fallback chain: the bundled fonts, then any .ttf files in PDF_FONT_DIR in
+// name order, so a deployment can add scripts (Bengali, Gujarati, ...) without a rebuild.
+func chain() []*face {
+	facesOnce.Do(func() {
+		faces = []*face{
+			bundledFace(Font, "DejaVuSansCondensed.ttf", "DejaVuSansCondensed-Bold.ttf", "DejaVuSansCondensed-Oblique.ttf"),
+			bundledFace(Font+"Deva", "NotoSansDevanagari-Regular.ttf", "", ""),
+			bundledFace(Font+"Taml", "NotoSansTamil-Regular.ttf", "", ""),
+		}
+		faces = append(faces, extraFaces(os.Getenv("PDF_FONT_DIR"))...)
+	})
+	return faces
+}
+
+func bundledFace(family, regular, bold, italic string) *face {
+	read := func(name string) []byte {
+		if name == "" {
+			return nil
+		}
+		b, err := bundled.ReadFile("fonts/" + name)
+		if err != nil {
+			panic("pdfkit: missing bundled font " + name)
+		}
+		return b
+	}
+	f := &face{family: family, regular: read(regular), bold: read(bold), italic: read(italic)}
+	f.runes = cmapRunes(f.regular)
+	return f
+}
+
+func extraFaces(dir string) []*face {
+	if dir == "" {
+		return nil
+	}
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.ttf"))
+	sort.Strings(paths)
+	out := make([]*face, 0, len(paths))
+	for i, p := range paths {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			log.Printf("pdfkit: font %s: %v", p, err)
+			continue
+		}
+		runes := cmapRunes(b)
+		if len(runes) == 0 {
+			log.Printf("pdfkit: font %s: no usable cmap, skipped", p)
+			continue
+		}
+		out = append(out, &face{family: Font + "X" + strconv.Itoa(i), regular: b, runes: runes})
+	}
+	return out
+}
+
+// registerFonts adds every face of the chain to pdf under its family, in all three styles.
+func registerFonts(d *Doc) {
+	for _, f := range chain() {
+		d.AddUTF8FontFromBytes(f.family, "", f.regular)
+		d.AddUTF8FontFromBytes(f.family, "B", orRegular(f.bold, f.regular))
+		d.AddUTF8FontFromBytes(f.family, "I", orRegular(f.italic, f.regular))
+	}
+}
+
+func orRegular(b, regular []byte) []byte {
+	if len(b) == 0 {
+		return regular
+	}
+	return b
+}
+
+// cmapRunes lists the code points a TrueType font maps to a glyph, from its Windows Unicode
+// cmap subtable (format 12 when present, else format 4). It returns nil for anything it can't
+// read.
+func cmapRunes(ttf []byte) map[rune]bool {
+	if len(ttf) < 12 {
+		return nil
+	}
+	be := binary.BigEndian
+	numTables := int(be.Uint16(ttf[4:]))
+	var cmap []byte
+	for i := 0; i < numTables; i++ {
+		rec := 12 + 16*i
+		if rec+16 > len(ttf) {
+			return nil
+		}
+		if string(ttf[rec:rec+4]) == "cmap" {
+			off, n := int(be.Uint32(ttf[rec+8:])), int(be.Uint32(ttf[rec+12:]))
+			if off+n > len(ttf) {
+				return nil
+			}
+			cmap = ttf[off : off+n]
+			break
+		}
+	}
+	if len(cmap) < 4 {
+		return nil
+	}
+
+	var fmt4, fmt12 []byte
+	for i := 0; i < int(be.Uint16(cmap[2:])); i++ {
+		rec := 4 + 8*i
+		if rec+8 > len(cmap) {
+			break
+		}
+		platform, encoding := be.Uint16(cmap[rec:]), be.Uint16(cmap[rec+2:])
+		off := int(be.Uint32(cmap[rec+4:]))
+		if platform != 3 || off+2 > len(cmap) {
+			continue
+		}
+		switch {
+		case encoding == 10 && be.Uint16(cmap[off:]) == 12:
+			fmt12 = cmap[off:]
+		case encoding == 1 && be.Uint16(cmap[off:]) == 4:
+			fmt4 = cmap[off:]
+		}
+	}
+
+	out := make(map[rune]bool)
+	switch {
+	case len(fmt12) >= 16:
+		n := int(be.Uint32(fmt12[12:]))
+		for g := 0; g < n && 16+12*g+12 <= len(fmt12); g++ {
+			grp := fmt12[16+12*g:]
+			start, end := rune(be.Uint32(grp)), rune(be.Uint32(grp[4:]))
+			for r := start; r <= end && r-start < 0x10000; r++ {
+				out[r] = true
+			}
+		}
+	case len(fmt4) >= 14:
+		segs := int(be.Uint16(fmt4[6:])) / 2
+		ends, starts := 14, 16+2*segs
+		deltas, offsets := starts+2*segs, starts+4*segs
+		if offsets+2*segs > len(fmt4) {
+			return nil
+		}
+		for s := 0; s < segs; s++ {
+			end := int(be.Uint16(fmt4[ends+2*s:]))
+			start := int(be.Uint16(fmt4[starts+2*s:]))
+			delta := int(be.Uint16(fmt4[deltas+2*s:]))
+			rangeOff := int(be.Uint16(fmt4[offsets+2*s:]))
+			for c := start; c <= end && c != 0xFFFF; c++ {
+				gid := (c + delta) & 0xFFFF
+				if rangeOff != 0 {
+					at := offsets + 2*s + rangeOff + 2*(c-start)
+					if at+2 > len(fmt4) {
+						continue
+					}
+					gid = int(be.Uint16(fmt4[at:]))
+					if gid != 0 {
+						gid = (gid + delta) & 0xFFFF
+					}
+				}
+				if gid != 0 {
+					out[rune(c)] = true
+				}
+			}
+		}
+	}
+	return out
+}
+
+// faceFor picks the font for r. ASCII other than the space always uses the primary font, so
+// digits look alike across scripts and the page-count alias stays in one run; otherwise the
+// current run's font is kept when it has the glyph, so spaces don't split a Hindi phrase, else
+// the first font in the chain that has it wins.
+func faceFor(r rune, current *face) *face {
+	if r > ' ' && r < 0x80 && chain()[0].has(r) {
+		return chain()[0]
+	}
+	if current != nil && (current.has(r) || joinsPrevious(r)) {
+		return current
+	}
+	for _, f := range chain() {
+		if f.has(r) {
+			return f
+		}
+	}
+	if current != nil {
+		return current
+	}
+	return chain()[0]
+}
+
+// joinsPrevious reports runes that belong to the preceding character: combining marks and
+// the zero-width joiners.
+func joinsPrevious(r rune) bool {
+	return r == '\u200c' || r == '\u200d' || isMark(r)
+}
+
+func styleOf(style string) string {
+	switch s := strings.ToUpper(style); {
+	case strings.Contains(s, "B"):
+		return "B"
+	case strings.Contains(s, "I"):
+		return "I"
+	}
+	return ""
+}
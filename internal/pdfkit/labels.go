@@ -0,0 +1,134 @@
+package pdfkit
+
+import "strings"
+
+// Languages PDF labels can be produced in.
+const (
+	LangEnglish = "en"
+	LangHindi   = "hi"
+)
+
+// ParseLang maps a lang parameter or Accept-Language value to a supported language,
+// defaulting to English.
+func ParseLang(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if strings.HasPrefix(s, LangHindi) {
+		return LangHindi
+	}
+	return LangEnglish
+}
+
+// ValidLang reports whether s names a supported language exactly.
+func ValidLang(s string) bool {
+	return s == LangEnglish || s == LangHindi
+}
+
+// T returns the label for key in lang, falling back to English and then to the key itself.
+func T(lang, key string) string {
+	if s, ok := labels[lang][key]; ok {
+		return s
+	}
+	if s, ok := labels[LangEnglish][key]; ok {
+		return s
+	}
+	return key
+}
+
+var labels = map[string]map[string]string{
+	LangEnglish: {
+		"generated_by":   "Generated by VANTRO",
+		"page_of":        "Page %n of %N",
+		"no_data":        "No data for this period",
+		"other":          "Other",
+		"income":         "Income",
+		"expense":        "Expense",
+		"expenses":       "Expenses",
+		"balance":        "Balance",
+		"user":           "User",
+		"statement":      "Statement",
+		"transactions":   "Transactions",
+		"by_category":    "Spending by category",
+		"income_vs_exp":  "Income vs expenses",
+		"running_bal":    "Running balance",
+		"col_type":       "TYPE",
+		"col_date":       "DATE",
+		"col_title":      "TITLE",
+		"col_amount":     "AMOUNT",
+		"col_id":         "ID",
+		"truncated":      "...truncated (too many rows)",
+		"pnl":            "Profit & Loss",
+		"pnl_current":    "This period",
+		"pnl_previous":   "Previous period",
+		"pnl_last_year":  "Last year",
+		"pnl_budget":     "Budget",
+		"pnl_income":     "Income",
+		"pnl_direct":     "Direct costs",
+		"pnl_operating":  "Operating expenses",
+		"pnl_total":      "Total %s",
+		"gross_profit":   "Gross profit",
+		"gross_margin":   "Gross margin",
+		"net_profit":     "Net profit",
+		"net_margin":     "Net margin",
+		"pnl_chart":      "Income, expenses and net profit",
+		"expense_memory": "Expense Memory",
+		"total_spend":    "Total Spend",
+		"insights":       "Insights",
+		"insight":        "Insight",
+		"breakdown":      "Category Breakdown",
+		"category":       "Category",
+		"amount":         "Amount",
+		"money_went":     "Where the money went",
+		"spend_per_day":  "Spend per day",
+		"spend_so_far":   "Spend so far this month",
+		"spend":          "Spend",
+		"cumulative":     "Cumulative",
+	},
+	LangHindi: {
+		"generated_by":   "VANTRO द्वारा तैयार",
+		"page_of":        "पृष्ठ %n / %N",
+		"no_data":        "इस अवधि का कोई डेटा नहीं",
+		"other":          "अन्य",
+		"income":         "आय",
+		"expense":        "खर्च",
+		"expenses":       "खर्च",
+		"balance":        "शेष",
+		"user":           "उपयोगकर्ता",
+		"statement":      "खाता विवरण",
+		"transactions":   "लेन-देन",
+		"by_category":    "श्रेणी के अनुसार खर्च",
+		"income_vs_exp":  "आय बनाम खर्च",
+		"running_bal":    "चालू शेष",
+		"col_type":       "प्रकार",
+		"col_date":       "तारीख",
+		"col_title":      "विवरण",
+		"col_amount":     "राशि",
+		"col_id":         "आईडी",
+		"truncated":      "...सूची छोटी की गई (बहुत अधिक पंक्तियाँ)",
+		"pnl":            "लाभ और हानि",
+		"pnl_current":    "यह अवधि",
+		"pnl_previous":   "पिछली अवधि",
+		"pnl_last_year":  "पिछला वर्ष",
+		"pnl_budget":     "बजट",
+		"pnl_income":     "आय",
+		"pnl_direct":     "प्रत्यक्ष लागत",
+		"pnl_operating":  "परिचालन खर्च",
+		"pnl_total":      "कुल %s",
+		"gross_profit":   "सकल लाभ",
+		"gross_margin":   "सकल मार्जिन",
+		"net_profit":     "शुद्ध लाभ",
+		"net_margin":     "शुद्ध मार्जिन",
+		"pnl_chart":      "आय, खर्च और शुद्ध लाभ",
+		"expense_memory": "Expense Memory",
+		"total_spend":    "कुल खर्च",
+		"insights":       "मुख्य बातें",
+		"insight":        "मुख्य बात",
+		"breakdown":      "श्रेणीवार ब्योरा",
+		"category":       "श्रेणी",
+		"amount":         "राशि",
+		"money_went":     "पैसा कहाँ गया",
+		"spend_per_day":  "प्रतिदिन खर्च",
+		"spend_so_far":   "इस महीने अब तक का खर्च",
+		"spend":          "खर्च",
+		"cumulative":     "कुल मिलाकर",
+	},
+}
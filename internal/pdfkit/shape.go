@@ -0,0 +1,146 @@
+package pdfkit
+
+import "unicode"
+
+// gofpdf maps each rune straight to a glyph: there is no OpenType shaping, so conjuncts print
+// with a visible virama and Arabic letters in their isolated forms. shape does the part that can
+// be done on the text itself, putting runes in the order they are drawn:
+//
+//   - two-part Indic vowel signs are split into their halves (Tamil ொ becomes ெ + ா);
+//   - pre-base vowel signs (Devanagari ि, Tamil ெ, ...) move in front of their consonant cluster;
+//   - right-to-left runs (Hebrew, Arabic) are reversed, keeping numbers left to right.
+func shape(s string) string {
+	rs := []rune(s)
+	if !needsShaping(rs) {
+		return s
+	}
+	rs = splitVowels(rs)
+	reorderPreBase(rs)
+	reverseRTL(rs)
+	return string(rs)
+}
+
+func needsShaping(rs []rune) bool {
+	for _, r := range rs {
+		if r >= 0x0590 {
+			return true
+		}
+	}
+	return false
+}
+
+// twoPart holds the Indic vowel signs written on both sides of the consonant.
+var twoPart = map[rune][2]rune{
+	0x09CB: {0x09C7, 0x09BE}, 0x09CC: {0x09C7, 0x09D7}, // Bengali
+	0x0B48: {0x0B47, 0x0B56}, 0x0B4B: {0x0B47, 0x0B3E}, 0x0B4C: {0x0B47, 0x0B57}, // Oriya
+	0x0BCA: {0x0BC6, 0x0BBE}, 0x0BCB: {0x0BC7, 0x0BBE}, 0x0BCC: {0x0BC6, 0x0BD7}, // Tamil
+	0x0D4A: {0x0D46, 0x0D3E}, 0x0D4B: {0x0D47, 0x0D3E}, 0x0D4C: {0x0D46, 0x0D57}, // Malayalam
+}
+
+func splitVowels(rs []rune) []rune {
+	out := rs[:0:0]
+	for _, r := range rs {
+		if parts, ok := twoPart[r]; ok {
+			out = append(out, parts[0], parts[1])
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// preBase holds the vowel signs drawn before the consonant they follow in memory.
+var preBase = map[rune]bool{
+	0x093F: true,                             // Devanagari i
+	0x09BF: true, 0x09C7: true, 0x09C8: true, // Bengali i, e, ai
+	0x0A3F: true,                             // Gurmukhi i
+	0x0ABF: true,                             // Gujarati i
+	0x0B47: true,                             // Oriya e
+	0x0BC6: true, 0x0BC7: true, 0x0BC8: true, // Tamil e, ee, ai
+	0x0D46: true, 0x0D47: true, 0x0D48: true, // Malayalam e, ee, ai
+}
+
+func reorderPreBase(rs []rune) {
+	for i, r := range rs {
+		if !preBase[r] {
+			continue
+		}
+		start := clusterStart(rs, i)
+		copy(rs[start+1:i+1], rs[start:i])
+		rs[start] = r
+	}
+}
+
+// clusterStart walks back from the vowel sign at i over consonant (+ nukta) (+ virama
+// consonant (+ nukta))... and returns where the cluster begins, or i when there is none.
+func clusterStart(rs []rune, i int) int {
+	start := i
+	for k := i - 1; k >= 0; {
+		if indic(rs[k]) == 0x3C && k > 0 { // nukta
+			k--
+		}
+		if o := indic(rs[k]); o < 0x15 || o > 0x39 {
+			break
+		}
+		start = k
+		if k < 2 || indic(rs[k-1]) != 0x4D { // virama
+			break
+		}
+		k -= 2
+	}
+	return start
+}
+
+// indic returns r's offset within its Indic block; the blocks share the ISCII layout, so
+// consonants sit at 0x15-0x39, the nukta at 0x3C and the virama at 0x4D. It is -1 elsewhere.
+func indic(r rune) int {
+	if r < 0x0900 || r > 0x0D7F {
+		return -1
+	}
+	return int(r & 0x7F)
+}
+
+func isRTL(r rune) bool {
+	return unicode.In(r, unicode.Hebrew, unicode.Arabic, unicode.Syriac, unicode.Thaana)
+}
+
+func isMark(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Mc)
+}
+
+// reverseRTL reverses each stretch that starts and ends with a right-to-left letter, so it
+// reads correctly when drawn left to right; digit groups inside it keep their order.
+func reverseRTL(rs []rune) {
+	for i := 0; i < len(rs); i++ {
+		if !isRTL(rs[i]) {
+			continue
+		}
+		end := i
+		for j := i + 1; j < len(rs); j++ {
+			if isRTL(rs[j]) {
+				end = j
+			} else if unicode.IsLetter(rs[j]) {
+				break
+			}
+		}
+		reverse(rs[i : end+1])
+		for j := i; j <= end; j++ {
+			if !unicode.IsDigit(rs[j]) {
+				continue
+			}
+			k := j
+			for k+1 <= end && (unicode.IsDigit(rs[k+1]) || rs[k+1] == '.' || rs[k+1] == ',') {
+				k++
+			}
+			reverse(rs[j : k+1])
+			j = k
+		}
+		i = end
+	}
+}
+
+func reverse(rs []rune) {
+	for i, j := 0, len(rs)-1; i < j; i, j = i+1, j-1 {
+		rs[i], rs[j] = rs[j], rs[i]
+	}
+}
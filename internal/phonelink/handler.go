@@ -0,0 +1,329 @@
+package phonelink
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/expense"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/export"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/messaging"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/reminders"
+)
+
+// Handler links a phone to the signed-in account (/api/me/phone) and serves the linked phone's
+// Expense Memory to the dashboard (/api/me/expense-memory), the same entries the bot keeps, and
+// its reminder settings (/api/me/reminders).
+type Handler struct {
+	Store    *Store
+	Channels *messaging.Registry
+	Expenses *expense.Store
+	// Reminders is optional; the reminder routes answer 503 while it is nil.
+	Reminders *reminders.Store
+	// Export is optional; the export route answers 503 while it is nil.
+	Export *export.Handler
+}
+
+func NewHandler(store *Store, channels *messaging.Registry, expenses *expense.Store) *Handler {
+	return &Handler{Store: store, Channels: channels, Expenses: expenses}
+}
+
+type SendCodeRequest struct {
+	Phone string `json:"phone"`
+	// Channel is whatsapp (default) or sms.
+	Channel string `json:"channel"`
+}
+
+type VerifyRequest struct {
+	Code string `json:"code"`
+}
+
+func (h *Handler) Get(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	link, err := h.Store.Get(c.UserContext(), userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load phone: "+err.Error())
+	}
+	return c.JSON(link)
+}
+
+// SendCode texts a verification code to the phone: POST /api/me/phone/otp.
+func (h *Handler) SendCode(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	var req SendCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+	phone, err := NormalizePhone(req.Phone)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	channel := strings.ToLower(strings.TrimSpace(req.Channel))
+	if channel == "" {
+		channel = messaging.ChannelWhatsApp
+	}
+	if channel != messaging.ChannelWhatsApp && channel != messaging.ChannelSMS {
+		return fiber.NewError(fiber.StatusBadRequest, "channel must be whatsapp or sms")
+	}
+	ch, err := h.Channels.Get(channel)
+	if err != nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, channel+" codes are not configured")
+	}
+
+	ctx := c.UserContext()
+	code, err := h.Store.IssueOTP(ctx, userID, phone)
+	switch {
+	case errors.Is(err, ErrPhoneTaken):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, ErrOTPTooSoon):
+		return fiber.NewError(fiber.StatusTooManyRequests, "a code was sent less than a minute ago")
+	case errors.Is(err, ErrOTPTooMany):
+		return fiber.NewError(fiber.StatusTooManyRequests, "too many codes today; try again tomorrow")
+	case err != nil:
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to issue code: "+err.Error())
+	}
+	msg := "Your Vantro code to link this number is " + code + ". It expires in 10 minutes. Don't share it."
+	if _, err := ch.SendText(ctx, phone, msg); err != nil {
+		log.Printf("phone link %s: send code: %v", userID, err)
+		return fiber.NewError(fiber.StatusBadGateway, "failed to send code")
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"phone":      phone,
+		"channel":    channel,
+		"expires_in": int(otpTTL.Seconds()),
+	})
+}
+
+// Verify links the phone the last code went to: POST /api/me/phone/verify.
+func (h *Handler) Verify(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	var req VerifyRequest
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Code) == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "code required")
+	}
+	link, attached, err := h.Store.Verify(c.UserContext(), userID, req.Code)
+	switch {
+	case errors.Is(err, ErrOTPInvalid):
+		return fiber.NewError(fiber.StatusUnauthorized, "that code is wrong or has expired")
+	case errors.Is(err, ErrPhoneTaken):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case err != nil:
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to link phone: "+err.Error())
+	}
+	return c.JSON(fiber.Map{"link": link, "attached": attached})
+}
+
+func (h *Handler) Unlink(c *fiber.Ctx) error {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	err = h.Store.Unlink(c.UserContext(), userID)
+	if errors.Is(err, ErrNotLinked) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to unlink phone: "+err.Error())
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListExpenses returns the linked phone's Expense Memory entries, newest first: ?limit=.
+func (h *Handler) ListExpenses(c *fiber.Ctx) error {
+	phone, err := h.phone(c)
+	if err != nil {
+		return err
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	items, err := h.Expenses.ListExpenses(c.UserContext(), expense.ListExpensesParams{UserPhone: phone, Limit: limit})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to list expenses: "+err.Error())
+	}
+	return c.JSON(items)
+}
+
+// AddExpense logs an Expense Memory entry from the dashboard; the bot sees it like its own.
+func (h *Handler) AddExpense(c *fiber.Ctx) error {
+	phone, err := h.phone(c)
+	if err != nil {
+		return err
+	}
+	var req expense.AddExpenseRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+	req.UserPhone = phone
+	if req.Source == "" {
+		req.Source = "app"
+	}
+	e, err := h.Expenses.AddExpense(c.UserContext(), req)
+	if errors.Is(err, expense.ErrBadRequest) {
+		return fiber.NewError(fiber.StatusBadRequest, "amount_rupees or text required")
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to add expense: "+err.Error())
+	}
+	return c.Status(fiber.StatusCreated).JSON(e)
+}
+
+// ExportExpenses streams the linked phone's Expense Memory as CSV or XLSX:
+// GET /api/me/expense-memory/export with the query parameters of /api/export/:dataset.
+func (h *Handler) ExportExpenses(c *fiber.Ctx) error {
+	if h.Export == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "export is not configured")
+	}
+	phone, err := h.phone(c)
+	if err != nil {
+		return err
+	}
+	return h.Export.Memory(c, phone)
+}
+
+// Attachment serves a receipt photo of the linked phone: GET /api/me/expense-memory/attachments/:id.
+func (h *Handler) Attachment(c *fiber.Ctx) error {
+	phone, err := h.phone(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "id invalid")
+	}
+	ctx := c.UserContext()
+	a, err := h.Expenses.GetAttachment(ctx, phone, id)
+	if errors.Is(err, expense.ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "attachment not found")
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load attachment: "+err.Error())
+	}
+	data, err := h.Expenses.AttachmentData(ctx, a)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load attachment: "+err.Error())
+	}
+	c.Set("Content-Type", a.ContentType)
+	c.Set("Cache-Control", "private, no-store")
+	return c.Send(data)
+}
+
+// Summary is the linked phone's month, the one the bot's summary command shows: ?year=&month=,
+// this month by default.
+func (h *Handler) Summary(c *fiber.Ctx) error {
+	phone, err := h.phone(c)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	year, month := now.Year(), int(now.Month())
+	if v := c.Query("year"); v != "" {
+		if year, err = strconv.Atoi(v); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "year invalid")
+		}
+	}
+	if v := c.Query("month"); v != "" {
+		if month, err = strconv.Atoi(v); err != nil || month < 1 || month > 12 {
+			return fiber.NewError(fiber.StatusBadRequest, "month invalid")
+		}
+	}
+	sum, err := h.Expenses.MonthlySummary(c.UserContext(), phone, year, month)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to summarise: "+err.Error())
+	}
+	return c.JSON(sum)
+}
+
+// GetReminders returns the linked phone's reminder settings and streak: GET /api/me/reminders.
+func (h *Handler) GetReminders(c *fiber.Ctx) error {
+	phone, err := h.remindersPhone(c)
+	if err != nil {
+		return err
+	}
+	ctx := c.UserContext()
+	p, err := h.Reminders.Get(ctx, phone)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load reminders: "+err.Error())
+	}
+	st, err := h.Reminders.Streak(ctx, phone)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load streak: "+err.Error())
+	}
+	return c.JSON(fiber.Map{"prefs": p, "streak": st})
+}
+
+// SaveReminders changes the linked phone's reminder settings: PUT /api/me/reminders with any of
+// enabled, time, timezone, days, quiet_start and quiet_end; the rest keep their values.
+func (h *Handler) SaveReminders(c *fiber.Ctx) error {
+	phone, err := h.remindersPhone(c)
+	if err != nil {
+		return err
+	}
+	ctx := c.UserContext()
+	p, err := h.Reminders.Get(ctx, phone)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load reminders: "+err.Error())
+	}
+	if err := c.BodyParser(&p); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+	p.Phone = phone
+
+	saved, err := h.Reminders.Save(ctx, p)
+	switch {
+	case errors.Is(err, reminders.ErrBadTime), errors.Is(err, reminders.ErrBadTimezone),
+		errors.Is(err, reminders.ErrBadDays), errors.Is(err, reminders.ErrBadQuiet):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case err != nil:
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to save reminders: "+err.Error())
+	}
+	return c.JSON(saved)
+}
+
+func (h *Handler) remindersPhone(c *fiber.Ctx) (string, error) {
+	if h.Reminders == nil {
+		return "", fiber.NewError(fiber.StatusServiceUnavailable, "reminders are not configured")
+	}
+	return h.phone(c)
+}
+
+// phone is the signed-in account's verified phone; 409 until one is linked.
+func (h *Handler) phone(c *fiber.Ctx) (string, error) {
+	userID, err := extractUserID(c)
+	if err != nil {
+		return "", fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	link, err := h.Store.Get(c.UserContext(), userID)
+	if err != nil {
+		return "", fiber.NewError(fiber.StatusInternalServerError, "failed to load phone: "+err.Error())
+	}
+	if link.Phone == "" {
+		return "", fiber.NewError(fiber.StatusConflict, "link a phone first")
+	}
+	return link.Phone, nil
+}
+
+func extractUserID(c *fiber.Ctx) (string, error) {
+	val := c.Locals("user_id")
+	if val == nil {
+		val = c.Locals("userID")
+	}
+	if val == nil {
+		return "", errors.New("user id missing")
+	}
+	if uid, ok := val.(string); ok && strings.TrimSpace(uid) != "" {
+		return uid, nil
+	}
+	return "", errors.New("user id missing")
+}
@@ -0,0 +1,272 @@
+// Package phonelink verifies a phone number for a signed-in account with a one-time code sent
+// over WhatsApp or SMS, and attaches the phone's Expense Memory data to the account.
+package phonelink
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	otpTTL         = 10 * time.Minute
+	otpResendAfter = time.Minute
+	otpMaxAttempts = 5
+	// codes an account can ask for, and a phone can receive, in a day
+	otpMaxPerDay = 5
+)
+
+var (
+	ErrBadPhone   = errors.New("phone must be in international format, like +919876543210")
+	ErrPhoneTaken = errors.New("phone is linked to another account")
+	ErrOTPInvalid = errors.New("invalid or expired code")
+	// ErrOTPTooSoon means a code was sent less than otpResendAfter ago; it is still usable.
+	ErrOTPTooSoon = errors.New("a code was sent recently")
+	ErrOTPTooMany = errors.New("too many codes today")
+	ErrNotLinked  = errors.New("no phone linked")
+)
+
+// Link is an account's verified phone.
+type Link struct {
+	Phone      string     `json:"phone,omitempty"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+}
+
+// Attached counts the phone-keyed rows a link moved onto the account.
+type Attached struct {
+	Expenses      int64 `json:"expenses"`
+	Subscriptions int64 `json:"subscriptions"`
+	Reports       int64 `json:"reports"`
+}
+
+type Store struct {
+	DB *sql.DB
+}
+
+// NormalizePhone reads "+91 98765-43210" or "whatsapp:+919876543210" as +919876543210, the
+// form the chat channels key phones by.
+func NormalizePhone(p string) (string, error) {
+	p = strings.TrimPrefix(strings.TrimSpace(p), "whatsapp:")
+	if !strings.HasPrefix(p, "+") {
+		return "", ErrBadPhone
+	}
+	digits := strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9':
+			return r
+		case r == ' ' || r == '-' || r == '(' || r == ')' || r == '+':
+			return -1
+		}
+		return 'x'
+	}, p)
+	if strings.ContainsRune(digits, 'x') || len(digits) < 8 || len(digits) > 15 {
+		return "", ErrBadPhone
+	}
+	return "+" + digits, nil
+}
+
+// Get returns userID's verified phone; a zero Link when there is none.
+func (s *Store) Get(ctx context.Context, userID string) (Link, error) {
+	var phone sql.NullString
+	var verified sql.NullTime
+	err := s.DB.QueryRowContext(ctx, `SELECT phone, phone_verified_at FROM users WHERE id = $1::uuid;`, userID).
+		Scan(&phone, &verified)
+	if err != nil {
+		return Link{}, err
+	}
+	if !phone.Valid || !verified.Valid {
+		return Link{}, nil
+	}
+	return Link{Phone: phone.String, VerifiedAt: &verified.Time}, nil
+}
+
+// AccountFor returns the account phone is verified for; "" when none.
+func (s *Store) AccountFor(ctx context.Context, phone string) (string, error) {
+	var userID string
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT id::text FROM users WHERE phone = $1 AND phone_verified_at IS NOT NULL;
+	`, strings.TrimSpace(phone)).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return userID, err
+}
+
+// IssueOTP stores a fresh six-digit code for linking phone to userID and returns it for sending;
+// asking for a different phone replaces the last code. A phone verified for another account is
+// refused. While the last code is younger than otpResendAfter it returns ErrOTPTooSoon, and
+// once the account or the phone has had otpMaxPerDay codes in a day, ErrOTPTooMany.
+func (s *Store) IssueOTP(ctx context.Context, userID, phone string) (string, error) {
+	owner, err := s.AccountFor(ctx, phone)
+	if err != nil {
+		return "", err
+	}
+	if owner != "" && owner != userID {
+		return "", ErrPhoneTaken
+	}
+	var byUser, byPhone int
+	if err := s.DB.QueryRowContext(ctx, `
+		SELECT count(*) FILTER (WHERE user_id = $1::uuid), count(*) FILTER (WHERE phone = $2)
+		FROM phone_otp_sends
+		WHERE (user_id = $1::uuid OR phone = $2) AND sent_at > now() - interval '1 day';
+	`, userID, phone).Scan(&byUser, &byPhone); err != nil {
+		return "", err
+	}
+	if byUser >= otpMaxPerDay || byPhone >= otpMaxPerDay {
+		return "", ErrOTPTooMany
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO phone_otps (user_id, phone, code_hash, attempts, sent_at, expires_at)
+		VALUES ($1::uuid, $2, $3, 0, now(), now() + make_interval(secs => $4))
+		ON CONFLICT (user_id) DO UPDATE
+		SET phone = EXCLUDED.phone, code_hash = EXCLUDED.code_hash, attempts = 0,
+		    sent_at = EXCLUDED.sent_at, expires_at = EXCLUDED.expires_at
+		WHERE phone_otps.sent_at < now() - make_interval(secs => $5);
+	`, userID, phone, hashOTP(userID, phone, code), otpTTL.Seconds(), otpResendAfter.Seconds())
+	if err != nil {
+		return "", err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return "", ErrOTPTooSoon
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO phone_otp_sends (user_id, phone) VALUES ($1::uuid, $2);`, userID, phone); err != nil {
+		return "", err
+	}
+	return code, tx.Commit()
+}
+
+// Verify checks code against userID's live code and, on success, links the phone it was sent to
+// and attaches that phone's expenses, subscriptions and reports to the account. A phone linked
+// before is detached first. Each code allows otpMaxAttempts guesses.
+func (s *Store) Verify(ctx context.Context, userID, code string) (Link, Attached, error) {
+	var phone, hash string
+	err := s.DB.QueryRowContext(ctx, `
+		UPDATE phone_otps SET attempts = attempts + 1
+		WHERE user_id = $1::uuid AND expires_at > now() AND attempts < $2
+		RETURNING phone, code_hash;
+	`, userID, otpMaxAttempts).Scan(&phone, &hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Link{}, Attached{}, ErrOTPInvalid
+	}
+	if err != nil {
+		return Link{}, Attached{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(hashOTP(userID, phone, strings.TrimSpace(code)))) != 1 {
+		return Link{}, Attached{}, ErrOTPInvalid
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return Link{}, Attached{}, err
+	}
+	defer tx.Rollback()
+
+	if err := detach(ctx, tx, userID); err != nil {
+		return Link{}, Attached{}, err
+	}
+	var link Link
+	var verified time.Time
+	err = tx.QueryRowContext(ctx, `
+		UPDATE users SET phone = $2, phone_verified_at = now()
+		WHERE id = $1::uuid
+		RETURNING phone, phone_verified_at;
+	`, userID, phone).Scan(&link.Phone, &verified)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return Link{}, Attached{}, ErrPhoneTaken
+	}
+	if err != nil {
+		return Link{}, Attached{}, err
+	}
+	link.VerifiedAt = &verified
+
+	var a Attached
+	if a.Expenses, err = execCount(ctx, tx, `UPDATE expenses SET user_id = $1::uuid WHERE user_phone = $2;`, userID, phone); err != nil {
+		return Link{}, Attached{}, err
+	}
+	if a.Subscriptions, err = execCount(ctx, tx, `UPDATE subscriptions SET user_id = $1::uuid WHERE user_phone = $2;`, userID, phone); err != nil {
+		return Link{}, Attached{}, err
+	}
+	// links made for another account (a scheduled delivery to this number) stay with it
+	if a.Reports, err = execCount(ctx, tx, `UPDATE reports SET user_id = $1::uuid WHERE user_phone = $2 AND user_id IS NULL;`, userID, phone); err != nil {
+		return Link{}, Attached{}, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM phone_otps WHERE user_id = $1::uuid;`, userID); err != nil {
+		return Link{}, Attached{}, err
+	}
+	return link, a, tx.Commit()
+}
+
+// Unlink removes userID's phone and hands its Expense Memory data back to the phone alone.
+func (s *Store) Unlink(ctx context.Context, userID string) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var phone sql.NullString
+	if err := tx.QueryRowContext(ctx, `SELECT phone FROM users WHERE id = $1::uuid FOR UPDATE;`, userID).Scan(&phone); err != nil {
+		return err
+	}
+	if !phone.Valid {
+		return ErrNotLinked
+	}
+	if err := detach(ctx, tx, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET phone = NULL, phone_verified_at = NULL WHERE id = $1::uuid;`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// detach clears the account from the rows of its current phone, if it has one.
+func detach(ctx context.Context, tx *sql.Tx, userID string) error {
+	for _, q := range []string{
+		`UPDATE expenses SET user_id = NULL WHERE user_id = $1::uuid AND user_phone = (SELECT phone FROM users WHERE id = $1::uuid);`,
+		`UPDATE subscriptions SET user_id = NULL WHERE user_id = $1::uuid;`,
+		`UPDATE reports SET user_id = NULL WHERE user_id = $1::uuid AND kind = 'expense_memory';`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func execCount(ctx context.Context, tx *sql.Tx, q string, args ...any) (int64, error) {
+	res, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func hashOTP(userID, phone, code string) string {
+	sum := sha256.Sum256([]byte(userID + ":" + phone + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
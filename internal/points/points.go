@@ -88,6 +88,49 @@ SET points_total = points_balance.points_total + EXCLUDED.points_total,
 	return pointsAwarded, nil
 }
 
+// AwardBonusPoints credits a flat number of points (no tier multiplier) at most once per sourceID.
+// It returns 0 when the bonus for sourceID was already granted.
+func AwardBonusPoints(ctx context.Context, db *pgxpool.Pool, userID string, sourceID string, pts int64, reason string) (int64, error) {
+	if pts <= 0 || strings.TrimSpace(sourceID) == "" {
+		return 0, nil
+	}
+
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	ct, err := tx.Exec(ctx, `
+INSERT INTO points_ledger (user_id, source_txn_id, points_delta, reason, created_at)
+VALUES ($1, $2, $3, $4, NOW())
+ON CONFLICT (user_id, source_txn_id) WHERE source_txn_id IS NOT NULL DO NOTHING
+`, userID, sourceID, pts, reason)
+	if err != nil {
+		return 0, err
+	}
+	if ct.RowsAffected() == 0 {
+		return 0, nil
+	}
+
+	_, err = tx.Exec(ctx, `
+INSERT INTO points_balance (user_id, points_total, updated_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (user_id) DO UPDATE
+SET points_total = points_balance.points_total + EXCLUDED.points_total,
+    updated_at = NOW()
+`, userID, pts)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return pts, nil
+}
+
 func lookupTier(ctx context.Context, tx pgx.Tx, points int64) (string, float64, error) {
 	rows, err := tx.Query(ctx, `SELECT tier_name, min_points, multiplier FROM tiers ORDER BY min_points DESC`)
 	if err != nil {
@@ -0,0 +1,119 @@
+package recurring
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	Repo *Repository
+}
+
+func NewHandler(repo *Repository) *Handler {
+	return &Handler{Repo: repo}
+}
+
+func getUserID(c *fiber.Ctx) string {
+	if v := c.Locals("user_id"); v != nil {
+		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+			return strings.TrimSpace(s)
+		}
+	}
+	return ""
+}
+
+func (h *Handler) List(c *fiber.Ctx) error {
+	userID := getUserID(c)
+	if userID == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	items, err := h.Repo.List(c.UserContext(), userID, false)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to list recurring rules: "+err.Error())
+	}
+	return c.JSON(items)
+}
+
+func (h *Handler) Create(c *fiber.Ctx) error {
+	userID := getUserID(c)
+	if userID == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	var req CreateRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+
+	kind := strings.ToLower(strings.TrimSpace(req.Kind))
+	if kind != "income" && kind != "expense" {
+		return fiber.NewError(fiber.StatusBadRequest, "kind must be income or expense")
+	}
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "title required")
+	}
+	if req.Amount <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "amount must be greater than zero")
+	}
+	cadence := strings.ToLower(strings.TrimSpace(req.Cadence))
+	if cadence == "" {
+		cadence = CadenceMonthly
+	}
+	if !validCadence(cadence) {
+		return fiber.NewError(fiber.StatusBadRequest, "cadence must be weekly, monthly, quarterly or yearly")
+	}
+
+	startOn, err := time.Parse("2006-01-02", strings.TrimSpace(req.StartOn))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "start_on must be YYYY-MM-DD")
+	}
+	var endOn *time.Time
+	if strings.TrimSpace(req.EndOn) != "" {
+		d, err := time.Parse("2006-01-02", strings.TrimSpace(req.EndOn))
+		if err != nil || d.Before(startOn) {
+			return fiber.NewError(fiber.StatusBadRequest, "end_on must be YYYY-MM-DD on or after start_on")
+		}
+		endOn = &d
+	}
+
+	rule, err := h.Repo.Create(c.UserContext(), Rule{
+		UserID:   userID,
+		Kind:     kind,
+		Title:    title,
+		Category: strings.TrimSpace(req.Category),
+		Amount:   req.Amount,
+		Cadence:  cadence,
+		StartOn:  startOn,
+		EndOn:    endOn,
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to create recurring rule: "+err.Error())
+	}
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+func (h *Handler) Delete(c *fiber.Ctx) error {
+	userID := getUserID(c)
+	if userID == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	id, err := strconv.ParseInt(strings.TrimSpace(c.Params("id")), 10, 64)
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+
+	if err := h.Repo.Deactivate(c.UserContext(), userID, id); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, "not found")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to delete recurring rule: "+err.Error())
+	}
+	return c.JSON(fiber.Map{"status": "ok"})
+}
@@ -0,0 +1,111 @@
+package recurring
+
+import "time"
+
+const (
+	CadenceWeekly    = "weekly"
+	CadenceMonthly   = "monthly"
+	CadenceQuarterly = "quarterly"
+	CadenceYearly    = "yearly"
+)
+
+// Rule is a repeating income or expense (rent, retainer, SaaS bill) used for planning.
+type Rule struct {
+	ID        int64      `json:"id"`
+	UserID    string     `json:"user_id"`
+	Kind      string     `json:"kind"`     // income | expense
+	Title     string     `json:"title"`    // client_name or vendor_name
+	Category  string     `json:"category"` // expense category; empty for income
+	Amount    int64      `json:"amount"`   // paise
+	Currency  string     `json:"currency"`
+	Cadence   string     `json:"cadence"` // weekly | monthly | quarterly | yearly
+	StartOn   time.Time  `json:"start_on"`
+	EndOn     *time.Time `json:"end_on,omitempty"`
+	Active    bool       `json:"active"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type CreateRuleRequest struct {
+	Kind     string `json:"kind"`
+	Title    string `json:"title"`
+	Category string `json:"category"`
+	Amount   int64  `json:"amount"`
+	Cadence  string `json:"cadence"`
+	StartOn  string `json:"start_on"` // YYYY-MM-DD
+	EndOn    string `json:"end_on"`   // YYYY-MM-DD, optional
+}
+
+// Occurrences returns the rule's due dates falling within [from, to] (inclusive, by day).
+func (r Rule) Occurrences(from, to time.Time) []time.Time {
+	if !r.Active {
+		return nil
+	}
+	from = truncateDay(from)
+	to = truncateDay(to)
+	start := truncateDay(r.StartOn)
+
+	var out []time.Time
+	for i := 0; i < 5000; i++ {
+		d := r.nth(start, i)
+		if d.After(to) {
+			break
+		}
+		if r.EndOn != nil && d.After(truncateDay(*r.EndOn)) {
+			break
+		}
+		if !d.Before(from) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// MonthlyEquivalent normalises the rule amount to an average month.
+func (r Rule) MonthlyEquivalent() float64 {
+	switch r.Cadence {
+	case CadenceWeekly:
+		return float64(r.Amount) * 52 / 12
+	case CadenceQuarterly:
+		return float64(r.Amount) / 3
+	case CadenceYearly:
+		return float64(r.Amount) / 12
+	default:
+		return float64(r.Amount)
+	}
+}
+
+func (r Rule) nth(start time.Time, i int) time.Time {
+	switch r.Cadence {
+	case CadenceWeekly:
+		return start.AddDate(0, 0, 7*i)
+	case CadenceQuarterly:
+		return addMonthsClamped(start, 3*i)
+	case CadenceYearly:
+		return addMonthsClamped(start, 12*i)
+	default:
+		return addMonthsClamped(start, i)
+	}
+}
+
+// addMonthsClamped keeps end-of-month rules on the last day instead of overflowing (Jan 31 -> Feb 28).
+func addMonthsClamped(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC).AddDate(0, months, 0)
+	last := first.AddDate(0, 1, -1).Day()
+	if d > last {
+		d = last
+	}
+	return time.Date(first.Year(), first.Month(), d, 0, 0, 0, 0, time.UTC)
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func validCadence(c string) bool {
+	switch c {
+	case CadenceWeekly, CadenceMonthly, CadenceQuarterly, CadenceYearly:
+		return true
+	}
+	return false
+}
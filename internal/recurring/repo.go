@@ -0,0 +1,76 @@
+package recurring
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrNotFound = errors.New("recurring rule not found")
+
+type Repository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{Pool: pool}
+}
+
+const ruleColumns = `id, user_id::text, kind, title, category, amount, currency, cadence, start_on, end_on, active, created_at`
+
+func scanRule(row pgx.Row) (Rule, error) {
+	var r Rule
+	err := row.Scan(&r.ID, &r.UserID, &r.Kind, &r.Title, &r.Category, &r.Amount, &r.Currency, &r.Cadence, &r.StartOn, &r.EndOn, &r.Active, &r.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Rule{}, ErrNotFound
+	}
+	return r, err
+}
+
+func (r *Repository) Create(ctx context.Context, rule Rule) (Rule, error) {
+	row := r.Pool.QueryRow(ctx, `
+INSERT INTO recurring_rules (user_id, kind, title, category, amount, cadence, start_on, end_on)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING `+ruleColumns,
+		rule.UserID, rule.Kind, rule.Title, rule.Category, rule.Amount, rule.Cadence, rule.StartOn, rule.EndOn,
+	)
+	return scanRule(row)
+}
+
+// List returns the user's rules; when activeOnly is set, deactivated rules are skipped.
+func (r *Repository) List(ctx context.Context, userID string, activeOnly bool) ([]Rule, error) {
+	rows, err := r.Pool.Query(ctx, `
+SELECT `+ruleColumns+`
+FROM recurring_rules
+WHERE user_id = $1
+  AND (NOT $2 OR active)
+ORDER BY created_at DESC
+`, userID, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Rule, 0)
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rule)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) Deactivate(ctx context.Context, userID string, id int64) error {
+	ct, err := r.Pool.Exec(ctx, `UPDATE recurring_rules SET active = FALSE WHERE id = $1 AND user_id = $2 AND active`, id, userID)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
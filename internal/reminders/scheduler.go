@@ -0,0 +1,190 @@
+package reminders
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/outbox"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/points"
+)
+
+type enqueuer interface {
+	Enqueue(ctx context.Context, m outbox.Message) (outbox.Message, error)
+}
+
+// accountResolver finds the account a phone belongs to; "" when it has none yet.
+type accountResolver interface {
+	AccountFor(ctx context.Context, phone string) (string, error)
+}
+
+// Scheduler checks each phone once a day at its reminder time: a phone with nothing logged
+// that day gets a nudge through the outbox, and its streak is recounted either way. It also
+// credits streak bonuses to the points ledger of phones that belong to an account.
+type Scheduler struct {
+	Store  *Store
+	Outbox enqueuer
+	// Pool and Accounts credit bonuses; they stay uncredited while either is unset.
+	Pool     *pgxpool.Pool
+	Accounts accountResolver
+	Interval time.Duration
+}
+
+// claimLease is how long a claimed phone stays with one instance; one tick handles it well
+// inside this.
+const claimLease = 5 * time.Minute
+
+func NewScheduler(store *Store, out enqueuer, pool *pgxpool.Pool) *Scheduler {
+	return &Scheduler{Store: store, Outbox: out, Pool: pool, Interval: time.Minute}
+}
+
+// Run blocks until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	t := time.NewTicker(s.Interval)
+	defer t.Stop()
+	for {
+		s.Tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (s *Scheduler) Tick(ctx context.Context) {
+	now := time.Now()
+	due, err := s.Store.claimDue(ctx, 500, claimLease)
+	if err != nil {
+		log.Printf("reminders: list: %v", err)
+		return
+	}
+	sent := 0
+	for _, p := range due {
+		nudged, err := s.check(ctx, p, now)
+		if err != nil {
+			log.Printf("reminders: %s: %v", p.Phone, err)
+			continue
+		}
+		if nudged {
+			sent++
+		}
+	}
+	if sent > 0 {
+		log.Printf("reminders: queued %d nudges", sent)
+	}
+
+	s.credit(ctx)
+}
+
+// check handles p if its reminder time has come, and reports whether it queued a nudge.
+func (s *Scheduler) check(ctx context.Context, p Prefs, now time.Time) (bool, error) {
+	local := now.In(p.Location())
+	minute := local.Hour()*60 + local.Minute()
+	at, err := clockMinutes(p.Time)
+	if err != nil || minute < at {
+		return false, err
+	}
+
+	// a disabled phone is still checked daily so its streak and bonuses stay current
+	nudge := p.Enabled && p.On(local)
+	if nudge {
+		if until, quiet := p.quietUntil(minute); quiet {
+			if until >= 0 {
+				// try again when the quiet hours end
+				end := time.Date(local.Year(), local.Month(), local.Day(), until/60, until%60, 0, 0, local.Location())
+				return false, s.Store.snooze(ctx, p.Phone, end)
+			}
+			nudge = false // quiet for the rest of the day
+		}
+	}
+
+	st, err := s.Store.Update(ctx, p.Phone, now)
+	if err != nil {
+		return false, err
+	}
+	if len(st.Earned) > 0 {
+		if _, err := s.Outbox.Enqueue(ctx, outbox.Message{To: p.Phone, Body: earnedText(st), Purpose: outbox.PurposeStreakBonus}); err != nil {
+			return false, err
+		}
+	}
+	nudge = nudge && !st.Today
+	if nudge {
+		if _, err := s.Outbox.Enqueue(ctx, outbox.Message{To: p.Phone, Body: nudgeText(st), Purpose: outbox.PurposeReminder}); err != nil {
+			return false, err
+		}
+	}
+	return nudge, s.Store.markChecked(ctx, p.Phone, local)
+}
+
+// quietUntil reports whether minute falls in p's quiet hours and, if so, the minute they end
+// today; -1 when they run past midnight.
+func (p Prefs) quietUntil(minute int) (int, bool) {
+	if p.QuietStart == "" {
+		return 0, false
+	}
+	start, err1 := clockMinutes(p.QuietStart)
+	end, err2 := clockMinutes(p.QuietEnd)
+	if err1 != nil || err2 != nil || start == end {
+		return 0, false
+	}
+	if start < end {
+		return end, minute >= start && minute < end
+	}
+	// wraps midnight, like 22:00-07:00
+	switch {
+	case minute >= start:
+		return -1, true
+	case minute < end:
+		return end, true
+	}
+	return 0, false
+}
+
+func nudgeText(st Streak) string {
+	if st.Current > 1 {
+		return fmt.Sprintf("You're on a %d-day logging streak. Nothing logged today yet: reply with what you spent, like \"250 lunch\", to keep it going.\n\nReply STOP to stop these reminders.", st.Current)
+	}
+	return "Nothing logged today yet. Reply with what you spent, like \"250 lunch\" or \"kal 2 chai 40\".\n\nReply STOP to stop these reminders."
+}
+
+func earnedText(st Streak) string {
+	m := st.Earned[len(st.Earned)-1]
+	return fmt.Sprintf("%d days of logging in a row! You've earned %d bonus points.", m.Days, m.Points)
+}
+
+// credit moves pending bonuses of phones that now belong to an account onto its ledger.
+func (s *Scheduler) credit(ctx context.Context) {
+	if s.Pool == nil || s.Accounts == nil {
+		return
+	}
+	bonuses, err := s.Store.uncredited(ctx, 100)
+	if err != nil {
+		log.Printf("reminders: bonuses: %v", err)
+		return
+	}
+	for _, b := range bonuses {
+		userID, err := s.Accounts.AccountFor(ctx, b.Phone)
+		if err != nil {
+			log.Printf("reminders: account for %s: %v", b.Phone, err)
+			continue
+		}
+		if userID == "" {
+			if err := s.Store.markTried(ctx, b.SourceID); err != nil {
+				log.Printf("reminders: bonus %s: %v", b.SourceID, err)
+			}
+			continue
+		}
+		// the ledger ignores a source it has seen, so a crash between the two steps is harmless
+		if _, err := points.AwardBonusPoints(ctx, s.Pool, userID, b.SourceID, b.Points, BonusReason); err != nil {
+			log.Printf("reminders: credit %s: %v", b.SourceID, err)
+			continue
+		}
+		if err := s.Store.markCredited(ctx, b.SourceID, userID); err != nil {
+			log.Printf("reminders: credit %s: %v", b.SourceID, err)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Package reminders nudges Expense Memory users who haven't logged anything by their reminder
+// time, tracks daily logging streaks and grants streak bonuses to the points ledger.
+package reminders
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrBadTime     = errors.New("time must look like 21:00 or 9pm")
+	ErrBadTimezone = errors.New("unknown timezone")
+	ErrBadDays     = errors.New("days must be daily, weekdays, weekends or names like mon,wed,fri")
+	ErrBadQuiet    = errors.New("quiet hours need both a start and an end")
+)
+
+const (
+	DefaultTime     = "21:00"
+	DefaultTimezone = "Asia/Kolkata"
+)
+
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+const allDays = 1<<7 - 1
+
+// Prefs are one phone's reminder settings. Time and the quiet hours are HH:MM in Timezone; a
+// phone without a row has reminders off.
+type Prefs struct {
+	Phone      string    `json:"phone"`
+	Enabled    bool      `json:"enabled"`
+	Time       string    `json:"time"`
+	Timezone   string    `json:"timezone"`
+	Days       []string  `json:"days"`
+	QuietStart string    `json:"quiet_start"`
+	QuietEnd   string    `json:"quiet_end"`
+	OptedOut   bool      `json:"opted_out"` // STOP; overrides Enabled until START
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func defaults(phone string) Prefs {
+	return Prefs{Phone: phone, Time: DefaultTime, Timezone: DefaultTimezone, Days: daysOf(allDays)}
+}
+
+// Location is the phone's timezone, India when it doesn't load.
+func (p Prefs) Location() *time.Location {
+	if loc, err := time.LoadLocation(p.Timezone); err == nil {
+		return loc
+	}
+	return time.FixedZone("IST", 5*60*60+30*60)
+}
+
+// On reports whether reminders go out on t's weekday.
+func (p Prefs) On(t time.Time) bool {
+	mask, _ := daysMask(p.Days)
+	return mask&(1<<int(t.Weekday())) != 0
+}
+
+type Store struct {
+	DB *sql.DB
+}
+
+const prefsColumns = `phone, enabled, remind_at, timezone, days_mask, quiet_start, quiet_end, opted_out, updated_at`
+
+func scanPrefs(row interface{ Scan(...any) error }) (Prefs, error) {
+	var p Prefs
+	var mask int
+	err := row.Scan(&p.Phone, &p.Enabled, &p.Time, &p.Timezone, &mask, &p.QuietStart, &p.QuietEnd, &p.OptedOut, &p.UpdatedAt)
+	p.Days = daysOf(mask)
+	return p, err
+}
+
+// Get returns phone's settings, or the defaults (reminders off) when it has none.
+func (s *Store) Get(ctx context.Context, phone string) (Prefs, error) {
+	phone = strings.TrimSpace(phone)
+	p, err := scanPrefs(s.DB.QueryRowContext(ctx, `SELECT `+prefsColumns+` FROM reminder_prefs WHERE phone = $1;`, phone))
+	if errors.Is(err, sql.ErrNoRows) {
+		return defaults(phone), nil
+	}
+	return p, err
+}
+
+// Save validates and stores p. The opt-out is left alone; only STOP and START change it.
+func (s *Store) Save(ctx context.Context, p Prefs) (Prefs, error) {
+	p.Phone = strings.TrimSpace(p.Phone)
+	if p.Phone == "" {
+		return Prefs{}, errors.New("phone required")
+	}
+	if p.Time == "" {
+		p.Time = DefaultTime
+	}
+	if p.Timezone == "" {
+		p.Timezone = DefaultTimezone
+	}
+	if len(p.Days) == 0 {
+		p.Days = daysOf(allDays)
+	}
+
+	var err error
+	if p.Time, err = NormalizeClock(p.Time); err != nil {
+		return Prefs{}, err
+	}
+	if _, err := time.LoadLocation(p.Timezone); err != nil {
+		return Prefs{}, ErrBadTimezone
+	}
+	mask, err := daysMask(p.Days)
+	if err != nil {
+		return Prefs{}, err
+	}
+	if (p.QuietStart == "") != (p.QuietEnd == "") {
+		return Prefs{}, ErrBadQuiet
+	}
+	if p.QuietStart != "" {
+		if p.QuietStart, err = NormalizeClock(p.QuietStart); err != nil {
+			return Prefs{}, err
+		}
+		if p.QuietEnd, err = NormalizeClock(p.QuietEnd); err != nil {
+			return Prefs{}, err
+		}
+	}
+
+	return scanPrefs(s.DB.QueryRowContext(ctx, `
+		INSERT INTO reminder_prefs (phone, enabled, remind_at, timezone, days_mask, quiet_start, quiet_end)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (phone) DO UPDATE
+		SET enabled = EXCLUDED.enabled,
+		    remind_at = EXCLUDED.remind_at,
+		    timezone = EXCLUDED.timezone,
+		    days_mask = EXCLUDED.days_mask,
+		    quiet_start = EXCLUDED.quiet_start,
+		    quiet_end = EXCLUDED.quiet_end,
+		    updated_at = now()
+		RETURNING `+prefsColumns+`;
+	`, p.Phone, p.Enabled, p.Time, p.Timezone, mask, p.QuietStart, p.QuietEnd))
+}
+
+// SetOptedOut records STOP (true) or START (false) from phone.
+func (s *Store) SetOptedOut(ctx context.Context, phone string, out bool) error {
+	_, err := s.DB.ExecContext(ctx, `
+		INSERT INTO reminder_prefs (phone, opted_out) VALUES ($1, $2)
+		ON CONFLICT (phone) DO UPDATE SET opted_out = EXCLUDED.opted_out, updated_at = now();
+	`, strings.TrimSpace(phone), out)
+	return err
+}
+
+// claimDue leases the phones due in their own timezone: past their reminder time, not opted
+// out, not yet handled by the scheduler on their local date and not leased already. Row locks
+// with SKIP LOCKED and the lease keep several instances from nudging the same phone; a phone
+// whose handling fails is tried again when its lease runs out.
+func (s *Store) claimDue(ctx context.Context, limit int, lease time.Duration) ([]Prefs, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		UPDATE reminder_prefs
+		SET claimed_until = now() + make_interval(secs => $2)
+		WHERE phone IN (
+			SELECT phone FROM reminder_prefs
+			WHERE NOT opted_out
+			  AND (checked_on IS NULL OR checked_on < (now() AT TIME ZONE timezone)::date)
+			  AND remind_at <= to_char(now() AT TIME ZONE timezone, 'HH24:MI')
+			  AND (claimed_until IS NULL OR claimed_until <= now())
+			ORDER BY checked_on NULLS FIRST, phone
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+prefsColumns+`;
+	`, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Prefs
+	for rows.Next() {
+		p, err := scanPrefs(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// snooze keeps phone out of claimDue until the given time, the end of its quiet hours.
+func (s *Store) snooze(ctx context.Context, phone string, until time.Time) error {
+	_, err := s.DB.ExecContext(ctx, `UPDATE reminder_prefs SET claimed_until = $2 WHERE phone = $1;`, phone, until)
+	return err
+}
+
+// markChecked records that phone was handled for its local day and releases its lease.
+func (s *Store) markChecked(ctx context.Context, phone string, day time.Time) error {
+	_, err := s.DB.ExecContext(ctx, `
+		UPDATE reminder_prefs SET checked_on = $2, claimed_until = NULL WHERE phone = $1;
+	`, phone, day.Format("2006-01-02"))
+	return err
+}
+
+// NormalizeClock reads "21:00", "9:30", "9pm" or "9:30 pm" as HH:MM.
+func NormalizeClock(s string) (string, error) {
+	m, err := clockMinutes(s)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%02d:%02d", m/60, m%60), nil
+}
+
+func clockMinutes(s string) (int, error) {
+	s = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), " ", ""))
+	pm := strings.HasSuffix(s, "pm")
+	am := strings.HasSuffix(s, "am")
+	if pm || am {
+		s = s[:len(s)-2]
+	}
+	hs, ms, hasMinutes := strings.Cut(s, ":")
+	if !hasMinutes {
+		hs, ms, hasMinutes = strings.Cut(s, ".")
+	}
+	h, err := strconv.Atoi(hs)
+	if err != nil {
+		return 0, ErrBadTime
+	}
+	m := 0
+	if hasMinutes {
+		if m, err = strconv.Atoi(ms); err != nil || len(ms) != 2 {
+			return 0, ErrBadTime
+		}
+	}
+	if pm || am {
+		if h < 1 || h > 12 {
+			return 0, ErrBadTime
+		}
+		h %= 12
+		if pm {
+			h += 12
+		}
+	}
+	if h > 23 || m > 59 {
+		return 0, ErrBadTime
+	}
+	return h*60 + m, nil
+}
+
+// ParseDays reads "daily", "weekdays", "weekends" or day names ("mon,wed fri").
+func ParseDays(s string) ([]string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "", "daily", "everyday", "every day", "all":
+		return daysOf(allDays), nil
+	case "weekdays":
+		return daysOf(allDays &^ (1 | 1<<6)), nil
+	case "weekends":
+		return daysOf(1 | 1<<6), nil
+	}
+	mask, err := daysMask(strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }))
+	if err != nil {
+		return nil, err
+	}
+	return daysOf(mask), nil
+}
+
+func daysMask(days []string) (int, error) {
+	mask := 0
+	for _, d := range days {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if len(d) > 3 {
+			d = d[:3]
+		}
+		i := indexOf(dayNames, d)
+		if i < 0 {
+			return 0, ErrBadDays
+		}
+		mask |= 1 << i
+	}
+	if mask == 0 {
+		return 0, ErrBadDays
+	}
+	return mask, nil
+}
+
+func daysOf(mask int) []string {
+	days := []string{}
+	// Monday first, the way people list a week here
+	for _, i := range []int{1, 2, 3, 4, 5, 6, 0} {
+		if mask&(1<<i) != 0 {
+			days = append(days, dayNames[i])
+		}
+	}
+	return days
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
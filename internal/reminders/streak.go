@@ -0,0 +1,198 @@
+package reminders
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Milestone is a streak length worth a one-off bonus.
+type Milestone struct {
+	Days   int
+	Points int64
+}
+
+// Milestones pay once per streak: a broken streak earns them again from the start.
+var Milestones = []Milestone{{7, 50}, {30, 250}, {100, 1000}, {365, 5000}}
+
+// BonusReason is the points ledger reason of streak bonuses.
+const BonusReason = "logging_streak"
+
+// Streak counts the consecutive days, in the phone's timezone, with at least one expense. A
+// streak that ran through yesterday is still alive until today ends.
+type Streak struct {
+	Phone   string    `json:"phone"`
+	Current int       `json:"current_days"`
+	Best    int       `json:"best_days"`
+	Start   string    `json:"start,omitempty"` // first day of the current streak, YYYY-MM-DD
+	Today   bool      `json:"logged_today"`
+	Updated time.Time `json:"updated_at"`
+	// Extended is set by Update when the stored streak grew by this call.
+	Extended bool `json:"-"`
+	// Earned are the milestones this call reached for the first time.
+	Earned []Milestone `json:"-"`
+}
+
+// Update recounts phone's streak as of now, stores it and records any milestone bonus it
+// reached; the bonus reaches the points ledger once the phone belongs to an account.
+func (s *Store) Update(ctx context.Context, phone string, now time.Time) (Streak, error) {
+	phone = strings.TrimSpace(phone)
+	p, err := s.Get(ctx, phone)
+	if err != nil {
+		return Streak{}, err
+	}
+	now = now.In(p.Location())
+	st, err := s.count(ctx, phone, now)
+	if err != nil {
+		return Streak{}, err
+	}
+
+	var prevCurrent int
+	var prevStart string
+	err = s.DB.QueryRowContext(ctx, `SELECT current_days, start_on FROM logging_streaks WHERE phone = $1;`, phone).
+		Scan(&prevCurrent, &prevStart)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Streak{}, err
+	}
+	st.Extended = st.Current > prevCurrent || (st.Current > 0 && st.Start != prevStart)
+
+	err = s.DB.QueryRowContext(ctx, `
+		INSERT INTO logging_streaks (phone, current_days, best_days, start_on, logged_today)
+		VALUES ($1, $2, $2, $3, $4)
+		ON CONFLICT (phone) DO UPDATE
+		SET current_days = EXCLUDED.current_days,
+		    best_days = GREATEST(logging_streaks.best_days, EXCLUDED.current_days),
+		    start_on = EXCLUDED.start_on,
+		    logged_today = EXCLUDED.logged_today,
+		    updated_at = now()
+		RETURNING best_days, updated_at;
+	`, phone, st.Current, st.Start, st.Today).Scan(&st.Best, &st.Updated)
+	if err != nil {
+		return Streak{}, err
+	}
+
+	for _, m := range Milestones {
+		if st.Current < m.Days {
+			break
+		}
+		res, err := s.DB.ExecContext(ctx, `
+			INSERT INTO streak_bonuses (source_id, phone, days, points)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (source_id) DO NOTHING;
+		`, bonusSource(phone, st.Start, m.Days), phone, m.Days, m.Points)
+		if err != nil {
+			return Streak{}, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			st.Earned = append(st.Earned, m)
+		}
+	}
+	return st, nil
+}
+
+// Streak returns phone's streak as the last Update stored it.
+func (s *Store) Streak(ctx context.Context, phone string) (Streak, error) {
+	st := Streak{Phone: strings.TrimSpace(phone)}
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT current_days, best_days, start_on, logged_today, updated_at
+		FROM logging_streaks WHERE phone = $1;
+	`, st.Phone).Scan(&st.Current, &st.Best, &st.Start, &st.Today, &st.Updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return st, nil
+	}
+	return st, err
+}
+
+// bonusSource keys a milestone to the streak that reached it, for the ledger's idempotency.
+func bonusSource(phone, start string, days int) string {
+	return "streak:" + phone + ":" + start + ":" + strconv.Itoa(days)
+}
+
+// count walks back from today over the local days phone logged an expense on.
+func (s *Store) count(ctx context.Context, phone string, now time.Time) (Streak, error) {
+	st := Streak{Phone: phone}
+	// a year and a bit covers the longest milestone; older days can't extend a live streak
+	since := now.AddDate(0, 0, -400)
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT DISTINCT to_char(created_at AT TIME ZONE $2, 'YYYY-MM-DD') AS day
+		FROM expenses
+		WHERE user_phone = $1 AND created_at >= $3 AND created_at <= $4
+		ORDER BY day DESC;
+	`, phone, now.Location().String(), since, now)
+	if err != nil {
+		return st, err
+	}
+	defer rows.Close()
+
+	today := now.Format("2006-01-02")
+	want := today
+	for rows.Next() {
+		var day string
+		if err := rows.Scan(&day); err != nil {
+			return st, err
+		}
+		if st.Current == 0 && day == today {
+			st.Today = true
+		}
+		if st.Current == 0 && day != today {
+			// nothing today yet: the streak may still run through yesterday
+			want = now.AddDate(0, 0, -1).Format("2006-01-02")
+		}
+		if day != want {
+			break
+		}
+		st.Current++
+		st.Start = day
+		d, _ := time.Parse("2006-01-02", day)
+		want = d.AddDate(0, 0, -1).Format("2006-01-02")
+	}
+	return st, rows.Err()
+}
+
+// Bonus is a streak bonus waiting to be credited to an account's points ledger.
+type Bonus struct {
+	SourceID string
+	Phone    string
+	Days     int
+	Points   int64
+}
+
+// uncredited lists bonuses not yet on the points ledger, those not tried lately first.
+func (s *Store) uncredited(ctx context.Context, limit int) ([]Bonus, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT source_id, phone, days, points FROM streak_bonuses
+		WHERE credited_at IS NULL
+		ORDER BY tried_at NULLS FIRST, created_at
+		LIMIT $1;
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Bonus
+	for rows.Next() {
+		var b Bonus
+		if err := rows.Scan(&b.SourceID, &b.Phone, &b.Days, &b.Points); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// markTried puts a bonus whose phone has no account yet behind the others.
+func (s *Store) markTried(ctx context.Context, sourceID string) error {
+	_, err := s.DB.ExecContext(ctx, `UPDATE streak_bonuses SET tried_at = now() WHERE source_id = $1;`, sourceID)
+	return err
+}
+
+func (s *Store) markCredited(ctx context.Context, sourceID, userID string) error {
+	_, err := s.DB.ExecContext(ctx, `
+		UPDATE streak_bonuses SET user_id = $2, credited_at = now() WHERE source_id = $1;
+	`, sourceID, userID)
+	return err
+}
@@ -0,0 +1,234 @@
+package reports
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+const (
+	otpTTL         = 10 * time.Minute
+	otpResendAfter = time.Minute
+	otpMaxAttempts = 5
+	// a link locks after this many codes or wrong guesses without a right one, until its owner
+	// saves its access settings again
+	otpMaxSends   = 3
+	otpMaxGuesses = 10
+)
+
+var (
+	ErrOTPInvalid = errors.New("invalid or expired code")
+	// ErrOTPTooSoon means a code was sent less than otpResendAfter ago; it is still usable.
+	ErrOTPTooSoon = errors.New("a code was sent recently")
+	ErrOTPLocked  = errors.New("too many codes or attempts; the link is locked")
+)
+
+// Download is one served file in a link's access log.
+type Download struct {
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ClaimDownload counts one download against the link and logs who fetched it. It fails with
+// ErrUnavailable when the link was revoked, expired or used up in the meantime.
+func (s *Store) ClaimDownload(ctx context.Context, id int64, ip, userAgent string) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE reports SET download_count = download_count + 1
+		WHERE id = $1 AND revoked_at IS NULL AND expires_at > now()
+		  AND (max_downloads IS NULL OR download_count < max_downloads);
+	`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrUnavailable
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO report_downloads (report_id, ip, user_agent) VALUES ($1, $2, $3);
+	`, id, ip, trimTo(userAgent, 300)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListForUser returns the links owned by userID, newest first.
+func (s *Store) ListForUser(ctx context.Context, userID string, limit int) ([]Report, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT `+reportColumns+` FROM reports
+		WHERE user_id = $1::uuid
+		ORDER BY created_at DESC
+		LIMIT $2;
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Report, 0)
+	for rows.Next() {
+		r, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) GetForUser(ctx context.Context, userID string, id int64) (Report, error) {
+	return scanReport(s.DB.QueryRowContext(ctx, `
+		SELECT `+reportColumns+` FROM reports WHERE id = $1 AND user_id = $2::uuid;
+	`, id, userID))
+}
+
+// Downloads returns a link's access log, newest first.
+func (s *Store) Downloads(ctx context.Context, id int64, limit int) ([]Download, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT ip, user_agent, created_at FROM report_downloads
+		WHERE report_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2;
+	`, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Download, 0)
+	for rows.Next() {
+		var d Download
+		if err := rows.Scan(&d.IP, &d.UserAgent, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// UpdateAccess saves the owner's download cap and OTP setting, and unlocks a link locked by
+// too many codes.
+func (s *Store) UpdateAccess(ctx context.Context, userID string, id int64, maxDownloads *int, requireOTP bool) (Report, error) {
+	r, err := scanReport(s.DB.QueryRowContext(ctx, `
+		UPDATE reports SET max_downloads = $3, require_otp = $4
+		WHERE id = $1 AND user_id = $2::uuid
+		RETURNING `+reportColumns+`;
+	`, id, userID, maxDownloads, requireOTP))
+	if err != nil {
+		return r, err
+	}
+	_, err = s.DB.ExecContext(ctx, `DELETE FROM report_otps WHERE report_id = $1;`, id)
+	return r, err
+}
+
+// Revoke disables the link for good and drops its file; revoking twice keeps the first time.
+func (s *Store) Revoke(ctx context.Context, userID string, id int64) (Report, error) {
+	r, err := scanReport(s.DB.QueryRowContext(ctx, `
+		UPDATE reports SET revoked_at = COALESCE(revoked_at, now())
+		WHERE id = $1 AND user_id = $2::uuid
+		RETURNING `+reportColumns+`;
+	`, id, userID))
+	if err != nil {
+		return r, err
+	}
+	if r.ObjectKey != "" {
+		if err := s.Blobs.Delete(ctx, r.ObjectKey); err != nil {
+			return r, err
+		}
+	}
+	return r, nil
+}
+
+// IssueOTP stores a fresh six-digit code for the link and returns it for sending. While the last
+// code is younger than otpResendAfter it returns ErrOTPTooSoon instead, and ErrOTPLocked once
+// the link has had otpMaxSends codes or otpMaxGuesses wrong guesses.
+func (s *Store) IssueOTP(ctx context.Context, id int64) (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+
+	res, err := s.DB.ExecContext(ctx, `
+		INSERT INTO report_otps (report_id, code_hash, attempts, sends, sent_at, expires_at)
+		VALUES ($1, $2, 0, 1, now(), now() + make_interval(secs => $3))
+		ON CONFLICT (report_id) DO UPDATE
+		SET code_hash = EXCLUDED.code_hash, attempts = 0, sends = report_otps.sends + 1,
+		    sent_at = EXCLUDED.sent_at, expires_at = EXCLUDED.expires_at
+		WHERE report_otps.sent_at < now() - make_interval(secs => $4)
+		  AND report_otps.sends < $5 AND report_otps.guesses < $6;
+	`, id, hashOTP(id, code), otpTTL.Seconds(), otpResendAfter.Seconds(), otpMaxSends, otpMaxGuesses)
+	if err != nil {
+		return "", err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		locked, err := s.otpLocked(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		if locked {
+			return "", ErrOTPLocked
+		}
+		return "", ErrOTPTooSoon
+	}
+	return code, nil
+}
+
+// VerifyOTP checks code against the link's live code and uses it up on success. Each code allows
+// otpMaxAttempts guesses and the link otpMaxGuesses in all; past that it is ErrOTPLocked.
+func (s *Store) VerifyOTP(ctx context.Context, id int64, code string) error {
+	var hash string
+	err := s.DB.QueryRowContext(ctx, `
+		UPDATE report_otps SET attempts = attempts + 1, guesses = guesses + 1
+		WHERE report_id = $1 AND expires_at > now() AND attempts < $2 AND guesses < $3
+		RETURNING code_hash;
+	`, id, otpMaxAttempts, otpMaxGuesses).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		locked, err := s.otpLocked(ctx, id)
+		if err != nil {
+			return err
+		}
+		if locked {
+			return ErrOTPLocked
+		}
+		return ErrOTPInvalid
+	}
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(hashOTP(id, code))) != 1 {
+		return ErrOTPInvalid
+	}
+	_, err = s.DB.ExecContext(ctx, `DELETE FROM report_otps WHERE report_id = $1;`, id)
+	return err
+}
+
+// otpLocked reports whether the link has used up its codes or guesses.
+func (s *Store) otpLocked(ctx context.Context, id int64) (bool, error) {
+	var locked bool
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT sends >= $2 OR guesses >= $3 FROM report_otps WHERE report_id = $1;
+	`, id, otpMaxSends, otpMaxGuesses).Scan(&locked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return locked, err
+}
+
+func hashOTP(id int64, code string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%s", id, code)))
+	return hex.EncodeToString(sum[:])
+}
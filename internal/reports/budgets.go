@@ -0,0 +1,175 @@
+package reports
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/businesses"
+)
+
+// Budget is a monthly target for one expense category or income client. The P&L budget
+// column prorates it by the days of the month a report covers.
+type Budget struct {
+	Kind   string `json:"kind"` // income or expense
+	Name   string `json:"name"` // client name or expense category
+	Amount int64  `json:"amount"`
+}
+
+type BudgetMonth struct {
+	Month      string   `json:"month"` // YYYY-MM
+	BusinessID *int64   `json:"business_id,omitempty"`
+	Budgets    []Budget `json:"budgets"`
+}
+
+// Budgets lists one month's budgets: GET ?month=YYYY-MM&business_id=.
+func (h *Handler) Budgets(c *fiber.Ctx) error {
+	userID, month, businessID, err := budgetScope(c, c.Query("month"), c.Query("business_id"))
+	if err != nil {
+		return err
+	}
+
+	rows, err := h.Pool.Query(c.UserContext(), `
+SELECT kind, name, amount
+FROM budgets
+WHERE user_id=$1 AND month=$2::date AND business_id IS NOT DISTINCT FROM $3::bigint
+ORDER BY kind DESC, lower(name)
+`, userID, month, businessID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed budgets: "+err.Error())
+	}
+	defer rows.Close()
+
+	out := BudgetMonth{Month: month.Format("2006-01"), BusinessID: businessID, Budgets: make([]Budget, 0)}
+	for rows.Next() {
+		var b Budget
+		if err := rows.Scan(&b.Kind, &b.Name, &b.Amount); err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "scan budgets: "+err.Error())
+		}
+		out.Budgets = append(out.Budgets, b)
+	}
+	if err := rows.Err(); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "budgets rows error: "+err.Error())
+	}
+	return c.JSON(out)
+}
+
+// SaveBudgets replaces one month's budgets with the body's list.
+func (h *Handler) SaveBudgets(c *fiber.Ctx) error {
+	var req struct {
+		Month      string   `json:"month"`
+		BusinessID *int64   `json:"business_id"`
+		Budgets    []Budget `json:"budgets"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+	business := ""
+	if req.BusinessID != nil {
+		business = strconv.FormatInt(*req.BusinessID, 10)
+	}
+	userID, month, businessID, err := budgetScope(c, req.Month, business)
+	if err != nil {
+		return err
+	}
+	if len(req.Budgets) > 300 {
+		return fiber.NewError(fiber.StatusBadRequest, "at most 300 budgets per month")
+	}
+
+	seen := map[string]bool{}
+	for i := range req.Budgets {
+		b := &req.Budgets[i]
+		b.Kind = strings.ToLower(strings.TrimSpace(b.Kind))
+		b.Name = strings.Join(strings.Fields(b.Name), " ")
+		if b.Kind != KindIncome && b.Kind != KindExpense {
+			return fiber.NewError(fiber.StatusBadRequest, "kind must be income or expense")
+		}
+		if b.Name == "" || len(b.Name) > 100 {
+			return fiber.NewError(fiber.StatusBadRequest, "name is required and must be at most 100 characters")
+		}
+		if b.Amount < 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "amount must not be negative")
+		}
+		k := b.Kind + ":" + pnlKey(b.Name)
+		if seen[k] {
+			return fiber.NewError(fiber.StatusBadRequest, "duplicate budget for "+b.Kind+" "+strconv.Quote(b.Name))
+		}
+		seen[k] = true
+	}
+
+	ctx := c.UserContext()
+	if businessID != nil {
+		ok, err := businesses.OwnedBy(ctx, h.Pool, *businessID, userID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "failed business lookup: "+err.Error())
+		}
+		if !ok {
+			return fiber.NewError(fiber.StatusNotFound, "business not found")
+		}
+	}
+
+	tx, err := h.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to save budgets: "+err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+DELETE FROM budgets WHERE user_id=$1 AND month=$2::date AND business_id IS NOT DISTINCT FROM $3::bigint
+`, userID, month, businessID); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to save budgets: "+err.Error())
+	}
+	for _, b := range req.Budgets {
+		if _, err := tx.Exec(ctx, `
+INSERT INTO budgets (user_id, business_id, kind, name, month, amount)
+VALUES ($1, $2, $3, $4, $5::date, $6)
+`, userID, businessID, b.Kind, b.Name, month, b.Amount); err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "failed to save budgets: "+err.Error())
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to save budgets: "+err.Error())
+	}
+
+	if req.Budgets == nil {
+		req.Budgets = []Budget{}
+	}
+	return c.JSON(BudgetMonth{Month: month.Format("2006-01"), BusinessID: businessID, Budgets: req.Budgets})
+}
+
+// budgetScope resolves the caller, the first day of the month (default: this month) and the
+// optional business.
+func budgetScope(c *fiber.Ctx, rawMonth, rawBusiness string) (string, time.Time, *int64, error) {
+	uidVal := c.Locals("user_id")
+	if uidVal == nil {
+		uidVal = c.Locals("userID")
+	}
+	userID, _ := uidVal.(string)
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return "", time.Time{}, nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	month := time.Now()
+	if raw := strings.TrimSpace(rawMonth); raw != "" {
+		m, err := time.Parse("2006-01", raw)
+		if err != nil {
+			return "", time.Time{}, nil, fiber.NewError(fiber.StatusBadRequest, "month must be YYYY-MM")
+		}
+		month = m
+	}
+	month = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var businessID *int64
+	if raw := strings.TrimSpace(rawBusiness); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			return "", time.Time{}, nil, fiber.NewError(fiber.StatusBadRequest, "invalid business_id")
+		}
+		businessID = &id
+	}
+	return userID, month, businessID, nil
+}
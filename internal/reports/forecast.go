@@ -0,0 +1,436 @@
+package reports
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/invoices"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/recurring"
+)
+
+const (
+	// forecastHistoryDays is how far back average daily spend is measured.
+	forecastHistoryDays = 180
+	// forecastBandZ gives an ~80% confidence band around the projected balance.
+	forecastBandZ  = 1.2816
+	daysPerMonth   = 30.4375
+	runwayLookback = 90
+)
+
+type ForecastDay struct {
+	Date    string `json:"date"` // YYYY-MM-DD
+	Inflow  int64  `json:"inflow"`
+	Outflow int64  `json:"outflow"`
+	Balance int64  `json:"balance"`
+	Low     int64  `json:"low"`
+	High    int64  `json:"high"`
+}
+
+type ForecastEvent struct {
+	Date   string `json:"date"`
+	Source string `json:"source"` // recurring | invoice
+	Kind   string `json:"kind"`   // income | expense
+	Title  string `json:"title"`
+	Amount int64  `json:"amount"`
+	RefID  int64  `json:"ref_id"`
+}
+
+type Runway struct {
+	TrailingDays     int      `json:"trailing_days"`
+	NetBurnMonthly   int64    `json:"net_burn_monthly"`
+	GrossBurnMonthly int64    `json:"gross_burn_monthly"`
+	Months           *float64 `json:"months"`       // nil when cash flow is positive
+	GrossMonths      *float64 `json:"gross_months"` // months if income stopped today
+	CashFlowPositive bool     `json:"cash_flow_positive"`
+}
+
+type ForecastResponse struct {
+	Currency           string             `json:"currency"`
+	AsOf               string             `json:"as_of"`
+	HorizonDays        int                `json:"horizon_days"`
+	OpeningBalance     int64              `json:"opening_balance"`
+	ClosingBalance     int64              `json:"closing_balance"`
+	TotalInflow        int64              `json:"total_inflow"`
+	TotalOutflow       int64              `json:"total_outflow"`
+	CategoryOutflow    map[string]int64   `json:"category_outflow"`
+	Daily              []ForecastDay      `json:"daily"`
+	Events             []ForecastEvent    `json:"events"`
+	Runway             Runway             `json:"runway"`
+	WeekdaySeasonality map[string]float64 `json:"weekday_seasonality"`
+	MonthSeasonality   float64            `json:"month_seasonality"`
+}
+
+// forecastInput is everything the projection needs; it is built from the DB by the handler.
+type forecastInput struct {
+	Start          time.Time
+	Days           int
+	OpeningBalance int64
+	Rules          []recurring.Rule
+	Invoices       []invoices.Invoice
+	CategoryDaily  map[string]float64 // discretionary spend per category per day
+	WeekdayFactor  [7]float64
+	MonthFactor    [12]float64
+	DailyStdDev    float64
+}
+
+// projectCashFlow walks the horizon day by day: recurring rules and expected invoice payments are
+// placed on their dates, discretionary spend is spread from history scaled by weekday and month
+// seasonality. Invoice inflows are left out of the low band since clients may pay late.
+func projectCashFlow(in forecastInput) ([]ForecastDay, []ForecastEvent, map[string]int64) {
+	start := time.Date(in.Start.Year(), in.Start.Month(), in.Start.Day(), 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, in.Days-1)
+
+	inflow := make([]int64, in.Days)
+	outflow := make([]int64, in.Days)
+	invoiceIn := make([]int64, in.Days)
+	categoryOut := map[string]int64{}
+	var events []ForecastEvent
+
+	for _, rule := range in.Rules {
+		for _, d := range rule.Occurrences(start, end) {
+			i := int(d.Sub(start).Hours() / 24)
+			if i < 0 || i >= in.Days {
+				continue
+			}
+			if rule.Kind == "income" {
+				inflow[i] += rule.Amount
+			} else {
+				outflow[i] += rule.Amount
+				categoryOut[categoryKey(rule.Category)] += rule.Amount
+			}
+			events = append(events, ForecastEvent{
+				Date:   d.Format("2006-01-02"),
+				Source: "recurring",
+				Kind:   rule.Kind,
+				Title:  rule.Title,
+				Amount: rule.Amount,
+				RefID:  rule.ID,
+			})
+		}
+	}
+
+	for _, inv := range in.Invoices {
+		d := inv.ExpectedDate()
+		i := int(time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC).Sub(start).Hours() / 24)
+		if i < 0 {
+			// overdue: assume it lands on the first projected day
+			i = 0
+		}
+		if i >= in.Days {
+			continue
+		}
+		inflow[i] += inv.Amount
+		invoiceIn[i] += inv.Amount
+		events = append(events, ForecastEvent{
+			Date:   start.AddDate(0, 0, i).Format("2006-01-02"),
+			Source: "invoice",
+			Kind:   "income",
+			Title:  inv.ClientName,
+			Amount: inv.Amount,
+			RefID:  inv.ID,
+		})
+	}
+
+	daily := make([]ForecastDay, 0, in.Days)
+	balance := in.OpeningBalance
+	var invoiceCum int64
+	for i := 0; i < in.Days; i++ {
+		d := start.AddDate(0, 0, i)
+		factor := in.WeekdayFactor[int(d.Weekday())] * in.MonthFactor[int(d.Month())-1]
+		for cat, perDay := range in.CategoryDaily {
+			amt := int64(math.Round(perDay * factor))
+			outflow[i] += amt
+			categoryOut[cat] += amt
+		}
+
+		balance += inflow[i] - outflow[i]
+		invoiceCum += invoiceIn[i]
+		spread := int64(math.Round(forecastBandZ * in.DailyStdDev * math.Sqrt(float64(i+1))))
+
+		daily = append(daily, ForecastDay{
+			Date:    d.Format("2006-01-02"),
+			Inflow:  inflow[i],
+			Outflow: outflow[i],
+			Balance: balance,
+			Low:     balance - spread - invoiceCum,
+			High:    balance + spread,
+		})
+	}
+
+	sort.SliceStable(events, func(a, b int) bool { return events[a].Date < events[b].Date })
+	return daily, events, categoryOut
+}
+
+// computeRunway divides the current balance by the trailing monthly burn.
+func computeRunway(balance, trailingIncome, trailingExpense int64, trailingDays int) Runway {
+	months := float64(trailingDays) / daysPerMonth
+	r := Runway{TrailingDays: trailingDays}
+	if months <= 0 {
+		return r
+	}
+
+	r.NetBurnMonthly = int64(math.Round(float64(trailingExpense-trailingIncome) / months))
+	r.GrossBurnMonthly = int64(math.Round(float64(trailingExpense) / months))
+
+	runwayMonths := func(burn int64) *float64 {
+		v := 0.0
+		if balance > 0 {
+			v = math.Round(float64(balance)/float64(burn)*10) / 10
+		}
+		return &v
+	}
+
+	if r.NetBurnMonthly <= 0 {
+		r.CashFlowPositive = true
+	} else {
+		r.Months = runwayMonths(r.NetBurnMonthly)
+	}
+	if r.GrossBurnMonthly > 0 {
+		r.GrossMonths = runwayMonths(r.GrossBurnMonthly)
+	}
+	return r
+}
+
+func categoryKey(cat string) string {
+	cat = strings.TrimSpace(cat)
+	if cat == "" {
+		return "General"
+	}
+	return cat
+}
+
+func (h *Handler) Forecast(c *fiber.Ctx) error {
+	uidVal := c.Locals("user_id")
+	if uidVal == nil {
+		uidVal = c.Locals("userID")
+	}
+	userID, _ := uidVal.(string)
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	days := 30
+	if v := strings.TrimSpace(c.Query("days")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || (n != 30 && n != 60 && n != 90) {
+			return fiber.NewError(fiber.StatusBadRequest, "days must be 30, 60 or 90")
+		}
+		days = n
+	}
+
+	ctx := c.UserContext()
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var balance int64
+	if err := h.Pool.QueryRow(ctx, `
+		SELECT
+		  (SELECT COALESCE(SUM(amount),0) FROM incomes WHERE user_id=$1 AND deleted_at IS NULL AND received_on <= $2::date)
+		- (SELECT COALESCE(SUM(amount),0) FROM expenses WHERE user_id=$1 AND deleted_at IS NULL AND spent_on <= $2::date)
+	`, userID, today).Scan(&balance); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed balance: "+err.Error())
+	}
+
+	rules, err := recurring.NewRepository(h.Pool).List(ctx, userID, true)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed recurring rules: "+err.Error())
+	}
+	openInvoices, err := invoices.NewRepository(h.Pool).List(ctx, userID, invoices.StatusOpen)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed invoices: "+err.Error())
+	}
+
+	in := forecastInput{
+		Start:          today.AddDate(0, 0, 1),
+		Days:           days,
+		OpeningBalance: balance,
+		Rules:          rules,
+		Invoices:       openInvoices,
+	}
+	if err := h.loadSpendHistory(ctx, userID, today, rules, &in); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed spend history: "+err.Error())
+	}
+
+	var trailingIncome, trailingExpense int64
+	if err := h.Pool.QueryRow(ctx, `
+		SELECT
+		  (SELECT COALESCE(SUM(amount),0) FROM incomes WHERE user_id=$1 AND deleted_at IS NULL AND received_on > $2::date - $3::int AND received_on <= $2::date),
+		  (SELECT COALESCE(SUM(amount),0) FROM expenses WHERE user_id=$1 AND deleted_at IS NULL AND spent_on > $2::date - $3::int AND spent_on <= $2::date)
+	`, userID, today, runwayLookback).Scan(&trailingIncome, &trailingExpense); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed trailing totals: "+err.Error())
+	}
+
+	daily, events, categoryOut := projectCashFlow(in)
+
+	resp := ForecastResponse{
+		Currency:           "INR",
+		AsOf:               today.Format("2006-01-02"),
+		HorizonDays:        days,
+		OpeningBalance:     balance,
+		ClosingBalance:     balance,
+		CategoryOutflow:    categoryOut,
+		Daily:              daily,
+		Events:             events,
+		Runway:             computeRunway(balance, trailingIncome, trailingExpense, runwayLookback),
+		WeekdaySeasonality: map[string]float64{},
+		MonthSeasonality:   in.MonthFactor[int(in.Start.Month())-1],
+	}
+	for i, f := range in.WeekdayFactor {
+		resp.WeekdaySeasonality[time.Weekday(i).String()] = math.Round(f*100) / 100
+	}
+	for _, d := range daily {
+		resp.TotalInflow += d.Inflow
+		resp.TotalOutflow += d.Outflow
+		resp.ClosingBalance = d.Balance
+	}
+
+	return c.JSON(resp)
+}
+
+// loadSpendHistory fills the discretionary spend baseline and seasonality factors from past expenses.
+// Spend already explained by active recurring expense rules is removed so it is not counted twice.
+func (h *Handler) loadSpendHistory(ctx context.Context, userID string, today time.Time, rules []recurring.Rule, in *forecastInput) error {
+	for i := range in.WeekdayFactor {
+		in.WeekdayFactor[i] = 1
+	}
+	for i := range in.MonthFactor {
+		in.MonthFactor[i] = 1
+	}
+	in.CategoryDaily = map[string]float64{}
+
+	from := today.AddDate(0, 0, -forecastHistoryDays+1)
+
+	var first *time.Time
+	if err := h.Pool.QueryRow(ctx, `
+		SELECT MIN(spent_on) FROM expenses WHERE user_id=$1 AND deleted_at IS NULL AND spent_on BETWEEN $2::date AND $3::date
+	`, userID, from, today).Scan(&first); err != nil {
+		return err
+	}
+	if first == nil {
+		return nil
+	}
+	// Short histories are averaged over the days actually observed (at least 30).
+	window := int(today.Sub(*first).Hours()/24) + 1
+	if window < 30 {
+		window = 30
+	}
+	if window > forecastHistoryDays {
+		window = forecastHistoryDays
+	}
+	windowStart := today.AddDate(0, 0, -window+1)
+
+	rows, err := h.Pool.Query(ctx, `
+		SELECT spent_on, COALESCE(NULLIF(category,''),'General'), SUM(amount)::bigint
+		FROM expenses
+		WHERE user_id=$1 AND deleted_at IS NULL AND spent_on BETWEEN $2::date AND $3::date
+		GROUP BY 1, 2
+	`, userID, windowStart, today)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	dayTotals := make([]float64, window)
+	var weekdayTotals [7]float64
+	var total float64
+	catTotals := map[string]float64{}
+	for rows.Next() {
+		var day time.Time
+		var cat string
+		var amt int64
+		if err := rows.Scan(&day, &cat, &amt); err != nil {
+			return err
+		}
+		i := int(day.Sub(windowStart).Hours() / 24)
+		if i >= 0 && i < window {
+			dayTotals[i] += float64(amt)
+		}
+		weekdayTotals[int(day.Weekday())] += float64(amt)
+		catTotals[cat] += float64(amt)
+		total += float64(amt)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	var recurringDaily float64
+	for _, r := range rules {
+		if r.Kind == "expense" {
+			recurringDaily += r.MonthlyEquivalent() / daysPerMonth
+		}
+	}
+	avgDaily := total / float64(window)
+	scale := math.Max(0, avgDaily-recurringDaily) / avgDaily
+	for cat, sum := range catTotals {
+		in.CategoryDaily[cat] = sum / float64(window) * scale
+	}
+
+	var sumSq float64
+	for _, v := range dayTotals {
+		diff := v*scale - avgDaily*scale
+		sumSq += diff * diff
+	}
+	in.DailyStdDev = math.Sqrt(sumSq / float64(window))
+
+	if window >= 28 {
+		meanWeekday := total / 7
+		for i := range in.WeekdayFactor {
+			in.WeekdayFactor[i] = clampFactor(weekdayTotals[i] / meanWeekday)
+		}
+	}
+
+	// Month-of-year seasonality needs a full year of history.
+	monthRows, err := h.Pool.Query(ctx, `
+		SELECT EXTRACT(MONTH FROM spent_on)::int, SUM(amount)::bigint
+		FROM expenses
+		WHERE user_id=$1 AND deleted_at IS NULL AND spent_on > $2::date - 365 AND spent_on <= $2::date
+		GROUP BY 1
+	`, userID, today)
+	if err != nil {
+		return err
+	}
+	defer monthRows.Close()
+
+	var monthTotals [12]float64
+	var yearTotal float64
+	seen := 0
+	for monthRows.Next() {
+		var m int
+		var amt int64
+		if err := monthRows.Scan(&m, &amt); err != nil {
+			return err
+		}
+		if m >= 1 && m <= 12 {
+			monthTotals[m-1] = float64(amt)
+			yearTotal += float64(amt)
+			seen++
+		}
+	}
+	if err := monthRows.Err(); err != nil {
+		return err
+	}
+	if seen == 12 && yearTotal > 0 {
+		meanMonth := yearTotal / 12
+		for i := range in.MonthFactor {
+			in.MonthFactor[i] = clampFactor(monthTotals[i] / meanMonth)
+		}
+	}
+	return nil
+}
+
+func clampFactor(f float64) float64 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 1
+	}
+	return math.Min(2, math.Max(0.5, f))
+}
@@ -1,6 +1,8 @@
 package reports
 
 import (
+	"context"
+	"errors"
 	"strings"
 	"time"
 
@@ -63,29 +65,59 @@ func (h *Handler) Get(c *fiber.Ctx) error {
 
 	ctx := c.UserContext()
 
+	totalIncome, totalExpense, err := Totals(ctx, h.Pool, userID, from, to)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	daily, err := DailySeries(ctx, h.Pool, userID, from, to)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	resp := ReportResponse{
+		Currency:     "INR",
+		From:         from,
+		To:           to,
+		TotalIncome:  totalIncome,
+		TotalExpense: totalExpense,
+		Balance:      totalIncome - totalExpense,
+		Daily:        daily,
+	}
+
+	return c.JSON(resp)
+}
+
+// Totals sums incomes and expenses dated in [from, to] (YYYY-MM-DD).
+func Totals(ctx context.Context, pool *pgxpool.Pool, userID, from, to string) (int64, int64, error) {
 	var totalIncome int64
-	if err := h.Pool.QueryRow(ctx, `
+	if err := pool.QueryRow(ctx, `
 		SELECT COALESCE(SUM(amount),0)
 		FROM incomes
 		WHERE user_id=$1
 		  AND deleted_at IS NULL
 		  AND received_on BETWEEN $2::date AND $3::date
 	`, userID, from, to).Scan(&totalIncome); err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "failed total income: "+err.Error())
+		return 0, 0, errors.New("failed total income: " + err.Error())
 	}
 
 	var totalExpense int64
-	if err := h.Pool.QueryRow(ctx, `
+	if err := pool.QueryRow(ctx, `
 		SELECT COALESCE(SUM(amount),0)
 		FROM expenses
 		WHERE user_id=$1
 		  AND deleted_at IS NULL
 		  AND spent_on BETWEEN $2::date AND $3::date
 	`, userID, from, to).Scan(&totalExpense); err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "failed total expense: "+err.Error())
+		return 0, 0, errors.New("failed total expense: " + err.Error())
 	}
 
-	rows, err := h.Pool.Query(ctx, `
+	return totalIncome, totalExpense, nil
+}
+
+// DailySeries returns one point per day of [from, to], zero-filled, with a running balance.
+func DailySeries(ctx context.Context, pool *pgxpool.Pool, userID, from, to string) ([]DayPoint, error) {
+	rows, err := pool.Query(ctx, `
 WITH days AS (
   SELECT d::date AS day
   FROM generate_series($2::date, $3::date, interval '1 day') AS d
@@ -112,7 +144,7 @@ LEFT JOIN exp ON exp.day = days.day
 ORDER BY days.day ASC
 `, userID, from, to)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "failed daily series: "+err.Error())
+		return nil, errors.New("failed daily series: " + err.Error())
 	}
 	defer rows.Close()
 
@@ -122,7 +154,7 @@ ORDER BY days.day ASC
 		var day string
 		var incAmt, expAmt int64
 		if err := rows.Scan(&day, &incAmt, &expAmt); err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, "failed scan daily: "+err.Error())
+			return nil, errors.New("failed scan daily: " + err.Error())
 		}
 		running += incAmt - expAmt
 		daily = append(daily, DayPoint{
@@ -133,18 +165,8 @@ ORDER BY days.day ASC
 		})
 	}
 	if err := rows.Err(); err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "daily rows error: "+err.Error())
-	}
-
-	resp := ReportResponse{
-		Currency:     "INR",
-		From:         from,
-		To:           to,
-		TotalIncome:  totalIncome,
-		TotalExpense: totalExpense,
-		Balance:      totalIncome - totalExpense,
-		Daily:        daily,
+		return nil, errors.New("daily rows error: " + err.Error())
 	}
 
-	return c.JSON(resp)
+	return daily, nil
 }
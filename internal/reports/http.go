@@ -1,44 +1,118 @@
 package reports
 
 import (
-	"os"
-	"path/filepath"
+	"context"
+	"errors"
+	"html/template"
+	"log"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func DownloadHandler(store *Store) fiber.Handler {
+type textSender interface {
+	SendWhatsAppText(ctx context.Context, toPhone, body string) error
+}
+
+var otpPage = template.Must(template.New("otp").Parse(`<!doctype html>
+<html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Vantro report</title></head>
+<body style="font-family: sans-serif; max-width: 24rem; margin: 3rem auto; padding: 0 1rem">
+<h2>Enter your code</h2>
+<p>We sent a 6-digit code to your WhatsApp number ending in {{.Last}}.</p>
+{{if .Error}}<p style="color: #b00020">{{.Error}}</p>{{end}}
+<form method="post">
+<input name="code" inputmode="numeric" autocomplete="one-time-code" maxlength="6" required autofocus>
+<button type="submit">Open report</button>
+</form>
+</body></html>
+`))
+
+// DownloadHandler serves GET and POST /r/:token. Links with RequireOTP answer GET with a form and
+// send a code to the report's phone over wa; the PDF comes back from POSTing the code.
+func DownloadHandler(store *Store, wa textSender) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		token := strings.TrimSpace(c.Params("token"))
 		if token == "" {
 			return fiber.ErrNotFound
 		}
 
-		path, exp, err := store.GetByToken(c.Context(), token)
-		if err != nil || time.Now().After(exp) {
+		ctx := c.UserContext()
+		rep, err := store.GetByToken(ctx, token)
+		if err != nil {
 			return fiber.ErrNotFound
 		}
+		if !rep.Available(time.Now()) {
+			return fiber.NewError(fiber.StatusGone, ErrUnavailable.Error())
+		}
 
-		clean := filepath.Clean(path)
-		if !strings.Contains(clean, string(filepath.Separator)+"reports"+string(filepath.Separator)) {
-			return fiber.ErrNotFound
+		if rep.RequireOTP {
+			if c.Method() != fiber.MethodPost {
+				return sendOTP(c, store, wa, rep)
+			}
+			switch err := store.VerifyOTP(ctx, rep.ID, strings.TrimSpace(c.FormValue("code"))); {
+			case errors.Is(err, ErrOTPInvalid):
+				return renderOTP(c.Status(fiber.StatusUnauthorized), rep, "That code is wrong or has expired. Reload the page for a new one.")
+			case errors.Is(err, ErrOTPLocked):
+				return fiber.NewError(fiber.StatusLocked, otpLockedText)
+			case err != nil:
+				return fiber.NewError(fiber.StatusInternalServerError, "failed to check code: "+err.Error())
+			}
 		}
 
-		f, err := os.Open(clean)
+		data, err := store.Open(ctx, rep)
 		if err != nil {
 			return fiber.ErrNotFound
 		}
-		defer f.Close()
-
-		stat, _ := f.Stat()
+		if err := store.ClaimDownload(ctx, rep.ID, c.IP(), c.Get(fiber.HeaderUserAgent)); err != nil {
+			if errors.Is(err, ErrUnavailable) {
+				return fiber.NewError(fiber.StatusGone, err.Error())
+			}
+			return fiber.NewError(fiber.StatusInternalServerError, "failed to record download: "+err.Error())
+		}
 
 		c.Set("Content-Type", "application/pdf")
 		c.Set("Content-Disposition", "inline; filename=vantro-report.pdf")
-		if stat != nil {
-			return c.SendStream(f, int(stat.Size()))
-		}
-		return c.SendStream(f)
+		c.Set("Cache-Control", "no-store")
+		return c.Send(data)
+	}
+}
+
+const otpLockedText = "this link is locked after too many codes; ask whoever shared it for a new one"
+
+func sendOTP(c *fiber.Ctx, store *Store, wa textSender, rep Report) error {
+	if wa == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "code delivery is not configured")
+	}
+	code, err := store.IssueOTP(c.UserContext(), rep.ID)
+	if errors.Is(err, ErrOTPTooSoon) {
+		return renderOTP(c, rep, "")
+	}
+	if errors.Is(err, ErrOTPLocked) {
+		return fiber.NewError(fiber.StatusLocked, otpLockedText)
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to issue code: "+err.Error())
+	}
+	msg := "Your Vantro report code is " + code + ". It expires in 10 minutes. Don't share it."
+	if err := wa.SendWhatsAppText(c.UserContext(), rep.Phone, msg); err != nil {
+		log.Printf("report %d: send code: %v", rep.ID, err)
+		return fiber.NewError(fiber.StatusBadGateway, "failed to send code")
+	}
+	return renderOTP(c, rep, "")
+}
+
+func renderOTP(c *fiber.Ctx, rep Report, msg string) error {
+	last := rep.Phone
+	if r := []rune(last); len(r) > 4 {
+		last = string(r[len(r)-4:])
+	}
+	var b strings.Builder
+	if err := otpPage.Execute(&b, struct{ Last, Error string }{last, msg}); err != nil {
+		return err
 	}
+	c.Set("Content-Type", "text/html; charset=utf-8")
+	c.Set("Cache-Control", "no-store")
+	return c.SendString(b.String())
 }
@@ -0,0 +1,143 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Janitor deletes expired report links together with their stored files, once per Interval,
+// and abandoned drafts through Drafts when it is set.
+type Janitor struct {
+	Store    *Store
+	Interval time.Duration
+	// Batch bounds the rows handled per query so a large backlog doesn't hold one long statement.
+	Batch int
+	// Drafts expires upload drafts nobody confirmed; expense.Store's receipt photos are one.
+	Drafts DraftExpirer
+}
+
+// DraftExpirer deletes the drafts that were left unanswered at now, at most limit of them.
+type DraftExpirer interface {
+	ExpireDrafts(ctx context.Context, now time.Time, limit int) (int, error)
+}
+
+func NewJanitor(store *Store) *Janitor {
+	return &Janitor{Store: store, Interval: time.Hour, Batch: 200}
+}
+
+// Run blocks until ctx is cancelled.
+func (j *Janitor) Run(ctx context.Context) {
+	t := time.NewTicker(j.Interval)
+	defer t.Stop()
+	for {
+		if n, err := j.Tick(ctx, time.Now()); err != nil {
+			log.Printf("report janitor: %v", err)
+		} else if n > 0 {
+			log.Printf("report janitor: removed %d expired reports", n)
+		}
+		if n, err := j.sweepDrafts(ctx, time.Now()); err != nil {
+			log.Printf("report janitor: drafts: %v", err)
+		} else if n > 0 {
+			log.Printf("report janitor: removed %d abandoned drafts", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Tick removes everything that expired before now and returns how many reports went.
+func (j *Janitor) Tick(ctx context.Context, now time.Time) (int, error) {
+	total := 0
+	for {
+		n, err := j.purge(ctx, now)
+		total += n
+		if err != nil || n < j.Batch {
+			return total, err
+		}
+	}
+}
+
+func (j *Janitor) sweepDrafts(ctx context.Context, now time.Time) (int, error) {
+	if j.Drafts == nil {
+		return 0, nil
+	}
+	total := 0
+	for {
+		n, err := j.Drafts.ExpireDrafts(ctx, now, j.Batch)
+		total += n
+		if err != nil || n < j.Batch {
+			return total, err
+		}
+	}
+}
+
+// purge handles one batch: files first, then rows, so a failed delete leaves the row for the
+// next tick instead of orphaning the file.
+func (j *Janitor) purge(ctx context.Context, now time.Time) (int, error) {
+	rows, err := j.Store.DB.QueryContext(ctx, `
+		SELECT id, COALESCE(object_key, ''), COALESCE(file_path, '')
+		FROM reports WHERE expires_at < $1
+		ORDER BY expires_at
+		LIMIT $2;
+	`, now, j.Batch)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var key, path string
+		if err := rows.Scan(&id, &key, &path); err != nil {
+			return 0, err
+		}
+		if key != "" {
+			if err := j.Store.Blobs.Delete(ctx, key); err != nil {
+				log.Printf("report janitor: report %d: %v", id, err)
+				continue
+			}
+		}
+		if err := removeLegacyFile(path); err != nil {
+			log.Printf("report janitor: report %d: %v", id, err)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if _, err := j.Store.DB.ExecContext(ctx, `DELETE FROM reports WHERE id = $1;`, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
+// removeLegacyFile deletes a file written under data/reports before reports moved to the blob
+// store. Paths outside that directory are left alone.
+func removeLegacyFile(path string) error {
+	if path == "" {
+		return nil
+	}
+	clean := filepath.ToSlash(filepath.Clean(path))
+	if !strings.HasPrefix(clean, "data/reports/") {
+		return nil
+	}
+	if err := os.Remove(clean); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
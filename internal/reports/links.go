@@ -0,0 +1,124 @@
+package reports
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LinksHandler lets account owners see and control their shared report links (/api/me/reports).
+type LinksHandler struct {
+	Store *Store
+}
+
+func NewLinksHandler(store *Store) *LinksHandler {
+	return &LinksHandler{Store: store}
+}
+
+type UpdateLinkRequest struct {
+	// MaxDownloads of 0 removes the cap.
+	MaxDownloads *int  `json:"max_downloads"`
+	RequireOTP   *bool `json:"require_otp"`
+}
+
+func (h *LinksHandler) List(c *fiber.Ctx) error {
+	userID, err := linkOwner(c)
+	if err != nil {
+		return err
+	}
+	items, err := h.Store.ListForUser(c.UserContext(), userID, 200)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to list report links: "+err.Error())
+	}
+	return c.JSON(items)
+}
+
+// Downloads returns the link's access log, newest first.
+func (h *LinksHandler) Downloads(c *fiber.Ctx) error {
+	rep, err := h.load(c)
+	if err != nil {
+		return err
+	}
+	items, err := h.Store.Downloads(c.UserContext(), rep.ID, 500)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to list downloads: "+err.Error())
+	}
+	return c.JSON(items)
+}
+
+func (h *LinksHandler) Update(c *fiber.Ctx) error {
+	rep, err := h.load(c)
+	if err != nil {
+		return err
+	}
+	var req UpdateLinkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+	if req.MaxDownloads != nil {
+		switch n := *req.MaxDownloads; {
+		case n < 0 || n > 1000:
+			return fiber.NewError(fiber.StatusBadRequest, "max_downloads must be between 0 and 1000")
+		case n == 0:
+			rep.MaxDownloads = nil
+		default:
+			rep.MaxDownloads = &n
+		}
+	}
+	if req.RequireOTP != nil {
+		rep.RequireOTP = *req.RequireOTP
+	}
+
+	out, err := h.Store.UpdateAccess(c.UserContext(), rep.UserID, rep.ID, rep.MaxDownloads, rep.RequireOTP)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to update report link: "+err.Error())
+	}
+	return c.JSON(out)
+}
+
+// Revoke turns the link off for good; the stored file is deleted.
+func (h *LinksHandler) Revoke(c *fiber.Ctx) error {
+	rep, err := h.load(c)
+	if err != nil {
+		return err
+	}
+	out, err := h.Store.Revoke(c.UserContext(), rep.UserID, rep.ID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to revoke report link: "+err.Error())
+	}
+	return c.JSON(out)
+}
+
+func (h *LinksHandler) load(c *fiber.Ctx) (Report, error) {
+	userID, err := linkOwner(c)
+	if err != nil {
+		return Report{}, err
+	}
+	id, err := strconv.ParseInt(strings.TrimSpace(c.Params("id")), 10, 64)
+	if err != nil || id <= 0 {
+		return Report{}, fiber.NewError(fiber.StatusBadRequest, "invalid report id")
+	}
+	rep, err := h.Store.GetForUser(c.UserContext(), userID, id)
+	if errors.Is(err, ErrNotFound) {
+		return rep, fiber.NewError(fiber.StatusNotFound, "report link not found")
+	}
+	if err != nil {
+		return rep, fiber.NewError(fiber.StatusInternalServerError, "failed to load report link: "+err.Error())
+	}
+	return rep, nil
+}
+
+func linkOwner(c *fiber.Ctx) (string, error) {
+	uidVal := c.Locals("user_id")
+	if uidVal == nil {
+		uidVal = c.Locals("userID")
+	}
+	userID, _ := uidVal.(string)
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return "", fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	return userID, nil
+}
@@ -0,0 +1,438 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/businesses"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/pdfkit"
+)
+
+// Comparison columns a P&L can carry next to the selected period.
+const (
+	ComparePrevious = "previous"  // the same number of days immediately before
+	CompareLastYear = "last_year" // the same dates one year earlier
+	CompareBudget   = "budget"    // monthly budgets, prorated by day
+)
+
+const (
+	KindIncome  = "income"
+	KindExpense = "expense"
+)
+
+// defaultDirectCosts are the expense categories counted as cost of sales for gross profit.
+var defaultDirectCosts = []string{
+	"cost of goods sold", "cogs", "inventory", "purchases", "raw materials",
+	"subcontracting", "freelancers", "contractors", "hosting", "payment gateway fees",
+}
+
+type PnLColumn struct {
+	Key   string `json:"key"` // current, previous, last_year or budget
+	Label string `json:"label"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// PnLRow holds one client or category with a value per column, in Columns order.
+type PnLRow struct {
+	Name   string  `json:"name"`
+	Values []int64 `json:"values"`
+}
+
+type PnLSection struct {
+	Key      string   `json:"key"` // income, direct_costs or operating_expenses
+	Title    string   `json:"title"`
+	Rows     []PnLRow `json:"rows"`
+	Subtotal []int64  `json:"subtotal"`
+}
+
+// PnLReport is a profit and loss statement for one user, optionally narrowed to one business.
+// Margins are percentages of income and nil where a column has no income.
+type PnLReport struct {
+	Currency    string       `json:"currency"`
+	BusinessID  *int64       `json:"business_id,omitempty"`
+	Columns     []PnLColumn  `json:"columns"`
+	Sections    []PnLSection `json:"sections"`
+	GrossProfit []int64      `json:"gross_profit"`
+	NetProfit   []int64      `json:"net_profit"`
+	GrossMargin []*float64   `json:"gross_margin"`
+	NetMargin   []*float64   `json:"net_margin"`
+}
+
+type pnlParams struct {
+	userID      string
+	businessID  *int64
+	from, to    time.Time
+	compare     []string
+	directCosts map[string]bool
+}
+
+// PnL returns the report as JSON:
+// GET ?from=&to=&business_id=&compare=previous,last_year,budget&direct=cogs,inventory.
+func (h *Handler) PnL(c *fiber.Ctx) error {
+	rep, _, err := h.pnlFromRequest(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(rep)
+}
+
+func (h *Handler) PnLCSV(c *fiber.Ctx) error {
+	rep, p, err := h.pnlFromRequest(c)
+	if err != nil {
+		return err
+	}
+	body, err := rep.CSV()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to build CSV")
+	}
+	c.Set("Content-Type", "text/csv; charset=utf-8")
+	c.Set("Content-Disposition", `attachment; filename="`+pnlFilename(p)+`.csv"`)
+	return c.Send(body)
+}
+
+func (h *Handler) PnLPDF(c *fiber.Ctx) error {
+	rep, p, err := h.pnlFromRequest(c)
+	if err != nil {
+		return err
+	}
+	ctx := c.UserContext()
+	lang := pdfkit.ParseLang(c.Query("lang", c.Get(fiber.HeaderAcceptLanguage)))
+	body, err := rep.PDF(BusinessName(ctx, h.Pool, p.businessID), BusinessLogo(ctx, h.Pool, p.userID, p.businessID), lang)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "pdf build failed: "+err.Error())
+	}
+	c.Set("Content-Type", "application/pdf")
+	c.Set("Content-Disposition", `attachment; filename="`+pnlFilename(p)+`.pdf"`)
+	return c.Send(body)
+}
+
+func pnlFilename(p pnlParams) string {
+	name := "vantro-pnl-" + p.from.Format("2006-01-02") + "-to-" + p.to.Format("2006-01-02")
+	if p.businessID != nil {
+		name += "-business-" + strconv.FormatInt(*p.businessID, 10)
+	}
+	return name
+}
+
+func (h *Handler) pnlFromRequest(c *fiber.Ctx) (*PnLReport, pnlParams, error) {
+	p, err := parsePnLParams(c)
+	if err != nil {
+		return nil, p, err
+	}
+	ctx := c.UserContext()
+	if p.businessID != nil {
+		ok, err := businesses.OwnedBy(ctx, h.Pool, *p.businessID, p.userID)
+		if err != nil {
+			return nil, p, fiber.NewError(fiber.StatusInternalServerError, "failed business lookup: "+err.Error())
+		}
+		if !ok {
+			return nil, p, fiber.NewError(fiber.StatusNotFound, "business not found")
+		}
+	}
+	rep, err := buildPnL(ctx, h.Pool, p)
+	if err != nil {
+		return nil, p, fiber.NewError(fiber.StatusInternalServerError, "failed profit and loss: "+err.Error())
+	}
+	return rep, p, nil
+}
+
+func parsePnLParams(c *fiber.Ctx) (pnlParams, error) {
+	var p pnlParams
+	uidVal := c.Locals("user_id")
+	if uidVal == nil {
+		uidVal = c.Locals("userID")
+	}
+	userID, _ := uidVal.(string)
+	p.userID = strings.TrimSpace(userID)
+	if p.userID == "" {
+		return p, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	from := strings.TrimSpace(c.Query("from"))
+	to := strings.TrimSpace(c.Query("to"))
+	if from == "" || to == "" {
+		// this month so far
+		now := time.Now()
+		from = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+		to = now.Format("2006-01-02")
+	}
+	var err error
+	if p.from, err = time.Parse("2006-01-02", from); err != nil {
+		return p, fiber.NewError(fiber.StatusBadRequest, "from must be YYYY-MM-DD")
+	}
+	if p.to, err = time.Parse("2006-01-02", to); err != nil {
+		return p, fiber.NewError(fiber.StatusBadRequest, "to must be YYYY-MM-DD")
+	}
+	if p.to.Before(p.from) {
+		return p, fiber.NewError(fiber.StatusBadRequest, "to must not be before from")
+	}
+	if p.to.Sub(p.from) > 3*366*24*time.Hour {
+		return p, fiber.NewError(fiber.StatusBadRequest, "range must be at most three years")
+	}
+
+	if raw := strings.TrimSpace(c.Query("business_id")); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			return p, fiber.NewError(fiber.StatusBadRequest, "invalid business_id")
+		}
+		p.businessID = &id
+	}
+
+	seen := map[string]bool{}
+	for _, k := range strings.Split(c.Query("compare", ComparePrevious+","+CompareLastYear+","+CompareBudget), ",") {
+		k = strings.ToLower(strings.TrimSpace(k))
+		switch k {
+		case "":
+			continue
+		case ComparePrevious, CompareLastYear, CompareBudget:
+		default:
+			return p, fiber.NewError(fiber.StatusBadRequest, "compare takes previous, last_year and budget")
+		}
+		if !seen[k] {
+			seen[k] = true
+			p.compare = append(p.compare, k)
+		}
+	}
+
+	p.directCosts = map[string]bool{}
+	direct := defaultDirectCosts
+	if c.Context().QueryArgs().Has("direct") {
+		direct = strings.Split(c.Query("direct"), ",")
+	}
+	for _, d := range direct {
+		if d = pnlKey(d); d != "" {
+			p.directCosts[d] = true
+		}
+	}
+	return p, nil
+}
+
+// BuildPnL computes the report outside a request, with the default direct cost categories.
+// A nil businessID covers all of the user's businesses.
+func BuildPnL(ctx context.Context, pool *pgxpool.Pool, userID string, businessID *int64, from, to time.Time, compare ...string) (*PnLReport, error) {
+	p := pnlParams{userID: userID, businessID: businessID, from: from, to: to, compare: compare, directCosts: map[string]bool{}}
+	for _, d := range defaultDirectCosts {
+		p.directCosts[d] = true
+	}
+	return buildPnL(ctx, pool, p)
+}
+
+func buildPnL(ctx context.Context, pool *pgxpool.Pool, p pnlParams) (*PnLReport, error) {
+	days := int(p.to.Sub(p.from).Hours()/24) + 1
+	cols := []PnLColumn{{Key: "current", Label: "This period", From: p.from.Format("2006-01-02"), To: p.to.Format("2006-01-02")}}
+	for _, k := range p.compare {
+		switch k {
+		case ComparePrevious:
+			to := p.from.AddDate(0, 0, -1)
+			cols = append(cols, PnLColumn{Key: k, Label: "Previous period", From: to.AddDate(0, 0, 1-days).Format("2006-01-02"), To: to.Format("2006-01-02")})
+		case CompareLastYear:
+			cols = append(cols, PnLColumn{Key: k, Label: "Last year", From: p.from.AddDate(-1, 0, 0).Format("2006-01-02"), To: p.to.AddDate(-1, 0, 0).Format("2006-01-02")})
+		case CompareBudget:
+			cols = append(cols, PnLColumn{Key: k, Label: "Budget", From: p.from.Format("2006-01-02"), To: p.to.Format("2006-01-02")})
+		}
+	}
+
+	// kind -> lower(name) -> row
+	rows := map[string]map[string]*PnLRow{KindIncome: {}, KindExpense: {}}
+	for i, col := range cols {
+		query := pnlActualsQuery
+		if col.Key == CompareBudget {
+			query = pnlBudgetQuery
+		}
+		r, err := pool.Query(ctx, query, p.userID, col.From, col.To, p.businessID)
+		if err != nil {
+			return nil, err
+		}
+		for r.Next() {
+			var kind, name string
+			var total int64
+			if err := r.Scan(&kind, &name, &total); err != nil {
+				r.Close()
+				return nil, err
+			}
+			byName := rows[kind]
+			if byName == nil {
+				continue
+			}
+			key := pnlKey(name)
+			row := byName[key]
+			if row == nil {
+				row = &PnLRow{Name: name, Values: make([]int64, len(cols))}
+				byName[key] = row
+			}
+			row.Values[i] += total
+		}
+		r.Close()
+		if err := r.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	income := newSection("income", "Income", rows[KindIncome], len(cols), nil)
+	direct := newSection("direct_costs", "Direct costs", rows[KindExpense], len(cols), func(k string) bool { return p.directCosts[k] })
+	operating := newSection("operating_expenses", "Operating expenses", rows[KindExpense], len(cols), func(k string) bool { return !p.directCosts[k] })
+
+	rep := &PnLReport{
+		Currency:    pnlCurrency,
+		BusinessID:  p.businessID,
+		Columns:     cols,
+		Sections:    []PnLSection{income, direct, operating},
+		GrossProfit: make([]int64, len(cols)),
+		NetProfit:   make([]int64, len(cols)),
+		GrossMargin: make([]*float64, len(cols)),
+		NetMargin:   make([]*float64, len(cols)),
+	}
+	for i := range cols {
+		rep.GrossProfit[i] = income.Subtotal[i] - direct.Subtotal[i]
+		rep.NetProfit[i] = rep.GrossProfit[i] - operating.Subtotal[i]
+		rep.GrossMargin[i] = margin(rep.GrossProfit[i], income.Subtotal[i])
+		rep.NetMargin[i] = margin(rep.NetProfit[i], income.Subtotal[i])
+	}
+	return rep, nil
+}
+
+// pnlCurrency is the one currency a P&L is drawn up in. Incomes and expenses in other currencies
+// are left out rather than added to it at face value; budgets carry no currency and are in it.
+const pnlCurrency = "INR"
+
+// pnlActualsQuery totals incomes by client and expenses by category in [$2, $3].
+const pnlActualsQuery = `
+SELECT 'income', MIN(TRIM(client_name)), SUM(amount)::bigint
+FROM incomes
+WHERE user_id=$1 AND deleted_at IS NULL AND received_on BETWEEN $2::date AND $3::date
+  AND ($4::bigint IS NULL OR business_id = $4)
+  AND currency = '` + pnlCurrency + `'
+GROUP BY lower(TRIM(client_name))
+UNION ALL
+SELECT 'expense', MIN(COALESCE(NULLIF(TRIM(category),''),'General')), SUM(amount)::bigint
+FROM expenses
+WHERE user_id=$1 AND deleted_at IS NULL AND spent_on BETWEEN $2::date AND $3::date
+  AND ($4::bigint IS NULL OR business_id = $4)
+  AND currency = '` + pnlCurrency + `'
+GROUP BY lower(COALESCE(NULLIF(TRIM(category),''),'General'))`
+
+// pnlBudgetQuery prorates each monthly budget by the share of its month inside [$2, $3].
+const pnlBudgetQuery = `
+SELECT kind, name,
+  ROUND(amount::numeric
+    * (LEAST($3::date, (month + interval '1 month - 1 day')::date) - GREATEST($2::date, month) + 1)
+    / EXTRACT(DAY FROM month + interval '1 month - 1 day'))::bigint
+FROM budgets
+WHERE user_id=$1
+  AND month <= $3::date AND (month + interval '1 month - 1 day')::date >= $2::date
+  AND business_id IS NOT DISTINCT FROM $4::bigint`
+
+func newSection(key, title string, byName map[string]*PnLRow, n int, keep func(string) bool) PnLSection {
+	s := PnLSection{Key: key, Title: title, Rows: make([]PnLRow, 0), Subtotal: make([]int64, n)}
+	for k, row := range byName {
+		if keep != nil && !keep(k) {
+			continue
+		}
+		s.Rows = append(s.Rows, *row)
+		for i, v := range row.Values {
+			s.Subtotal[i] += v
+		}
+	}
+	sort.Slice(s.Rows, func(i, j int) bool {
+		if s.Rows[i].Values[0] != s.Rows[j].Values[0] {
+			return s.Rows[i].Values[0] > s.Rows[j].Values[0]
+		}
+		return pnlKey(s.Rows[i].Name) < pnlKey(s.Rows[j].Name)
+	})
+	return s
+}
+
+func margin(profit, income int64) *float64 {
+	if income <= 0 {
+		return nil
+	}
+	m := float64(int64(float64(profit)*10000/float64(income))) / 100
+	return &m
+}
+
+func pnlKey(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// CSV lays the report out like the PDF: one line per row, section subtotals and the profit
+// lines, amounts in rupees.
+func (rep *PnLReport) CSV() ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	header := []string{"Section", "Line"}
+	for _, col := range rep.Columns {
+		header = append(header, col.Label+" ("+col.From+" to "+col.To+")")
+	}
+	_ = w.Write(header)
+
+	amounts := func(section, line string, values []int64) {
+		rec := []string{section, line}
+		for _, v := range values {
+			rec = append(rec, money.PaiseToRupeesString(v))
+		}
+		_ = w.Write(rec)
+	}
+	percents := func(line string, values []*float64) {
+		rec := []string{"", line}
+		for _, v := range values {
+			rec = append(rec, formatPercent(v))
+		}
+		_ = w.Write(rec)
+	}
+
+	for i, s := range rep.Sections {
+		for _, row := range s.Rows {
+			amounts(s.Title, row.Name, row.Values)
+		}
+		amounts(s.Title, "Total "+strings.ToLower(s.Title), s.Subtotal)
+		if i == 1 {
+			amounts("", "Gross profit", rep.GrossProfit)
+			percents("Gross margin %", rep.GrossMargin)
+		}
+	}
+	amounts("", "Net profit", rep.NetProfit)
+	percents("Net margin %", rep.NetMargin)
+
+	w.Flush()
+	return b.Bytes(), w.Error()
+}
+
+func formatPercent(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', 2, 64)
+}
+
+// BusinessName returns the business's name for report headers, or "" when id is nil or unknown.
+func BusinessName(ctx context.Context, pool *pgxpool.Pool, id *int64) string {
+	if id == nil {
+		return ""
+	}
+	var name string
+	_ = pool.QueryRow(ctx, `SELECT name FROM businesses WHERE id = $1`, *id).Scan(&name)
+	return name
+}
+
+// BusinessLogo returns the logo to brand a report with: the given business's, or with no
+// business the user's oldest business that has one. It is nil when there is none.
+func BusinessLogo(ctx context.Context, pool *pgxpool.Pool, userID string, id *int64) []byte {
+	var logo []byte
+	_ = pool.QueryRow(ctx, `
+SELECT logo
+FROM businesses
+WHERE owner_user_id = $1 AND logo IS NOT NULL AND ($2::bigint IS NULL OR id = $2)
+ORDER BY created_at
+LIMIT 1`, userID, id).Scan(&logo)
+	return logo
+}
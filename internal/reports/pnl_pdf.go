@@ -0,0 +1,139 @@
+package reports
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/pdfkit"
+)
+
+// PDF renders the report as an A4 table: one value column per comparison, section subtotals
+// in bold and the profit and margin lines at the bottom, followed by a chart comparing income,
+// expenses and net profit across the columns. logo, when set, goes in the page header; labels
+// are in lang ("en" or "hi").
+func (rep *PnLReport) PDF(business string, logo []byte, lang string) ([]byte, error) {
+	cur := rep.Columns[0]
+	subtitle := cur.From + " to " + cur.To + "  |  " + rep.Currency
+	if business != "" {
+		subtitle = business + "  |  " + subtitle
+	}
+	pdf := pdfkit.New(pdfkit.Brand{Title: pdfkit.T(lang, "pnl"), Subtitle: subtitle, Logo: logo, Lang: lang})
+
+	nameW := 182.0 - 28*float64(len(rep.Columns))
+	header := func() {
+		pdf.SetFont("B", 9)
+		pdf.SetFillColor(245, 245, 245)
+		pdf.SetTextColor(20, 20, 20)
+		pdf.CellFormat(nameW, 8, "", "1", 0, "L", true, 0, "")
+		for _, col := range rep.Columns {
+			pdf.CellFormat(28, 8, pdf.T(pnlLabels[col.Key]), "1", 0, "R", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("", 7)
+		pdf.SetTextColor(110, 110, 110)
+		pdf.CellFormat(nameW, 5, "", "LRB", 0, "L", true, 0, "")
+		for _, col := range rep.Columns {
+			pdf.CellFormat(28, 5, shortDate(col.From)+" - "+shortDate(col.To), "LRB", 0, "R", true, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	line := func(label string, cells []string, bold bool) {
+		if pdf.GetY() > 270 {
+			pdf.AddPage()
+			header()
+		}
+		style := ""
+		if bold {
+			style = "B"
+		}
+		pdf.SetFont(style, 9)
+		pdf.SetTextColor(30, 30, 30)
+		pdf.CellFormat(nameW, 7, trimTo(label, int(nameW/1.8)), "1", 0, "L", bold, 0, "")
+		for _, s := range cells {
+			pdf.CellFormat(28, 7, s, "1", 0, "R", bold, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	amounts := func(values []int64) []string {
+		out := make([]string, len(values))
+		for i, v := range values {
+			out[i] = money.FormatINR(v)
+		}
+		return out
+	}
+	percents := func(values []*float64) []string {
+		out := make([]string, len(values))
+		for i, v := range values {
+			out[i] = "-"
+			if v != nil {
+				out[i] = formatPercent(v) + "%"
+			}
+		}
+		return out
+	}
+
+	header()
+	for i, s := range rep.Sections {
+		pdf.SetFillColor(250, 250, 250)
+		pdf.SetFont("B", 9)
+		pdf.SetTextColor(60, 60, 60)
+		pdf.CellFormat(0, 7, strings.ToUpper(pdf.T(pnlLabels[s.Key])), "1", 1, "L", false, 0, "")
+		for _, row := range s.Rows {
+			line("  "+row.Name, amounts(row.Values), false)
+		}
+		line(strings.Replace(pdf.T("pnl_total"), "%s", strings.ToLower(pdf.T(pnlLabels[s.Key])), 1), amounts(s.Subtotal), true)
+		if i == 1 {
+			line(pdf.T("gross_profit"), amounts(rep.GrossProfit), true)
+			line(pdf.T("gross_margin"), percents(rep.GrossMargin), false)
+		}
+	}
+	line(pdf.T("net_profit"), amounts(rep.NetProfit), true)
+	line(pdf.T("net_margin"), percents(rep.NetMargin), false)
+
+	labels := make([]string, len(rep.Columns))
+	for i, col := range rep.Columns {
+		labels[i] = pdf.T(pnlLabels[col.Key])
+	}
+	expenses := make([]int64, len(rep.Columns))
+	for i := range expenses {
+		expenses[i] = rep.Sections[1].Subtotal[i] + rep.Sections[2].Subtotal[i]
+	}
+	if pdf.GetY() > 190 {
+		pdf.AddPage()
+	}
+	pdf.Ln(8)
+	pdfkit.Heading(pdf, pdf.T("pnl_chart"))
+	pdfkit.BarChart(pdf, 14, pdf.GetY(), 182, 70, labels,
+		pdfkit.Series{Name: pdf.T("income"), Values: rep.Sections[0].Subtotal},
+		pdfkit.Series{Name: pdf.T("expenses"), Values: expenses},
+		pdfkit.Series{Name: pdf.T("net_profit"), Values: rep.NetProfit},
+	)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// pnlLabels maps column and section keys to their pdfkit label keys.
+var pnlLabels = map[string]string{
+	"current":            "pnl_current",
+	ComparePrevious:      "pnl_previous",
+	CompareLastYear:      "pnl_last_year",
+	CompareBudget:        "pnl_budget",
+	"income":             "pnl_income",
+	"direct_costs":       "pnl_direct",
+	"operating_expenses": "pnl_operating",
+}
+
+// shortDate turns YYYY-MM-DD into DD/MM/YY to fit a value column.
+func shortDate(s string) string {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return s
+	}
+	return t.Format("02/01/06")
+}
@@ -1,10 +1,17 @@
 package reports
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
 )
 
 type StatementItem struct {
@@ -24,6 +31,35 @@ type StatementResponse struct {
 	Items    []StatementItem `json:"items"`
 }
 
+// statementQuery lists incomes and expenses dated in [$2, $3], newest first; callers add a LIMIT.
+const statementQuery = `
+SELECT type, id, title, amount, currency, date, created_at
+FROM (
+  SELECT 'income' AS type,
+         id::text AS id,
+         client_name AS title,
+         amount::bigint AS amount,
+         COALESCE(currency,'INR') AS currency,
+         received_on::text AS date,
+         created_at::text AS created_at
+  FROM incomes
+  WHERE user_id=$1 AND deleted_at IS NULL AND received_on BETWEEN $2::date AND $3::date
+
+  UNION ALL
+
+  SELECT 'expense' AS type,
+         id::text AS id,
+         vendor_name AS title,
+         amount::bigint AS amount,
+         COALESCE(currency,'INR') AS currency,
+         spent_on::text AS date,
+         created_at::text AS created_at
+  FROM expenses
+  WHERE user_id=$1 AND deleted_at IS NULL AND spent_on BETWEEN $2::date AND $3::date
+) t
+ORDER BY date DESC, created_at DESC
+`
+
 func (h *Handler) Statement(c *fiber.Ctx) error {
 	uidVal := c.Locals("user_id")
 	if uidVal == nil {
@@ -53,34 +89,7 @@ func (h *Handler) Statement(c *fiber.Ctx) error {
 
 	ctx := c.UserContext()
 
-	rows, err := h.Pool.Query(ctx, `
-SELECT type, id, title, amount, currency, date, created_at
-FROM (
-  SELECT 'income' AS type,
-         id::text AS id,
-         client_name AS title,
-         amount::bigint AS amount,
-         COALESCE(currency,'INR') AS currency,
-         received_on::text AS date,
-         created_at::text AS created_at
-  FROM incomes
-  WHERE user_id=$1 AND deleted_at IS NULL AND received_on BETWEEN $2::date AND $3::date
-
-  UNION ALL
-
-  SELECT 'expense' AS type,
-         id::text AS id,
-         vendor_name AS title,
-         amount::bigint AS amount,
-         COALESCE(currency,'INR') AS currency,
-         spent_on::text AS date,
-         created_at::text AS created_at
-  FROM expenses
-  WHERE user_id=$1 AND deleted_at IS NULL AND spent_on BETWEEN $2::date AND $3::date
-) t
-ORDER BY date DESC, created_at DESC
-LIMIT 1000
-`, userID, from, to)
+	rows, err := h.Pool.Query(ctx, statementQuery+`LIMIT 1000`, userID, from, to)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "failed statement: "+err.Error())
 	}
@@ -102,3 +111,32 @@ LIMIT 1000
 		Items:    items,
 	})
 }
+
+// BuildStatementCSV writes the same rows as the statement PDF, amounts in rupees.
+func BuildStatementCSV(ctx context.Context, pool *pgxpool.Pool, userID, from, to string) ([]byte, error) {
+	rows, err := pool.Query(ctx, statementQuery+`LIMIT 2000`, userID, from, to)
+	if err != nil {
+		return nil, errors.New("failed statement: " + err.Error())
+	}
+	defer rows.Close()
+
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	_ = w.Write([]string{"Date", "Type", "Title", "Amount", "Currency", "ID"})
+	for rows.Next() {
+		var it StatementItem
+		if err := rows.Scan(&it.Type, &it.ID, &it.Title, &it.Amount, &it.Currency, &it.Date, &it.CreatedAt); err != nil {
+			return nil, errors.New("scan statement: " + err.Error())
+		}
+		amount := it.Amount
+		if it.Type == "expense" {
+			amount = -amount
+		}
+		_ = w.Write([]string{it.Date, it.Type, it.Title, money.PaiseToRupeesString(amount), it.Currency, it.ID})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("statement rows error: " + err.Error())
+	}
+	w.Flush()
+	return b.Bytes(), w.Error()
+}
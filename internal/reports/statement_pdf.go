@@ -2,11 +2,16 @@ package reports
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/phpdave11/gofpdf"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/pdfkit"
 )
 
 func (h *Handler) StatementPDF(c *fiber.Ctx) error {
@@ -36,37 +41,35 @@ func (h *Handler) StatementPDF(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "to must be YYYY-MM-DD")
 	}
 
-	ctx := c.UserContext()
-	rows, err := h.Pool.Query(ctx, `
-SELECT type, id, title, amount, currency, date, created_at
-FROM (
-  SELECT 'income' AS type,
-         id::text AS id,
-         client_name AS title,
-         amount::bigint AS amount,
-         COALESCE(currency,'INR') AS currency,
-         received_on::text AS date,
-         created_at::text AS created_at
-  FROM incomes
-  WHERE user_id=$1 AND deleted_at IS NULL AND received_on BETWEEN $2::date AND $3::date
-
-  UNION ALL
-
-  SELECT 'expense' AS type,
-         id::text AS id,
-         vendor_name AS title,
-         amount::bigint AS amount,
-         COALESCE(currency,'INR') AS currency,
-         spent_on::text AS date,
-         created_at::text AS created_at
-  FROM expenses
-  WHERE user_id=$1 AND deleted_at IS NULL AND spent_on BETWEEN $2::date AND $3::date
-) t
-ORDER BY date DESC, created_at DESC
-LIMIT 2000
-`, userID, from, to)
+	var businessID *int64
+	if raw := strings.TrimSpace(c.Query("business_id")); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid business_id")
+		}
+		businessID = &id
+	}
+
+	lang := pdfkit.ParseLang(c.Query("lang", c.Get(fiber.HeaderAcceptLanguage)))
+	pdf, err := BuildStatementPDF(c.UserContext(), h.Pool, userID, from, to, businessID, lang)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "failed statement: "+err.Error())
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	filename := "vantro-statement-" + from + "-to-" + to + ".pdf"
+	c.Set("Content-Type", "application/pdf")
+	c.Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	return c.Send(pdf)
+}
+
+// BuildStatementPDF renders the statement for [from, to] (YYYY-MM-DD): totals, charts of spend
+// by category, income against expenses and the running balance, then the transactions. The
+// header carries businessID's logo, or the user's first logo when it is nil; labels are in lang
+// ("en" or "hi"). The HTTP handler and scheduled deliveries share it.
+func BuildStatementPDF(ctx context.Context, pool *pgxpool.Pool, userID, from, to string, businessID *int64, lang string) ([]byte, error) {
+	rows, err := pool.Query(ctx, statementQuery+`LIMIT 2000`, userID, from, to)
+	if err != nil {
+		return nil, errors.New("failed statement: " + err.Error())
 	}
 	defer rows.Close()
 
@@ -85,7 +88,7 @@ LIMIT 2000
 	for rows.Next() {
 		var r row
 		if err := rows.Scan(&r.Type, &r.ID, &r.Title, &r.Amount, &r.Currency, &r.Date, &r.CreatedAt); err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, "scan statement: "+err.Error())
+			return nil, errors.New("scan statement: " + err.Error())
 		}
 		if strings.TrimSpace(r.Currency) != "" {
 			currency = r.Currency
@@ -94,96 +97,104 @@ LIMIT 2000
 	}
 
 	var totalIncome int64
-	if err := h.Pool.QueryRow(ctx, `
+	if err := pool.QueryRow(ctx, `
 		SELECT COALESCE(SUM(amount),0)
 		FROM incomes
 		WHERE user_id=$1 AND deleted_at IS NULL AND received_on BETWEEN $2::date AND $3::date
 	`, userID, from, to).Scan(&totalIncome); err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "totals income: "+err.Error())
+		return nil, errors.New("totals income: " + err.Error())
 	}
 
 	var totalExpense int64
-	if err := h.Pool.QueryRow(ctx, `
+	if err := pool.QueryRow(ctx, `
 		SELECT COALESCE(SUM(amount),0)
 		FROM expenses
 		WHERE user_id=$1 AND deleted_at IS NULL AND spent_on BETWEEN $2::date AND $3::date
 	`, userID, from, to).Scan(&totalExpense); err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "totals expense: "+err.Error())
+		return nil, errors.New("totals expense: " + err.Error())
 	}
 
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	pdf.SetMargins(14, 14, 14)
-	pdf.AddPage()
-
-	pdf.SetFont("Helvetica", "B", 48)
-	pdf.SetTextColor(235, 235, 235)
-	pdf.Text(25, 140, "VANTRO")
-
-	pdf.SetTextColor(20, 20, 20)
-	pdf.SetFont("Helvetica", "B", 18)
-	pdf.Cell(0, 10, "VANTRO Statement")
-	pdf.Ln(8)
+	categories, err := expenseCategories(ctx, pool, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	daily, err := DailySeries(ctx, pool, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
 
-	pdf.SetFont("Helvetica", "", 10)
-	pdf.SetTextColor(80, 80, 80)
-	pdf.Cell(0, 6, "Period: "+from+" to "+to)
-	pdf.Ln(5)
-	pdf.Cell(0, 6, "User: "+maskID(userID))
-	pdf.Ln(10)
+	pdf := pdfkit.New(pdfkit.Brand{
+		Title:    pdfkit.T(lang, "statement"),
+		Subtitle: from + " to " + to + "  |  " + pdfkit.T(lang, "user") + " " + maskID(userID),
+		Logo:     BusinessLogo(ctx, pool, userID, businessID),
+		Lang:     lang,
+	})
 
 	pdf.SetDrawColor(200, 200, 200)
 	pdf.SetFillColor(248, 248, 248)
 	pdf.SetTextColor(20, 20, 20)
-	pdf.SetFont("Helvetica", "B", 11)
+	pdf.SetFont("B", 11)
 
 	sumW := []float64{62, 62, 62}
-	pdf.CellFormat(sumW[0], 10, "Income ("+currency+")", "1", 0, "C", true, 0, "")
-	pdf.CellFormat(sumW[1], 10, "Expense ("+currency+")", "1", 0, "C", true, 0, "")
-	pdf.CellFormat(sumW[2], 10, "Balance ("+currency+")", "1", 1, "C", true, 0, "")
+	pdf.CellFormat(sumW[0], 10, pdf.T("income")+" ("+currency+")", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(sumW[1], 10, pdf.T("expense")+" ("+currency+")", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(sumW[2], 10, pdf.T("balance")+" ("+currency+")", "1", 1, "C", true, 0, "")
 
-	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetFont("", 11)
 	pdf.CellFormat(sumW[0], 10, formatMoney(totalIncome), "1", 0, "C", false, 0, "")
 	pdf.CellFormat(sumW[1], 10, formatMoney(totalExpense), "1", 0, "C", false, 0, "")
 	pdf.CellFormat(sumW[2], 10, formatMoney(totalIncome-totalExpense), "1", 1, "C", false, 0, "")
-	pdf.Ln(6)
+	pdf.Ln(8)
 
-	pdf.SetFont("Helvetica", "B", 10)
-	pdf.SetFillColor(245, 245, 245)
-	pdf.SetTextColor(20, 20, 20)
+	pdfkit.Heading(pdf, pdf.T("by_category"))
+	pdfkit.Donut(pdf, 14, pdf.GetY(), 56, categories, formatMoney(totalExpense))
+	pdf.Ln(8)
 
-	colW := []float64{22, 26, 92, 30, 20}
-	pdf.CellFormat(colW[0], 8, "TYPE", "1", 0, "C", true, 0, "")
-	pdf.CellFormat(colW[1], 8, "DATE", "1", 0, "C", true, 0, "")
-	pdf.CellFormat(colW[2], 8, "TITLE", "1", 0, "L", true, 0, "")
-	pdf.CellFormat(colW[3], 8, "AMOUNT", "1", 0, "R", true, 0, "")
-	pdf.CellFormat(colW[4], 8, "ID", "1", 1, "C", true, 0, "")
+	labels, income, expense, balance := statementBuckets(daily)
+	pdfkit.Heading(pdf, pdf.T("income_vs_exp"))
+	pdfkit.BarChart(pdf, 14, pdf.GetY(), 182, 58, labels,
+		pdfkit.Series{Name: pdf.T("income"), Values: income, Color: &pdfkit.RGB{R: 22, G: 163, B: 74}},
+		pdfkit.Series{Name: pdf.T("expenses"), Values: expense, Color: &pdfkit.RGB{R: 220, G: 38, B: 38}},
+	)
+	pdf.Ln(8)
 
-	pdf.SetFont("Helvetica", "", 9)
-	pdf.SetTextColor(30, 30, 30)
+	pdfkit.Heading(pdf, pdf.T("running_bal"))
+	pdfkit.LineChart(pdf, 14, pdf.GetY(), 182, 50, labels, pdfkit.Series{Name: pdf.T("balance"), Values: balance})
+
+	pdf.AddPage()
+	pdfkit.Heading(pdf, pdf.T("transactions"))
+
+	colW := []float64{22, 26, 92, 30, 20}
+	header := func() {
+		pdf.SetFont("B", 10)
+		pdf.SetFillColor(245, 245, 245)
+		pdf.SetTextColor(20, 20, 20)
+		pdf.CellFormat(colW[0], 8, pdf.T("col_type"), "1", 0, "C", true, 0, "")
+		pdf.CellFormat(colW[1], 8, pdf.T("col_date"), "1", 0, "C", true, 0, "")
+		pdf.CellFormat(colW[2], 8, pdf.T("col_title"), "1", 0, "L", true, 0, "")
+		pdf.CellFormat(colW[3], 8, pdf.T("col_amount"), "1", 0, "R", true, 0, "")
+		pdf.CellFormat(colW[4], 8, pdf.T("col_id"), "1", 1, "C", true, 0, "")
+		pdf.SetFont("", 9)
+		pdf.SetTextColor(30, 30, 30)
+	}
+	header()
 
 	maxRows := 200
 	for i, it := range items {
 		if i >= maxRows {
-			pdf.SetFont("Helvetica", "I", 9)
-			pdf.CellFormat(0, 8, "…truncated (too many rows)", "1", 1, "C", false, 0, "")
+			pdf.SetFont("I", 9)
+			pdf.CellFormat(0, 8, pdf.T("truncated"), "1", 1, "C", false, 0, "")
 			break
 		}
 
-		typ := strings.ToUpper(it.Type)
+		typ := strings.ToUpper(pdf.T(it.Type))
 		date := it.Date
 		title := it.Title
 		amt := formatMoneySigned(it.Amount, it.Type)
 
 		if pdf.GetY() > 270 {
 			pdf.AddPage()
-			pdf.SetFont("Helvetica", "B", 10)
-			pdf.SetFillColor(245, 245, 245)
-			pdf.CellFormat(colW[0], 8, "TYPE", "1", 0, "C", true, 0, "")
-			pdf.CellFormat(colW[1], 8, "DATE", "1", 0, "C", true, 0, "")
-			pdf.CellFormat(colW[2], 8, "TITLE", "1", 0, "L", true, 0, "")
-			pdf.CellFormat(colW[3], 8, "AMOUNT", "1", 0, "R", true, 0, "")
-			pdf.CellFormat(colW[4], 8, "ID", "1", 1, "C", true, 0, "")
-			pdf.SetFont("Helvetica", "", 9)
+			header()
 		}
 
 		pdf.CellFormat(colW[0], 8, typ, "1", 0, "C", false, 0, "")
@@ -200,20 +211,62 @@ LIMIT 2000
 		pdf.CellFormat(colW[4], usedH, shortID(it.ID), "1", 1, "C", false, 0, "")
 	}
 
-	pdf.SetY(-18)
-	pdf.SetFont("Helvetica", "", 9)
-	pdf.SetTextColor(120, 120, 120)
-	pdf.CellFormat(0, 10, "Generated by VANTRO • "+time.Now().Format(time.RFC3339), "", 0, "C", false, 0, "")
-
 	var buf bytes.Buffer
 	if err := pdf.Output(&buf); err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "pdf build failed: "+err.Error())
+		return nil, errors.New("pdf build failed: " + err.Error())
 	}
+	return buf.Bytes(), nil
+}
 
-	filename := "vantro-statement-" + from + "-to-" + to + ".pdf"
-	c.Set("Content-Type", "application/pdf")
-	c.Set("Content-Disposition", `attachment; filename="`+filename+`"`)
-	return c.Send(buf.Bytes())
+// expenseCategories totals the period's expenses by category, largest first.
+func expenseCategories(ctx context.Context, pool *pgxpool.Pool, userID, from, to string) ([]pdfkit.Slice, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT COALESCE(NULLIF(TRIM(category), ''), 'General') AS category, SUM(amount)::bigint
+		FROM expenses
+		WHERE user_id=$1 AND deleted_at IS NULL AND spent_on BETWEEN $2::date AND $3::date
+		GROUP BY 1
+		ORDER BY 2 DESC
+	`, userID, from, to)
+	if err != nil {
+		return nil, errors.New("categories: " + err.Error())
+	}
+	defer rows.Close()
+
+	var out []pdfkit.Slice
+	for rows.Next() {
+		var s pdfkit.Slice
+		if err := rows.Scan(&s.Label, &s.Value); err != nil {
+			return nil, errors.New("scan categories: " + err.Error())
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// statementBuckets groups the daily series for charting: by day up to about two months, by
+// week (labelled with its first day) beyond that. balance is the closing balance of each bucket.
+func statementBuckets(daily []DayPoint) (labels []string, income, expense, balance []int64) {
+	size := 1
+	if len(daily) > 62 {
+		size = 7
+	}
+	for i := 0; i < len(daily); i += size {
+		end := min(i+size, len(daily))
+		var inc, exp int64
+		for _, d := range daily[i:end] {
+			inc += d.Income
+			exp += d.Expense
+		}
+		label := daily[i].Date
+		if t, err := time.Parse("2006-01-02", label); err == nil {
+			label = t.Format("02 Jan")
+		}
+		labels = append(labels, label)
+		income = append(income, inc)
+		expense = append(expense, exp)
+		balance = append(balance, daily[end-1].Balance)
+	}
+	return labels, income, expense, balance
 }
 
 func shortID(id string) string {
@@ -233,11 +286,11 @@ func maskID(id string) string {
 }
 
 func trimTo(s string, max int) string {
-	s = strings.TrimSpace(s)
-	if len(s) <= max {
-		return s
+	r := []rune(strings.TrimSpace(s))
+	if len(r) <= max {
+		return string(r)
 	}
-	return s[:max-1] + "…"
+	return string(r[:max-1]) + "…"
 }
 
 func formatMoney(n int64) string {
@@ -5,15 +5,59 @@ import (
 	"crypto/rand"
 	"database/sql"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"time"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/blobstore"
+)
+
+// Kinds of shared report. The kind picks the Regenerator that rebuilds a report whose stored
+// copy is gone.
+const (
+	KindExpenseMemory = "expense_memory" // monthly Expense Memory PDF; params: {"lang"}
+	KindScheduled     = "scheduled"      // a report_deliveries file; params: {"delivery_id"}
 )
 
+// Store keeps tokenized report links in the reports table and the files in Blobs.
 type Store struct {
-	DB *sql.DB
+	DB    *sql.DB
+	Blobs blobstore.Store
+	// Regenerators rebuild reports by kind; see Open.
+	Regenerators map[string]Regenerator
 }
 
-var ErrNotFound = errors.New("not found")
+// Report is one shared link.
+type Report struct {
+	ID    int64  `json:"id"`
+	Phone string `json:"phone"`
+	// UserID is the account that owns the link and may revoke it; "" for links made for a
+	// phone number alone.
+	UserID    string          `json:"-"`
+	Month     string          `json:"month"` // YYYY-MM
+	Kind      string          `json:"kind"`
+	Params    json.RawMessage `json:"-"`
+	ObjectKey string          `json:"-"` // "" until stored, or for links made before blob storage
+	// MaxDownloads caps successful downloads; nil means no cap. The WhatsApp media fetch counts.
+	MaxDownloads  *int `json:"max_downloads"`
+	DownloadCount int  `json:"download_count"`
+	// RequireOTP makes the link ask for a code sent to Phone on WhatsApp before serving the file.
+	RequireOTP bool       `json:"require_otp"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Regenerator rebuilds the file of a report from its kind and params.
+type Regenerator interface {
+	Regenerate(ctx context.Context, r Report) ([]byte, error)
+}
+
+var (
+	ErrNotFound = errors.New("not found")
+	// ErrUnavailable means the link exists but was revoked, expired or used up.
+	ErrUnavailable = errors.New("report link is no longer available")
+)
 
 func newToken(nBytes int) (string, error) {
 	b := make([]byte, nBytes)
@@ -23,33 +67,120 @@ func newToken(nBytes int) (string, error) {
 	return hex.EncodeToString(b), nil
 }
 
-func (s *Store) Create(ctx context.Context, phone, month, filePath string, ttl time.Duration) (string, time.Time, error) {
+// newObjectKey spreads report files by creation day; the random part is unrelated to the link
+// token, so a listing of the bucket doesn't hand out links.
+func newObjectKey(now time.Time) (string, error) {
+	id, err := newToken(12)
+	if err != nil {
+		return "", err
+	}
+	return "reports/" + now.UTC().Format("2006/01/02") + "/" + id + ".pdf", nil
+}
+
+// Create stores data and returns a link token valid for ttl.
+func (s *Store) Create(ctx context.Context, r Report, data []byte, ttl time.Duration) (string, time.Time, error) {
 	token, err := newToken(24)
 	if err != nil {
 		return "", time.Time{}, err
 	}
-	expires := time.Now().Add(ttl)
+	now := time.Now()
+	key, err := newObjectKey(now)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if err := s.Blobs.Put(ctx, key, data, "application/pdf"); err != nil {
+		return "", time.Time{}, err
+	}
+	if len(r.Params) == 0 {
+		r.Params = json.RawMessage(`{}`)
+	}
+	expires := now.Add(ttl)
 
 	const q = `
-		INSERT INTO reports (user_phone, month, token, file_path, expires_at)
-		VALUES ($1, $2, $3, $4, $5);
+		INSERT INTO reports (user_phone, user_id, month, token, kind, params, object_key, max_downloads, require_otp, expires_at)
+		VALUES ($1, COALESCE(NULLIF($2, '')::uuid, (SELECT id FROM users WHERE phone = $1 AND phone_verified_at IS NOT NULL)),
+		        $3, $4, $5, $6, $7, $8, $9, $10);
 	`
-	if _, err := s.DB.ExecContext(ctx, q, phone, month, token, filePath, expires); err != nil {
+	if _, err := s.DB.ExecContext(ctx, q, r.Phone, r.UserID, r.Month, token, r.Kind, []byte(r.Params), key,
+		r.MaxDownloads, r.RequireOTP, expires); err != nil {
+		_ = s.Blobs.Delete(ctx, key)
 		return "", time.Time{}, err
 	}
 	return token, expires, nil
 }
 
-func (s *Store) GetByToken(ctx context.Context, token string) (string, time.Time, error) {
-	const q = `SELECT file_path, expires_at FROM reports WHERE token = $1;`
+const reportColumns = `id, user_phone, COALESCE(user_id::text, ''), month, kind, params, COALESCE(object_key, ''),
+	max_downloads, download_count, require_otp, revoked_at, expires_at, created_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
 
-	var path string
-	var exp time.Time
-	if err := s.DB.QueryRowContext(ctx, q, token).Scan(&path, &exp); err != nil {
-		if err == sql.ErrNoRows {
-			return "", time.Time{}, ErrNotFound
+func scanReport(row rowScanner) (Report, error) {
+	var r Report
+	var params []byte
+	var maxDownloads sql.NullInt64
+	var revokedAt sql.NullTime
+	err := row.Scan(&r.ID, &r.Phone, &r.UserID, &r.Month, &r.Kind, &params, &r.ObjectKey,
+		&maxDownloads, &r.DownloadCount, &r.RequireOTP, &revokedAt, &r.ExpiresAt, &r.CreatedAt)
+	if err == sql.ErrNoRows {
+		return r, ErrNotFound
+	}
+	r.Params = params
+	if maxDownloads.Valid {
+		n := int(maxDownloads.Int64)
+		r.MaxDownloads = &n
+	}
+	if revokedAt.Valid {
+		r.RevokedAt = &revokedAt.Time
+	}
+	return r, err
+}
+
+func (s *Store) GetByToken(ctx context.Context, token string) (Report, error) {
+	return scanReport(s.DB.QueryRowContext(ctx, `SELECT `+reportColumns+` FROM reports WHERE token = $1;`, token))
+}
+
+// Available reports whether the link may still be downloaded at now.
+func (r Report) Available(now time.Time) bool {
+	return r.RevokedAt == nil && now.Before(r.ExpiresAt) &&
+		(r.MaxDownloads == nil || r.DownloadCount < *r.MaxDownloads)
+}
+
+// Open returns the report's file. When the stored copy is missing (links made before blob
+// storage, a wiped disk) it is regenerated and stored again.
+func (s *Store) Open(ctx context.Context, r Report) ([]byte, error) {
+	if r.ObjectKey != "" {
+		data, err := s.Blobs.Get(ctx, r.ObjectKey)
+		if !errors.Is(err, blobstore.ErrNotFound) {
+			return data, err
 		}
-		return "", time.Time{}, err
 	}
-	return path, exp, nil
+
+	gen, ok := s.Regenerators[r.Kind]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	data, err := gen.Regenerate(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	key, err := newObjectKey(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Blobs.Put(ctx, key, data, "application/pdf"); err != nil {
+		return nil, err
+	}
+	// a concurrent download may have stored it first; keep theirs and drop ours
+	res, err := s.DB.ExecContext(ctx, `
+		UPDATE reports SET object_key = $2 WHERE id = $1 AND COALESCE(object_key, '') = $3;
+	`, r.ID, key, r.ObjectKey)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		_ = s.Blobs.Delete(ctx, key)
+	}
+	return data, nil
 }
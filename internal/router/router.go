@@ -6,11 +6,20 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 
+	"github.com/ishantswami13-crypto/vantro-backend/internal/accounting"
 	"github.com/ishantswami13-crypto/vantro-backend/internal/admin"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/delivery"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/duplicates"
 	"github.com/ishantswami13-crypto/vantro-backend/internal/expense"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/export"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/goals"
 	handlers "github.com/ishantswami13-crypto/vantro-backend/internal/http"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/imports"
 	"github.com/ishantswami13-crypto/vantro-backend/internal/income"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/invoices"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/phonelink"
 	"github.com/ishantswami13-crypto/vantro-backend/internal/points"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/recurring"
 	"github.com/ishantswami13-crypto/vantro-backend/internal/reports"
 	"github.com/ishantswami13-crypto/vantro-backend/internal/summary"
 	"github.com/ishantswami13-crypto/vantro-backend/internal/transactions"
@@ -28,7 +37,17 @@ type Router struct {
 	AdminHandler        *admin.Handler
 	OnboardingHandler   *handlers.OnboardingHandler
 	ReportsHandler      *reports.Handler
+	ReportLinksHandler  *reports.LinksHandler
 	PointsHandler       *points.Handler
+	GoalsHandler        *goals.Handler
+	RecurringHandler    *recurring.Handler
+	InvoicesHandler     *invoices.Handler
+	DuplicatesHandler   *duplicates.Handler
+	ImportsHandler      *imports.Handler
+	ExportHandler       *export.Handler
+	AccountingHandler   *accounting.Handler
+	DeliveryHandler     *delivery.Handler
+	PhoneLinkHandler    *phonelink.Handler
 	AuthMW              fiber.Handler
 }
 
@@ -62,9 +81,11 @@ func (r *Router) RegisterRoutes(app *fiber.App) {
 		if r.AuthMW != nil {
 			app.Post("/api/expenses", r.AuthMW, writeLimiter, r.ExpenseHandler.CreateExpense)
 			app.Get("/api/expenses", r.AuthMW, r.ExpenseHandler.ListExpenses)
+			app.Get("/api/expenses/subscriptions", r.AuthMW, r.ExpenseHandler.Subscriptions)
 		} else {
 			app.Post("/api/expenses", writeLimiter, r.ExpenseHandler.CreateExpense)
 			app.Get("/api/expenses", r.ExpenseHandler.ListExpenses)
+			app.Get("/api/expenses/subscriptions", r.ExpenseHandler.Subscriptions)
 		}
 	}
 
@@ -92,9 +113,15 @@ func (r *Router) RegisterRoutes(app *fiber.App) {
 		if r.AuthMW != nil {
 			app.Get("/api/businesses", r.AuthMW, r.BizHandler.List)
 			app.Post("/api/businesses", r.AuthMW, r.BizHandler.Create)
+			app.Get("/api/businesses/:id/logo", r.AuthMW, r.BizHandler.GetLogo)
+			app.Put("/api/businesses/:id/logo", r.AuthMW, writeLimiter, r.BizHandler.UploadLogo)
+			app.Delete("/api/businesses/:id/logo", r.AuthMW, writeLimiter, r.BizHandler.DeleteLogo)
 		} else {
 			app.Get("/api/businesses", r.BizHandler.List)
 			app.Post("/api/businesses", r.BizHandler.Create)
+			app.Get("/api/businesses/:id/logo", r.BizHandler.GetLogo)
+			app.Put("/api/businesses/:id/logo", writeLimiter, r.BizHandler.UploadLogo)
+			app.Delete("/api/businesses/:id/logo", writeLimiter, r.BizHandler.DeleteLogo)
 		}
 	}
 
@@ -136,6 +163,19 @@ func (r *Router) RegisterRoutes(app *fiber.App) {
 		app.Get("/api/reports/categories", r.AuthMW, r.ReportsHandler.Categories)
 		app.Get("/api/reports/statement", r.AuthMW, r.ReportsHandler.Statement)
 		app.Get("/api/reports/statement.pdf", r.AuthMW, r.ReportsHandler.StatementPDF)
+		app.Get("/api/reports/forecast", r.AuthMW, r.ReportsHandler.Forecast)
+		app.Get("/api/reports/pnl", r.AuthMW, r.ReportsHandler.PnL)
+		app.Get("/api/reports/pnl.csv", r.AuthMW, r.ReportsHandler.PnLCSV)
+		app.Get("/api/reports/pnl.pdf", r.AuthMW, r.ReportsHandler.PnLPDF)
+		app.Get("/api/reports/budgets", r.AuthMW, r.ReportsHandler.Budgets)
+		app.Put("/api/reports/budgets", r.AuthMW, writeLimiter, r.ReportsHandler.SaveBudgets)
+	}
+
+	if r.ReportLinksHandler != nil && r.AuthMW != nil {
+		app.Get("/api/me/reports", r.AuthMW, r.ReportLinksHandler.List)
+		app.Patch("/api/me/reports/:id", r.AuthMW, writeLimiter, r.ReportLinksHandler.Update)
+		app.Post("/api/me/reports/:id/revoke", r.AuthMW, writeLimiter, r.ReportLinksHandler.Revoke)
+		app.Get("/api/me/reports/:id/downloads", r.AuthMW, r.ReportLinksHandler.Downloads)
 	}
 
 	if r.PointsHandler != nil && r.AuthMW != nil {
@@ -144,4 +184,85 @@ func (r *Router) RegisterRoutes(app *fiber.App) {
 		app.Get("/rewards", r.AuthMW, r.PointsHandler.Rewards)
 		app.Post("/redeem", r.AuthMW, writeLimiter, r.PointsHandler.Redeem)
 	}
+
+	if r.GoalsHandler != nil && r.AuthMW != nil {
+		app.Get("/api/goals", r.AuthMW, r.GoalsHandler.List)
+		app.Post("/api/goals", r.AuthMW, writeLimiter, r.GoalsHandler.Create)
+		app.Get("/api/goals/:id", r.AuthMW, r.GoalsHandler.Get)
+		app.Put("/api/goals/:id", r.AuthMW, writeLimiter, r.GoalsHandler.Update)
+		app.Delete("/api/goals/:id", r.AuthMW, r.GoalsHandler.Archive)
+		app.Get("/api/goals/:id/contributions", r.AuthMW, r.GoalsHandler.Contributions)
+		app.Post("/api/goals/:id/contributions", r.AuthMW, writeLimiter, r.GoalsHandler.Contribute)
+	}
+
+	if r.RecurringHandler != nil && r.AuthMW != nil {
+		app.Get("/api/recurring", r.AuthMW, r.RecurringHandler.List)
+		app.Post("/api/recurring", r.AuthMW, writeLimiter, r.RecurringHandler.Create)
+		app.Delete("/api/recurring/:id", r.AuthMW, r.RecurringHandler.Delete)
+	}
+
+	if r.InvoicesHandler != nil && r.AuthMW != nil {
+		app.Get("/api/invoices", r.AuthMW, r.InvoicesHandler.List)
+		app.Post("/api/invoices", r.AuthMW, writeLimiter, r.InvoicesHandler.Create)
+		app.Post("/api/invoices/:id/paid", r.AuthMW, writeLimiter, r.InvoicesHandler.MarkPaid)
+		app.Post("/api/invoices/:id/void", r.AuthMW, r.InvoicesHandler.Void)
+	}
+
+	if r.DuplicatesHandler != nil && r.AuthMW != nil {
+		app.Get("/api/duplicates", r.AuthMW, r.DuplicatesHandler.List)
+		app.Get("/api/duplicates/resolutions", r.AuthMW, r.DuplicatesHandler.Resolutions)
+		app.Post("/api/duplicates/merge", r.AuthMW, writeLimiter, r.DuplicatesHandler.Merge)
+		app.Post("/api/duplicates/dismiss", r.AuthMW, writeLimiter, r.DuplicatesHandler.Dismiss)
+	}
+
+	if r.ImportsHandler != nil && r.AuthMW != nil {
+		app.Get("/api/imports", r.AuthMW, r.ImportsHandler.List)
+		app.Get("/api/imports/presets", r.AuthMW, r.ImportsHandler.Presets)
+		app.Get("/api/imports/apps", r.AuthMW, r.ImportsHandler.Apps)
+		app.Post("/api/imports/preview", r.AuthMW, writeLimiter, r.ImportsHandler.Preview)
+		app.Get("/api/imports/:id", r.AuthMW, r.ImportsHandler.Get)
+		app.Post("/api/imports/:id/commit", r.AuthMW, writeLimiter, r.ImportsHandler.Commit)
+		app.Post("/api/imports/:id/rollback", r.AuthMW, writeLimiter, r.ImportsHandler.Rollback)
+	}
+
+	if r.ExportHandler != nil && r.AuthMW != nil {
+		app.Get("/api/export", r.AuthMW, r.ExportHandler.List)
+		app.Get("/api/export/:dataset", r.AuthMW, r.ExportHandler.Export)
+	}
+
+	if r.AccountingHandler != nil && r.AuthMW != nil {
+		app.Get("/api/accounting/settings", r.AuthMW, r.AccountingHandler.GetConfig)
+		app.Put("/api/accounting/settings", r.AuthMW, writeLimiter, r.AccountingHandler.SaveSettings)
+		app.Put("/api/accounting/mappings", r.AuthMW, writeLimiter, r.AccountingHandler.SaveMappings)
+		app.Get("/api/accounting/exports", r.AuthMW, r.AccountingHandler.List)
+		app.Get("/api/accounting/exports/preview", r.AuthMW, r.AccountingHandler.Preview)
+		app.Post("/api/accounting/exports", r.AuthMW, writeLimiter, r.AccountingHandler.Export)
+		app.Get("/api/accounting/exports/:id/download", r.AuthMW, r.AccountingHandler.Download)
+		app.Get("/api/accounting/journal", r.AuthMW, r.AccountingHandler.Journal)
+		app.Get("/api/accounting/journal-accounts", r.AuthMW, r.AccountingHandler.GetJournalAccounts)
+		app.Put("/api/accounting/journal-accounts", r.AuthMW, writeLimiter, r.AccountingHandler.SaveJournalAccounts)
+	}
+
+	if r.DeliveryHandler != nil && r.AuthMW != nil {
+		app.Get("/api/report-subscriptions", r.AuthMW, r.DeliveryHandler.List)
+		app.Post("/api/report-subscriptions", r.AuthMW, writeLimiter, r.DeliveryHandler.Create)
+		app.Patch("/api/report-subscriptions/:id", r.AuthMW, writeLimiter, r.DeliveryHandler.Update)
+		app.Delete("/api/report-subscriptions/:id", r.AuthMW, writeLimiter, r.DeliveryHandler.Delete)
+		app.Get("/api/report-subscriptions/:id/deliveries", r.AuthMW, r.DeliveryHandler.Deliveries)
+		app.Post("/api/report-subscriptions/:id/send", r.AuthMW, writeLimiter, r.DeliveryHandler.SendNow)
+	}
+
+	if r.PhoneLinkHandler != nil && r.AuthMW != nil {
+		app.Get("/api/me/phone", r.AuthMW, r.PhoneLinkHandler.Get)
+		app.Post("/api/me/phone/otp", r.AuthMW, authLimiter, r.PhoneLinkHandler.SendCode)
+		app.Post("/api/me/phone/verify", r.AuthMW, authLimiter, r.PhoneLinkHandler.Verify)
+		app.Delete("/api/me/phone", r.AuthMW, writeLimiter, r.PhoneLinkHandler.Unlink)
+		app.Get("/api/me/expense-memory", r.AuthMW, r.PhoneLinkHandler.ListExpenses)
+		app.Post("/api/me/expense-memory", r.AuthMW, writeLimiter, r.PhoneLinkHandler.AddExpense)
+		app.Get("/api/me/expense-memory/summary", r.AuthMW, r.PhoneLinkHandler.Summary)
+		app.Get("/api/me/expense-memory/export", r.AuthMW, r.PhoneLinkHandler.ExportExpenses)
+		app.Get("/api/me/expense-memory/attachments/:id", r.AuthMW, r.PhoneLinkHandler.Attachment)
+		app.Get("/api/me/reminders", r.AuthMW, r.PhoneLinkHandler.GetReminders)
+		app.Put("/api/me/reminders", r.AuthMW, writeLimiter, r.PhoneLinkHandler.SaveReminders)
+	}
 }
@@ -82,6 +82,7 @@ func (r *SimpleRepo) List(ctx context.Context, userID string, limit int) ([]Txn,
 SELECT id::text, amount, direction, COALESCE(note,''), created_at::text
 FROM user_transactions
 WHERE user_id = $1
+  AND deleted_at IS NULL
 ORDER BY created_at DESC
 LIMIT $2
 `, userID, limit)
@@ -2,10 +2,12 @@ package transactions
 
 import (
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/ishantswami13-crypto/vantro-backend/internal/audit"
+	"github.com/ishantswami13-crypto/vantro-backend/internal/duplicates"
 )
 
 type SimpleHandler struct {
@@ -55,7 +57,22 @@ func (h *SimpleHandler) Create(c *fiber.Ctx) error {
 	}
 	_ = audit.Write(c.UserContext(), h.Repo.Pool, entry)
 
-	return c.JSON(tx)
+	resp := createTxnResponse{Txn: tx}
+	resp.DuplicateWarning = duplicates.Warn(c.UserContext(), h.Repo.Pool, userID, duplicates.Record{
+		Kind:      duplicates.KindUserTransaction,
+		ID:        tx.ID,
+		Direction: tx.Direction,
+		Amount:    tx.Amount,
+		Date:      time.Now(),
+		Label:     tx.Note,
+	})
+
+	return c.JSON(resp)
+}
+
+type createTxnResponse struct {
+	Txn
+	DuplicateWarning *duplicates.Warning `json:"duplicate_warning,omitempty"`
 }
 
 func (h *SimpleHandler) List(c *fiber.Ctx) error {
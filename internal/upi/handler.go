@@ -0,0 +1,99 @@
+package upi
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// companion apps forward a backlog after being offline; cap one request
+const maxBatch = 200
+
+type SMSMessage struct {
+	Text       string `json:"text"`
+	Sender     string `json:"sender,omitempty"`      // e.g. VM-HDFCBK; informational only
+	ReceivedAt string `json:"received_at,omitempty"` // RFC3339
+}
+
+// SMSRequest accepts either one message inline or a batch in Messages.
+type SMSRequest struct {
+	UserPhone string `json:"user_phone"`
+	SMSMessage
+	Messages []SMSMessage `json:"messages,omitempty"`
+}
+
+type SMSResult struct {
+	Index  int      `json:"index"`
+	Status string   `json:"status"` // created | duplicate | ignored | error
+	Alert  *Alert   `json:"alert,omitempty"`
+	Saved  *Capture `json:"saved,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
+
+func SMSHandler(store *Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req SMSRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid json"})
+		}
+		req.UserPhone = strings.TrimSpace(req.UserPhone)
+		if req.UserPhone == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "user_phone required"})
+		}
+
+		msgs := req.Messages
+		if strings.TrimSpace(req.Text) != "" {
+			msgs = append([]SMSMessage{req.SMSMessage}, msgs...)
+		}
+		if len(msgs) == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "text or messages required"})
+		}
+		if len(msgs) > maxBatch {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "too many messages in one request"})
+		}
+
+		counts := map[string]int{}
+		results := make([]SMSResult, 0, len(msgs))
+		for i, m := range msgs {
+			res := SMSResult{Index: i}
+			receivedAt := time.Now()
+			if t, err := time.Parse(time.RFC3339, strings.TrimSpace(m.ReceivedAt)); err == nil {
+				receivedAt = t
+			}
+
+			alert, err := Parse(m.Text, receivedAt)
+			if err != nil {
+				res.Status = "ignored"
+				if !errors.Is(err, ErrNotTransaction) {
+					res.Error = err.Error()
+				}
+				counts[res.Status]++
+				results = append(results, res)
+				continue
+			}
+			res.Alert = &alert
+
+			saved, err := store.Save(c.Context(), req.UserPhone, alert)
+			switch {
+			case err != nil:
+				res.Status, res.Error = "error", "could not save"
+			case saved.Duplicate:
+				res.Status, res.Saved = "duplicate", &saved
+			default:
+				res.Status, res.Saved = "created", &saved
+			}
+			counts[res.Status]++
+			results = append(results, res)
+		}
+
+		return c.JSON(fiber.Map{
+			"created":    counts["created"],
+			"duplicates": counts["duplicate"],
+			"ignored":    counts["ignored"],
+			"errors":     counts["error"],
+			"results":    results,
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package upi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/money"
+)
+
+var ErrNotTransaction = errors.New("not a transaction alert")
+
+// Alert is what a bank SMS tells us about one UPI payment.
+type Alert struct {
+	Template     string    `json:"template"`
+	Bank         string    `json:"bank"`
+	Direction    string    `json:"direction"` // IN | OUT
+	AmountPaise  int64     `json:"amount_paise"`
+	Account      string    `json:"account,omitempty"` // masked account digits from the SMS
+	Counterparty string    `json:"counterparty,omitempty"`
+	VPA          string    `json:"vpa,omitempty"`
+	Reference    string    `json:"reference,omitempty"`
+	At           time.Time `json:"at"`
+}
+
+var ist = time.FixedZone("IST", 5*3600+1800)
+
+var smsDateLayouts = []string{
+	"02/01/06", "02/01/2006", "02-01-06", "02-01-2006", "02Jan06", "02-Jan-06", "02-Jan-2006", "02 Jan 2006",
+}
+
+// Parse extracts a payment from alert text. receivedAt is used when the SMS has no usable
+// date (and for the time of day, which most banks leave out).
+func Parse(text string, receivedAt time.Time) (Alert, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || nonTransaction.MatchString(text) {
+		return Alert{}, ErrNotTransaction
+	}
+	if receivedAt.IsZero() {
+		receivedAt = time.Now()
+	}
+
+	for _, t := range Templates {
+		m := t.re.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		g := map[string]string{}
+		for i, name := range t.re.SubexpNames() {
+			if name != "" {
+				g[name] = strings.TrimSpace(m[i])
+			}
+		}
+
+		a := Alert{
+			Template:     t.Name,
+			Bank:         t.Bank,
+			Direction:    t.Direction,
+			Account:      g["acct"],
+			Counterparty: strings.TrimRight(g["counterparty"], " .,;"),
+			VPA:          strings.ToLower(g["vpa"]),
+			Reference:    g["ref"],
+		}
+		if a.Direction == "" {
+			a.Direction = "OUT"
+			if strings.EqualFold(g["dir"], "credited") {
+				a.Direction = "IN"
+			}
+		}
+		paise, err := money.ParseRupees(g["amount"])
+		if err != nil || paise <= 0 {
+			return Alert{}, ErrNotTransaction
+		}
+		a.AmountPaise = paise
+		a.At = alertTime(g["date"], g["time"], receivedAt)
+		return a, nil
+	}
+	return parseGeneric(text, receivedAt)
+}
+
+func parseGeneric(text string, receivedAt time.Time) (Alert, error) {
+	am := genericAmount.FindStringSubmatch(text)
+	if am == nil {
+		return Alert{}, ErrNotTransaction
+	}
+	paise, err := money.ParseRupees(am[1])
+	if err != nil || paise <= 0 {
+		return Alert{}, ErrNotTransaction
+	}
+
+	// "debited ... credited to X" describes a debit: the first verb wins
+	debit := genericDebit.FindStringIndex(text)
+	credit := genericCredit.FindStringIndex(text)
+	var dir string
+	switch {
+	case debit != nil && (credit == nil || debit[0] < credit[0]):
+		dir = "OUT"
+	case credit != nil:
+		dir = "IN"
+	default:
+		return Alert{}, ErrNotTransaction
+	}
+
+	a := Alert{Template: "generic", Direction: dir, AmountPaise: paise, At: receivedAt}
+	if m := genericRef.FindStringSubmatch(text); m != nil {
+		a.Reference = m[1]
+	}
+	if m := genericVPA.FindStringSubmatch(text); m != nil {
+		a.VPA = strings.ToLower(m[1])
+	}
+	if m := genericAcct.FindStringSubmatch(text); m != nil {
+		a.Account = m[1]
+	}
+	if a.Reference == "" && a.VPA == "" {
+		return Alert{}, ErrNotTransaction
+	}
+	return a, nil
+}
+
+// alertTime combines the SMS date with its time (or the time it was received). Dates in the
+// future are clock or parse errors, so receivedAt wins then.
+func alertTime(date, clock string, receivedAt time.Time) time.Time {
+	if date == "" {
+		return receivedAt
+	}
+	var d time.Time
+	ok := false
+	for _, l := range smsDateLayouts {
+		if t, err := time.ParseInLocation(l, date, ist); err == nil {
+			d, ok = t, true
+			break
+		}
+	}
+	if !ok {
+		return receivedAt
+	}
+
+	local := receivedAt.In(ist)
+	h, m, s := local.Hour(), local.Minute(), local.Second()
+	if y, mo, dd := local.Date(); y != d.Year() || mo != d.Month() || dd != d.Day() {
+		// SMS arrived on a later day (phone was off); noon avoids pushing the date across midnight
+		h, m, s = 12, 0, 0
+	}
+	if clock != "" {
+		parts := strings.Split(clock, ":")
+		h, _ = strconv.Atoi(parts[0])
+		m, _ = strconv.Atoi(parts[1])
+		s = 0
+		if len(parts) > 2 {
+			s, _ = strconv.Atoi(parts[2])
+		}
+	}
+	at := time.Date(d.Year(), d.Month(), d.Day(), h, m, s, 0, ist)
+	if at.After(receivedAt.Add(24 * time.Hour)) {
+		return receivedAt
+	}
+	return at
+}
+
+// ExternalID is the dedupe key: the UPI reference (RRN) when the bank sent one, otherwise a
+// hash of what the alert says so a re-sent batch still doesn't double-count.
+func (a Alert) ExternalID() string {
+	if a.Reference != "" {
+		return "upi:" + a.Reference
+	}
+	base := strings.Join([]string{
+		a.Direction,
+		strconv.FormatInt(a.AmountPaise, 10),
+		a.Account,
+		a.VPA,
+		strings.ToLower(a.Counterparty),
+		a.At.UTC().Format("2006-01-02T15:04"),
+	}, "|")
+	sum := sha256.Sum256([]byte(base))
+	return "upi:h:" + hex.EncodeToString(sum[:12])
+}
+
+// Label is the best human name for the other side of the payment.
+func (a Alert) Label() string {
+	if a.Counterparty != "" {
+		return a.Counterparty
+	}
+	return a.VPA
+}
@@ -0,0 +1,76 @@
+package upi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/ishantswami13-crypto/vantro-backend/internal/expense"
+)
+
+// Store writes captured alerts into Expense Memory: debits become expenses, credits go to
+// phone_incomes. Both carry source "upi" and the alert's external id.
+type Store struct {
+	DB *sql.DB
+}
+
+type Capture struct {
+	Kind      string `json:"kind"` // expense | income
+	ID        int64  `json:"id,omitempty"`
+	Duplicate bool   `json:"duplicate"`
+}
+
+func (s *Store) Save(ctx context.Context, userPhone string, a Alert) (Capture, error) {
+	userPhone = strings.TrimSpace(userPhone)
+	if userPhone == "" {
+		return Capture{}, expense.ErrBadRequest
+	}
+	note := noteFor(a)
+
+	if a.Direction == "IN" {
+		c := Capture{Kind: "income"}
+		err := s.DB.QueryRowContext(ctx, `
+			INSERT INTO phone_incomes (user_phone, amount_paise, currency, counterparty, note, source, external_id, created_at)
+			VALUES ($1, $2, 'INR', NULLIF($3,''), $4, 'upi', $5, $6)
+			ON CONFLICT (user_phone, external_id) WHERE external_id IS NOT NULL DO NOTHING
+			RETURNING id;
+		`, userPhone, a.AmountPaise, a.Label(), note, a.ExternalID(), a.At).Scan(&c.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.Duplicate = true
+			return c, nil
+		}
+		return c, err
+	}
+
+	c := Capture{Kind: "expense"}
+	category := expense.Categorize(a.Counterparty + " " + a.VPA)
+	err := s.DB.QueryRowContext(ctx, `
+		INSERT INTO expenses (user_phone, amount_paise, currency, category, note, source, external_id, created_at, user_id)
+		VALUES ($1, $2, 'INR', $3, $4, 'upi', $5, $6, (SELECT id FROM users WHERE phone = $1 AND phone_verified_at IS NOT NULL))
+		ON CONFLICT (user_phone, external_id) WHERE external_id IS NOT NULL DO NOTHING
+		RETURNING id;
+	`, userPhone, a.AmountPaise, category, note, a.ExternalID(), a.At).Scan(&c.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.Duplicate = true
+		return c, nil
+	}
+	return c, err
+}
+
+// noteFor keeps the counterparty first so the insights engine's merchant key picks it up.
+func noteFor(a Alert) string {
+	parts := []string{}
+	if l := a.Label(); l != "" {
+		parts = append(parts, l)
+	}
+	via := "UPI"
+	if a.Bank != "" {
+		via += " " + a.Bank
+	}
+	if a.Account != "" {
+		via += " xx" + a.Account
+	}
+	parts = append(parts, "("+via+")")
+	return strings.Join(parts, " ")
+}
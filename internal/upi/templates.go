@@ -0,0 +1,62 @@
+package upi
+
+import "regexp"
+
+// Template recognises one bank's alert wording. Named groups: amount (required), dir
+// (debited/credited words, when Direction is empty), acct, counterparty, vpa, ref, date, time.
+type Template struct {
+	Name      string
+	Bank      string
+	Direction string // IN | OUT | "" (read from the dir group)
+	re        *regexp.Regexp
+}
+
+const amountGroup = `(?P<amount>[\d,]+(?:\.\d{1,2})?)`
+
+// Templates are tried in order before the generic fallback. Sample texts are in the comments
+// so wording changes at a bank are easy to diff against.
+var Templates = []Template{
+	// Sent Rs.250.00 From HDFC Bank A/C *1234 To ZOMATO On 05/03/24 Ref 412345678901 Not You? ...
+	{Name: "hdfc_upi_debit", Bank: "HDFC", Direction: "OUT", re: regexp.MustCompile(
+		`(?is)Sent Rs\.?\s*` + amountGroup + `\s*From HDFC Bank A/C\s*[*xX]*(?P<acct>\d+)\s*To\s+(?P<counterparty>.+?)\s*On\s+(?P<date>\d{2}/\d{2}/\d{2,4})\s*Ref\s*(?P<ref>\d+)`)},
+	// Rs.500.00 credited to HDFC Bank A/c XX1234 on 05-03-24 from VPA rahul@okaxis (UPI 412345678901)
+	{Name: "hdfc_upi_credit", Bank: "HDFC", Direction: "IN", re: regexp.MustCompile(
+		`(?is)Rs\.?\s*` + amountGroup + `\s*credited to HDFC Bank A/c\s*[*xX]*(?P<acct>\d+)\s*on\s+(?P<date>\d{2}-\d{2}-\d{2,4})\s*from VPA\s+(?P<vpa>\S+)\s*\(UPI\s*(?P<ref>\d+)\)`)},
+	// Dear UPI user A/C X1234 debited by 250.0 on date 05Mar24 trf to ZOMATO Refno 412345678901. If not u? call ... -SBI
+	{Name: "sbi_upi_debit", Bank: "SBI", Direction: "OUT", re: regexp.MustCompile(
+		`(?is)A/C\s*X?(?P<acct>\d+)\s*debited by\s*(?:Rs\.?\s*)?` + amountGroup + `\s*on date\s*(?P<date>\d{2}[A-Za-z]{3}\d{2})\s*trf to\s+(?P<counterparty>.+?)\s*Refno\s*(?P<ref>\d+)`)},
+	// Dear SBI UPI User, ur A/cX1234 credited by Rs500 on 05Mar24 by RAHUL (Ref no 412345678901)
+	{Name: "sbi_upi_credit", Bank: "SBI", Direction: "IN", re: regexp.MustCompile(
+		`(?is)A/c\s*X?(?P<acct>\d+)\s*credited by\s*Rs\.?\s*` + amountGroup + `\s*on\s*(?P<date>\d{2}[A-Za-z]{3}\d{2})\s*by\s*(?P<counterparty>[^(]*?)\s*\(Ref no\s*(?P<ref>\d+)\)`)},
+	// ICICI Bank Acct XX123 debited for Rs 250.00 on 05-Mar-24; ZOMATO credited. UPI:412345678901. Call ...
+	{Name: "icici_upi_debit", Bank: "ICICI", Direction: "OUT", re: regexp.MustCompile(
+		`(?is)ICICI Bank Acct\s*X*(?P<acct>\d+)\s*debited for Rs\.?\s*` + amountGroup + `\s*on\s*(?P<date>\d{2}-[A-Za-z]{3}-\d{2})\s*;\s*(?P<counterparty>.+?)\s*credited\.\s*UPI:\s*(?P<ref>\d+)`)},
+	// Dear Customer, Acct XX123 is credited with Rs 500.00 on 05-Mar-24 from RAHUL. UPI:412345678901-ICICI Bank.
+	{Name: "icici_upi_credit", Bank: "ICICI", Direction: "IN", re: regexp.MustCompile(
+		`(?is)Acct\s*X*(?P<acct>\d+)\s*is credited with Rs\.?\s*` + amountGroup + `\s*on\s*(?P<date>\d{2}-[A-Za-z]{3}-\d{2})\s*from\s+(?P<counterparty>.+?)\.\s*UPI:\s*(?P<ref>\d+)`)},
+	// INR 250.00 debited A/c no. XX1234 05-03-24, 14:22:11 UPI/P2M/412345678901/ZOMATO Not you? ... - Axis Bank
+	{Name: "axis_upi", Bank: "AXIS", re: regexp.MustCompile(
+		`(?is)INR\s*` + amountGroup + `\s*(?P<dir>debited|credited)\s*A/c no\.\s*X*(?P<acct>\d+)\s*(?P<date>\d{2}-\d{2}-\d{2,4}),?\s*(?P<time>\d{2}:\d{2}(?::\d{2})?)\s*UPI/P2[AM]/(?P<ref>\d+)/(?P<counterparty>[^\n/]+)`)},
+	// Sent Rs.250.00 from Kotak Bank AC X1234 to zomato@hdfcbank on 05-03-24.UPI Ref 412345678901. Not you, ...
+	{Name: "kotak_upi_debit", Bank: "KOTAK", Direction: "OUT", re: regexp.MustCompile(
+		`(?is)Sent Rs\.?\s*` + amountGroup + `\s*from Kotak Bank AC\s*X?(?P<acct>\d+)\s*to\s+(?P<vpa>\S+?)\s+on\s+(?P<date>\d{2}-\d{2}-\d{2,4})\.?\s*UPI Ref:?\s*(?P<ref>\d+)`)},
+	// Received Rs.500.00 in your Kotak Bank AC X1234 from rahul@okicici on 05-03-24.UPI Ref:412345678901.
+	{Name: "kotak_upi_credit", Bank: "KOTAK", Direction: "IN", re: regexp.MustCompile(
+		`(?is)Received Rs\.?\s*` + amountGroup + `\s*in your Kotak Bank AC\s*X?(?P<acct>\d+)\s*from\s+(?P<vpa>\S+?)\s+on\s+(?P<date>\d{2}-\d{2}-\d{2,4})\.?\s*UPI Ref:?\s*(?P<ref>\d+)`)},
+	// Rs.250 paid to ZOMATO from Paytm Payments Bank a/c 91XX1234. UPI Ref: 412345678901. ...
+	{Name: "paytm_upi_debit", Bank: "PAYTM", Direction: "OUT", re: regexp.MustCompile(
+		`(?is)Rs\.?\s*` + amountGroup + `\s*paid to\s+(?P<counterparty>.+?)\s*from Paytm Payments Bank a/c\s*\w*?(?P<acct>\d{4})\.\s*UPI Ref:?\s*(?P<ref>\d+)`)},
+}
+
+// fallback patterns for banks without a template: the alert must still name an amount,
+// a debit/credit verb and a UPI reference or VPA before anything is recorded.
+var (
+	genericAmount = regexp.MustCompile(`(?i)(?:Rs\.?|INR|₹)\s*` + amountGroup)
+	genericDebit  = regexp.MustCompile(`(?i)\b(debited|sent|paid|withdrawn|spent)\b`)
+	genericCredit = regexp.MustCompile(`(?i)\b(credited|received|deposited)\b`)
+	genericRef    = regexp.MustCompile(`(?i)(?:UPI\s*Ref(?:erence)?(?:\s*No)?|Ref(?:\s*No)?|RRN|UPI)[\s:.#-]*(\d{10,14})`)
+	genericVPA    = regexp.MustCompile(`(?i)\b([a-z0-9.\-_]{2,}@[a-z]{2,})\b`)
+	genericAcct   = regexp.MustCompile(`(?i)a/?c\.?\s*(?:no\.?)?\s*[*xX]+(\d{3,6})`)
+	// collect requests, OTPs and mandates look like alerts but move no money
+	nonTransaction = regexp.MustCompile(`(?i)(has requested|requested money|collect request|\bOTP\b|one time password|mandate|autopay.*(?:set up|created))`)
+)
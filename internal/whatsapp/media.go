@@ -0,0 +1,51 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxMediaBytes caps inbound media downloads; WhatsApp images are at most 5 MB.
+const MaxMediaBytes = 10 << 20
+
+// mediaTimeout bounds one download, redirect included, whatever deadline the caller has.
+const mediaTimeout = 30 * time.Second
+
+var mediaClient = &http.Client{Timeout: mediaTimeout}
+
+// FetchMedia downloads an inbound message's MediaUrl. Twilio serves media behind the account's
+// basic auth and redirects to storage, which gets no credentials.
+func (t *TwilioClient) FetchMedia(ctx context.Context, mediaURL string) ([]byte, string, error) {
+	if !strings.HasPrefix(mediaURL, "https://api.twilio.com/") {
+		return nil, "", errors.New("not a twilio media url")
+	}
+	ctx, cancel := context.WithTimeout(ctx, mediaTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mediaURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.SetBasicAuth(t.AccountSID, t.AuthToken)
+
+	res, err := mediaClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return nil, "", &twilioHTTPError{Status: res.StatusCode, Body: "media " + strconv.Itoa(res.StatusCode)}
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, MaxMediaBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > MaxMediaBytes {
+		return nil, "", errors.New("media is too large")
+	}
+	return data, res.Header.Get("Content-Type"), nil
+}
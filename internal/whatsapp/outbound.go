@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
 )
 
 type TwilioClient struct {
@@ -26,10 +28,60 @@ func NewTwilioFromEnv() *TwilioClient {
 
 func (t *TwilioClient) SendWhatsAppPDF(ctx context.Context, toPhone, caption, pdfURL string) error {
 	form := url.Values{}
-	form.Set("From", t.FromWA)
-	form.Set("To", "whatsapp:"+toPhone)
 	form.Set("Body", caption)
 	form.Set("MediaUrl", pdfURL)
+	form.Set("To", "whatsapp:"+toPhone)
+	_, err := t.send(ctx, form)
+	return err
+}
+
+// SendWhatsAppText sends a plain message, e.g. a one-time code.
+func (t *TwilioClient) SendWhatsAppText(ctx context.Context, toPhone, body string) error {
+	form := url.Values{}
+	form.Set("Body", body)
+	form.Set("To", "whatsapp:"+toPhone)
+	_, err := t.send(ctx, form)
+	return err
+}
+
+// SentMessage is Twilio's answer to a send: the message sid and its first status.
+type SentMessage struct {
+	SID    string `json:"sid"`
+	Status string `json:"status"`
+}
+
+// SendMessage sends body and an optional media URL from sender ("" for FromWA) and asks Twilio
+// to POST status changes to statusCallback when it is set.
+func (t *TwilioClient) SendMessage(ctx context.Context, sender, toPhone, body, mediaURL, statusCallback string) (SentMessage, error) {
+	from := ""
+	if sender != "" {
+		from = "whatsapp:" + strings.TrimPrefix(sender, "whatsapp:")
+	}
+	return t.Send(ctx, from, "whatsapp:"+toPhone, body, mediaURL, statusCallback)
+}
+
+// Send is the raw Messages API call: from and to are Twilio addresses ("whatsapp:+91..." or an
+// SMS number) and from "" is FromWA.
+func (t *TwilioClient) Send(ctx context.Context, from, to, body, mediaURL, statusCallback string) (SentMessage, error) {
+	form := url.Values{}
+	form.Set("Body", body)
+	if mediaURL != "" {
+		form.Set("MediaUrl", mediaURL)
+	}
+	if from != "" {
+		form.Set("From", from)
+	}
+	form.Set("To", to)
+	if statusCallback != "" {
+		form.Set("StatusCallback", statusCallback)
+	}
+	return t.send(ctx, form)
+}
+
+func (t *TwilioClient) send(ctx context.Context, form url.Values) (SentMessage, error) {
+	if form.Get("From") == "" {
+		form.Set("From", t.FromWA)
+	}
 
 	endpoint := "https://api.twilio.com/2010-04-01/Accounts/" + t.AccountSID + "/Messages.json"
 	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBufferString(form.Encode()))
@@ -38,17 +90,18 @@ func (t *TwilioClient) SendWhatsAppPDF(ctx context.Context, toPhone, caption, pd
 
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return err
+		return SentMessage{}, err
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode >= 300 {
 		body, _ := io.ReadAll(res.Body)
-		return &twilioHTTPError{Status: res.StatusCode, Body: string(body)}
+		return SentMessage{}, &twilioHTTPError{Status: res.StatusCode, Body: string(body)}
 	}
 
-	_ = json.NewDecoder(res.Body).Decode(&map[string]any{})
-	return nil
+	var out SentMessage
+	_ = json.NewDecoder(res.Body).Decode(&out)
+	return out, nil
 }
 
 type twilioHTTPError struct {
@@ -57,5 +110,10 @@ type twilioHTTPError struct {
 }
 
 func (e *twilioHTTPError) Error() string {
-	return "twilio send failed"
+	return "twilio send failed: " + strconv.Itoa(e.Status) + " " + strings.TrimSpace(e.Body)
+}
+
+// Retryable reports whether trying again later may succeed: rate limiting and Twilio-side errors.
+func (e *twilioHTTPError) Retryable() bool {
+	return e.Status == http.StatusTooManyRequests || e.Status >= 500
 }
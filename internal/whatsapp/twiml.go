@@ -0,0 +1,32 @@
+package whatsapp
+
+import (
+	"encoding/xml"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type twimlResponse struct {
+	XMLName xml.Name      `xml:"Response"`
+	Message *twimlMessage `xml:"Message,omitempty"`
+}
+
+type twimlMessage struct {
+	Body  string `xml:"Body"`
+	Media string `xml:"Media,omitempty"`
+}
+
+// SendTwiML answers a Twilio messaging webhook with text and an optional media URL; with
+// neither it answers with an empty response, which sends nothing.
+func SendTwiML(c *fiber.Ctx, text, mediaURL string) error {
+	resp := twimlResponse{}
+	if text != "" || mediaURL != "" {
+		resp.Message = &twimlMessage{Body: text, Media: mediaURL}
+	}
+	out, err := xml.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	c.Set("Content-Type", "text/xml")
+	return c.SendString(xml.Header + string(out))
+}
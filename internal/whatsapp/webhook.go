@@ -0,0 +1,159 @@
+package whatsapp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// WebhookGuard authenticates Twilio webhooks by their X-Twilio-Signature and drops messages it
+// has seen before.
+type WebhookGuard struct {
+	AuthToken string
+	// BaseURL is the public scheme and host Twilio calls (PUBLIC_BASE_URL); the signature covers
+	// the full URL, so it must match the webhook configured in Twilio, not what a proxy forwards.
+	BaseURL string
+	// Required rejects every webhook while AuthToken is unset instead of letting them through.
+	Required bool
+	// DB records MessageSids; nil disables replay protection.
+	DB *sql.DB
+}
+
+// NewWebhookGuardFromEnv requires validation unless ENV=dev.
+func NewWebhookGuardFromEnv(db *sql.DB) *WebhookGuard {
+	return &WebhookGuard{
+		AuthToken: os.Getenv("TWILIO_AUTH_TOKEN"),
+		BaseURL:   strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/"),
+		Required:  !strings.EqualFold(strings.TrimSpace(os.Getenv("ENV")), "dev"),
+		DB:        db,
+	}
+}
+
+// Signature computes X-Twilio-Signature: base64 HMAC-SHA1, keyed by the auth token, of the full
+// URL followed by every POST parameter name and value in name order.
+func Signature(authToken, fullURL string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(fullURL)
+	for _, k := range keys {
+		values := append([]string(nil), params[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			b.WriteString(k + v)
+		}
+	}
+	m := hmac.New(sha1.New, []byte(authToken))
+	m.Write([]byte(b.String()))
+	return base64.StdEncoding.EncodeToString(m.Sum(nil))
+}
+
+// ErrReplayed is returned by Check for a MessageSid that was accepted before.
+var ErrReplayed = errors.New("replayed twilio message")
+
+// sidLocal is the fiber.Ctx local Check leaves the recorded MessageSid in, for Forget.
+const sidLocal = "twilio_message_sid"
+
+// Middleware guards a Twilio webhook route. A replayed MessageSid gets an empty TwiML answer so
+// Twilio stops retrying without the message being handled twice; a sid whose handler fails is
+// forgotten so Twilio's retry goes through.
+func (g *WebhookGuard) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := g.Check(c)
+		var fe *fiber.Error
+		switch {
+		case err == nil:
+			err = c.Next()
+			if err != nil || c.Response().StatusCode() >= fiber.StatusInternalServerError {
+				g.Forget(c)
+			}
+			return err
+		case errors.Is(err, ErrReplayed):
+			return SendTwiML(c, "", "")
+		case errors.As(err, &fe):
+			return c.Status(fe.Code).SendString(fe.Message)
+		}
+		return err
+	}
+}
+
+// Check verifies the request's signature and records its MessageSid. It returns ErrReplayed
+// for a sid seen before and a *fiber.Error for a request to reject. A handler that then fails
+// should call Forget.
+func (g *WebhookGuard) Check(c *fiber.Ctx) error {
+	if g.AuthToken == "" {
+		if g.Required {
+			return fiber.NewError(fiber.StatusForbidden, "webhook validation is not configured")
+		}
+		return nil
+	}
+
+	params, err := url.ParseQuery(string(c.Body()))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "bad form body")
+	}
+	base := g.BaseURL
+	if base == "" {
+		base = c.BaseURL()
+	}
+	want := Signature(g.AuthToken, base+c.OriginalURL(), params)
+	if !hmac.Equal([]byte(want), []byte(c.Get("X-Twilio-Signature"))) {
+		return fiber.NewError(fiber.StatusForbidden, "invalid signature")
+	}
+
+	if g.DB == nil {
+		return nil
+	}
+	sid := params.Get("MessageSid")
+	if sid == "" {
+		sid = params.Get("SmsSid")
+	}
+	if sid == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "missing MessageSid")
+	}
+	// sids of handled messages are kept for good: a signature never expires, so a forgotten
+	// sid could be replayed
+	res, err := g.DB.ExecContext(c.UserContext(), `
+		INSERT INTO twilio_inbound_messages (message_sid, from_phone)
+		VALUES ($1, $2)
+		ON CONFLICT (message_sid) DO NOTHING;
+	`, sid, strings.TrimPrefix(params.Get("From"), "whatsapp:"))
+	if err != nil {
+		log.Printf("[twilio] record %s: %v", sid, err)
+		return fiber.NewError(fiber.StatusInternalServerError, "server error")
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		log.Printf("[twilio] dropped replayed message %s", sid)
+		return ErrReplayed
+	}
+	c.Locals(sidLocal, sid)
+	return nil
+}
+
+// Forget removes the MessageSid Check recorded for this request, so that Twilio's retry of a
+// message whose handling failed isn't dropped as a replay.
+func (g *WebhookGuard) Forget(c *fiber.Ctx) {
+	sid, _ := c.Locals(sidLocal).(string)
+	if g.DB == nil || sid == "" {
+		return
+	}
+	if _, err := g.DB.ExecContext(context.Background(), `
+		DELETE FROM twilio_inbound_messages WHERE message_sid = $1;
+	`, sid); err != nil {
+		log.Printf("[twilio] forget %s: %v", sid, err)
+	}
+}
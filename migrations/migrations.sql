@@ -404,7 +404,7 @@ CREATE TABLE IF NOT EXISTS expenses (
   currency TEXT NOT NULL DEFAULT 'INR',
   category TEXT NOT NULL DEFAULT 'MISC',
   note TEXT,
-  source TEXT NOT NULL DEFAULT 'manual', -- manual | whatsapp | app | upi (future)
+  source TEXT NOT NULL DEFAULT 'manual', -- manual | whatsapp | app | upi
   created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
@@ -539,3 +539,550 @@ END $$;
 
 
 ✅ This makes money storage consistent + safe.
+
+-- ============================
+-- SAVINGS GOALS
+-- ============================
+CREATE TABLE IF NOT EXISTS savings_goals (
+  id BIGSERIAL PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  target_amount BIGINT NOT NULL CHECK (target_amount > 0), -- paise
+  saved_amount BIGINT NOT NULL DEFAULT 0 CHECK (saved_amount >= 0),
+  currency TEXT NOT NULL DEFAULT 'INR',
+  target_date DATE NULL,
+  auto_allocate_percent NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (auto_allocate_percent BETWEEN 0 AND 100),
+  status TEXT NOT NULL DEFAULT 'ACTIVE', -- ACTIVE | COMPLETED | ARCHIVED
+  completed_at TIMESTAMPTZ NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_savings_goals_user_status
+  ON savings_goals(user_id, status);
+
+CREATE TABLE IF NOT EXISTS savings_goal_contributions (
+  id BIGSERIAL PRIMARY KEY,
+  goal_id BIGINT NOT NULL REFERENCES savings_goals(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL,
+  amount BIGINT NOT NULL CHECK (amount > 0),
+  source TEXT NOT NULL DEFAULT 'manual', -- manual | auto
+  income_id TEXT NULL,                    -- set for auto-allocations
+  note TEXT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_savings_goal_contributions_goal_created_at
+  ON savings_goal_contributions(goal_id, created_at DESC);
+-- one auto-allocation per goal per income
+CREATE UNIQUE INDEX IF NOT EXISTS uq_savings_goal_contributions_goal_income
+  ON savings_goal_contributions(goal_id, income_id)
+  WHERE income_id IS NOT NULL;
+
+-- ============================
+-- RECURRING RULES + INVOICES (cash-flow forecast inputs)
+-- ============================
+CREATE TABLE IF NOT EXISTS recurring_rules (
+  id BIGSERIAL PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL CHECK (kind IN ('income','expense')),
+  title TEXT NOT NULL,
+  category TEXT NOT NULL DEFAULT '',
+  amount BIGINT NOT NULL CHECK (amount > 0), -- paise
+  currency TEXT NOT NULL DEFAULT 'INR',
+  cadence TEXT NOT NULL DEFAULT 'monthly', -- weekly | monthly | quarterly | yearly
+  start_on DATE NOT NULL,
+  end_on DATE NULL,
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_recurring_rules_user_active
+  ON recurring_rules(user_id) WHERE active;
+
+CREATE TABLE IF NOT EXISTS invoices (
+  id BIGSERIAL PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  business_id BIGINT NULL REFERENCES businesses(id) ON DELETE SET NULL,
+  client_name TEXT NOT NULL,
+  amount BIGINT NOT NULL CHECK (amount > 0), -- paise
+  currency TEXT NOT NULL DEFAULT 'INR',
+  issued_on DATE NOT NULL DEFAULT CURRENT_DATE,
+  due_on DATE NULL,
+  expected_pay_on DATE NULL,
+  status TEXT NOT NULL DEFAULT 'OPEN', -- OPEN | PAID | VOID
+  paid_on DATE NULL,
+  income_id TEXT NULL,                 -- incomes.id booked when paid
+  note TEXT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_invoices_user_status
+  ON invoices(user_id, status);
+
+-- ============================
+-- DUPLICATE DETECTION
+-- ============================
+-- merged duplicates are soft-deleted like incomes/expenses
+ALTER TABLE transactions_v1
+  ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+ALTER TABLE user_transactions
+  ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS duplicate_resolutions (
+  id BIGSERIAL PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  resolution TEXT NOT NULL CHECK (resolution IN ('merged','dismissed')),
+  kept_kind TEXT NOT NULL,   -- income | expense | transaction | user_transaction
+  kept_id TEXT NOT NULL,
+  other_kind TEXT NOT NULL,  -- for merges, the soft-deleted row
+  other_id TEXT NOT NULL,
+  pair_key TEXT NOT NULL,    -- order-independent "kind:id|kind:id"
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+-- insert-only: every merge or dismissal of a pair is kept as history
+DROP INDEX IF EXISTS uq_duplicate_resolutions_user_pair;
+CREATE INDEX IF NOT EXISTS idx_duplicate_resolutions_user_pair
+  ON duplicate_resolutions(user_id, pair_key, created_at DESC);
+
+-- ============================
+-- STATEMENT IMPORTS (CSV / OFX / CAMT.053)
+-- ============================
+CREATE TABLE IF NOT EXISTS import_batches (
+  id BIGSERIAL PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  format TEXT NOT NULL,                    -- csv | ofx | camt053
+  preset TEXT NULL,                        -- csv preset used, if any
+  file_name TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL DEFAULT 'PREVIEW',  -- PREVIEW | COMMITTED | ROLLED_BACK
+  total_rows INT NOT NULL DEFAULT 0,
+  error_rows INT NOT NULL DEFAULT 0,
+  duplicate_rows INT NOT NULL DEFAULT 0,
+  incomes_imported INT NOT NULL DEFAULT 0,
+  expenses_imported INT NOT NULL DEFAULT 0,
+  rows JSONB NOT NULL DEFAULT '[]'::jsonb, -- parsed rows as shown in the preview
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  committed_at TIMESTAMPTZ NULL,
+  rolled_back_at TIMESTAMPTZ NULL
+);
+CREATE INDEX IF NOT EXISTS idx_import_batches_user_created_at
+  ON import_batches(user_id, created_at DESC);
+
+-- stable ids from the source (bank FITID / AcctSvcrRef or a content hash)
+ALTER TABLE incomes
+  ADD COLUMN IF NOT EXISTS external_id TEXT,
+  ADD COLUMN IF NOT EXISTS import_batch_id BIGINT REFERENCES import_batches(id) ON DELETE SET NULL;
+
+ALTER TABLE expenses
+  ADD COLUMN IF NOT EXISTS external_id TEXT,
+  ADD COLUMN IF NOT EXISTS import_batch_id BIGINT REFERENCES import_batches(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_incomes_user_external_id
+  ON incomes(user_id, external_id) WHERE external_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_expenses_user_external_id
+  ON expenses(user_id, external_id) WHERE external_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_incomes_import_batch
+  ON incomes(import_batch_id) WHERE import_batch_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_expenses_import_batch
+  ON expenses(import_batch_id) WHERE import_batch_id IS NOT NULL;
+
+-- ============================
+-- UPI SMS CAPTURE (Expense Memory)
+-- ============================
+-- external_id ("upi:<RRN>") was added to expenses with statement imports; phone rows get their own key
+CREATE UNIQUE INDEX IF NOT EXISTS uq_expenses_phone_external_id
+  ON expenses(user_phone, external_id) WHERE external_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS phone_incomes (
+  id BIGSERIAL PRIMARY KEY,
+  user_phone TEXT NOT NULL,
+  amount_paise BIGINT NOT NULL CHECK (amount_paise > 0),
+  currency TEXT NOT NULL DEFAULT 'INR',
+  counterparty TEXT,
+  note TEXT,
+  source TEXT NOT NULL DEFAULT 'manual', -- manual | upi
+  external_id TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_phone_incomes_user_phone_created_at
+  ON phone_incomes (user_phone, created_at DESC);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_phone_incomes_phone_external_id
+  ON phone_incomes(user_phone, external_id) WHERE external_id IS NOT NULL;
+
+-- ============================
+-- ACCOUNTING EXPORT (Tally / Zoho Books)
+-- ============================
+CREATE TABLE IF NOT EXISTS accounting_settings (
+  user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  company_name TEXT NOT NULL DEFAULT '',
+  bank_ledger TEXT NOT NULL DEFAULT '',
+  income_ledger TEXT NOT NULL DEFAULT '',
+  expense_ledger TEXT NOT NULL DEFAULT '',
+  sales_ledger TEXT NOT NULL DEFAULT '',
+  purchase_ledger TEXT NOT NULL DEFAULT '',
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS accounting_ledger_mappings (
+  id BIGSERIAL PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL,            -- category | client
+  match_key TEXT NOT NULL,       -- expense category or income client name
+  ledger_name TEXT NOT NULL DEFAULT '',
+  voucher_type TEXT NULL,        -- Payment | Purchase | Receipt | Sales
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_accounting_ledger_mappings_key
+  ON accounting_ledger_mappings(user_id, kind, lower(match_key));
+
+CREATE TABLE IF NOT EXISTS accounting_exports (
+  id BIGSERIAL PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  format TEXT NOT NULL,          -- tally | zoho
+  from_date DATE NOT NULL,
+  to_date DATE NOT NULL,
+  entry_count INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_accounting_exports_user_created
+  ON accounting_exports(user_id, created_at DESC);
+
+-- which incomes/expenses/invoices went out in which export, so they are not exported twice
+CREATE TABLE IF NOT EXISTS accounting_exported_entries (
+  export_id BIGINT NOT NULL REFERENCES accounting_exports(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL,
+  format TEXT NOT NULL,
+  entry_kind TEXT NOT NULL,      -- income | expense | invoice
+  entry_id TEXT NOT NULL,
+  PRIMARY KEY (export_id, entry_kind, entry_id)
+);
+CREATE INDEX IF NOT EXISTS idx_accounting_exported_entries_lookup
+  ON accounting_exported_entries(user_id, format, entry_kind, entry_id);
+
+-- ============================
+-- PLAIN-TEXT ACCOUNTING (Beancount / ledger-cli)
+-- ============================
+-- account name templates, see accounting.JournalAccounts
+ALTER TABLE accounting_settings ADD COLUMN IF NOT EXISTS journal_accounts JSONB NULL;
+
+-- ============================
+-- PROFIT & LOSS: per-business incomes/expenses, monthly budgets
+-- ============================
+ALTER TABLE incomes ADD COLUMN IF NOT EXISTS business_id BIGINT NULL REFERENCES businesses(id) ON DELETE SET NULL;
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS business_id BIGINT NULL REFERENCES businesses(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_incomes_business_received_on
+  ON incomes(business_id, received_on) WHERE business_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_expenses_business_spent_on
+  ON expenses(business_id, spent_on) WHERE business_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS budgets (
+  id BIGSERIAL PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  business_id BIGINT NULL REFERENCES businesses(id) ON DELETE CASCADE, -- NULL = across all businesses
+  kind TEXT NOT NULL CHECK (kind IN ('income','expense')),
+  name TEXT NOT NULL,            -- client name or expense category
+  month DATE NOT NULL CHECK (EXTRACT(DAY FROM month) = 1),
+  amount BIGINT NOT NULL CHECK (amount >= 0), -- paise
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_budgets_user_business_kind_name_month
+  ON budgets(user_id, COALESCE(business_id, 0), kind, lower(name), month);
+
+-- ============================
+-- SCHEDULED REPORT DELIVERY
+-- ============================
+CREATE TABLE IF NOT EXISTS report_subscriptions (
+  id BIGSERIAL PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  report TEXT NOT NULL,          -- weekly_digest | monthly_statement | quarterly_pnl
+  format TEXT NOT NULL DEFAULT 'pdf', -- pdf | csv
+  channel TEXT NOT NULL DEFAULT 'email', -- email | whatsapp
+  destination TEXT NOT NULL,     -- email address or E.164 phone
+  timezone TEXT NOT NULL DEFAULT 'Asia/Kolkata',
+  business_id BIGINT NULL REFERENCES businesses(id) ON DELETE CASCADE,
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  next_run_at TIMESTAMPTZ NOT NULL,
+  last_run_at TIMESTAMPTZ NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_report_subscriptions_user
+  ON report_subscriptions(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_report_subscriptions_due
+  ON report_subscriptions(next_run_at) WHERE active;
+
+CREATE TABLE IF NOT EXISTS report_deliveries (
+  id BIGSERIAL PRIMARY KEY,
+  subscription_id BIGINT NOT NULL REFERENCES report_subscriptions(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL,
+  period_from DATE NOT NULL,
+  period_to DATE NOT NULL,
+  channel TEXT NOT NULL,
+  destination TEXT NOT NULL,
+  manual BOOLEAN NOT NULL DEFAULT FALSE,
+  status TEXT NOT NULL DEFAULT 'pending', -- pending | sending | sent | failed
+  attempts INT NOT NULL DEFAULT 0,
+  last_error TEXT NULL,
+  next_attempt_at TIMESTAMPTZ NULL DEFAULT now(),
+  sent_at TIMESTAMPTZ NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_report_deliveries_subscription
+  ON report_deliveries(subscription_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_report_deliveries_queue
+  ON report_deliveries(next_attempt_at) WHERE status IN ('pending','sending');
+
+-- ============================
+-- BUSINESS LOGOS (PDF BRANDING)
+-- ============================
+ALTER TABLE businesses ADD COLUMN IF NOT EXISTS logo BYTEA NULL;
+ALTER TABLE businesses ADD COLUMN IF NOT EXISTS logo_content_type TEXT NULL;
+
+-- ============================
+-- PDF LOCALE FOR SCHEDULED REPORTS
+-- ============================
+ALTER TABLE report_subscriptions ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT 'en';
+
+-- ============================
+-- REPORT FILES IN BLOB STORAGE
+-- ============================
+-- file_path is kept for links made before blob storage; the janitor removes those files on expiry
+ALTER TABLE reports ALTER COLUMN file_path DROP NOT NULL;
+ALTER TABLE reports ADD COLUMN IF NOT EXISTS object_key TEXT NULL;
+ALTER TABLE reports ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'expense_memory';
+ALTER TABLE reports ADD COLUMN IF NOT EXISTS params JSONB NOT NULL DEFAULT '{}';
+UPDATE reports SET kind = 'scheduled'
+WHERE kind = 'expense_memory' AND file_path LIKE 'data/reports/scheduled/%';
+
+-- ============================
+-- REPORT LINK ACCESS CONTROL
+-- ============================
+-- user_id is set for links an account can manage (scheduled deliveries); phone-only links stay NULL
+ALTER TABLE reports ADD COLUMN IF NOT EXISTS user_id UUID NULL REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE reports ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMPTZ NULL;
+ALTER TABLE reports ADD COLUMN IF NOT EXISTS max_downloads INT NULL CHECK (max_downloads > 0);
+ALTER TABLE reports ADD COLUMN IF NOT EXISTS download_count INT NOT NULL DEFAULT 0;
+ALTER TABLE reports ADD COLUMN IF NOT EXISTS require_otp BOOLEAN NOT NULL DEFAULT false;
+CREATE INDEX IF NOT EXISTS idx_reports_user_id ON reports(user_id, created_at DESC) WHERE user_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS report_downloads (
+  id BIGSERIAL PRIMARY KEY,
+  report_id BIGINT NOT NULL REFERENCES reports(id) ON DELETE CASCADE,
+  ip TEXT NOT NULL DEFAULT '',
+  user_agent TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_report_downloads_report
+  ON report_downloads(report_id, created_at DESC);
+
+-- one live code per link; only the hash is kept
+CREATE TABLE IF NOT EXISTS report_otps (
+  report_id BIGINT PRIMARY KEY REFERENCES reports(id) ON DELETE CASCADE,
+  code_hash TEXT NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  sent_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at TIMESTAMPTZ NOT NULL
+);
+-- lifetime counts since the last right code; the link locks when either hits its cap
+ALTER TABLE report_otps ADD COLUMN IF NOT EXISTS sends INT NOT NULL DEFAULT 1;
+ALTER TABLE report_otps ADD COLUMN IF NOT EXISTS guesses INT NOT NULL DEFAULT 0;
+
+-- ============================
+-- EXPENSE MEMORY CHAT BOT
+-- ============================
+-- per-phone conversation state: the open question and what "undo" removes
+CREATE TABLE IF NOT EXISTS bot_sessions (
+  phone TEXT PRIMARY KEY,
+  state TEXT NOT NULL DEFAULT '',
+  expense_id BIGINT NULL,
+  pending JSONB NOT NULL DEFAULT '{}',
+  last_expense_id BIGINT NULL,
+  expires_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- monthly category limits for phone-based users ("budget food 5000")
+CREATE TABLE IF NOT EXISTS expense_budgets (
+  user_phone TEXT NOT NULL,
+  category TEXT NOT NULL,
+  amount_paise BIGINT NOT NULL CHECK (amount_paise > 0),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_phone, category)
+);
+
+-- ============================
+-- TWILIO WEBHOOK REPLAY PROTECTION
+-- ============================
+-- every accepted inbound MessageSid; a repeat is a retry or a replay and is not handled again
+CREATE TABLE IF NOT EXISTS twilio_inbound_messages (
+  message_sid TEXT PRIMARY KEY,
+  from_phone TEXT NOT NULL DEFAULT '',
+  received_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- ============================
+-- OUTBOUND MESSAGE QUEUE
+-- ============================
+CREATE TABLE IF NOT EXISTS outbound_messages (
+  id BIGSERIAL PRIMARY KEY,
+  to_phone TEXT NOT NULL,
+  sender TEXT NOT NULL DEFAULT '',          -- '' = the client's default number
+  body TEXT NOT NULL DEFAULT '',
+  media_url TEXT NOT NULL DEFAULT '',
+  purpose TEXT NOT NULL DEFAULT '',         -- e.g. monthly_report
+  status TEXT NOT NULL DEFAULT 'pending'
+    CHECK (status IN ('pending','sending','queued','sent','delivered','read','undelivered','failed')),
+  attempts INT NOT NULL DEFAULT 0,
+  provider_sid TEXT NOT NULL DEFAULT '',
+  last_error TEXT NULL,
+  next_attempt_at TIMESTAMPTZ NULL DEFAULT now(),
+  sent_at TIMESTAMPTZ NULL,
+  delivered_at TIMESTAMPTZ NULL,
+  read_at TIMESTAMPTZ NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_outbound_messages_queue
+  ON outbound_messages(next_attempt_at) WHERE status IN ('pending','sending');
+CREATE INDEX IF NOT EXISTS idx_outbound_messages_to
+  ON outbound_messages(to_phone, created_at DESC);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_outbound_messages_provider_sid
+  ON outbound_messages(provider_sid) WHERE provider_sid <> '';
+
+-- ============================
+-- MESSAGING CHANNELS
+-- ============================
+-- where each phone wants reports and reminders; no row means whatsapp
+CREATE TABLE IF NOT EXISTS messaging_preferences (
+  phone TEXT PRIMARY KEY,
+  channel TEXT NOT NULL DEFAULT 'whatsapp', -- whatsapp | sms | telegram
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- addresses on channels that don't use the phone number (a Telegram chat id), linked when the
+-- user shares their own contact
+CREATE TABLE IF NOT EXISTS messaging_links (
+  channel TEXT NOT NULL,
+  address TEXT NOT NULL,
+  phone TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (channel, address),
+  UNIQUE (channel, phone)
+);
+
+-- Telegram retries an update until it gets a 2xx; seen ids are acknowledged without handling
+CREATE TABLE IF NOT EXISTS telegram_updates (
+  update_id BIGINT PRIMARY KEY,
+  received_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE outbound_messages ADD COLUMN IF NOT EXISTS channel TEXT NOT NULL DEFAULT 'whatsapp';
+
+-- ============================
+-- EXPENSE RECEIPT ATTACHMENTS
+-- ============================
+-- receipt photos sent to the bot; a draft waits for the user to confirm the expense read off it
+CREATE TABLE IF NOT EXISTS expense_attachments (
+  id BIGSERIAL PRIMARY KEY,
+  user_phone TEXT NOT NULL,
+  expense_id BIGINT NULL,               -- the confirmed expense; no FK, as in bot_sessions
+  object_key TEXT NOT NULL,
+  content_type TEXT NOT NULL DEFAULT '',
+  size_bytes INT NOT NULL DEFAULT 0,
+  ocr_text TEXT NOT NULL DEFAULT '',
+  merchant TEXT NOT NULL DEFAULT '',
+  total_paise BIGINT NOT NULL DEFAULT 0,
+  receipt_date DATE NULL,
+  status TEXT NOT NULL DEFAULT 'draft', -- draft | confirmed
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_expense_attachments_phone
+  ON expense_attachments(user_phone, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_expense_attachments_expense
+  ON expense_attachments(expense_id) WHERE expense_id IS NOT NULL;
+-- the janitor expires drafts nobody answered
+CREATE INDEX IF NOT EXISTS idx_expense_attachments_drafts
+  ON expense_attachments(created_at) WHERE status = 'draft';
+
+-- ============================
+-- BOT UNDO
+-- ============================
+-- what undo removes: {"expense_ids": [...]} or {"income_id": n}; last_expense_id stays for old rows
+ALTER TABLE bot_sessions ADD COLUMN IF NOT EXISTS undo JSONB NOT NULL DEFAULT '{}';
+
+-- ============================
+-- LOGGING REMINDERS AND STREAKS
+-- ============================
+-- a phone's daily nudge; remind_at and quiet hours are HH:MM in timezone, days_mask has bit 0 for
+-- Sunday. checked_on is the local day the scheduler last handled the phone.
+CREATE TABLE IF NOT EXISTS reminder_prefs (
+  phone TEXT PRIMARY KEY,
+  enabled BOOLEAN NOT NULL DEFAULT false,
+  remind_at TEXT NOT NULL DEFAULT '21:00',
+  timezone TEXT NOT NULL DEFAULT 'Asia/Kolkata',
+  days_mask INT NOT NULL DEFAULT 127,
+  quiet_start TEXT NOT NULL DEFAULT '',
+  quiet_end TEXT NOT NULL DEFAULT '',
+  opted_out BOOLEAN NOT NULL DEFAULT false, -- STOP; START clears it
+  checked_on DATE NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+-- the scheduler's lease on a due phone, or the end of its quiet hours
+ALTER TABLE reminder_prefs ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMPTZ NULL;
+
+CREATE TABLE IF NOT EXISTS logging_streaks (
+  phone TEXT PRIMARY KEY,
+  current_days INT NOT NULL DEFAULT 0,
+  best_days INT NOT NULL DEFAULT 0,
+  start_on TEXT NOT NULL DEFAULT '',
+  logged_today BOOLEAN NOT NULL DEFAULT false,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- milestone bonuses wait here until the phone belongs to an account, then go to points_ledger
+-- under the same source_id
+CREATE TABLE IF NOT EXISTS streak_bonuses (
+  source_id TEXT PRIMARY KEY,           -- streak:<phone>:<start day>:<days>
+  phone TEXT NOT NULL,
+  days INT NOT NULL,
+  points BIGINT NOT NULL,
+  user_id UUID NULL,
+  tried_at TIMESTAMPTZ NULL,
+  credited_at TIMESTAMPTZ NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_streak_bonuses_uncredited
+  ON streak_bonuses(tried_at NULLS FIRST, created_at) WHERE credited_at IS NULL;
+
+-- ============================
+-- PHONE ACCOUNT LINKING
+-- ============================
+-- an account's verified phone joins it to the phone-keyed Expense Memory tables
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone TEXT NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMPTZ NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_users_phone ON users(phone) WHERE phone IS NOT NULL;
+
+-- one live code per account; only the hash is kept
+CREATE TABLE IF NOT EXISTS phone_otps (
+  user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  phone TEXT NOT NULL,
+  code_hash TEXT NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  sent_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- every code sent, for the per-day caps on an account and on a phone
+CREATE TABLE IF NOT EXISTS phone_otp_sends (
+  id BIGSERIAL PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  phone TEXT NOT NULL,
+  sent_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_phone_otp_sends_user ON phone_otp_sends(user_id, sent_at DESC);
+CREATE INDEX IF NOT EXISTS idx_phone_otp_sends_phone ON phone_otp_sends(phone, sent_at DESC);
+
+-- set when the phone is linked, and on new rows of a linked phone; unlinking clears it
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS user_id UUID NULL REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_expenses_user_id ON expenses(user_id) WHERE user_id IS NOT NULL;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS user_id UUID NULL REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_id ON subscriptions(user_id) WHERE user_id IS NOT NULL;